@@ -163,6 +163,14 @@ ovn_sb_raft_election_timer=${OVN_SB_RAFT_ELECTION_TIMER:-1000}
 
 ovn_hybrid_overlay_enable=${OVN_HYBRID_OVERLAY_ENABLE:-}
 ovn_hybrid_overlay_net_cidr=${OVN_HYBRID_OVERLAY_NET_CIDR:-}
+# OVN_IPSEC_ENABLE encrypts the overlay with certificates signed through the
+# Kubernetes certificate signing request API
+ovn_ipsec_enable=${OVN_IPSEC_ENABLE:-}
+# OVN_NBDB_CHECK_INTERVAL - seconds between two checks of the nb db against
+# the kubernetes objects (default 0, disabled); OVN_NBDB_REPAIR repairs what
+# they find
+ovn_nbdb_check_interval=${OVN_NBDB_CHECK_INTERVAL:-0}
+ovn_nbdb_repair=${OVN_NBDB_REPAIR:-}
 #OVN_REMOTE_PROBE_INTERVAL - ovn remote probe interval in ms (default 100000)
 ovn_remote_probe_interval=${OVN_REMOTE_PROBE_INTERVAL:-100000}
 
@@ -758,6 +766,14 @@ ovn-master() {
       hybrid_overlay_flags="${hybrid_overlay_flags} --hybrid-overlay-cluster-subnets=${ovn_hybrid_overlay_net_cidr}"
     fi
   fi
+  ipsec_flags=
+  if [[ -n "${ovn_ipsec_enable}" ]]; then
+    ipsec_flags="--enable-ipsec"
+  fi
+  nbdb_check_flags="--nbdb-check-interval=${ovn_nbdb_check_interval}"
+  if [[ -n "${ovn_nbdb_repair}" ]]; then
+    nbdb_check_flags="${nbdb_check_flags} --nbdb-repair"
+  fi
   local ovn_master_ssl_opts=""
   [[ "yes" == ${OVN_SSL_ENABLE} ]] && {
     ovn_master_ssl_opts="
@@ -778,6 +794,8 @@ ovn-master() {
     --nbctl-daemon-mode \
     --loglevel=${ovnkube_loglevel} \
     ${hybrid_overlay_flags} \
+    ${ipsec_flags} \
+    ${nbdb_check_flags} \
     --pidfile ${OVN_RUNDIR}/ovnkube-master.pid \
     --logfile /var/log/ovn-kubernetes/ovnkube-master.log \
     ${ovn_master_ssl_opts} \
@@ -857,6 +875,17 @@ ovn-node() {
     hybrid_overlay_flags="--enable-hybrid-overlay"
   fi
 
+  ipsec_flags=
+  if [[ -n "${ovn_ipsec_enable}" ]]; then
+    ipsec_flags="--enable-ipsec"
+  fi
+
+  # OVN_MTU=auto detects the MTU from the encapsulation interface
+  mtu_flags="--mtu=${mtu}"
+  if [[ "${mtu}" == "auto" ]]; then
+    mtu_flags="--mtu-auto-detect"
+  fi
+
   OVN_ENCAP_IP=""
   ovn_encap_ip=$(ovs-vsctl --if-exists get Open_vSwitch . external_ids:ovn-encap-ip)
   if [[ $? == 0 ]]; then
@@ -883,10 +912,11 @@ ovn-node() {
     --cluster-subnets ${net_cidr} --k8s-service-cidr=${svc_cidr} \
     --nb-address=${ovn_nbdb} --sb-address=${ovn_sbdb} \
     --nodeport \
-    --mtu=${mtu} \
+    ${mtu_flags} \
     ${OVN_ENCAP_IP} \
     --loglevel=${ovnkube_loglevel} \
     ${hybrid_overlay_flags} \
+    ${ipsec_flags} \
     --gateway-mode=${ovn_gateway_mode} ${ovn_gateway_opts} \
     --pidfile ${OVN_RUNDIR}/ovnkube.pid \
     --logfile /var/log/ovn-kubernetes/ovnkube.log \
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	"github.com/urfave/cli/v2"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+	kexec "k8s.io/utils/exec"
+)
+
+// setOVNNorth configures the connection of ovn-nbctl to the OVN northbound
+// database; by default ovn-nbctl connects to the local unix socket
+func setOVNNorth(ctx *cli.Context) error {
+	address := ctx.String("ovn-nb-address")
+	if address == "" {
+		return nil
+	}
+	parts := strings.SplitN(address, ":", 2)
+	scheme := config.OvnDBScheme(parts[0])
+	if len(parts) != 2 || (scheme != config.OvnDBSchemeSSL && scheme != config.OvnDBSchemeTCP) {
+		return fmt.Errorf("invalid OVN northbound database address %q", address)
+	}
+	config.OvnNorth = config.OvnAuthConfig{
+		Address: address,
+		PrivKey: ctx.String("ovn-nb-client-privkey"),
+		Cert:    ctx.String("ovn-nb-client-cert"),
+		CACert:  ctx.String("ovn-nb-client-cacert"),
+		Scheme:  scheme,
+	}
+	return nil
+}
+
+// OwnershipCommand finds the OVN northbound database rows owned by a
+// Kubernetes object, or the object owning a row
+var OwnershipCommand = cli.Command{
+	Name:  "ownership",
+	Usage: "find the OVN NB rows owned by a Kubernetes object, or the object owning an OVN NB row",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "kubeconfig",
+			Usage: "absolute path to the kubeconfig file (default: the in-cluster configuration)",
+		},
+		&cli.StringFlag{
+			Name:  "object",
+			Usage: "the Kubernetes object as pod/<namespace>/<name>, namespace/<name>, networkpolicy/<namespace>/<name> or service/<namespace>/<name>",
+		},
+		&cli.StringFlag{
+			Name:  "row",
+			Usage: "the OVN NB row as <table>/<uuid or name>, or load_balancer/<vip>",
+		},
+		&cli.StringFlag{
+			Name:  "ovn-nb-address",
+			Usage: "the address of the OVN northbound database (default: the local unix socket)",
+		},
+		&cli.StringFlag{
+			Name:  "ovn-nb-client-privkey",
+			Usage: "the private key of the SSL connection to the OVN northbound database",
+		},
+		&cli.StringFlag{
+			Name:  "ovn-nb-client-cert",
+			Usage: "the certificate of the SSL connection to the OVN northbound database",
+		},
+		&cli.StringFlag{
+			Name:  "ovn-nb-client-cacert",
+			Usage: "the CA certificate of the SSL connection to the OVN northbound database",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		object, row := ctx.String("object"), ctx.String("row")
+		if (object == "") == (row == "") {
+			return fmt.Errorf("exactly one of object or row must be specified")
+		}
+		if err := util.SetExec(kexec.New()); err != nil {
+			return err
+		}
+		if err := setOVNNorth(ctx); err != nil {
+			return err
+		}
+		// The client is only needed for services, whose rows are found from
+		// their addresses
+		var kclient kubernetes.Interface
+		clientset, err := util.NewClientset(&config.KubernetesConfig{Kubeconfig: ctx.String("kubeconfig")})
+		if err != nil {
+			klog.Warningf("Failed to create a Kubernetes client, services cannot be resolved: %v", err)
+		} else {
+			kclient = clientset
+		}
+
+		if object != "" {
+			owner, err := ovn.ParseOwnerReference(object)
+			if err != nil {
+				return err
+			}
+			rows, err := ovn.FindOwnedRows(kclient, owner)
+			if err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				return fmt.Errorf("no OVN NB row is owned by %s", owner)
+			}
+			for _, row := range rows {
+				fmt.Println(row)
+			}
+			return nil
+		}
+
+		parts := strings.SplitN(row, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid row %q: expected <table>/<uuid or name>", row)
+		}
+		owner, err := ovn.FindRowOwner(kclient, parts[0], parts[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println(owner)
+		return nil
+	},
+}
@@ -0,0 +1,473 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	"github.com/urfave/cli/v2"
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kexec "k8s.io/utils/exec"
+	utilnet "k8s.io/utils/net"
+)
+
+// tracePacket is the packet a pod sends that is traced through the OVN
+// logical pipelines and the OpenFlow tables of br-int
+type tracePacket struct {
+	// logicalSwitch is the logical switch the source pod is on, the switch
+	// of its node or of its provider network
+	logicalSwitch string
+	// inport is the logical port of the source pod
+	inport   string
+	srcMAC   net.HardwareAddr
+	dstMAC   net.HardwareAddr
+	srcIP    net.IP
+	dstIP    net.IP
+	protocol string
+	dstPort  int
+}
+
+// ovnMicroflow returns the microflow ovn-trace injects on the inport
+func (p *tracePacket) ovnMicroflow() string {
+	ipVer := "ip4"
+	if utilnet.IsIPv6(p.dstIP) {
+		ipVer = "ip6"
+	}
+	match := []string{
+		fmt.Sprintf("inport == %q", p.inport),
+		fmt.Sprintf("eth.src == %s", p.srcMAC),
+		fmt.Sprintf("eth.dst == %s", p.dstMAC),
+		fmt.Sprintf("%s.src == %s", ipVer, p.srcIP),
+		fmt.Sprintf("%s.dst == %s", ipVer, p.dstIP),
+		"ip.ttl == 64",
+	}
+	switch p.protocol {
+	case "icmp":
+		if ipVer == "ip6" {
+			match = append(match, "icmp6.type == 128")
+		} else {
+			match = append(match, "icmp4.type == 8")
+		}
+	default:
+		match = append(match, fmt.Sprintf("%s.dst == %d", p.protocol, p.dstPort))
+	}
+	return strings.Join(match, " && ")
+}
+
+// ovsFlow returns the flow ofproto/trace injects on the OpenFlow port of the
+// source pod
+func (p *tracePacket) ovsFlow(ofport string) string {
+	ipv6 := utilnet.IsIPv6(p.dstIP)
+	proto := p.protocol
+	if ipv6 {
+		proto += "6"
+	}
+	flow := []string{
+		"in_port=" + ofport,
+		proto,
+		"dl_src=" + p.srcMAC.String(),
+		"dl_dst=" + p.dstMAC.String(),
+	}
+	if ipv6 {
+		flow = append(flow, "ipv6_src="+p.srcIP.String(), "ipv6_dst="+p.dstIP.String())
+	} else {
+		flow = append(flow, "nw_src="+p.srcIP.String(), "nw_dst="+p.dstIP.String())
+	}
+	flow = append(flow, "nw_ttl=64")
+	switch {
+	case p.protocol != "icmp":
+		flow = append(flow, fmt.Sprintf("tp_dst=%d", p.dstPort))
+	case ipv6:
+		flow = append(flow, "icmp_type=128")
+	default:
+		flow = append(flow, "icmp_type=8")
+	}
+	return strings.Join(flow, ",")
+}
+
+// traceDecision is a logical flow of the trace that decided what happens to
+// the packet
+type traceDecision struct {
+	kind     string
+	datapath string
+	stage    string
+	priority int
+	match    string
+	actions  string
+}
+
+func (d *traceDecision) String() string {
+	return fmt.Sprintf("%-13s %s %s, priority %d: %s => %s",
+		d.kind+":", d.datapath, d.stage, d.priority, d.match, d.actions)
+}
+
+// traceSummary summarises the output of ovn-trace
+type traceSummary struct {
+	decisions []*traceDecision
+	// dropped is the logical flow that dropped the packet, if any
+	dropped *traceDecision
+	// outputs are the logical ports the packet was output to
+	outputs []string
+}
+
+var (
+	traceDatapathRe = regexp.MustCompile(`^(?:ingress|egress)\(dp="([^"]*)"`)
+	traceFlowRe     = regexp.MustCompile(`^\s*\d+\. (\S+) \([^)]*\): (.*), priority (\d+), uuid [0-9a-f]+$`)
+	traceOutputRe   = regexp.MustCompile(`output to "([^"]*)"`)
+)
+
+// ovn-northd adds aclPriorityOffset to the priority of the ACLs in the logical
+// flows of the ACL stages; the flows outside of that range are northd's own.
+const (
+	aclPriorityOffset = 1000
+	aclMaxPriority    = 32767
+)
+
+// summarizeTrace extracts the ACLs, load balancers and routes that decided
+// the fate of the packet from the detailed output of ovn-trace
+func summarizeTrace(output string) *traceSummary {
+	summary := &traceSummary{}
+	var datapath string
+	var flow *traceDecision
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := traceDatapathRe.FindStringSubmatch(line); m != nil {
+			datapath = m[1]
+			flow = nil
+			continue
+		}
+		if m := traceFlowRe.FindStringSubmatch(line); m != nil {
+			priority, _ := strconv.Atoi(m[3])
+			flow = &traceDecision{datapath: datapath, stage: m[1], match: m[2], priority: priority}
+			continue
+		}
+		if m := traceOutputRe.FindStringSubmatch(line); m != nil {
+			summary.outputs = append(summary.outputs, m[1])
+			continue
+		}
+		actions := strings.TrimSpace(line)
+		if flow == nil || actions == "" || strings.HasPrefix(actions, "/*") {
+			continue
+		}
+		// Only the first line of actions of a flow is kept; the following
+		// ones belong to nested pipelines
+		flow.actions = actions
+		switch {
+		case strings.Contains(flow.stage, "_acl") && flow.priority >= aclPriorityOffset &&
+			flow.priority <= aclPriorityOffset+aclMaxPriority:
+			flow.kind = "ACL"
+		case strings.Contains(actions, "ct_lb"):
+			flow.kind = "Load balancer"
+		case strings.HasSuffix(flow.stage, "_ip_routing"):
+			flow.kind = "Route"
+		}
+		if flow.kind != "" {
+			summary.decisions = append(summary.decisions, flow)
+		}
+		if strings.HasPrefix(actions, "drop;") || strings.HasPrefix(actions, "reject") {
+			summary.dropped = flow
+		}
+		flow = nil
+	}
+	return summary
+}
+
+func (s *traceSummary) String() string {
+	var b strings.Builder
+	for _, d := range s.decisions {
+		fmt.Fprintln(&b, d)
+	}
+	switch {
+	case s.dropped != nil:
+		fmt.Fprintf(&b, "Verdict: dropped by %s in %s, priority %d: %s\n",
+			s.dropped.datapath, s.dropped.stage, s.dropped.priority, s.dropped.match)
+	case len(s.outputs) > 0:
+		fmt.Fprintf(&b, "Verdict: output to %s\n", strings.Join(s.outputs, ", "))
+	default:
+		fmt.Fprintln(&b, "Verdict: unknown, see the detailed trace")
+	}
+	return b.String()
+}
+
+// splitName splits a <namespace>/<name> argument
+func splitName(flag, value string) (string, string, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid %s %q: expected <namespace>/<name>", flag, value)
+	}
+	return parts[0], parts[1], nil
+}
+
+// ipOfFamily returns the first of the IPs in the family of ip
+func ipOfFamily(ips []*net.IPNet, ip net.IP) *net.IPNet {
+	for _, ipNet := range ips {
+		if utilnet.IsIPv6(ipNet.IP) == utilnet.IsIPv6(ip) {
+			return ipNet
+		}
+	}
+	return nil
+}
+
+func getPodAnnotation(clientset kubernetes.Interface, value string) (*kapi.Pod, *util.PodAnnotation, error) {
+	namespace, name, err := splitName("pod", value)
+	if err != nil {
+		return nil, nil, err
+	}
+	pod, err := clientset.CoreV1().Pods(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get pod %s: %v", value, err)
+	}
+	annotation, err := util.UnmarshalPodAnnotation(pod.Annotations)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the network annotation of pod %s: %v", value, err)
+	}
+	return pod, annotation, nil
+}
+
+// getPodLogicalSwitch returns the logical switch of a pod's port: the switch
+// of the provider network the pod or its namespace is attached to, or else
+// the switch of the pod's node
+func getPodLogicalSwitch(clientset kubernetes.Interface, pod *kapi.Pod) (string, error) {
+	network, ok := pod.Annotations[util.ProviderNetworkAnnotation]
+	if !ok {
+		namespace, err := clientset.CoreV1().Namespaces().Get(context.TODO(), pod.Namespace, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get namespace %s: %v", pod.Namespace, err)
+		}
+		network = namespace.Annotations[util.ProviderNetworkAnnotation]
+	}
+	if network == "" {
+		return pod.Spec.NodeName, nil
+	}
+	return util.ProviderNetworkSwitch(network), nil
+}
+
+// buildTracePacket looks up the addresses of the packet the source pod sends
+// to the destination in the pod, service and node annotations
+func buildTracePacket(clientset kubernetes.Interface, ctx *cli.Context) (*tracePacket, error) {
+	srcPod, srcAnnotation, err := getPodAnnotation(clientset, ctx.String("src"))
+	if err != nil {
+		return nil, err
+	}
+	logicalSwitch, err := getPodLogicalSwitch(clientset, srcPod)
+	if err != nil {
+		return nil, err
+	}
+	packet := &tracePacket{
+		logicalSwitch: logicalSwitch,
+		inport:        srcPod.Namespace + "_" + srcPod.Name,
+		srcMAC:        srcAnnotation.MAC,
+		protocol:      strings.ToLower(ctx.String("protocol")),
+		dstPort:       ctx.Int("dst-port"),
+	}
+	switch packet.protocol {
+	case "tcp", "udp", "sctp", "icmp":
+	default:
+		return nil, fmt.Errorf("invalid protocol %q", packet.protocol)
+	}
+
+	var dstPodAnnotation *util.PodAnnotation
+	switch {
+	case ctx.String("dst-pod") != "":
+		_, dstPodAnnotation, err = getPodAnnotation(clientset, ctx.String("dst-pod"))
+		if err != nil {
+			return nil, err
+		}
+		packet.dstIP = dstPodAnnotation.IPs[0].IP
+	case ctx.String("dst-service") != "":
+		namespace, name, err := splitName("service", ctx.String("dst-service"))
+		if err != nil {
+			return nil, err
+		}
+		service, err := clientset.CoreV1().Services(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service %s: %v", ctx.String("dst-service"), err)
+		}
+		if !util.IsClusterIPSet(service) {
+			return nil, fmt.Errorf("service %s has no cluster IP", ctx.String("dst-service"))
+		}
+		packet.dstIP = net.ParseIP(service.Spec.ClusterIP)
+		if packet.dstPort == 0 && packet.protocol != "icmp" && len(service.Spec.Ports) > 0 {
+			packet.dstPort = int(service.Spec.Ports[0].Port)
+			packet.protocol = strings.ToLower(string(service.Spec.Ports[0].Protocol))
+		}
+	case ctx.String("dst-ip") != "":
+		packet.dstIP = net.ParseIP(ctx.String("dst-ip"))
+		if packet.dstIP == nil {
+			return nil, fmt.Errorf("invalid destination IP %q", ctx.String("dst-ip"))
+		}
+	default:
+		return nil, fmt.Errorf("one of dst-pod, dst-service or dst-ip must be specified")
+	}
+	if packet.protocol != "icmp" && packet.dstPort == 0 {
+		return nil, fmt.Errorf("the destination port of the %s packet must be specified", packet.protocol)
+	}
+
+	srcIP := ipOfFamily(srcAnnotation.IPs, packet.dstIP)
+	if srcIP == nil {
+		return nil, fmt.Errorf("pod %s has no IP address of the family of %s", ctx.String("src"), packet.dstIP)
+	}
+	packet.srcIP = srcIP.IP
+
+	// The destination pods on the subnet of the source pod are reached
+	// directly, everything else through the node's logical router port
+	if dstPodAnnotation != nil && srcIP.Contains(packet.dstIP) {
+		packet.dstMAC = dstPodAnnotation.MAC
+		return packet, nil
+	}
+	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), srcPod.Spec.NodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %v", srcPod.Spec.NodeName, err)
+	}
+	subnets, err := util.ParseNodeHostSubnetAnnotation(node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the subnets of node %s: %v", node.Name, err)
+	}
+	for _, subnet := range subnets {
+		if subnet.Contains(packet.srcIP) {
+			packet.dstMAC = util.IPAddrToHWAddr(util.GetNodeGatewayIfAddr(subnet).IP)
+			return packet, nil
+		}
+	}
+	return nil, fmt.Errorf("node %s has no subnet containing %s", node.Name, packet.srcIP)
+}
+
+// getOVNTraceDBArgs returns the arguments connecting ovn-trace to the OVN
+// southbound database
+func getOVNTraceDBArgs(ctx *cli.Context) []string {
+	var args []string
+	if privKey := ctx.String("ovn-sb-client-privkey"); privKey != "" {
+		args = append(args,
+			fmt.Sprintf("--private-key=%s", privKey),
+			fmt.Sprintf("--certificate=%s", ctx.String("ovn-sb-client-cert")),
+			fmt.Sprintf("--bootstrap-ca-cert=%s", ctx.String("ovn-sb-client-cacert")))
+	}
+	if address := ctx.String("ovn-sb-address"); address != "" {
+		args = append(args, fmt.Sprintf("--db=%s", address))
+	}
+	return args
+}
+
+// traceOVS traces the packet through the OpenFlow tables of br-int; it must
+// run on the source pod's node
+func traceOVS(packet *tracePacket) error {
+	ofport, stderr, err := util.RunOVSVsctl("--bare", "--columns=ofport", "find", "Interface",
+		"external_ids:iface-id="+packet.inport)
+	if err != nil {
+		return fmt.Errorf("failed to get the OpenFlow port of %s, stderr: %q, error: %v", packet.inport, stderr, err)
+	}
+	if ofport == "" {
+		return fmt.Errorf("logical port %s is not bound on this node", packet.inport)
+	}
+	flow := packet.ovsFlow(ofport)
+	fmt.Printf("ovs-appctl ofproto/trace br-int %s\n", flow)
+	output, stderr, err := util.RunOVSAppctl("ofproto/trace", "br-int", flow)
+	if err != nil {
+		return fmt.Errorf("failed to trace %s on br-int, stderr: %q, error: %v", flow, stderr, err)
+	}
+	fmt.Println(output)
+	return nil
+}
+
+// TraceCommand traces a packet from a pod to a pod, service or IP address
+// through the OVN logical pipelines and summarises what decided its fate
+var TraceCommand = cli.Command{
+	Name:  "trace",
+	Usage: "trace a packet from a pod to a pod, service or IP address",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "kubeconfig",
+			Usage: "absolute path to the kubeconfig file (default: the in-cluster configuration)",
+		},
+		&cli.StringFlag{
+			Name:     "src",
+			Usage:    "the source pod as <namespace>/<name>",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "dst-pod",
+			Usage: "the destination pod as <namespace>/<name>",
+		},
+		&cli.StringFlag{
+			Name:  "dst-service",
+			Usage: "the destination service as <namespace>/<name>",
+		},
+		&cli.StringFlag{
+			Name:  "dst-ip",
+			Usage: "the destination IP address",
+		},
+		&cli.IntFlag{
+			Name:  "dst-port",
+			Usage: "the destination port (default: the first port of the destination service)",
+		},
+		&cli.StringFlag{
+			Name:  "protocol",
+			Usage: "the protocol of the packet: tcp, udp, sctp or icmp",
+			Value: "tcp",
+		},
+		&cli.StringFlag{
+			Name:  "ovn-sb-address",
+			Usage: "the address of the OVN southbound database (default: the local unix socket)",
+		},
+		&cli.StringFlag{
+			Name:  "ovn-sb-client-privkey",
+			Usage: "the private key of the SSL connection to the OVN southbound database",
+		},
+		&cli.StringFlag{
+			Name:  "ovn-sb-client-cert",
+			Usage: "the certificate of the SSL connection to the OVN southbound database",
+		},
+		&cli.StringFlag{
+			Name:  "ovn-sb-client-cacert",
+			Usage: "the CA certificate of the SSL connection to the OVN southbound database",
+		},
+		&cli.BoolFlag{
+			Name:  "ovs",
+			Usage: "also trace the packet through br-int; must run on the source pod's node",
+		},
+		&cli.BoolFlag{
+			Name:  "detailed",
+			Usage: "print the output of ovn-trace along with its summary",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		if err := util.SetExec(kexec.New()); err != nil {
+			return err
+		}
+		clientset, err := util.NewClientset(&config.KubernetesConfig{Kubeconfig: ctx.String("kubeconfig")})
+		if err != nil {
+			return err
+		}
+		packet, err := buildTracePacket(clientset, ctx)
+		if err != nil {
+			return err
+		}
+
+		microflow := packet.ovnMicroflow()
+		args := append(getOVNTraceDBArgs(ctx), "--ct=new", packet.logicalSwitch, microflow)
+		fmt.Printf("ovn-trace %s '%s'\n", packet.logicalSwitch, microflow)
+		output, stderr, err := util.RawExec("ovn-trace", args...)
+		if err != nil {
+			return fmt.Errorf("failed to run ovn-trace, stderr: %q, error: %v", stderr, err)
+		}
+		if ctx.Bool("detailed") {
+			fmt.Println(output)
+		}
+		fmt.Print(summarizeTrace(output))
+
+		if ctx.Bool("ovs") {
+			return traceOVS(packet)
+		}
+		return nil
+	},
+}
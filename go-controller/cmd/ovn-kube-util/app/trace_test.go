@@ -0,0 +1,159 @@
+package app
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTracePacketFlows(t *testing.T) {
+	srcMAC, _ := net.ParseMAC("0a:58:0a:80:01:03")
+	dstMAC, _ := net.ParseMAC("0a:58:0a:80:01:01")
+	packet := &tracePacket{
+		logicalSwitch: "node1",
+		inport:        "ns1_pod1",
+		srcMAC:        srcMAC,
+		dstMAC:        dstMAC,
+		srcIP:         net.ParseIP("10.128.1.3"),
+		dstIP:         net.ParseIP("172.30.0.10"),
+		protocol:      "tcp",
+		dstPort:       80,
+	}
+	microflow := `inport == "ns1_pod1" && eth.src == 0a:58:0a:80:01:03 && eth.dst == 0a:58:0a:80:01:01 && ` +
+		`ip4.src == 10.128.1.3 && ip4.dst == 172.30.0.10 && ip.ttl == 64 && tcp.dst == 80`
+	if got := packet.ovnMicroflow(); got != microflow {
+		t.Errorf("expected microflow %s, got %s", microflow, got)
+	}
+	flow := "in_port=5,tcp,dl_src=0a:58:0a:80:01:03,dl_dst=0a:58:0a:80:01:01," +
+		"nw_src=10.128.1.3,nw_dst=172.30.0.10,nw_ttl=64,tp_dst=80"
+	if got := packet.ovsFlow("5"); got != flow {
+		t.Errorf("expected flow %s, got %s", flow, got)
+	}
+
+	packet.srcIP = net.ParseIP("fd00:10:128:1::3")
+	packet.dstIP = net.ParseIP("fd00:10:128:2::4")
+	packet.protocol = "icmp"
+	microflow = `inport == "ns1_pod1" && eth.src == 0a:58:0a:80:01:03 && eth.dst == 0a:58:0a:80:01:01 && ` +
+		`ip6.src == fd00:10:128:1::3 && ip6.dst == fd00:10:128:2::4 && ip.ttl == 64 && icmp6.type == 128`
+	if got := packet.ovnMicroflow(); got != microflow {
+		t.Errorf("expected microflow %s, got %s", microflow, got)
+	}
+	flow = "in_port=5,icmp6,dl_src=0a:58:0a:80:01:03,dl_dst=0a:58:0a:80:01:01," +
+		"ipv6_src=fd00:10:128:1::3,ipv6_dst=fd00:10:128:2::4,nw_ttl=64,icmp_type=128"
+	if got := packet.ovsFlow("5"); got != flow {
+		t.Errorf("expected flow %s, got %s", flow, got)
+	}
+}
+
+const testTraceOutput = `# tcp,reg14=0x2,vlan_tci=0x0000,dl_src=0a:58:0a:80:01:03,dl_dst=0a:58:0a:80:01:01,nw_src=10.128.1.3,nw_dst=172.30.0.10,nw_tos=0,nw_ecn=0,nw_ttl=64,tp_src=0,tp_dst=80,tcp_flags=0
+
+ingress(dp="node1", inport="ns1_pod1")
+--------------------------------------
+ 0. ls_in_port_sec_l2 (ovn-northd.c:4629): inport == "ns1_pod1" && eth.src == {0a:58:0a:80:01:03}, priority 50, uuid 5ba9bd2d
+    next;
+ 6. ls_in_acl (ovn-northd.c:4998): ip && (!ct.est || (ct.est && ct_label.blocked == 1)), priority 1, uuid 9ab4dc8e
+    next;
+10. ls_in_stateful (ovn-northd.c:5317): ct.new && ip4.dst == 172.30.0.10 && tcp.dst == 80, priority 120, uuid 0c3f1ed7
+    ct_lb(backends=10.128.2.4:8080);
+
+ct_lb
+-----
+ * Connection tracking: ct_lb(backends=10.128.2.4:8080)
+
+ingress(dp="ovn_cluster_router", inport="rtos-node1")
+-----------------------------------------------------
+ 7. lr_in_ip_routing (ovn-northd.c:7038): ip4.dst == 10.128.2.0/24, priority 49, uuid 3f9b5c12
+    ip.ttl--;
+    reg8[0..15] = 0;
+    next;
+
+egress(dp="node2", inport="stor-node2", outport="ns1_pod2")
+-----------------------------------------------------------
+ 4. ls_out_acl (ovn-northd.c:4960): outport == @a1234 && ip4, priority 2000, uuid 8e3c1a2b
+    drop;
+`
+
+func TestPodLogicalSwitch(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&kapi.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1"}},
+		&kapi.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "ns2",
+			Annotations: map[string]string{"k8s.ovn.org/provider-network": "physnet2"},
+		}},
+	)
+	for _, tc := range []struct {
+		namespace   string
+		annotations map[string]string
+		expected    string
+	}{
+		{namespace: "ns1", expected: "node1"},
+		{
+			namespace:   "ns1",
+			annotations: map[string]string{"k8s.ovn.org/provider-network": "physnet1"},
+			expected:    "provnet_physnet1",
+		},
+		{namespace: "ns2", expected: "provnet_physnet2"},
+	} {
+		pod := &kapi.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: tc.namespace, Annotations: tc.annotations},
+			Spec:       kapi.PodSpec{NodeName: "node1"},
+		}
+		logicalSwitch, err := getPodLogicalSwitch(clientset, pod)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if logicalSwitch != tc.expected {
+			t.Errorf("expected pod in %s with annotations %v on switch %s, got %s",
+				tc.namespace, tc.annotations, tc.expected, logicalSwitch)
+		}
+	}
+}
+
+func TestSummarizeTrace(t *testing.T) {
+	summary := summarizeTrace(testTraceOutput)
+	expected := []*traceDecision{
+		{
+			kind:     "Load balancer",
+			datapath: "node1",
+			stage:    "ls_in_stateful",
+			priority: 120,
+			match:    "ct.new && ip4.dst == 172.30.0.10 && tcp.dst == 80",
+			actions:  "ct_lb(backends=10.128.2.4:8080);",
+		},
+		{
+			kind:     "Route",
+			datapath: "ovn_cluster_router",
+			stage:    "lr_in_ip_routing",
+			priority: 49,
+			match:    "ip4.dst == 10.128.2.0/24",
+			actions:  "ip.ttl--;",
+		},
+		{
+			kind:     "ACL",
+			datapath: "node2",
+			stage:    "ls_out_acl",
+			priority: 2000,
+			match:    "outport == @a1234 && ip4",
+			actions:  "drop;",
+		},
+	}
+	if !reflect.DeepEqual(summary.decisions, expected) {
+		t.Errorf("expected decisions %v, got %v", expected, summary.decisions)
+	}
+	if summary.dropped != summary.decisions[2] {
+		t.Errorf("expected the packet to be dropped by the ACL, got %v", summary.dropped)
+	}
+
+	summary = summarizeTrace(`egress(dp="node2", inport="stor-node2", outport="ns1_pod2")
+ 9. ls_out_port_sec_l2 (ovn-northd.c:4694): outport == "ns1_pod2" && eth.dst == {0a:58:0a:80:02:04}, priority 50, uuid 1a2b3c4d
+    output;
+    /* output to "ns1_pod2", type "" */
+`)
+	if summary.dropped != nil || !reflect.DeepEqual(summary.outputs, []string{"ns1_pod2"}) {
+		t.Errorf("expected the packet to be output to ns1_pod2, got %v", summary)
+	}
+}
@@ -29,6 +29,8 @@ func main() {
 		&app.BridgesToNicCommand,
 		&app.ReadinessProbeCommand,
 		&app.OvnDBExporterCommand,
+		&app.TraceCommand,
+		&app.OwnershipCommand,
 	}
 
 	c.Before = func(ctx *cli.Context) error {
@@ -15,13 +15,16 @@ import (
 	"text/template"
 	"time"
 
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
 
 	"github.com/urfave/cli/v2"
 	"gopkg.in/fsnotify/fsnotify.v1"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	nodefirewallv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodefirewall/v1"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/logging"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	ovnnode "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/node"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn"
@@ -193,13 +196,6 @@ func runOvnKube(ctx *cli.Context) error {
 		return err
 	}
 
-	// create factory and start the controllers asked for
-	stopChan := make(chan struct{})
-	factory, err := factory.NewWatchFactory(clientset, stopChan)
-	if err != nil {
-		return err
-	}
-
 	master := ctx.String("init-master")
 	node := ctx.String("init-node")
 
@@ -215,23 +211,67 @@ func runOvnKube(ctx *cli.Context) error {
 		return nil
 	}
 
+	if ctx.Bool("check-nbdb") {
+		if master != "" || node != "" {
+			return fmt.Errorf("cannot specify check-nbdb together with 'init-node or 'init-master'")
+		}
+		return checkNBDB(clientset)
+	}
+
 	if master == "" && node == "" {
 		return fmt.Errorf("need to run ovnkube in either master and/or node mode")
 	}
 
+	// The JSON log messages carry the roles of the process and its node
+	switch {
+	case master != "" && node != "":
+		logging.SetComponent("ovnkube", node)
+	case master != "":
+		logging.SetComponent("ovnkube-master", master)
+	default:
+		logging.SetComponent("ovnkube-node", node)
+	}
+
 	// Set up a watch on our config file; if it changes, we exit -
 	// (we don't have the ability to dynamically reload config changes).
 	if err := watchForChanges(configFile); err != nil {
 		return fmt.Errorf("unable to setup configuration watch: %v", err)
 	}
 
+	// create factory and start the controllers asked for. A node only
+	// watches the resources it uses, and only its own pods.
+	stopChan := make(chan struct{})
+	var watchFactory *factory.WatchFactory
+	if master != "" {
+		watchFactory, err = factory.NewWatchFactory(clientset, stopChan)
+	} else {
+		resources := []factory.Resource{factory.Services, factory.Endpoints, factory.Nodes}
+		if config.HybridOverlay.Enabled {
+			resources = append(resources, factory.Pods, factory.Namespaces)
+		}
+		watchFactory, err = factory.NewNodeWatchFactory(clientset, node, stopChan, resources...)
+	}
+	if err != nil {
+		return err
+	}
+
 	if master != "" {
 		if runtime.GOOS == "windows" {
 			return fmt.Errorf("Windows is not supported as a master")
 		}
 		// register prometheus metrics exported by the master
 		metrics.RegisterMasterMetrics()
-		ovnController := ovn.NewOvnController(clientset, factory, stopChan)
+		var nodeFirewallClient nodefirewallv1.Interface
+		if config.EnableNodeFirewall {
+			restConfig, err := util.NewRESTConfig(&config.Kubernetes)
+			if err != nil {
+				return err
+			}
+			if nodeFirewallClient, err = nodefirewallv1.NewForConfig(restConfig); err != nil {
+				return err
+			}
+		}
+		ovnController := ovn.NewOvnController(clientset, nodeFirewallClient, watchFactory, stopChan)
 		if err := ovnController.Start(clientset, master); err != nil {
 			return err
 		}
@@ -246,7 +286,7 @@ func runOvnKube(ctx *cli.Context) error {
 		// register ovn specific (ovn-controller and ovn-northd) metrics
 		metrics.RegisterOvnMetrics()
 		start := time.Now()
-		n := ovnnode.NewNode(clientset, factory, node, stopChan)
+		n := ovnnode.NewNode(clientset, watchFactory, node, stopChan)
 		if err := n.Start(); err != nil {
 			return err
 		}
@@ -257,7 +297,8 @@ func runOvnKube(ctx *cli.Context) error {
 	// now that ovnkube master/node are running, lets expose the metrics HTTP endpoint if configured
 	// start the prometheus server
 	if config.Kubernetes.MetricsBindAddress != "" {
-		metrics.StartMetricsServer(config.Kubernetes.MetricsBindAddress, config.Kubernetes.MetricsEnablePprof)
+		metrics.StartMetricsServer(config.Kubernetes.MetricsBindAddress, config.Kubernetes.MetricsEnablePprof,
+			config.Kubernetes.MetricsDebugTokenFile)
 	}
 
 	// run until cancelled
@@ -266,6 +307,36 @@ func runOvnKube(ctx *cli.Context) error {
 	return nil
 }
 
+// checkNBDB prints the differences between the northbound database and the
+// Kubernetes objects, repairing them if asked to. It fails if it found
+// differences it did not repair.
+func checkNBDB(clientset kubernetes.Interface) error {
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	watchFactory, err := factory.NewWatchFactory(clientset, stopChan)
+	if err != nil {
+		return err
+	}
+
+	ovnController := ovn.NewOvnController(clientset, nil, watchFactory, stopChan)
+	drifts, err := ovnController.CheckNBDB()
+	if err != nil {
+		return err
+	}
+	for _, d := range drifts {
+		fmt.Println(d)
+	}
+	if len(drifts) == 0 {
+		return nil
+	}
+	if !config.NBDBCheck.Repair {
+		return fmt.Errorf("found %d differences between the northbound database and the kubernetes objects", len(drifts))
+	}
+	// Missing logical ports are added back by the master
+	ovnController.RepairNBDB(drifts, false)
+	return nil
+}
+
 // watchForChanges exits if the configuration file changed.
 func watchForChanges(configPath string) error {
 	if configPath == "" {
@@ -90,7 +90,8 @@ func runHybridOverlay(ctx *cli.Context) error {
 	stopChan := make(chan struct{})
 	defer close(stopChan)
 
-	factory, err := factory.NewWatchFactory(clientset, stopChan)
+	factory, err := factory.NewNodeWatchFactory(clientset, nodeName, stopChan,
+		factory.Pods, factory.Namespaces, factory.Nodes)
 	if err != nil {
 		return err
 	}
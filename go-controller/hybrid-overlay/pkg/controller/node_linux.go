@@ -15,6 +15,7 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	"github.com/vishvananda/netlink"
@@ -333,7 +334,12 @@ func (n *NodeController) Start(wf *factory.WatchFactory) error {
 		return err
 	}
 
-	return n.startPodWatch(wf)
+	if err := n.startPodWatch(wf); err != nil {
+		return err
+	}
+
+	metrics.RegisterDebugState("node/hybrid-overlay", n.debugState)
+	return nil
 }
 
 func (n *NodeController) startPodWatch(wf *factory.WatchFactory) error {
@@ -782,3 +788,69 @@ func (n *NodeController) updateFlowCacheEntry(cookie string, flows []string, ign
 	n.flowCache[cookie] = &flowCacheEntry{flows: flows}
 	n.flowCache[cookie].ignoreLearn = ignoreLearn
 }
+
+type debugFlowCacheEntry struct {
+	Flows       []string `json:"flows"`
+	LearnedFlow string   `json:"learnedFlow,omitempty"`
+}
+
+type debugNodeState struct {
+	// FlowCache maps the flow cookies to the cached flows
+	FlowCache map[string]*debugFlowCacheEntry `json:"flowCache"`
+	// TunMap maps the pod IPs to the VTEPs of their namespaces
+	TunMap map[string]string `json:"tunMap"`
+}
+
+// debugState returns the flow cache for the debug endpoint of the metrics
+// server. A filter on pods only returns the flows of the pods' IPs.
+func (n *NodeController) debugState(filter *metrics.DebugFilter) interface{} {
+	var podIPs map[string]bool
+	if filter.Namespace != "" || filter.Pod != "" {
+		podIPs = make(map[string]bool)
+		pods, err := n.wf.GetPods(filter.Namespace)
+		if err != nil {
+			klog.Errorf("Failed to get pods: %v", err)
+		}
+		for _, pod := range pods {
+			if !filter.MatchesPod(pod.Namespace, pod.Name) {
+				continue
+			}
+			ips, _, err := getPodDetails(pod, n.nodeName)
+			if err != nil {
+				continue
+			}
+			for _, ip := range ips {
+				podIPs[ip.IP.String()] = true
+			}
+		}
+	}
+	cookies := make(map[string]bool, len(podIPs))
+	for ip := range podIPs {
+		cookies[podIPToCookie(net.ParseIP(ip))] = true
+	}
+
+	state := &debugNodeState{
+		FlowCache: make(map[string]*debugFlowCacheEntry),
+		TunMap:    make(map[string]string),
+	}
+	n.flowMutex.Lock()
+	for cookie, entry := range n.flowCache {
+		if podIPs != nil && !cookies[cookie] {
+			continue
+		}
+		state.FlowCache[cookie] = &debugFlowCacheEntry{
+			Flows:       append([]string{}, entry.flows...),
+			LearnedFlow: entry.learnedFlow,
+		}
+	}
+	n.flowMutex.Unlock()
+
+	n.tunMapMutex.Lock()
+	for podIP, vtep := range n.tunMap {
+		if podIPs == nil || podIPs[podIP] {
+			state.TunMap[podIP] = vtep
+		}
+	}
+	n.tunMapMutex.Unlock()
+	return state
+}
@@ -17,6 +17,7 @@ import (
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/logging"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 )
 
@@ -61,8 +62,10 @@ func extractPodBandwidthResources(podAnnotations map[string]string) (int64, int6
 	return ingress, egress, nil
 }
 
+// podDescription returns the prefix of the log messages about the request,
+// the same as the CNI shim's
 func podDescription(pr *PodRequest) string {
-	return fmt.Sprintf("[%s/%s]", pr.PodNamespace, pr.PodName)
+	return logging.Pod(pr.PodNamespace, pr.PodName).Operation(pr.Command.operation()).String()
 }
 
 func (pr *PodRequest) cmdAdd(kclient kubernetes.Interface) ([]byte, error) {
@@ -86,7 +89,7 @@ func (pr *PodRequest) cmdAdd(kclient kubernetes.Interface) ([]byte, error) {
 				// Pod not found; don't bother waiting longer
 				return false, err
 			}
-			klog.Warningf("error getting pod annotations: %v", err)
+			klog.Warningf("%s error getting pod annotations: %v", podDescription(pr), err)
 			return false, nil
 		}
 		if _, ok := annotations[util.OvnPodAnnotationName]; ok {
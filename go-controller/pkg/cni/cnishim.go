@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"k8s.io/klog"
 	"net"
@@ -25,6 +26,7 @@ import (
 
 	ovntypes "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/logging"
 )
 
 // Plugin is the structure to hold the endpoint information and the corresponding
@@ -58,6 +60,14 @@ func newCNIRequest(args *skel.CmdArgs) *Request {
 	}
 }
 
+// logContext returns the context of the log messages about the pod and the
+// command of the request, the same as the CNI server's
+func logContext(req *Request) *logging.Context {
+	// the server reports missing arguments
+	cniArgs, _ := gatherCNIArgs(req.Env)
+	return logging.Pod(cniArgs["K8S_POD_NAMESPACE"], cniArgs["K8S_POD_NAME"]).Operation(command(req.Env["CNI_COMMAND"]).operation())
+}
+
 // Send a CNI request to the CNI server via JSON + HTTP over a root-owned unix socket,
 // and return the result
 func (p *Plugin) doCNI(url string, req interface{}) ([]byte, error) {
@@ -107,21 +117,32 @@ func setupLogging(conf *ovntypes.NetConf) {
 			klog.Warningf("failed to set klog log level to %s: %v", conf.LogLevel, err)
 		}
 	}
+	var file *os.File
 	if conf.LogFile != "" {
-		var file *os.File
-
 		if _, err = os.Stat(filepath.Dir(conf.LogFile)); os.IsNotExist(err) {
 			dir := filepath.Dir(conf.LogFile)
 			if err = os.MkdirAll(dir, 0755); err != nil {
 				klog.Warningf("failed to create logfile directory %s (%v).", dir, err)
-				return
 			}
 		}
 		file, err = os.OpenFile(conf.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0660)
 		if err != nil {
 			klog.Warningf("failed to open logfile %s (%v).", conf.LogFile, err)
-			return
+			file = nil
+		}
+	}
+	if conf.LogFormat == logging.FormatJSON {
+		logging.SetComponent("ovn-k8s-cni-overlay", "")
+		var out io.Writer = os.Stderr
+		if file != nil {
+			out = file
+		}
+		if err := logging.SetJSONOutput(out); err != nil {
+			klog.Warningf("failed to set up JSON logging: %v", err)
 		}
+		return
+	}
+	if file != nil {
 		klogFlags := flag.NewFlagSet("klog", flag.ExitOnError)
 		klog.InitFlags(klogFlags)
 		if err := klogFlags.Set("logtostderr", "false"); err != nil {
@@ -161,17 +182,18 @@ func (p *Plugin) CmdAdd(args *skel.CmdArgs) error {
 	setupLogging(conf)
 
 	req := newCNIRequest(args)
+	logCtx := logContext(req)
 
 	body, err := p.doCNI("http://dummy/", req)
 	if err != nil {
-		klog.Error(err.Error())
+		klog.Errorf("%s %v", logCtx, err)
 		return err
 	}
 
 	response := &Response{}
 	if err = json.Unmarshal(body, response); err != nil {
 		err = fmt.Errorf("failed to unmarshal response '%s': %v", string(body), err)
-		klog.Error(err.Error())
+		klog.Errorf("%s %v", logCtx, err)
 		return err
 	}
 
@@ -183,7 +205,7 @@ func (p *Plugin) CmdAdd(args *skel.CmdArgs) error {
 		result, err = pr.getCNIResult(response.PodIFInfo)
 		if err != nil {
 			err = fmt.Errorf("failed to get CNI Result from pod interface info %v: %v", response.PodIFInfo, err)
-			klog.Error(err.Error())
+			klog.Errorf("%s %v", logCtx, err)
 			return err
 		}
 	}
@@ -200,9 +222,10 @@ func (p *Plugin) CmdDel(args *skel.CmdArgs) error {
 		setupLogging(conf)
 	}
 
-	_, err = p.doCNI("http://dummy/", newCNIRequest(args))
+	req := newCNIRequest(args)
+	_, err = p.doCNI("http://dummy/", req)
 	if err != nil {
-		klog.Errorf(err.Error())
+		klog.Errorf("%s %v", logContext(req), err)
 	}
 	p.postMetrics(startTime, CNIDel, err)
 	return err
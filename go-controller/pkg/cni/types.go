@@ -2,6 +2,7 @@ package cni
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/containernetworking/cni/pkg/types/current"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/cni/types"
@@ -37,6 +38,15 @@ const CNIUpdate command = "UPDATE"
 // CNIDel is the command representing delete operation on a pod that is to be torn down
 const CNIDel command = "DEL"
 
+// operation returns the operation of the command in log messages, named like
+// the add, update and delete operations of the master's handlers
+func (c command) operation() string {
+	if c == CNIDel {
+		return "delete"
+	}
+	return strings.ToLower(string(c))
+}
+
 // Request sent to the Server by the OVN CNI plugin
 type Request struct {
 	// CNI environment variables, like CNI_COMMAND and CNI_NETNS
@@ -14,6 +14,8 @@ type NetConf struct {
 	LogFile string `json:"logFile,omitempty"`
 	// Level is the logging verbosity level
 	LogLevel string `json:"logLevel,omitempty"`
+	// LogFormat is the format of the log messages, text or json
+	LogFormat string `json:"logFormat,omitempty"`
 }
 
 // NetworkSelectionElement represents one element of the JSON format
@@ -31,3 +33,18 @@ type NetworkSelectionElement struct {
 	// GatewayRequest contains default route IP address for the pod
 	GatewayRequest []net.IP `json:"default-route,omitempty"`
 }
+
+// NetworkStatus represents one element of the JSON format Network Attachment
+// Status Annotation as described in section 4.1.2 of the CRD specification.
+type NetworkStatus struct {
+	// Name contains the name of the network attachment
+	Name string `json:"name"`
+	// Interface contains the pod interface name for the attachment
+	Interface string `json:"interface,omitempty"`
+	// IPs contains the IP addresses assigned to the attachment
+	IPs []string `json:"ips,omitempty"`
+	// Mac contains the MAC address of the attachment interface
+	Mac string `json:"mac,omitempty"`
+	// Default is true if the attachment is the pod's default network
+	Default bool `json:"default,omitempty"`
+}
@@ -21,8 +21,9 @@ func WriteCNIConfig() error {
 			Name:       "ovn-kubernetes",
 			Type:       CNI.Plugin,
 		},
-		LogFile:  Logging.CNIFile,
-		LogLevel: fmt.Sprintf("%d", Logging.Level),
+		LogFile:   Logging.CNIFile,
+		LogLevel:  fmt.Sprintf("%d", Logging.Level),
+		LogFormat: Logging.Format,
 	}
 
 	bytes, err := json.Marshal(netConf)
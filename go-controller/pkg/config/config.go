@@ -3,6 +3,7 @@ package config
 import (
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/url"
@@ -15,10 +16,12 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/logging"
 	"github.com/urfave/cli/v2"
 	gcfg "gopkg.in/gcfg.v1"
 	lumberjack "gopkg.in/natefinch/lumberjack.v2"
 	"k8s.io/klog"
+	klogv2 "k8s.io/klog/v2"
 
 	kexec "k8s.io/utils/exec"
 	utilnet "k8s.io/utils/net"
@@ -60,6 +63,7 @@ var (
 		File:    "", // do not log to a file by default
 		CNIFile: "",
 		Level:   4,
+		Format:  logging.FormatText,
 	}
 
 	// CNI holds CNI-related parsed config file parameters and command-line overrides
@@ -97,6 +101,19 @@ var (
 		RawClusterSubnets: "10.132.0.0/14/23",
 	}
 
+	// ProviderNetworks holds the provider networks config options.
+	ProviderNetworks ProviderNetworkConfig
+
+	// IPsec holds the overlay encryption config options.
+	IPsec = IPsecConfig{
+		CertDir:            "/etc/openvswitch/ipsec",
+		SignerName:         "kubernetes.io/legacy-unknown",
+		NodeServiceAccount: "ovn-kubernetes:ovn",
+	}
+
+	// NBDBCheck holds the northbound database drift check config options.
+	NBDBCheck NBDBCheckConfig
+
 	// NbctlDaemon enables ovn-nbctl to run in daemon mode
 	NbctlDaemonMode bool
 
@@ -106,6 +123,10 @@ var (
 	// EnableMulticast enables multicast support between the pods within the same namespace
 	EnableMulticast bool
 
+	// EnableNodeFirewall renders the NodeFirewall custom resources as ACLs on
+	// the external switches of the gateway routers
+	EnableNodeFirewall bool
+
 	// IPv4Mode captures whether we are using IPv4 for OVN logical topology. (ie, single-stack IPv4 or dual-stack)
 	IPv4Mode bool
 
@@ -123,6 +144,10 @@ const (
 type DefaultConfig struct {
 	// MTU value used for the overlay networks.
 	MTU int `gcfg:"mtu"`
+	// MTUAutoDetect sets the MTU of the overlay networks on each node to the
+	// MTU of the interface owning the encapsulation IP less the overhead of
+	// the encapsulation, instead of using MTU.
+	MTUAutoDetect bool `gcfg:"mtu-auto-detect"`
 	// ConntrackZone affects only the gateway nodes, This value is used to track connections
 	// that are initiated from the pods so that the reverse connections go back to the pods.
 	// This represents the conntrack zone used for the conntrack flow rules.
@@ -158,6 +183,9 @@ type LoggingConfig struct {
 	CNIFile string `gcfg:"cnilogfile"`
 	// Level is the logging verbosity level
 	Level int `gcfg:"loglevel"`
+	// Format is the format of the log messages, text or json. The CNI shim
+	// logs in the same format.
+	Format string `gcfg:"logformat"`
 }
 
 // CNIConfig holds CNI-related parsed config file parameters and command-line overrides
@@ -172,20 +200,21 @@ type CNIConfig struct {
 
 // KubernetesConfig holds Kubernetes-related parsed config file parameters and command-line overrides
 type KubernetesConfig struct {
-	Kubeconfig           string `gcfg:"kubeconfig"`
-	CACert               string `gcfg:"cacert"`
-	APIServer            string `gcfg:"apiserver"`
-	Token                string `gcfg:"token"`
-	CompatServiceCIDR    string `gcfg:"service-cidr"`
-	RawServiceCIDRs      string `gcfg:"service-cidrs"`
-	ServiceCIDRs         []*net.IPNet
-	OVNConfigNamespace   string `gcfg:"ovn-config-namespace"`
-	MetricsBindAddress   string `gcfg:"metrics-bind-address"`
-	MetricsEnablePprof   bool   `gcfg:"metrics-enable-pprof"`
-	OVNEmptyLbEvents     bool   `gcfg:"ovn-empty-lb-events"`
-	PodIP                string `gcfg:"pod-ip"` // UNUSED
-	RawNoHostSubnetNodes string `gcfg:"no-hostsubnet-nodes"`
-	NoHostSubnetNodes    *metav1.LabelSelector
+	Kubeconfig            string `gcfg:"kubeconfig"`
+	CACert                string `gcfg:"cacert"`
+	APIServer             string `gcfg:"apiserver"`
+	Token                 string `gcfg:"token"`
+	CompatServiceCIDR     string `gcfg:"service-cidr"`
+	RawServiceCIDRs       string `gcfg:"service-cidrs"`
+	ServiceCIDRs          []*net.IPNet
+	OVNConfigNamespace    string `gcfg:"ovn-config-namespace"`
+	MetricsBindAddress    string `gcfg:"metrics-bind-address"`
+	MetricsEnablePprof    bool   `gcfg:"metrics-enable-pprof"`
+	MetricsDebugTokenFile string `gcfg:"metrics-debug-token-file"`
+	OVNEmptyLbEvents      bool   `gcfg:"ovn-empty-lb-events"`
+	PodIP                 string `gcfg:"pod-ip"` // UNUSED
+	RawNoHostSubnetNodes  string `gcfg:"no-hostsubnet-nodes"`
+	NoHostSubnetNodes     *metav1.LabelSelector
 }
 
 // GatewayMode holds the node gateway mode
@@ -206,7 +235,8 @@ type GatewayConfig struct {
 	Mode GatewayMode `gcfg:"mode"`
 	// Interface is the network interface to use for the gateway in "shared" mode
 	Interface string `gcfg:"interface"`
-	// NextHop is the gateway IP address of Interface; will be autodetected if not given
+	// NextHop is the gateway IP address of Interface, or a comma-separated
+	// IPv4 and IPv6 pair on dual-stack nodes; will be autodetected if not given
 	NextHop string `gcfg:"next-hop"`
 	// VLANID is the option VLAN tag to apply to gateway traffic for "shared" mode
 	VLANID uint `gcfg:"vlan-id"`
@@ -251,6 +281,65 @@ type HybridOverlayConfig struct {
 	ClusterSubnets []CIDRNetworkEntry
 }
 
+// ProviderNetworkConfig holds configuration for provider networks, physical
+// networks that pods can be attached to directly instead of the cluster
+// network.
+type ProviderNetworkConfig struct {
+	// RawBridgeMappings holds the unparsed mappings of physical network names
+	// to the node's OVS bridges connected to them.
+	// Should only be used inside config module.
+	RawBridgeMappings string `gcfg:"bridge-mappings"`
+	// BridgeMappings holds the parsed physical network name to OVS bridge
+	// mappings and may be used outside the config module.
+	BridgeMappings map[string]string
+	// RawNetworks holds the unparsed provider network definitions.
+	// Should only be used inside config module.
+	RawNetworks string `gcfg:"networks"`
+	// Networks holds the parsed provider network definitions and may be
+	// used outside the config module.
+	Networks []ProviderNetwork
+}
+
+// ProviderNetwork is a physical network pods can be attached to
+type ProviderNetwork struct {
+	// Name is the name of the physical network, as used in bridge mappings
+	Name string
+	// VLANID is the VLAN of the network, or 0 if the network is untagged
+	VLANID uint
+	// Subnet is the subnet attached pods get their addresses from
+	Subnet *net.IPNet
+}
+
+// IPsecConfig holds configuration for the encryption of the traffic between
+// the nodes with OVS IPsec
+type IPsecConfig struct {
+	// Enabled encrypts the tunnels between the nodes
+	Enabled bool `gcfg:"enabled"`
+	// CertDir is the directory the node keeps its IPsec private key and
+	// certificate in
+	CertDir string `gcfg:"cert-dir"`
+	// CACert is the CA certificate of the signer of the certificate signing
+	// requests. The Kubernetes CA certificate is used if it is empty.
+	CACert string `gcfg:"ca-cert"`
+	// SignerName is the signer the nodes request their certificates from.
+	// The master only approves requests for this signer.
+	SignerName string `gcfg:"signer-name"`
+	// NodeServiceAccount is the <namespace>:<name> service account of
+	// ovnkube-node, whose certificate signing requests are approved when
+	// they come from a pod running on the requested node
+	NodeServiceAccount string `gcfg:"node-service-account"`
+}
+
+// NBDBCheckConfig holds configuration for the periodic check of the
+// northbound database against the Kubernetes objects
+type NBDBCheckConfig struct {
+	// Interval is the number of seconds between two checks. The check is
+	// disabled if it is 0.
+	Interval int `gcfg:"interval"`
+	// Repair repairs the differences the check finds
+	Repair bool `gcfg:"repair"`
+}
+
 // OvnDBScheme describes the OVN database connection transport method
 type OvnDBScheme string
 
@@ -265,27 +354,33 @@ const (
 
 // Config is used to read the structured config file and to cache config in testcases
 type config struct {
-	Default       DefaultConfig
-	Logging       LoggingConfig
-	CNI           CNIConfig
-	Kubernetes    KubernetesConfig
-	OvnNorth      OvnAuthConfig
-	OvnSouth      OvnAuthConfig
-	Gateway       GatewayConfig
-	MasterHA      MasterHAConfig
-	HybridOverlay HybridOverlayConfig
+	Default          DefaultConfig
+	Logging          LoggingConfig
+	CNI              CNIConfig
+	Kubernetes       KubernetesConfig
+	OvnNorth         OvnAuthConfig
+	OvnSouth         OvnAuthConfig
+	Gateway          GatewayConfig
+	MasterHA         MasterHAConfig
+	HybridOverlay    HybridOverlayConfig
+	ProviderNetworks ProviderNetworkConfig
+	IPsec            IPsecConfig
+	NBDBCheck        NBDBCheckConfig
 }
 
 var (
-	savedDefault       DefaultConfig
-	savedLogging       LoggingConfig
-	savedCNI           CNIConfig
-	savedKubernetes    KubernetesConfig
-	savedOvnNorth      OvnAuthConfig
-	savedOvnSouth      OvnAuthConfig
-	savedGateway       GatewayConfig
-	savedMasterHA      MasterHAConfig
-	savedHybridOverlay HybridOverlayConfig
+	savedDefault          DefaultConfig
+	savedLogging          LoggingConfig
+	savedCNI              CNIConfig
+	savedKubernetes       KubernetesConfig
+	savedOvnNorth         OvnAuthConfig
+	savedOvnSouth         OvnAuthConfig
+	savedGateway          GatewayConfig
+	savedMasterHA         MasterHAConfig
+	savedHybridOverlay    HybridOverlayConfig
+	savedProviderNetworks ProviderNetworkConfig
+	savedIPsec            IPsecConfig
+	savedNBDBCheck        NBDBCheckConfig
 	// legacy service-cluster-ip-range CLI option
 	serviceClusterIPRange string
 	// legacy cluster-subnet CLI option
@@ -307,6 +402,9 @@ func init() {
 	savedGateway = Gateway
 	savedMasterHA = MasterHA
 	savedHybridOverlay = HybridOverlay
+	savedProviderNetworks = ProviderNetworks
+	savedIPsec = IPsec
+	savedNBDBCheck = NBDBCheck
 	Flags = append(Flags, CommonFlags...)
 	Flags = append(Flags, CNIFlags...)
 	Flags = append(Flags, K8sFlags...)
@@ -315,6 +413,9 @@ func init() {
 	Flags = append(Flags, OVNGatewayFlags...)
 	Flags = append(Flags, MasterHAFlags...)
 	Flags = append(Flags, HybridOverlayFlags...)
+	Flags = append(Flags, ProviderNetworkFlags...)
+	Flags = append(Flags, IPsecFlags...)
+	Flags = append(Flags, NBDBCheckFlags...)
 }
 
 // PrepareTestConfig restores default config values. Used by testcases to
@@ -329,6 +430,9 @@ func PrepareTestConfig() {
 	Gateway = savedGateway
 	MasterHA = savedMasterHA
 	HybridOverlay = savedHybridOverlay
+	ProviderNetworks = savedProviderNetworks
+	IPsec = savedIPsec
+	NBDBCheck = savedNBDBCheck
 
 	// Don't pick up defaults from the environment
 	os.Unsetenv("KUBECONFIG")
@@ -410,6 +514,10 @@ var CommonFlags = []cli.Flag{
 		Name:  "cleanup-node",
 		Usage: "cleanup node, requires the name that node is registered with in kubernetes cluster",
 	},
+	&cli.BoolFlag{
+		Name:  "check-nbdb",
+		Usage: "check the logical switch ports, address sets, port groups and cluster load balancer VIPs in the northbound database against the kubernetes objects once and exit, repairing the differences with --nbdb-repair",
+	},
 	&cli.StringFlag{
 		Name:  "pidfile",
 		Usage: "Name of file that will hold the ovnkube pid (optional)",
@@ -425,6 +533,12 @@ var CommonFlags = []cli.Flag{
 		Destination: &cliConfig.Default.MTU,
 		Value:       Default.MTU,
 	},
+	&cli.BoolFlag{
+		Name: "mtu-auto-detect",
+		Usage: "Use the MTU of the interface owning the encapsulation IP less the " +
+			"encapsulation overhead as the MTU of the overlay networks, instead of --mtu",
+		Destination: &cliConfig.Default.MTUAutoDetect,
+	},
 	&cli.IntFlag{
 		Name:        "conntrack-zone",
 		Usage:       "For gateway nodes, the conntrack zone used for conntrack flow rules (default: 64000)",
@@ -496,6 +610,11 @@ var CommonFlags = []cli.Flag{
 		Usage:       "Adds multicast support. Valid only with --init-master option.",
 		Destination: &EnableMulticast,
 	},
+	&cli.BoolFlag{
+		Name:        "enable-node-firewall",
+		Usage:       "Restricts the external traffic reaching the nodes through their gateway routers according to the NodeFirewall custom resources. Valid only with --init-master option.",
+		Destination: &EnableNodeFirewall,
+	},
 	// Logging options
 	&cli.IntFlag{
 		Name:        "loglevel",
@@ -514,6 +633,12 @@ var CommonFlags = []cli.Flag{
 		Destination: &cliConfig.Logging.CNIFile,
 		Value:       "/var/log/ovn-kubernetes/ovn-k8s-cni-overlay.log",
 	},
+	&cli.StringFlag{
+		Name:        "logformat",
+		Usage:       "format of the log messages: text, or json for one JSON object per message carrying the objects it is about as fields (default: text)",
+		Destination: &cliConfig.Logging.Format,
+		Value:       Logging.Format,
+	},
 }
 
 // CNIFlags capture CNI-related options
@@ -596,6 +721,12 @@ var K8sFlags = []cli.Flag{
 		Usage:       "If true, then also accept pprof requests on the metrics port.",
 		Destination: &cliConfig.Kubernetes.MetricsEnablePprof,
 	},
+	&cli.StringFlag{
+		Name: "metrics-debug-token-file",
+		Usage: "If set, then also serve the internal state of the controllers as JSON on /debug/ovnkube/ " +
+			"of the metrics port to the requests with the bearer token in this file.",
+		Destination: &cliConfig.Kubernetes.MetricsDebugTokenFile,
+	},
 	&cli.BoolFlag{
 		Name: "ovn-empty-lb-events",
 		Usage: "If set, then load balancers do not get deleted when all backends are removed. " +
@@ -693,8 +824,9 @@ var OVNGatewayFlags = []cli.Flag{
 		Usage: "The external default gateway which is used as a next hop by " +
 			"OVN gateway.  This is many times just the default gateway " +
 			"of the node in question. If not specified, the default gateway" +
-			"configured in the node is used. Only useful with " +
-			"\"init-gateways\"",
+			"configured in the node is used. On dual-stack nodes a " +
+			"comma-separated IPv4 and IPv6 next hop may be given. Only useful " +
+			"with \"init-gateways\"",
 		Destination: &cliConfig.Gateway.NextHop,
 	},
 	&cli.UintFlag{
@@ -764,6 +896,76 @@ var HybridOverlayFlags = []cli.Flag{
 	},
 }
 
+// ProviderNetworkFlags capture provider network options
+var ProviderNetworkFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name: "provider-bridge-mappings",
+		Usage: "A comma separated set of mappings of physical network names " +
+			"to the OVS bridges of the node connected to them " +
+			"(eg, \"physnet1:br-vlan,physnet2:br-dc\").",
+		Destination: &cliConfig.ProviderNetworks.RawBridgeMappings,
+	},
+	&cli.StringFlag{
+		Name: "provider-networks",
+		Usage: "A comma separated set of provider networks pods can be " +
+			"attached to, each given in the form physical network name:VLAN ID:subnet " +
+			"(eg, \"physnet1:100:10.20.0.0/24\"). A VLAN ID of 0 means the network " +
+			"is untagged. IPv6 subnets must be /64.",
+		Destination: &cliConfig.ProviderNetworks.RawNetworks,
+	},
+}
+
+// IPsecFlags capture overlay encryption options
+var IPsecFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:        "enable-ipsec",
+		Usage:       "Encrypt the traffic between the nodes with OVS IPsec",
+		Destination: &cliConfig.IPsec.Enabled,
+	},
+	&cli.StringFlag{
+		Name:        "ipsec-cert-dir",
+		Usage:       "The directory the node keeps its IPsec private key and certificate in (default: /etc/openvswitch/ipsec)",
+		Destination: &cliConfig.IPsec.CertDir,
+		Value:       IPsec.CertDir,
+	},
+	&cli.StringFlag{
+		Name: "ipsec-ca-cert",
+		Usage: "The CA certificate of the signer of the nodes' IPsec certificates " +
+			"(default: the Kubernetes CA certificate)",
+		Destination: &cliConfig.IPsec.CACert,
+	},
+	&cli.StringFlag{
+		Name: "ipsec-signer-name",
+		Usage: "The signer the nodes request their IPsec certificates from " +
+			"(default: kubernetes.io/legacy-unknown)",
+		Destination: &cliConfig.IPsec.SignerName,
+		Value:       IPsec.SignerName,
+	},
+	&cli.StringFlag{
+		Name: "ipsec-node-service-account",
+		Usage: "The <namespace>:<name> service account of ovnkube-node, whose IPsec certificate " +
+			"signing requests are approved when they come from a pod on the requested node " +
+			"(default: ovn-kubernetes:ovn)",
+		Destination: &cliConfig.IPsec.NodeServiceAccount,
+		Value:       IPsec.NodeServiceAccount,
+	},
+}
+
+// NBDBCheckFlags capture northbound database drift check options
+var NBDBCheckFlags = []cli.Flag{
+	&cli.IntFlag{
+		Name: "nbdb-check-interval",
+		Usage: "The number of seconds between two checks of the northbound " +
+			"database against the Kubernetes objects (default: 0, disabled)",
+		Destination: &cliConfig.NBDBCheck.Interval,
+	},
+	&cli.BoolFlag{
+		Name:        "nbdb-repair",
+		Usage:       "Repair the differences found by the northbound database check",
+		Destination: &cliConfig.NBDBCheck.Repair,
+	},
+}
+
 // Flags are general command-line flags. Apps should add these flags to their
 // own urfave/cli flags and call InitConfig() early in the application.
 var Flags []cli.Flag
@@ -779,6 +981,9 @@ func GetFlags(customFlags []cli.Flag) []cli.Flag {
 	flags = append(flags, OVNGatewayFlags...)
 	flags = append(flags, MasterHAFlags...)
 	flags = append(flags, HybridOverlayFlags...)
+	flags = append(flags, ProviderNetworkFlags...)
+	flags = append(flags, IPsecFlags...)
+	flags = append(flags, NBDBCheckFlags...)
 	flags = append(flags, customFlags...)
 	return flags
 }
@@ -1052,6 +1257,87 @@ func buildHybridOverlayConfig(ctx *cli.Context, cli, file *config, allSubnets *c
 	return nil
 }
 
+func buildProviderNetworkConfig(cli, file *config, allSubnets *configSubnets) error {
+	// Copy config file values over default values
+	if err := overrideFields(&ProviderNetworks, &file.ProviderNetworks, &savedProviderNetworks); err != nil {
+		return err
+	}
+
+	// And CLI overrides over config file and default values
+	if err := overrideFields(&ProviderNetworks, &cli.ProviderNetworks, &savedProviderNetworks); err != nil {
+		return err
+	}
+
+	var err error
+	ProviderNetworks.BridgeMappings, err = parseBridgeMappings(ProviderNetworks.RawBridgeMappings)
+	if err != nil {
+		return fmt.Errorf("provider bridge mappings invalid: %v", err)
+	}
+	ProviderNetworks.Networks, err = parseProviderNetworks(ProviderNetworks.RawNetworks)
+	if err != nil {
+		return fmt.Errorf("provider networks invalid: %v", err)
+	}
+	for _, network := range ProviderNetworks.Networks {
+		allSubnets.append(configSubnetProvider, network.Subnet)
+	}
+
+	return nil
+}
+
+func buildIPsecConfig(cli, file *config) error {
+	// Copy config file values over default values
+	if err := overrideFields(&IPsec, &file.IPsec, &savedIPsec); err != nil {
+		return err
+	}
+
+	// And CLI overrides over config file and default values
+	if err := overrideFields(&IPsec, &cli.IPsec, &savedIPsec); err != nil {
+		return err
+	}
+
+	if IPsec.Enabled && IPsec.CACert == "" {
+		IPsec.CACert = Kubernetes.CACert
+		if IPsec.CACert == "" {
+			return fmt.Errorf("IPsec requires a CA certificate")
+		}
+	}
+	// The certificates of these signers authenticate clients to the API
+	// server and kubelets to its clients, approving them for the chassis
+	// would hand out Kubernetes credentials
+	if strings.HasPrefix(IPsec.SignerName, "kubernetes.io/kube-apiserver-client") ||
+		IPsec.SignerName == "kubernetes.io/kubelet-serving" {
+		return fmt.Errorf("invalid IPsec signer name %q: the signer issues Kubernetes credentials",
+			IPsec.SignerName)
+	}
+	if IPsec.NodeServiceAccount != "" {
+		parts := strings.Split(IPsec.NodeServiceAccount, ":")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid IPsec node service account %q: must be <namespace>:<name>",
+				IPsec.NodeServiceAccount)
+		}
+	}
+
+	return nil
+}
+
+func buildNBDBCheckConfig(cli, file *config) error {
+	// Copy config file values over default values
+	if err := overrideFields(&NBDBCheck, &file.NBDBCheck, &savedNBDBCheck); err != nil {
+		return err
+	}
+
+	// And CLI overrides over config file and default values
+	if err := overrideFields(&NBDBCheck, &cli.NBDBCheck, &savedNBDBCheck); err != nil {
+		return err
+	}
+
+	if NBDBCheck.Interval < 0 {
+		return fmt.Errorf("invalid northbound database check interval %d", NBDBCheck.Interval)
+	}
+
+	return nil
+}
+
 func buildDefaultConfig(cli, file *config, allSubnets *configSubnets) error {
 	if err := overrideFields(&Default, &file.Default, &savedDefault); err != nil {
 		return err
@@ -1124,15 +1410,18 @@ func initConfigWithPath(ctx *cli.Context, exec kexec.Interface, saPath string, d
 	var err error
 	// initialize cfg with default values, allow file read to override
 	cfg := config{
-		Default:       savedDefault,
-		Logging:       savedLogging,
-		CNI:           savedCNI,
-		Kubernetes:    savedKubernetes,
-		OvnNorth:      savedOvnNorth,
-		OvnSouth:      savedOvnSouth,
-		Gateway:       savedGateway,
-		MasterHA:      savedMasterHA,
-		HybridOverlay: savedHybridOverlay,
+		Default:          savedDefault,
+		Logging:          savedLogging,
+		CNI:              savedCNI,
+		Kubernetes:       savedKubernetes,
+		OvnNorth:         savedOvnNorth,
+		OvnSouth:         savedOvnSouth,
+		Gateway:          savedGateway,
+		MasterHA:         savedMasterHA,
+		HybridOverlay:    savedHybridOverlay,
+		ProviderNetworks: savedProviderNetworks,
+		IPsec:            savedIPsec,
+		NBDBCheck:        savedNBDBCheck,
 	}
 
 	allSubnets := newConfigSubnets()
@@ -1186,7 +1475,34 @@ func initConfigWithPath(ctx *cli.Context, exec kexec.Interface, saPath string, d
 	if err := level.Set(strconv.Itoa(Logging.Level)); err != nil {
 		return "", fmt.Errorf("failed to set klog log level %v", err)
 	}
+	// The kubernetes client libraries log through klog/v2, which is
+	// configured like klog
+	var levelV2 klogv2.Level
+	if err := levelV2.Set(strconv.Itoa(Logging.Level)); err != nil {
+		return "", fmt.Errorf("failed to set klog/v2 log level %v", err)
+	}
+	if Logging.Format != logging.FormatText && Logging.Format != logging.FormatJSON {
+		return "", fmt.Errorf("invalid log format %q: must be %s or %s", Logging.Format, logging.FormatText, logging.FormatJSON)
+	}
+	var logFile io.Writer
 	if Logging.File != "" {
+		logFile = &lumberjack.Logger{
+			Filename:   Logging.File,
+			MaxSize:    100, // megabytes
+			MaxBackups: 10,
+			MaxAge:     30, // days
+			Compress:   true,
+		}
+	}
+	if Logging.Format == logging.FormatJSON {
+		var out io.Writer = os.Stderr
+		if logFile != nil {
+			out = logFile
+		}
+		if err := logging.SetJSONOutput(out); err != nil {
+			return "", err
+		}
+	} else if logFile != nil {
 		klogFlags := flag.NewFlagSet("klog", flag.ExitOnError)
 		klog.InitFlags(klogFlags)
 		if err := klogFlags.Set("logtostderr", "false"); err != nil {
@@ -1195,13 +1511,17 @@ func initConfigWithPath(ctx *cli.Context, exec kexec.Interface, saPath string, d
 		if err := klogFlags.Set("alsologtostderr", "true"); err != nil {
 			klog.Errorf("Error setting klog alsologtostderr: %v", err)
 		}
-		klog.SetOutput(&lumberjack.Logger{
-			Filename:   Logging.File,
-			MaxSize:    100, // megabytes
-			MaxBackups: 10,
-			MaxAge:     30, // days
-			Compress:   true,
-		})
+		klog.SetOutput(logFile)
+
+		klogV2Flags := flag.NewFlagSet("klogv2", flag.ExitOnError)
+		klogv2.InitFlags(klogV2Flags)
+		if err := klogV2Flags.Set("logtostderr", "false"); err != nil {
+			klog.Errorf("Error setting klog/v2 logtostderr: %v", err)
+		}
+		if err := klogV2Flags.Set("alsologtostderr", "true"); err != nil {
+			klog.Errorf("Error setting klog/v2 alsologtostderr: %v", err)
+		}
+		klogv2.SetOutput(logFile)
 	}
 
 	if err = buildDefaultConfig(&cliConfig, &cfg, allSubnets); err != nil {
@@ -1224,6 +1544,18 @@ func initConfigWithPath(ctx *cli.Context, exec kexec.Interface, saPath string, d
 		return "", err
 	}
 
+	if err = buildProviderNetworkConfig(&cliConfig, &cfg, allSubnets); err != nil {
+		return "", err
+	}
+
+	if err = buildIPsecConfig(&cliConfig, &cfg); err != nil {
+		return "", err
+	}
+
+	if err = buildNBDBCheckConfig(&cliConfig, &cfg); err != nil {
+		return "", err
+	}
+
 	tmpAuth, err := buildOvnAuth(exec, true, &cliConfig.OvnNorth, &cfg.OvnNorth, defaults.OvnNorthAddress)
 	if err != nil {
 		return "", err
@@ -1254,6 +1586,9 @@ func initConfigWithPath(ctx *cli.Context, exec kexec.Interface, saPath string, d
 	klog.V(5).Infof("OVN North config: %+v", OvnNorth)
 	klog.V(5).Infof("OVN South config: %+v", OvnSouth)
 	klog.V(5).Infof("Hybrid Overlay config: %+v", HybridOverlay)
+	klog.V(5).Infof("Provider Networks config: %+v", ProviderNetworks)
+	klog.V(5).Infof("IPsec config: %+v", IPsec)
+	klog.V(5).Infof("NB DB check config: %+v", NBDBCheck)
 
 	return retConfigFile, nil
 }
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/urfave/cli/v2"
+	klogv2 "k8s.io/klog/v2"
 	kexec "k8s.io/utils/exec"
 
 	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
@@ -230,6 +231,7 @@ var _ = Describe("Config Operations", func() {
 			Expect(Default.ConntrackZone).To(Equal(64000))
 			Expect(Logging.File).To(Equal(""))
 			Expect(Logging.Level).To(Equal(4))
+			Expect(Logging.Format).To(Equal("text"))
 			Expect(CNI.ConfDir).To(Equal("/etc/cni/net.d"))
 			Expect(CNI.Plugin).To(Equal("ovn-k8s-cni-overlay"))
 			Expect(Kubernetes.Kubeconfig).To(Equal(""))
@@ -527,6 +529,9 @@ var _ = Describe("Config Operations", func() {
 			Expect(Default.ConntrackZone).To(Equal(5555))
 			Expect(Logging.File).To(Equal("/some/logfile"))
 			Expect(Logging.Level).To(Equal(3))
+			// the client libraries log through klog/v2 at the same level
+			Expect(klogv2.V(3).Enabled()).To(BeTrue())
+			Expect(klogv2.V(4).Enabled()).To(BeFalse())
 			Expect(CNI.ConfDir).To(Equal("/some/cni/dir"))
 			Expect(CNI.Plugin).To(Equal("a-plugin"))
 			Expect(Kubernetes.Kubeconfig).To(Equal(kubeconfigFile))
@@ -769,6 +774,20 @@ mode=shared
 		Expect(err).NotTo(HaveOccurred())
 	})
 
+	It("returns an error when the log format is invalid", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			Expect(err).To(MatchError("invalid log format \"xml\": must be text or json"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-logformat=xml",
+		}
+		err := app.Run(cliArgs)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
 	It("overrides config file and defaults with CLI options (multi-master)", func() {
 		kubeconfigFile, err := createTempFile("kubeconfig")
 		Expect(err).NotTo(HaveOccurred())
@@ -1032,6 +1051,136 @@ mode=shared
 		Expect(err).NotTo(HaveOccurred())
 	})
 
+	It("configures provider networks of any IP family", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(IPv4Mode).To(Equal(true))
+			Expect(IPv6Mode).To(Equal(false))
+			Expect(ProviderNetworks.BridgeMappings).To(Equal(map[string]string{
+				"physnet1": "br-vlan",
+				"physnet2": "br-dc",
+			}))
+			Expect(ProviderNetworks.Networks).To(Equal([]ProviderNetwork{
+				{Name: "physnet1", VLANID: 100, Subnet: ovntest.MustParseIPNet("10.20.0.0/24")},
+				{Name: "physnet2", VLANID: 0, Subnet: ovntest.MustParseIPNet("fd00:20::/64")},
+			}))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-cluster-subnets=10.0.0.0/16/24",
+			"-provider-bridge-mappings=physnet1:br-vlan,physnet2:br-dc",
+			"-provider-networks=physnet1:100:10.20.0.0/24,physnet2:0:fd00:20::/64",
+		}
+		err := app.Run(cliArgs)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns an error when a provider network is invalid", func() {
+		for _, providerNetworks := range []string{
+			"physnet1:10.20.0.0/24",
+			"physnet1:4096:10.20.0.0/24",
+			"physnet1:100:10.20.0.0/24,physnet1:200:10.30.0.0/24",
+			"physnet1:100:fd00:20::/48",
+			"physnet1:100:10.0.1.0/24",
+		} {
+			app.Action = func(ctx *cli.Context) error {
+				_, err := InitConfig(ctx, kexec.New(), nil)
+				Expect(err).To(HaveOccurred(), providerNetworks)
+				return nil
+			}
+			cliArgs := []string{
+				app.Name,
+				"-cluster-subnets=10.0.0.0/16/24",
+				"-provider-networks=" + providerNetworks,
+			}
+			err := app.Run(cliArgs)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	It("configures IPsec", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(IPsec.Enabled).To(BeTrue())
+			Expect(IPsec.CertDir).To(Equal("/var/lib/ovn-ipsec"))
+			Expect(IPsec.CACert).To(Equal("/etc/ovn-ipsec/ca.pem"))
+			Expect(IPsec.SignerName).To(Equal("example.com/ovn-ipsec"))
+			Expect(IPsec.NodeServiceAccount).To(Equal("ovn:ovnkube-node"))
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-enable-ipsec",
+			"-ipsec-cert-dir=/var/lib/ovn-ipsec",
+			"-ipsec-ca-cert=/etc/ovn-ipsec/ca.pem",
+			"-ipsec-signer-name=example.com/ovn-ipsec",
+			"-ipsec-node-service-account=ovn:ovnkube-node",
+		}
+		err := app.Run(cliArgs)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns an error when IPsec has no CA certificate", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			Expect(err).To(MatchError("IPsec requires a CA certificate"))
+			return nil
+		}
+		err := app.Run([]string{app.Name, "-enable-ipsec"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns an error when the IPsec signer issues Kubernetes credentials", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			Expect(err).To(MatchError("invalid IPsec signer name \"kubernetes.io/kube-apiserver-client-kubelet\": " +
+				"the signer issues Kubernetes credentials"))
+			return nil
+		}
+		err := app.Run([]string{app.Name, "-ipsec-signer-name=kubernetes.io/kube-apiserver-client-kubelet"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns an error when the IPsec node service account is invalid", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			Expect(err).To(MatchError("invalid IPsec node service account \"ovn\": must be <namespace>:<name>"))
+			return nil
+		}
+		err := app.Run([]string{app.Name, "-ipsec-node-service-account=ovn"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("configures the northbound database check", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(NBDBCheck.Interval).To(Equal(300))
+			Expect(NBDBCheck.Repair).To(BeTrue())
+			return nil
+		}
+		cliArgs := []string{
+			app.Name,
+			"-nbdb-check-interval=300",
+			"-nbdb-repair",
+		}
+		err := app.Run(cliArgs)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns an error for a negative northbound database check interval", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := InitConfig(ctx, kexec.New(), nil)
+			Expect(err).To(MatchError("invalid northbound database check interval -1"))
+			return nil
+		}
+		err := app.Run([]string{app.Name, "-nbdb-check-interval=-1"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
 	Describe("OvnDBAuth operations", func() {
 		var certFile, keyFile, caFile string
 
@@ -83,6 +83,67 @@ func ParseClusterSubnetEntries(clusterSubnetCmd string) ([]CIDRNetworkEntry, err
 	return parsedClusterList, nil
 }
 
+// parseBridgeMappings parses a comma-separated list of physical network
+// name to OVS bridge mappings, eg "physnet1:br-vlan,physnet2:br-dc"
+func parseBridgeMappings(bridgeMappingsCmd string) (map[string]string, error) {
+	bridgeMappings := make(map[string]string)
+	if bridgeMappingsCmd == "" {
+		return bridgeMappings, nil
+	}
+	for _, bridgeMapping := range strings.Split(bridgeMappingsCmd, ",") {
+		m := strings.Split(strings.TrimSpace(bridgeMapping), ":")
+		if len(m) != 2 || m[0] == "" || m[1] == "" {
+			return nil, fmt.Errorf("bridge mapping %q not properly formatted", bridgeMapping)
+		}
+		if _, ok := bridgeMappings[m[0]]; ok {
+			return nil, fmt.Errorf("physical network %q mapped more than once", m[0])
+		}
+		bridgeMappings[m[0]] = m[1]
+	}
+	return bridgeMappings, nil
+}
+
+// parseProviderNetworks parses a comma-separated list of provider networks,
+// each given as physical network name:VLAN ID:subnet,
+// eg "physnet1:100:10.20.0.0/24,physnet2:0:fd00:20::/64"
+func parseProviderNetworks(providerNetworksCmd string) ([]ProviderNetwork, error) {
+	var providerNetworks []ProviderNetwork
+	if providerNetworksCmd == "" {
+		return providerNetworks, nil
+	}
+	names := make(map[string]bool)
+	for _, entry := range strings.Split(providerNetworksCmd, ",") {
+		// The subnet comes last as IPv6 subnets contain colons
+		fields := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(fields) != 3 || fields[0] == "" {
+			return nil, fmt.Errorf("provider network %q not properly formatted", entry)
+		}
+		if names[fields[0]] {
+			return nil, fmt.Errorf("provider network %q defined more than once", fields[0])
+		}
+		names[fields[0]] = true
+
+		vlanID, err := strconv.ParseUint(fields[1], 10, 12)
+		if err != nil {
+			return nil, fmt.Errorf("provider network %q VLAN ID invalid: %v", fields[0], err)
+		}
+		_, subnet, err := net.ParseCIDR(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("provider network %q subnet invalid: %v", fields[0], err)
+		}
+		if ones, _ := subnet.Mask.Size(); utilnet.IsIPv6CIDR(subnet) && ones != 64 {
+			return nil, fmt.Errorf("provider network %q: IPv6 only supports /64 subnets", fields[0])
+		}
+
+		providerNetworks = append(providerNetworks, ProviderNetwork{
+			Name:   fields[0],
+			VLANID: uint(vlanID),
+			Subnet: subnet,
+		})
+	}
+	return providerNetworks, nil
+}
+
 type configSubnetType string
 
 const (
@@ -90,6 +151,9 @@ const (
 	configSubnetCluster configSubnetType = "cluster subnet"
 	configSubnetService configSubnetType = "service subnet"
 	configSubnetHybrid  configSubnetType = "hybrid overlay subnet"
+	// provider network subnets are not part of the cluster network, so
+	// they may be of any IP family
+	configSubnetProvider configSubnetType = "provider network subnet"
 )
 
 type configSubnet struct {
@@ -115,7 +179,7 @@ func newConfigSubnets() *configSubnets {
 // append adds a single subnet to cs
 func (cs *configSubnets) append(subnetType configSubnetType, subnet *net.IPNet) {
 	cs.subnets = append(cs.subnets, configSubnet{subnetType: subnetType, subnet: subnet})
-	if subnetType != configSubnetJoin {
+	if subnetType != configSubnetJoin && subnetType != configSubnetProvider {
 		if utilnet.IsIPv6CIDR(subnet) {
 			cs.v6[subnetType] = true
 		} else {
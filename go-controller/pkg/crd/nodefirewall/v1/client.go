@@ -0,0 +1,92 @@
+package v1
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	scheme         = runtime.NewScheme()
+	codecs         = serializer.NewCodecFactory(scheme)
+	parameterCodec = runtime.NewParameterCodec(scheme)
+)
+
+func init() {
+	if err := AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+}
+
+// Interface lists and watches NodeFirewalls
+type Interface interface {
+	List(opts metav1.ListOptions) (*NodeFirewallList, error)
+	Watch(opts metav1.ListOptions) (watch.Interface, error)
+}
+
+type client struct {
+	restClient rest.Interface
+}
+
+// NewForConfig creates a NodeFirewall client for the apiserver of config
+func NewForConfig(c *rest.Config) (Interface, error) {
+	config := *c
+	config.GroupVersion = &SchemeGroupVersion
+	config.APIPath = "/apis"
+	// custom resources are only served as JSON
+	config.ContentType = runtime.ContentTypeJSON
+	config.AcceptContentTypes = runtime.ContentTypeJSON
+	config.NegotiatedSerializer = codecs.WithoutConversion()
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+
+	restClient, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+	return &client{restClient: restClient}, nil
+}
+
+// List returns the NodeFirewalls matching opts
+func (c *client) List(opts metav1.ListOptions) (*NodeFirewallList, error) {
+	result := &NodeFirewallList{}
+	err := c.restClient.Get().
+		Resource("nodefirewalls").
+		VersionedParams(&opts, parameterCodec).
+		Do(context.TODO()).
+		Into(result)
+	return result, err
+}
+
+// Watch returns a watch of the NodeFirewalls matching opts
+func (c *client) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return c.restClient.Get().
+		Resource("nodefirewalls").
+		VersionedParams(&opts, parameterCodec).
+		Watch(context.TODO())
+}
+
+// NewInformer returns a shared informer for NodeFirewalls
+func NewInformer(c Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return c.List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return c.Watch(options)
+			},
+		},
+		&NodeFirewall{},
+		resyncPeriod,
+		cache.Indexers{},
+	)
+}
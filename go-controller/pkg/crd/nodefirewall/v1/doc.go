@@ -0,0 +1,5 @@
+// Package v1 contains the v1 version of the k8s.ovn.org NodeFirewall API,
+// which restricts the external sources that can reach the nodes through
+// their OVN gateway routers, and a client to list and watch it.
+// +groupName=k8s.ovn.org
+package v1
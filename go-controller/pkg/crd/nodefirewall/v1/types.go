@@ -0,0 +1,68 @@
+package v1
+
+import (
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodeFirewallAction is the action of a NodeFirewall rule
+type NodeFirewallAction string
+
+const (
+	// NodeFirewallActionAllow lets the matching traffic through
+	NodeFirewallActionAllow NodeFirewallAction = "Allow"
+	// NodeFirewallActionDeny drops the matching traffic
+	NodeFirewallActionDeny NodeFirewallAction = "Deny"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeFirewall restricts the traffic from outside the cluster that reaches
+// the node IPs and NodePorts of the selected nodes through their gateway
+// routers.
+type NodeFirewall struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NodeFirewallSpec `json:"spec"`
+}
+
+// NodeFirewallSpec is the specification of a NodeFirewall
+type NodeFirewallSpec struct {
+	// NodeSelector selects the nodes the rules apply to. An empty selector
+	// selects all nodes.
+	NodeSelector metav1.LabelSelector `json:"nodeSelector"`
+	// Rules are evaluated in order and the first rule matching a packet
+	// decides its fate. Packets matching no rule are allowed.
+	Rules []NodeFirewallRule `json:"rules"`
+}
+
+// NodeFirewallRule allows or denies the traffic from a source CIDR
+type NodeFirewallRule struct {
+	Action NodeFirewallAction `json:"action"`
+	// SourceCIDR is the CIDR the traffic comes from
+	SourceCIDR string `json:"sourceCIDR"`
+	// Ports restricts the rule to the given destination ports. A rule
+	// without ports matches all the traffic from SourceCIDR.
+	Ports []NodeFirewallPort `json:"ports,omitempty"`
+}
+
+// NodeFirewallPort is a destination port of a NodeFirewall rule
+type NodeFirewallPort struct {
+	// Protocol is TCP, UDP or SCTP
+	Protocol kapi.Protocol `json:"protocol"`
+	// Port is the destination port; 0 matches all ports of Protocol
+	Port int32 `json:"port,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeFirewallList is a list of NodeFirewalls
+type NodeFirewallList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NodeFirewall `json:"items"`
+}
@@ -0,0 +1,273 @@
+package factory
+
+import (
+	"sync"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// DependencyKind is a kind of object the events of other objects can wait for
+type DependencyKind string
+
+const (
+	// NamespaceDependency is a namespace processed by the namespace handler
+	NamespaceDependency DependencyKind = "namespace"
+	// ServiceDependency is a service processed by the service handler,
+	// named namespace/name
+	ServiceDependency DependencyKind = "service"
+	// NodeSwitchDependency is the logical switch of a node
+	NodeSwitchDependency DependencyKind = "node-switch"
+)
+
+// Dependency is an object the events of another object wait for
+type Dependency struct {
+	Kind DependencyKind
+	Name string
+}
+
+// DependencyFunc returns the dependencies the handling of an object waits
+// for. It is called again for each event of the object, and again once the
+// dependencies it returned are ready, so it can return more dependencies as
+// it learns about them.
+type DependencyFunc func(obj interface{}) []Dependency
+
+type eventType int
+
+const (
+	addEvent eventType = iota
+	updateEvent
+	deleteEvent
+)
+
+type dependentEvent struct {
+	eventType eventType
+	obj       interface{}
+	oldObj    interface{}
+}
+
+// waitingObject holds the events of an object that are waiting for its
+// dependencies or for its earlier events to be handled
+type waitingObject struct {
+	handler *dependentHandler
+	key     string
+	events  []*dependentEvent
+	// running is true while a goroutine handles the events
+	running bool
+	// waitingFor is the dependency the first event waits for
+	waitingFor *Dependency
+}
+
+// push adds an event of the object, merging it with the last event that
+// is still to be handled so that an object waits with at most one event.
+// A delete supersedes the events that are still to be handled.
+func (w *waitingObject) push(e *dependentEvent) {
+	if e.eventType == deleteEvent {
+		w.events = []*dependentEvent{e}
+		return
+	}
+	if n := len(w.events); n > 0 && e.eventType == updateEvent {
+		last := w.events[n-1]
+		switch last.eventType {
+		case addEvent:
+			w.events[n-1] = &dependentEvent{eventType: addEvent, obj: e.obj}
+			return
+		case updateEvent:
+			w.events[n-1] = &dependentEvent{eventType: updateEvent, obj: e.obj, oldObj: last.oldObj}
+			return
+		}
+	}
+	w.events = append(w.events, e)
+}
+
+// dispatcher tracks the dependencies that are ready and the objects waiting
+// for the others
+type dispatcher struct {
+	sync.Mutex
+	ready   map[Dependency]bool
+	waiters map[Dependency]map[*waitingObject]bool
+}
+
+func newDispatcher() *dispatcher {
+	return &dispatcher{
+		ready:   make(map[Dependency]bool),
+		waiters: make(map[Dependency]map[*waitingObject]bool),
+	}
+}
+
+// firstUnready returns the first of deps that is not ready. Caller must hold
+// the dispatcher lock.
+func (d *dispatcher) firstUnready(deps []Dependency) *Dependency {
+	for i := range deps {
+		if !d.ready[deps[i]] {
+			return &deps[i]
+		}
+	}
+	return nil
+}
+
+// addWaiter makes w wait for dep. Caller must hold the dispatcher lock.
+func (d *dispatcher) addWaiter(dep *Dependency, w *waitingObject) {
+	if d.waiters[*dep] == nil {
+		d.waiters[*dep] = make(map[*waitingObject]bool)
+	}
+	d.waiters[*dep][w] = true
+	w.waitingFor = dep
+}
+
+// removeWaiter stops w from waiting. Caller must hold the dispatcher lock.
+func (d *dispatcher) removeWaiter(w *waitingObject) {
+	if w.waitingFor == nil {
+		return
+	}
+	dep := *w.waitingFor
+	delete(d.waiters[dep], w)
+	if len(d.waiters[dep]) == 0 {
+		delete(d.waiters, dep)
+	}
+	w.waitingFor = nil
+}
+
+func (d *dispatcher) markReady(dep Dependency) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.ready[dep] = true
+	for w := range d.waiters[dep] {
+		w.waitingFor = nil
+		w.running = true
+		go w.handler.run(w)
+	}
+	delete(d.waiters, dep)
+}
+
+func (d *dispatcher) markNotReady(dep Dependency) {
+	d.Lock()
+	defer d.Unlock()
+	delete(d.ready, dep)
+}
+
+// dependentHandler holds back the events of an object until the
+// dependencies of the object are ready, keeping the order of the events of
+// each object. Deletes never wait.
+type dependentHandler struct {
+	d     *dispatcher
+	deps  DependencyFunc
+	funcs cache.ResourceEventHandler
+	// objects are the objects with events to handle, protected by the
+	// dispatcher lock
+	objects map[string]*waitingObject
+}
+
+func (h *dependentHandler) OnAdd(obj interface{}) {
+	h.enqueue(&dependentEvent{eventType: addEvent, obj: obj})
+}
+
+func (h *dependentHandler) OnUpdate(oldObj, newObj interface{}) {
+	h.enqueue(&dependentEvent{eventType: updateEvent, obj: newObj, oldObj: oldObj})
+}
+
+func (h *dependentHandler) OnDelete(obj interface{}) {
+	h.enqueue(&dependentEvent{eventType: deleteEvent, obj: obj})
+}
+
+func (h *dependentHandler) enqueue(e *dependentEvent) {
+	key, err := cache.MetaNamespaceKeyFunc(e.obj)
+	if err != nil {
+		klog.Errorf("Failed to get the key of %v: %v", e.obj, err)
+		return
+	}
+
+	h.d.Lock()
+	w, ok := h.objects[key]
+	if !ok {
+		w = &waitingObject{handler: h, key: key}
+		h.objects[key] = w
+	}
+	w.push(e)
+	if w.running {
+		// the goroutine handling the object's events handles this one too
+		h.d.Unlock()
+		return
+	}
+	// the new event may not wait for the same dependency as the ones it
+	// was merged with
+	h.d.removeWaiter(w)
+	w.running = true
+	h.d.Unlock()
+
+	h.run(w)
+}
+
+// run handles the events of w until they are all handled or the next one
+// waits for a dependency
+func (h *dependentHandler) run(w *waitingObject) {
+	for {
+		h.d.Lock()
+		if len(w.events) == 0 {
+			delete(h.objects, w.key)
+			w.running = false
+			h.d.Unlock()
+			return
+		}
+		e := w.events[0]
+		h.d.Unlock()
+
+		// The dependencies are looked up without the dispatcher lock as
+		// DependencyFunc may take the locks of the handler
+		var deps []Dependency
+		if e.eventType != deleteEvent {
+			deps = h.deps(e.obj)
+		}
+
+		h.d.Lock()
+		if len(w.events) == 0 || w.events[0] != e {
+			// a newer event was merged with this one
+			h.d.Unlock()
+			continue
+		}
+		if dep := h.d.firstUnready(deps); dep != nil {
+			klog.V(5).Infof("Events of %s wait for %s %s", w.key, dep.Kind, dep.Name)
+			w.running = false
+			h.d.addWaiter(dep, w)
+			h.d.Unlock()
+			return
+		}
+		w.events = w.events[1:]
+		h.d.Unlock()
+
+		switch e.eventType {
+		case addEvent:
+			h.funcs.OnAdd(e.obj)
+		case updateEvent:
+			h.funcs.OnUpdate(e.oldObj, e.obj)
+		case deleteEvent:
+			h.funcs.OnDelete(e.obj)
+		}
+	}
+}
+
+// WithDependencies returns a handler calling funcs once the dependencies
+// returned by deps for an object are ready. The events of an object that
+// wait are merged, and a delete is handled right away without the events it
+// supersedes. The held back events are still handled after the returned
+// handler is removed from the watch factory.
+func (wf *WatchFactory) WithDependencies(deps DependencyFunc, funcs cache.ResourceEventHandler) cache.ResourceEventHandler {
+	return &dependentHandler{
+		d:       wf.dispatcher,
+		deps:    deps,
+		funcs:   funcs,
+		objects: make(map[string]*waitingObject),
+	}
+}
+
+// MarkReady marks a dependency as ready, handling the events waiting for it
+func (wf *WatchFactory) MarkReady(kind DependencyKind, name string) {
+	wf.dispatcher.markReady(Dependency{Kind: kind, Name: name})
+}
+
+// MarkNotReady marks a dependency as no longer ready, so that the events of
+// the objects depending on it wait until it is ready again
+func (wf *WatchFactory) MarkNotReady(kind DependencyKind, name string) {
+	wf.dispatcher.markNotReady(Dependency{Kind: kind, Name: name})
+}
@@ -4,17 +4,23 @@ import (
 	"fmt"
 	"hash/fnv"
 	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/klog"
 
 	kapi "k8s.io/api/core/v1"
 	knet "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	informerfactory "k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
@@ -64,6 +70,11 @@ type event struct {
 	process func(*event)
 }
 
+// MetricEventQueueDepth is the number of events of each resource type
+// waiting in the event queues. It is set only for the ovnkube in master
+// mode, whose handlers the queues feed.
+var MetricEventQueueDepth *prometheus.GaugeVec
+
 type listerInterface interface{}
 
 type initialAddFn func(*Handler, []interface{})
@@ -156,6 +167,9 @@ func (i *informer) processEvents(events chan *event, stopChan <-chan struct{}) {
 			if !ok {
 				return
 			}
+			if MetricEventQueueDepth != nil {
+				MetricEventQueueDepth.WithLabelValues(resourceName(i.oType)).Dec()
+			}
 			e.process(e)
 		case <-stopChan:
 			return
@@ -163,6 +177,15 @@ func (i *informer) processEvents(events chan *event, stopChan <-chan struct{}) {
 	}
 }
 
+// resourceName returns the name of the resource type of the objects of
+// oType, like "pod" for *kapi.Pod
+func resourceName(oType reflect.Type) string {
+	if oType.Kind() == reflect.Ptr {
+		oType = oType.Elem()
+	}
+	return strings.ToLower(oType.Name())
+}
+
 func getQueueNum(oType reflect.Type, obj interface{}) uint32 {
 	meta, err := getObjectMeta(oType, obj)
 	if err != nil {
@@ -174,11 +197,11 @@ func getQueueNum(oType reflect.Type, obj interface{}) uint32 {
 	// namespaced name, so that all events for a given object are
 	// serialized in one queue.
 	h := fnv.New32()
-	if meta.Namespace != "" {
-		_, _ = h.Write([]byte(meta.Namespace))
+	if meta.GetNamespace() != "" {
+		_, _ = h.Write([]byte(meta.GetNamespace()))
 		_, _ = h.Write([]byte("/"))
 	}
-	_, _ = h.Write([]byte(meta.Name))
+	_, _ = h.Write([]byte(meta.GetName()))
 	return h.Sum32() % uint32(numEventQueues)
 }
 
@@ -189,6 +212,11 @@ func (i *informer) enqueueEvent(oldObj, obj interface{}, processFunc func(*event
 	defer i.RUnlock()
 	queueIdx := getQueueNum(i.oType, obj)
 	if i.events[queueIdx] != nil {
+		// The event is counted while the queue is full too, as it waits
+		// to be queued
+		if MetricEventQueueDepth != nil {
+			MetricEventQueueDepth.WithLabelValues(resourceName(i.oType)).Inc()
+		}
 		i.events[queueIdx] <- &event{
 			obj:     obj,
 			oldObj:  oldObj,
@@ -296,11 +324,9 @@ func newInformerLister(oType reflect.Type, sharedInformer cache.SharedIndexInfor
 		return listers.NewNamespaceLister(sharedInformer.GetIndexer()), nil
 	case nodeType:
 		return listers.NewNodeLister(sharedInformer.GetIndexer()), nil
-	case policyType:
-		return nil, nil
 	}
-
-	return nil, fmt.Errorf("cannot create lister from type %v", oType)
+	// The objects of the other types are read from the informer's store
+	return nil, nil
 }
 
 func newBaseInformer(oType reflect.Type, sharedInformer cache.SharedIndexInformer) (*informer, error) {
@@ -384,8 +410,14 @@ type WatchFactory struct {
 	// requirements with atomic accesses
 	handlerCounter uint64
 
-	iFactory  informerfactory.SharedInformerFactory
-	informers map[reflect.Type]*informer
+	iFactory   informerfactory.SharedInformerFactory
+	dispatcher *dispatcher
+	stopChan   chan struct{}
+
+	// informersLock protects informers, which gains the informers added
+	// with AddInformer after the factory is started
+	informersLock sync.RWMutex
+	informers     map[reflect.Type]*informer
 }
 
 // ObjectCacheInterface represents the exported methods for getting
@@ -423,6 +455,22 @@ var (
 	nodeType      reflect.Type = reflect.TypeOf(&kapi.Node{})
 )
 
+// Resource is a type of resource a node watch factory can watch
+type Resource string
+
+const (
+	// Pods are the pods scheduled on the node
+	Pods Resource = "pods"
+	// Services are all the services of the cluster
+	Services Resource = "services"
+	// Endpoints are all the endpoints of the cluster
+	Endpoints Resource = "endpoints"
+	// Namespaces are all the namespaces of the cluster
+	Namespaces Resource = "namespaces"
+	// Nodes are all the nodes of the cluster
+	Nodes Resource = "nodes"
+)
+
 // NewWatchFactory initializes a new watch factory
 func NewWatchFactory(c kubernetes.Interface, stopChan chan struct{}) (*WatchFactory, error) {
 	// resync time is 12 hours, none of the resources being watched in ovn-kubernetes have
@@ -431,8 +479,9 @@ func NewWatchFactory(c kubernetes.Interface, stopChan chan struct{}) (*WatchFact
 	// ovnkube master (currently, it is just a 'get' loop)
 	// the downside of making it tight (like 10 minutes) is needless spinning on all resources
 	wf := &WatchFactory{
-		iFactory:  informerfactory.NewSharedInformerFactory(c, resyncInterval),
-		informers: make(map[reflect.Type]*informer),
+		iFactory:   informerfactory.NewSharedInformerFactory(c, resyncInterval),
+		informers:  make(map[reflect.Type]*informer),
+		dispatcher: newDispatcher(),
 	}
 	var err error
 	// Create shared informers we know we'll use
@@ -461,10 +510,58 @@ func NewWatchFactory(c kubernetes.Interface, stopChan chan struct{}) (*WatchFact
 		return nil, err
 	}
 
+	return wf, wf.start(stopChan)
+}
+
+// NewNodeWatchFactory initializes a new watch factory for the node nodeName
+// watching only the given resources. It only watches the pods scheduled on
+// the node, and never network policies, so that the nodes do not each cache
+// the cluster's pods.
+func NewNodeWatchFactory(c kubernetes.Interface, nodeName string, stopChan chan struct{}, resources ...Resource) (*WatchFactory, error) {
+	wf := &WatchFactory{
+		iFactory:   informerfactory.NewSharedInformerFactory(c, resyncInterval),
+		informers:  make(map[reflect.Type]*informer),
+		dispatcher: newDispatcher(),
+	}
+	var err error
+	for _, resource := range resources {
+		switch resource {
+		case Pods:
+			podInformer := wf.iFactory.InformerFor(&kapi.Pod{}, func(c kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+				return coreinformers.NewFilteredPodInformer(c, metav1.NamespaceAll, resync,
+					cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+					func(options *metav1.ListOptions) {
+						options.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+					})
+			})
+			wf.informers[podType], err = newQueuedInformer(podType, podInformer, stopChan)
+		case Services:
+			wf.informers[serviceType], err = newInformer(serviceType, wf.iFactory.Core().V1().Services().Informer())
+		case Endpoints:
+			wf.informers[endpointsType], err = newInformer(endpointsType, wf.iFactory.Core().V1().Endpoints().Informer())
+		case Namespaces:
+			wf.informers[namespaceType], err = newInformer(namespaceType, wf.iFactory.Core().V1().Namespaces().Informer())
+		case Nodes:
+			wf.informers[nodeType], err = newQueuedInformer(nodeType, wf.iFactory.Core().V1().Nodes().Informer(), stopChan)
+		default:
+			err = fmt.Errorf("unknown resource %q", resource)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return wf, wf.start(stopChan)
+}
+
+// start starts the informers and waits for their caches to sync. The
+// informer handlers are removed when stopChan is closed.
+func (wf *WatchFactory) start(stopChan chan struct{}) error {
+	wf.stopChan = stopChan
 	wf.iFactory.Start(stopChan)
 	for oType, synced := range wf.iFactory.WaitForCacheSync(stopChan) {
 		if !synced {
-			return nil, fmt.Errorf("error in syncing cache for %v informer", oType)
+			return fmt.Errorf("error in syncing cache for %v informer", oType)
 		}
 	}
 
@@ -472,46 +569,25 @@ func NewWatchFactory(c kubernetes.Interface, stopChan chan struct{}) (*WatchFact
 		<-stopChan
 
 		// Remove all informer handlers
+		wf.informersLock.RLock()
+		defer wf.informersLock.RUnlock()
 		for _, inf := range wf.informers {
 			inf.shutdown()
 		}
 	}()
 
-	return wf, nil
+	return nil
 }
 
-func getObjectMeta(objType reflect.Type, obj interface{}) (*metav1.ObjectMeta, error) {
-	switch objType {
-	case podType:
-		if pod, ok := obj.(*kapi.Pod); ok {
-			return &pod.ObjectMeta, nil
-		}
-	case serviceType:
-		if service, ok := obj.(*kapi.Service); ok {
-			return &service.ObjectMeta, nil
-		}
-	case endpointsType:
-		if endpoints, ok := obj.(*kapi.Endpoints); ok {
-			return &endpoints.ObjectMeta, nil
-		}
-	case policyType:
-		if policy, ok := obj.(*knet.NetworkPolicy); ok {
-			return &policy.ObjectMeta, nil
-		}
-	case namespaceType:
-		if namespace, ok := obj.(*kapi.Namespace); ok {
-			return &namespace.ObjectMeta, nil
-		}
-	case nodeType:
-		if node, ok := obj.(*kapi.Node); ok {
-			return &node.ObjectMeta, nil
-		}
+func getObjectMeta(objType reflect.Type, obj interface{}) (metav1.Object, error) {
+	if reflect.TypeOf(obj) != objType {
+		return nil, fmt.Errorf("object type %v did not match expected %v", reflect.TypeOf(obj), objType)
 	}
-	return nil, fmt.Errorf("cannot get ObjectMeta from type %v", objType)
+	return meta.Accessor(obj)
 }
 
 func (wf *WatchFactory) addHandler(objType reflect.Type, namespace string, lsel *metav1.LabelSelector, funcs cache.ResourceEventHandler, processExisting func([]interface{})) (*Handler, error) {
-	inf, ok := wf.informers[objType]
+	inf, ok := wf.getInformer(objType)
 	if !ok {
 		return nil, fmt.Errorf("unknown object type %v", objType)
 	}
@@ -531,10 +607,10 @@ func (wf *WatchFactory) addHandler(objType reflect.Type, namespace string, lsel
 			klog.Errorf("watch handler filter error: %v", err)
 			return false
 		}
-		if namespace != "" && meta.Namespace != namespace {
+		if namespace != "" && meta.GetNamespace() != namespace {
 			return false
 		}
-		if lsel != nil && !sel.Matches(labels.Set(meta.Labels)) {
+		if lsel != nil && !sel.Matches(labels.Set(meta.GetLabels())) {
 			return false
 		}
 		return true
@@ -561,8 +637,16 @@ func (wf *WatchFactory) addHandler(objType reflect.Type, namespace string, lsel
 	return handler, nil
 }
 
+// getInformer returns the informer of objType
+func (wf *WatchFactory) getInformer(objType reflect.Type) (*informer, bool) {
+	wf.informersLock.RLock()
+	defer wf.informersLock.RUnlock()
+	inf, ok := wf.informers[objType]
+	return inf, ok
+}
+
 func (wf *WatchFactory) removeHandler(objType reflect.Type, handler *Handler) error {
-	if inf, ok := wf.informers[objType]; ok {
+	if inf, ok := wf.getInformer(objType); ok {
 		return inf.removeHandler(handler)
 	}
 	return fmt.Errorf("tried to remove unknown object type %v event handler", objType)
@@ -643,56 +727,204 @@ func (wf *WatchFactory) RemoveNodeHandler(handler *Handler) error {
 	return wf.removeHandler(nodeType, handler)
 }
 
+// AddInformer adds an informer for the objects of objType's type, such as a
+// custom resource watched through its generated clientset, so that handlers
+// can be added for them like for the core types. The events of a queued
+// informer are handled in parallel for different objects. The factory runs
+// the informer, which must not be run elsewhere, and waits for its cache to
+// sync.
+func (wf *WatchFactory) AddInformer(objType runtime.Object, sharedInformer cache.SharedIndexInformer, queued bool) error {
+	oType := reflect.TypeOf(objType)
+	if _, ok := wf.getInformer(oType); ok {
+		return fmt.Errorf("informer for %v already added", oType)
+	}
+
+	var inf *informer
+	var err error
+	if queued {
+		inf, err = newQueuedInformer(oType, sharedInformer, wf.stopChan)
+	} else {
+		inf, err = newInformer(oType, sharedInformer)
+	}
+	if err != nil {
+		return err
+	}
+	go sharedInformer.Run(wf.stopChan)
+	if !cache.WaitForCacheSync(wf.stopChan, sharedInformer.HasSynced) {
+		return fmt.Errorf("error in syncing cache for %v informer", oType)
+	}
+	wf.informersLock.Lock()
+	defer wf.informersLock.Unlock()
+	wf.informers[oType] = inf
+	return nil
+}
+
+// AddObjectHandler adds a handler function that will be executed on changes
+// of the objects of an informer added with AddInformer
+func (wf *WatchFactory) AddObjectHandler(objType runtime.Object, handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) (*Handler, error) {
+	return wf.addHandler(reflect.TypeOf(objType), "", nil, handlerFuncs, processExisting)
+}
+
+// AddFilteredObjectHandler adds a handler function that will be executed when
+// the objects of an informer added with AddInformer that match the given
+// filters change
+func (wf *WatchFactory) AddFilteredObjectHandler(objType runtime.Object, namespace string, lsel *metav1.LabelSelector, handlerFuncs cache.ResourceEventHandler, processExisting func([]interface{})) (*Handler, error) {
+	return wf.addHandler(reflect.TypeOf(objType), namespace, lsel, handlerFuncs, processExisting)
+}
+
+// RemoveObjectHandler removes an event handler function of an informer added
+// with AddInformer
+func (wf *WatchFactory) RemoveObjectHandler(objType runtime.Object, handler *Handler) error {
+	return wf.removeHandler(reflect.TypeOf(objType), handler)
+}
+
+// lister returns the lister of the informer of objType
+func (wf *WatchFactory) lister(objType reflect.Type) (listerInterface, error) {
+	inf, ok := wf.getInformer(objType)
+	if !ok {
+		return nil, fmt.Errorf("unknown object type %v", objType)
+	}
+	return inf.lister, nil
+}
+
 // GetPod returns the pod spec given the namespace and pod name
 func (wf *WatchFactory) GetPod(namespace, name string) (*kapi.Pod, error) {
-	podLister := wf.informers[podType].lister.(listers.PodLister)
+	lister, err := wf.lister(podType)
+	if err != nil {
+		return nil, err
+	}
+	podLister := lister.(listers.PodLister)
 	return podLister.Pods(namespace).Get(name)
 }
 
 // GetPods returns all the pods in a given namespace
 func (wf *WatchFactory) GetPods(namespace string) ([]*kapi.Pod, error) {
-	podLister := wf.informers[podType].lister.(listers.PodLister)
+	lister, err := wf.lister(podType)
+	if err != nil {
+		return nil, err
+	}
+	podLister := lister.(listers.PodLister)
 	return podLister.Pods(namespace).List(labels.Everything())
 }
 
 // GetNodes returns the node specs of all the nodes
 func (wf *WatchFactory) GetNodes() ([]*kapi.Node, error) {
-	nodeLister := wf.informers[nodeType].lister.(listers.NodeLister)
+	lister, err := wf.lister(nodeType)
+	if err != nil {
+		return nil, err
+	}
+	nodeLister := lister.(listers.NodeLister)
 	return nodeLister.List(labels.Everything())
 }
 
 // GetNode returns the node spec of a given node by name
 func (wf *WatchFactory) GetNode(name string) (*kapi.Node, error) {
-	nodeLister := wf.informers[nodeType].lister.(listers.NodeLister)
+	lister, err := wf.lister(nodeType)
+	if err != nil {
+		return nil, err
+	}
+	nodeLister := lister.(listers.NodeLister)
 	return nodeLister.Get(name)
 }
 
 // GetService returns the service spec of a service in a given namespace
 func (wf *WatchFactory) GetService(namespace, name string) (*kapi.Service, error) {
-	serviceLister := wf.informers[serviceType].lister.(listers.ServiceLister)
+	lister, err := wf.lister(serviceType)
+	if err != nil {
+		return nil, err
+	}
+	serviceLister := lister.(listers.ServiceLister)
 	return serviceLister.Services(namespace).Get(name)
 }
 
+// GetServices returns all the services in the cluster
+func (wf *WatchFactory) GetServices() ([]*kapi.Service, error) {
+	lister, err := wf.lister(serviceType)
+	if err != nil {
+		return nil, err
+	}
+	serviceLister := lister.(listers.ServiceLister)
+	return serviceLister.List(labels.Everything())
+}
+
 // GetEndpoints returns the endpoints list in a given namespace
 func (wf *WatchFactory) GetEndpoints(namespace string) ([]*kapi.Endpoints, error) {
-	endpointsLister := wf.informers[endpointsType].lister.(listers.EndpointsLister)
+	lister, err := wf.lister(endpointsType)
+	if err != nil {
+		return nil, err
+	}
+	endpointsLister := lister.(listers.EndpointsLister)
 	return endpointsLister.Endpoints(namespace).List(labels.Everything())
 }
 
 // GetEndpoint returns a specific endpoint in a given namespace
 func (wf *WatchFactory) GetEndpoint(namespace, name string) (*kapi.Endpoints, error) {
-	endpointsLister := wf.informers[endpointsType].lister.(listers.EndpointsLister)
+	lister, err := wf.lister(endpointsType)
+	if err != nil {
+		return nil, err
+	}
+	endpointsLister := lister.(listers.EndpointsLister)
 	return endpointsLister.Endpoints(namespace).Get(name)
 }
 
 // GetNamespace returns a specific namespace
 func (wf *WatchFactory) GetNamespace(name string) (*kapi.Namespace, error) {
-	namespaceLister := wf.informers[namespaceType].lister.(listers.NamespaceLister)
+	lister, err := wf.lister(namespaceType)
+	if err != nil {
+		return nil, err
+	}
+	namespaceLister := lister.(listers.NamespaceLister)
 	return namespaceLister.Get(name)
 }
 
 // GetNamespaces returns a list of namespaces in the cluster
 func (wf *WatchFactory) GetNamespaces() ([]*kapi.Namespace, error) {
-	namespaceLister := wf.informers[namespaceType].lister.(listers.NamespaceLister)
+	lister, err := wf.lister(namespaceType)
+	if err != nil {
+		return nil, err
+	}
+	namespaceLister := lister.(listers.NamespaceLister)
 	return namespaceLister.List(labels.Everything())
 }
+
+// GetObject returns an object of an informer added with AddInformer given
+// its namespace and name
+func (wf *WatchFactory) GetObject(objType runtime.Object, namespace, name string) (interface{}, error) {
+	inf, ok := wf.getInformer(reflect.TypeOf(objType))
+	if !ok {
+		return nil, fmt.Errorf("unknown object type %v", reflect.TypeOf(objType))
+	}
+	key := name
+	if namespace != "" {
+		key = namespace + "/" + name
+	}
+	obj, exists, err := inf.inf.GetStore().GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%v %s not found", reflect.TypeOf(objType), key)
+	}
+	return obj, nil
+}
+
+// GetObjects returns the objects of an informer added with AddInformer in a
+// given namespace, or in all namespaces if namespace is empty
+func (wf *WatchFactory) GetObjects(objType runtime.Object, namespace string) ([]interface{}, error) {
+	oType := reflect.TypeOf(objType)
+	inf, ok := wf.getInformer(oType)
+	if !ok {
+		return nil, fmt.Errorf("unknown object type %v", oType)
+	}
+	var objs []interface{}
+	for _, obj := range inf.inf.GetStore().List() {
+		meta, err := getObjectMeta(oType, obj)
+		if err != nil {
+			return nil, err
+		}
+		if namespace == "" || meta.GetNamespace() == namespace {
+			objs = append(objs, obj)
+		}
+	}
+	return objs, nil
+}
@@ -13,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
+	informerfactory "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
@@ -978,4 +979,165 @@ var _ = Describe("Watch Factory Operations", func() {
 
 		wf.RemovePodHandler(h)
 	})
+
+	It("watches only the node's pods and the requested resources in node mode", func() {
+		var podListOptions []string
+		fakeClient.PrependReactor("list", "pods", func(action core.Action) (bool, runtime.Object, error) {
+			podListOptions = append(podListOptions, action.(core.ListAction).GetListRestrictions().Fields.String())
+			return false, nil, nil
+		})
+		var policiesListed bool
+		fakeClient.PrependReactor("list", "networkpolicies", func(action core.Action) (bool, runtime.Object, error) {
+			policiesListed = true
+			return false, nil, nil
+		})
+		pods = append(pods, newPod("pod1", "default"))
+
+		wf, err := NewNodeWatchFactory(fakeClient, "mynode", stop, Pods, Nodes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(podListOptions).To(Equal([]string{"spec.nodeName=mynode"}))
+		Expect(policiesListed).To(BeFalse())
+
+		pod, err := wf.GetPod("default", "pod1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod.Spec.NodeName).To(Equal("mynode"))
+
+		// Resources that were not requested are not watched
+		_, err = wf.GetServices()
+		Expect(err).To(HaveOccurred())
+		_, err = wf.AddPolicyHandler(cache.ResourceEventHandlerFuncs{}, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("holds back the events of an object until its dependencies are ready", func() {
+		wf, err := NewWatchFactory(fakeClient, stop)
+		Expect(err).NotTo(HaveOccurred())
+
+		var eventsLock sync.Mutex
+		var events []string
+		record := func(event string) {
+			eventsLock.Lock()
+			defer eventsLock.Unlock()
+			events = append(events, event)
+		}
+		getEvents := func() []string {
+			eventsLock.Lock()
+			defer eventsLock.Unlock()
+			return append([]string{}, events...)
+		}
+		podDependencies := func(obj interface{}) []Dependency {
+			pod := obj.(*v1.Pod)
+			return []Dependency{
+				{Kind: NamespaceDependency, Name: pod.Namespace},
+				{Kind: NodeSwitchDependency, Name: pod.Spec.NodeName},
+			}
+		}
+		_, err = wf.AddPodHandler(wf.WithDependencies(podDependencies, cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				pod := obj.(*v1.Pod)
+				record(fmt.Sprintf("add %s v%s", pod.Name, pod.Labels["version"]))
+			},
+			UpdateFunc: func(old, new interface{}) {
+				oldPod, newPod := old.(*v1.Pod), new.(*v1.Pod)
+				record(fmt.Sprintf("update %s v%s v%s", newPod.Name, oldPod.Labels["version"], newPod.Labels["version"]))
+			},
+			DeleteFunc: func(obj interface{}) {
+				record("delete " + obj.(*v1.Pod).Name)
+			},
+		}), nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		pod := newPod("pod1", "default")
+		pod.Labels["version"] = "1"
+		podWatch.Add(pod)
+		pod = pod.DeepCopy()
+		pod.Labels["version"] = "2"
+		podWatch.Modify(pod)
+		wf.MarkReady(NamespaceDependency, "default")
+		Consistently(getEvents).Should(BeEmpty())
+
+		// The events that waited are merged
+		wf.MarkReady(NodeSwitchDependency, "mynode")
+		Eventually(getEvents).Should(Equal([]string{"add pod1 v2"}))
+
+		// The events of an object whose dependencies are ready do not wait
+		newer := pod.DeepCopy()
+		newer.Labels["version"] = "3"
+		podWatch.Modify(newer)
+		Eventually(getEvents).Should(Equal([]string{"add pod1 v2", "update pod1 v2 v3"}))
+
+		// A delete does not wait and supersedes the events that waited
+		other := newPod("pod2", "other")
+		podWatch.Add(other)
+		Consistently(getEvents).Should(HaveLen(2))
+		podWatch.Delete(other)
+		Eventually(getEvents).Should(Equal([]string{"add pod1 v2", "update pod1 v2 v3", "delete pod2"}))
+		wf.MarkReady(NamespaceDependency, "other")
+		Consistently(getEvents).Should(HaveLen(3))
+	})
+
+	It("watches the objects of added informers", func() {
+		configMaps := []*v1.ConfigMap{
+			{ObjectMeta: newObjectMeta("cm1", "default")},
+			{ObjectMeta: newObjectMeta("cm2", "other")},
+		}
+		configMapWatch := objSetup(fakeClient, "configmaps", func(core.Action) (bool, runtime.Object, error) {
+			obj := &v1.ConfigMapList{}
+			for _, cm := range configMaps {
+				obj.Items = append(obj.Items, *cm)
+			}
+			return true, obj, nil
+		})
+
+		wf, err := NewWatchFactory(fakeClient, stop)
+		Expect(err).NotTo(HaveOccurred())
+		configMapInformer := informerfactory.NewSharedInformerFactory(fakeClient, 0).Core().V1().ConfigMaps().Informer()
+		err = wf.AddInformer(&v1.ConfigMap{}, configMapInformer, true)
+		Expect(err).NotTo(HaveOccurred())
+		err = wf.AddInformer(&v1.ConfigMap{}, configMapInformer, true)
+		Expect(err).To(HaveOccurred())
+
+		obj, err := wf.GetObject(&v1.ConfigMap{}, "other", "cm2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(obj.(*v1.ConfigMap).Name).To(Equal("cm2"))
+		objs, err := wf.GetObjects(&v1.ConfigMap{}, "default")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).To(HaveLen(1))
+
+		var existing []interface{}
+		c := &handlerCalls{}
+		h, err := wf.AddFilteredObjectHandler(&v1.ConfigMap{}, "default", nil, cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				Expect(obj.(*v1.ConfigMap).Namespace).To(Equal("default"))
+				atomic.AddInt32(&c.added, 1)
+			},
+			UpdateFunc: func(old, new interface{}) {
+				Expect(new.(*v1.ConfigMap).Data).To(HaveKeyWithValue("key", "value"))
+				atomic.AddInt32(&c.updated, 1)
+			},
+			DeleteFunc: func(obj interface{}) {
+				atomic.AddInt32(&c.deleted, 1)
+			},
+		}, func(objs []interface{}) {
+			existing = objs
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(existing).To(HaveLen(1))
+		Expect(c.getAdded()).To(Equal(1))
+
+		added := &v1.ConfigMap{ObjectMeta: newObjectMeta("cm3", "default")}
+		configMapWatch.Add(added)
+		Eventually(c.getAdded, 2).Should(Equal(2))
+		configMapWatch.Add(&v1.ConfigMap{ObjectMeta: newObjectMeta("cm4", "other")})
+		updated := added.DeepCopy()
+		updated.Data = map[string]string{"key": "value"}
+		configMapWatch.Modify(updated)
+		Eventually(c.getUpdated, 2).Should(Equal(1))
+		configMapWatch.Delete(updated)
+		Eventually(c.getDeleted, 2).Should(Equal(1))
+		Expect(c.getAdded()).To(Equal(2))
+
+		err = wf.RemoveObjectHandler(&v1.ConfigMap{}, h)
+		Expect(err).NotTo(HaveOccurred())
+	})
 })
@@ -0,0 +1,260 @@
+// Package logging adds the Kubernetes objects and operations log messages are
+// about to the klog messages, and writes the messages as JSON objects for log
+// pipelines to correlate them across components.
+package logging
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+	klogv2 "k8s.io/klog/v2"
+)
+
+const (
+	// FormatText is the klog text format
+	FormatText = "text"
+	// FormatJSON is one JSON object per log message
+	FormatJSON = "json"
+)
+
+// The fields of the JSON log messages
+const (
+	FieldComponent = "component"
+	FieldNode      = "node"
+	FieldNamespace = "namespace"
+	FieldPod       = "pod"
+	FieldService   = "service"
+	FieldPolicy    = "policy"
+	FieldOperation = "operation"
+)
+
+// contextFields are the fields a Context prefix may have; a message prefix
+// with other fields is not a Context and is kept in the message
+var contextFields = map[string]bool{
+	FieldNamespace: true,
+	FieldPod:       true,
+	FieldService:   true,
+	FieldPolicy:    true,
+	FieldOperation: true,
+}
+
+type field struct {
+	key   string
+	value string
+}
+
+// Context is the Kubernetes object and the operation log messages are about.
+// It prefixes the messages, like "[namespace=ns1 pod=pod1 operation=add]",
+// and the JSON output turns the prefix into fields of the message.
+type Context struct {
+	fields []field
+}
+
+// Pod returns the context of log messages about a pod
+func Pod(namespace, name string) *Context {
+	return &Context{fields: []field{{FieldNamespace, namespace}, {FieldPod, name}}}
+}
+
+// Service returns the context of log messages about a service
+func Service(namespace, name string) *Context {
+	return &Context{fields: []field{{FieldNamespace, namespace}, {FieldService, name}}}
+}
+
+// Policy returns the context of log messages about a network policy
+func Policy(namespace, name string) *Context {
+	return &Context{fields: []field{{FieldNamespace, namespace}, {FieldPolicy, name}}}
+}
+
+// Namespace returns the context of log messages about a namespace
+func Namespace(name string) *Context {
+	return &Context{fields: []field{{FieldNamespace, name}}}
+}
+
+// Operation returns the context of log messages about the operation op on
+// the object of c
+func (c *Context) Operation(op string) *Context {
+	fields := make([]field, 0, len(c.fields)+1)
+	fields = append(fields, c.fields...)
+	return &Context{fields: append(fields, field{FieldOperation, op})}
+}
+
+// String returns the prefix of the log messages in the context
+func (c *Context) String() string {
+	parts := make([]string, 0, len(c.fields))
+	for _, f := range c.fields {
+		value := f.value
+		if value == "" || strings.ContainsAny(value, " =]\"") {
+			value = strconv.Quote(value)
+		}
+		parts = append(parts, f.key+"="+value)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// parseContext returns the fields of the Context prefixing msg, if any, and
+// the rest of the message
+func parseContext(msg string) (map[string]string, string) {
+	if !strings.HasPrefix(msg, "[") {
+		return nil, msg
+	}
+	fields := make(map[string]string)
+	rest := msg[1:]
+	for {
+		eq := strings.Index(rest, "=")
+		if eq <= 0 || !contextFields[rest[:eq]] {
+			return nil, msg
+		}
+		key := rest[:eq]
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := closingQuote(rest)
+			if end < 0 {
+				return nil, msg
+			}
+			var err error
+			if value, err = strconv.Unquote(rest[:end+1]); err != nil {
+				return nil, msg
+			}
+			rest = rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, " ]")
+			if end < 0 {
+				return nil, msg
+			}
+			value = rest[:end]
+			rest = rest[end:]
+		}
+		fields[key] = value
+		switch {
+		case strings.HasPrefix(rest, " "):
+			rest = rest[1:]
+		case strings.HasPrefix(rest, "]"):
+			return fields, strings.TrimPrefix(rest[1:], " ")
+		default:
+			return nil, msg
+		}
+	}
+}
+
+// closingQuote returns the index of the quote closing the quoted string s
+// starts with, or -1
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	processLock   sync.RWMutex
+	processFields = map[string]string{}
+)
+
+// SetComponent sets the component and the node of the process, which all its
+// JSON log messages carry. node may be empty.
+func SetComponent(component, node string) {
+	processLock.Lock()
+	defer processLock.Unlock()
+	processFields = map[string]string{FieldComponent: component}
+	if node != "" {
+		processFields[FieldNode] = node
+	}
+}
+
+// klogLineRe matches a klog line: its severity, date, time, thread ID and
+// caller, then the message
+var klogLineRe = regexp.MustCompile(`(?s)^([IWEF])\d{4} \d{2}:\d{2}:\d{2}\.\d{6}\s+\d+ ([^\]]+)\] (.*)$`)
+
+var severities = map[string]string{
+	"I": "info",
+	"W": "warning",
+	"E": "error",
+	"F": "fatal",
+}
+
+// jsonWriter writes the klog lines written to it as JSON objects
+type jsonWriter struct {
+	out io.Writer
+	now func() time.Time
+}
+
+func (w *jsonWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+	entry := map[string]string{
+		"ts":    w.now().UTC().Format(time.RFC3339Nano),
+		"level": "info",
+	}
+	processLock.RLock()
+	for key, value := range processFields {
+		entry[key] = value
+	}
+	processLock.RUnlock()
+	if match := klogLineRe.FindStringSubmatch(line); match != nil {
+		entry["level"] = severities[match[1]]
+		entry["caller"] = match[2]
+		line = match[3]
+	}
+	fields, msg := parseContext(line)
+	for key, value := range fields {
+		entry[key] = value
+	}
+	entry["msg"] = msg
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.out.Write(append(data, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// SetJSONOutput makes klog, and the klog/v2 the kubernetes client libraries
+// log through, write their messages to out as JSON objects instead of
+// writing text to stderr
+func SetJSONOutput(out io.Writer) error {
+	klogFlags := flag.NewFlagSet("klog", flag.ExitOnError)
+	klog.InitFlags(klogFlags)
+	klogV2Flags := flag.NewFlagSet("klogv2", flag.ExitOnError)
+	klogv2.InitFlags(klogV2Flags)
+	for name, value := range map[string]string{
+		"logtostderr":     "false",
+		"alsologtostderr": "false",
+		// only the fatal messages are written to stderr as text too
+		"stderrthreshold": "FATAL",
+	} {
+		if err := klogFlags.Set(name, value); err != nil {
+			return fmt.Errorf("error setting klog %s: %v", name, err)
+		}
+		if err := klogV2Flags.Set(name, value); err != nil {
+			return fmt.Errorf("error setting klog/v2 %s: %v", name, err)
+		}
+	}
+	// klog writes each message to the outputs of its severity and of all
+	// the lower severities, so the message is written once through the
+	// output of the info messages
+	writer := &jsonWriter{out: out, now: time.Now}
+	klog.SetOutputBySeverity("INFO", writer)
+	klogv2.SetOutputBySeverity("INFO", writer)
+	for _, severity := range []string{"WARNING", "ERROR", "FATAL"} {
+		klog.SetOutputBySeverity(severity, ioutil.Discard)
+		klogv2.SetOutputBySeverity(severity, ioutil.Discard)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestContext(t *testing.T) {
+	ctx := Pod("ns1", "pod1")
+	if got := ctx.Operation("add").String(); got != "[namespace=ns1 pod=pod1 operation=add]" {
+		t.Errorf("unexpected prefix %s", got)
+	}
+	if got := ctx.String(); got != "[namespace=ns1 pod=pod1]" {
+		t.Errorf("the operation changed the context: %s", got)
+	}
+	if got := Service("ns1", "").String(); got != `[namespace=ns1 service=""]` {
+		t.Errorf("unexpected prefix %s", got)
+	}
+
+	tests := []struct {
+		msg    string
+		fields map[string]string
+		rest   string
+	}{
+		{
+			msg:    "[namespace=ns1 pod=pod1 operation=add] addLogicalPort took 1s",
+			fields: map[string]string{"namespace": "ns1", "pod": "pod1", "operation": "add"},
+			rest:   "addLogicalPort took 1s",
+		},
+		{
+			msg:    `[namespace=ns1 policy="a b]"] Adding network policy`,
+			fields: map[string]string{"namespace": "ns1", "policy": "a b]"},
+			rest:   "Adding network policy",
+		},
+		{
+			msg:  "[ns1/pod1] not a context",
+			rest: "[ns1/pod1] not a context",
+		},
+		{
+			msg:  "[table=acl] not a context",
+			rest: "[table=acl] not a context",
+		},
+	}
+	for _, tc := range tests {
+		fields, rest := parseContext(tc.msg)
+		if !reflect.DeepEqual(fields, tc.fields) || rest != tc.rest {
+			t.Errorf("parsing %q: expected %v %q, got %v %q", tc.msg, tc.fields, tc.rest, fields, rest)
+		}
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	SetComponent("ovnkube-master", "node1")
+	defer SetComponent("", "")
+
+	out := &bytes.Buffer{}
+	w := &jsonWriter{
+		out: out,
+		now: func() time.Time { return time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC) },
+	}
+	line := "E0601 12:00:00.000000    1234 pods.go:229] [namespace=ns1 pod=pod1 operation=add] failed\n"
+	if _, err := w.Write([]byte(line)); err != nil {
+		t.Fatal(err)
+	}
+	entry := map[string]string{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	expected := map[string]string{
+		"ts":        "2020-06-01T12:00:00Z",
+		"level":     "error",
+		"caller":    "pods.go:229",
+		"component": "ovnkube-master",
+		"node":      "node1",
+		"namespace": "ns1",
+		"pod":       "pod1",
+		"operation": "add",
+		"msg":       "failed",
+	}
+	if !reflect.DeepEqual(entry, expected) {
+		t.Errorf("expected %v, got %v", expected, entry)
+	}
+}
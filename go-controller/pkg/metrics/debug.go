@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/klog"
+)
+
+// debugStatePath is the path of the debug endpoint on the metrics server
+const debugStatePath = "/debug/ovnkube/"
+
+// DebugFilter selects the objects a debug state dump is about. Empty fields
+// select all the objects.
+type DebugFilter struct {
+	Namespace string
+	Pod       string
+	Service   string
+}
+
+// MatchesNamespace returns true if the filter selects the namespace
+func (f *DebugFilter) MatchesNamespace(namespace string) bool {
+	return f.Namespace == "" || f.Namespace == namespace
+}
+
+// MatchesPod returns true if the filter selects the pod
+func (f *DebugFilter) MatchesPod(namespace, name string) bool {
+	return f.MatchesNamespace(namespace) && (f.Pod == "" || f.Pod == name)
+}
+
+// MatchesService returns true if the filter selects the service
+func (f *DebugFilter) MatchesService(namespace, name string) bool {
+	return f.MatchesNamespace(namespace) && (f.Service == "" || f.Service == name)
+}
+
+// DebugStateFunc returns the internal state of a controller the debug
+// endpoint dumps as JSON
+type DebugStateFunc func(filter *DebugFilter) interface{}
+
+var debugStates = struct {
+	sync.Mutex
+	funcs map[string]DebugStateFunc
+}{funcs: make(map[string]DebugStateFunc)}
+
+// RegisterDebugState registers the internal state the debug endpoint dumps
+// on /debug/ovnkube/<name>
+func RegisterDebugState(name string, state DebugStateFunc) {
+	debugStates.Lock()
+	defer debugStates.Unlock()
+	debugStates.funcs[name] = state
+}
+
+// debugStateHandler serves the registered debug states to the requests
+// bearing the token in tokenFile. The file is read for each request so that
+// the token can be rotated.
+type debugStateHandler struct {
+	tokenFile string
+}
+
+func (h *debugStateHandler) authorized(r *http.Request) bool {
+	token, err := ioutil.ReadFile(h.tokenFile)
+	if err != nil {
+		klog.Errorf("Failed to read the debug token file %s: %v", h.tokenFile, err)
+		return false
+	}
+	expected := strings.TrimSpace(string(token))
+	auth := r.Header.Get("Authorization")
+	if expected == "" || !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(expected)) == 1
+}
+
+func (h *debugStateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var result interface{}
+	name := strings.TrimPrefix(r.URL.Path, debugStatePath)
+	debugStates.Lock()
+	state, ok := debugStates.funcs[name]
+	if name == "" {
+		names := make([]string, 0, len(debugStates.funcs))
+		for name := range debugStates.funcs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		result = names
+	}
+	debugStates.Unlock()
+	if ok {
+		query := r.URL.Query()
+		result = state(&DebugFilter{
+			Namespace: query.Get("namespace"),
+			Pod:       query.Get("pod"),
+			Service:   query.Get("service"),
+		})
+	}
+	if result == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		klog.Errorf("Failed to write debug state %q: %v", name, err)
+	}
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDebugStateHandler(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "debug-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	tokenFile := filepath.Join(tmpDir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	RegisterDebugState("test/pods", func(filter *DebugFilter) interface{} {
+		pods := []string{}
+		for _, pod := range []string{"ns1/pod1", "ns1/pod2", "ns2/pod1"} {
+			parts := strings.Split(pod, "/")
+			if filter.MatchesPod(parts[0], parts[1]) {
+				pods = append(pods, pod)
+			}
+		}
+		return pods
+	})
+	handler := &debugStateHandler{tokenFile: tokenFile}
+
+	tests := []struct {
+		desc   string
+		path   string
+		token  string
+		status int
+		body   string
+	}{
+		{
+			desc:   "rejects requests without a token",
+			path:   "/debug/ovnkube/test/pods",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "rejects requests with a wrong token",
+			path:   "/debug/ovnkube/test/pods",
+			token:  "wrong",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "lists the debug states",
+			path:   "/debug/ovnkube/",
+			token:  "secret",
+			status: http.StatusOK,
+			body:   `["test/pods"]`,
+		},
+		{
+			desc:   "dumps a debug state",
+			path:   "/debug/ovnkube/test/pods",
+			token:  "secret",
+			status: http.StatusOK,
+			body:   `["ns1/pod1","ns1/pod2","ns2/pod1"]`,
+		},
+		{
+			desc:   "filters a debug state",
+			path:   "/debug/ovnkube/test/pods?namespace=ns1&pod=pod2",
+			token:  "secret",
+			status: http.StatusOK,
+			body:   `["ns1/pod2"]`,
+		},
+		{
+			desc:   "returns not found for unknown debug states",
+			path:   "/debug/ovnkube/test/unknown",
+			token:  "secret",
+			status: http.StatusNotFound,
+		},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.desc, tc.status, rec.Code)
+			continue
+		}
+		if tc.body == "" {
+			continue
+		}
+		body := strings.Join(strings.Fields(rec.Body.String()), "")
+		if body != tc.body {
+			t.Errorf("%s: expected body %s, got %s", tc.desc, tc.body, body)
+		}
+	}
+}
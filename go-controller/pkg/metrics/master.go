@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	util "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	kapi "k8s.io/api/core/v1"
 
@@ -52,6 +55,169 @@ var MetricMasterReadyDuration = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help:      "The duration for the master to get to ready state",
 })
 
+// MetricMasterResourceRetries is the number of retries of the failed
+// operations on each resource type
+var MetricMasterResourceRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemMaster,
+	Name:      "resource_retries_total",
+	Help:      "The number of retries of failed operations on resources",
+},
+	// labels
+	[]string{"resource"},
+)
+
+// MetricMasterResourceRetriesPending is the number of failed operations on
+// each resource type waiting for a retry
+var MetricMasterResourceRetriesPending = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemMaster,
+	Name:      "resource_retries_pending",
+	Help:      "The number of failed operations on resources waiting for a retry",
+},
+	// labels
+	[]string{"resource"},
+)
+
+// MetricMasterResourceOldestFailure is the time since the first failure of
+// the longest failing operation on each resource type
+var MetricMasterResourceOldestFailure = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemMaster,
+	Name:      "resource_oldest_failure_age_seconds",
+	Help:      "The time since the first failure of the longest failing operation on resources",
+},
+	// labels
+	[]string{"resource"},
+)
+
+// MetricMasterNBDBDrift is the number of northbound database objects of each
+// type the last check found to differ from the Kubernetes objects
+var MetricMasterNBDBDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemMaster,
+	Name:      "nbdb_drift_objects",
+	Help:      "The number of northbound database objects that differ from the Kubernetes objects",
+},
+	// labels
+	[]string{"type"},
+)
+
+// MetricMasterNBDBRepairs is the number of repairs of northbound database
+// objects of each type that differed from the Kubernetes objects
+var MetricMasterNBDBRepairs = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemMaster,
+	Name:      "nbdb_repairs_total",
+	Help:      "The number of repairs of northbound database objects that differed from the Kubernetes objects",
+},
+	// labels
+	[]string{"type"},
+)
+
+// metricHandlerDuration is the time the handlers of each resource type take
+// to process an operation on an object
+var metricHandlerDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemMaster,
+	Name:      "handler_duration_seconds",
+	Help:      "The time the handlers take to process add, update, delete and sync operations on resources",
+	Buckets:   prometheus.ExponentialBuckets(.001, 2, 18)},
+	// labels
+	[]string{"resource", "operation"},
+)
+
+// metricHandlerNBTransactions is the number of ovn-nbctl transactions the
+// handlers of each resource type issued
+var metricHandlerNBTransactions = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemMaster,
+	Name:      "handler_nb_transactions_total",
+	Help:      "The number of northbound database transactions issued by the handlers of resources",
+},
+	// labels
+	[]string{"resource", "operation"},
+)
+
+// metricHandlerErrors is the number of failed operations of the handlers of
+// each resource type
+var metricHandlerErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemMaster,
+	Name:      "handler_errors_total",
+	Help:      "The number of failed add, update, delete and sync operations of the handlers of resources",
+},
+	// labels
+	[]string{"resource", "operation"},
+)
+
+// metricEventQueueDepth is the number of events of each resource type
+// waiting in the watch factory's event queues
+var metricEventQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemMaster,
+	Name:      "event_queue_depth",
+	Help:      "The number of resource events waiting to be processed by the handlers",
+},
+	// labels
+	[]string{"resource"},
+)
+
+// metricMulticastGroupMembers is the number of logical ports that joined
+// each multicast group, as reported by the IGMP_Group table of the southbound
+// database.
+var metricMulticastGroupMembers = prometheus.NewDesc(
+	prometheus.BuildFQName(MetricOvnkubeNamespace, MetricOvnkubeSubsystemMaster, "multicast_group_members"),
+	"The number of logical ports that joined a multicast group, across all logical switches",
+	[]string{"group"}, nil,
+)
+
+// multicastGroupCollector reads multicast group membership from the
+// southbound database when scraped.
+type multicastGroupCollector struct{}
+
+func (c multicastGroupCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- metricMulticastGroupMembers
+}
+
+func (c multicastGroupCollector) Collect(ch chan<- prometheus.Metric) {
+	members, err := scrapeMulticastGroupMembers()
+	if err != nil {
+		klog.Errorf("failed to scrape multicast groups: %v", err)
+		return
+	}
+	for group, count := range members {
+		ch <- prometheus.MustNewConstMetric(metricMulticastGroupMembers,
+			prometheus.GaugeValue, float64(count), group)
+	}
+}
+
+// scrapeMulticastGroupMembers returns the number of member ports of each
+// multicast group. A group has one IGMP_Group row per datapath and chassis
+// it was joined on.
+func scrapeMulticastGroupMembers() (map[string]int, error) {
+	output, stderr, err := util.RunOVNSbctl("--data=bare", "--no-heading",
+		"--columns=address,ports", "list", "IGMP_Group")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IGMP groups: %s (%v)", stderr, err)
+	}
+
+	members := make(map[string]int)
+	for _, record := range strings.Split(output, "\n\n") {
+		lines := strings.Split(record, "\n")
+		group := strings.TrimSpace(lines[0])
+		if group == "" {
+			continue
+		}
+		ports := 0
+		if len(lines) > 1 {
+			ports = len(strings.Fields(lines[1]))
+		}
+		members[group] += ports
+	}
+	return members, nil
+}
+
 var registerMasterMetricsOnce sync.Once
 var startMasterUpdaterOnce sync.Once
 
@@ -80,8 +246,22 @@ func RegisterMasterMetrics() {
 			}, func() float64 {
 				return float64(util.SkippedNbctlDaemonCounter)
 			}))
+		if config.EnableMulticast {
+			prometheus.MustRegister(multicastGroupCollector{})
+		}
 		prometheus.MustRegister(MetricMasterReadyDuration)
+		prometheus.MustRegister(MetricMasterResourceRetries)
+		prometheus.MustRegister(MetricMasterResourceRetriesPending)
+		prometheus.MustRegister(MetricMasterResourceOldestFailure)
+		prometheus.MustRegister(MetricMasterNBDBDrift)
+		prometheus.MustRegister(MetricMasterNBDBRepairs)
 		prometheus.MustRegister(metricOvnCliLatency)
+		prometheus.MustRegister(metricHandlerDuration)
+		prometheus.MustRegister(metricHandlerNBTransactions)
+		prometheus.MustRegister(metricHandlerErrors)
+		prometheus.MustRegister(metricEventQueueDepth)
+		// likewise to not create circular import between metrics and factory package
+		factory.MetricEventQueueDepth = metricEventQueueDepth
 		// this is to not to create circular import between metrics and util package
 		util.MetricOvnCliLatency = metricOvnCliLatency
 		prometheus.MustRegister(prometheus.NewGaugeFunc(
@@ -159,3 +339,20 @@ func RecordPodCreated(pod *kapi.Pod) {
 		return
 	}
 }
+
+// MeasureHandler runs an operation of the handlers of resource and records
+// its duration, the northbound database transactions it issued and whether
+// it failed
+func MeasureHandler(resource, operation string, op func() error) error {
+	var err error
+	start := time.Now()
+	transactions := util.CountNBTransactions(func() {
+		err = op()
+	})
+	metricHandlerDuration.WithLabelValues(resource, operation).Observe(time.Since(start).Seconds())
+	metricHandlerNBTransactions.WithLabelValues(resource, operation).Add(float64(transactions))
+	if err != nil {
+		metricHandlerErrors.WithLabelValues(resource, operation).Inc()
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMeasureHandler(t *testing.T) {
+	fexec := ovntest.NewFakeExec()
+	if err := util.SetExec(fexec); err != nil {
+		t.Fatal(err)
+	}
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 ls-add test",
+	})
+
+	if err := MeasureHandler("test", "add", func() error {
+		_, _, err := util.RunOVNNbctl("ls-add", "test")
+		return err
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opErr := fmt.Errorf("failed")
+	if err := MeasureHandler("test", "add", func() error { return opErr }); err != opErr {
+		t.Fatalf("expected the error of the operation, got %v", err)
+	}
+
+	histogram := &dto.Metric{}
+	if err := metricHandlerDuration.WithLabelValues("test", "add").(prometheus.Metric).Write(histogram); err != nil {
+		t.Fatal(err)
+	}
+	if count := histogram.GetHistogram().GetSampleCount(); count != 2 {
+		t.Errorf("expected 2 durations recorded, got %d", count)
+	}
+
+	counter := &dto.Metric{}
+	if err := metricHandlerNBTransactions.WithLabelValues("test", "add").Write(counter); err != nil {
+		t.Fatal(err)
+	}
+	if transactions := counter.GetCounter().GetValue(); transactions != 1 {
+		t.Errorf("expected 1 transaction counted, got %v", transactions)
+	}
+
+	counter = &dto.Metric{}
+	if err := metricHandlerErrors.WithLabelValues("test", "add").Write(counter); err != nil {
+		t.Fatal(err)
+	}
+	if errors := counter.GetCounter().GetValue(); errors != 1 {
+		t.Errorf("expected 1 error counted, got %v", errors)
+	}
+}
@@ -28,11 +28,17 @@ var (
 	BuildDate string
 )
 
-// StartMetricsServer runs the prometheus listner so that metrics can be collected
-func StartMetricsServer(bindAddress string, enablePprof bool) {
+// StartMetricsServer runs the prometheus listner so that metrics can be collected.
+// If debugTokenFile is set, the states registered with RegisterDebugState are
+// served on /debug/ovnkube/ to the requests bearing the token in the file.
+func StartMetricsServer(bindAddress string, enablePprof bool, debugTokenFile string) {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
 
+	if debugTokenFile != "" {
+		mux.Handle(debugStatePath, &debugStateHandler{tokenFile: debugTokenFile})
+	}
+
 	if enablePprof {
 		mux.HandleFunc("/debug/pprof/", pprof.Index)
 		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
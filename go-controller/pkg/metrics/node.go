@@ -30,12 +30,54 @@ var MetricNodeReadyDuration = prometheus.NewGauge(prometheus.GaugeOpts{
 	Help:      "The duration for the node to get to ready state",
 })
 
+// MetricManagementPortHealthy is a prometheus metric that is 1 when the
+// management port passed its last health check and 0 otherwise
+var MetricManagementPortHealthy = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemNode,
+	Name:      "management_port_healthy",
+	Help:      "Whether the management port passed its last health check",
+})
+
+// MetricManagementPortRepairs is a prometheus metric that counts the repairs
+// of the management port configuration
+var MetricManagementPortRepairs = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemNode,
+	Name:      "management_port_repairs_total",
+	Help:      "The total number of repairs of the management port configuration",
+})
+
+// MetricIPsecTunnels is a prometheus metric that counts the node's tunnels to
+// the other nodes by whether they are encrypted with IPsec
+var MetricIPsecTunnels = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemNode,
+	Name:      "ipsec_tunnels",
+	Help:      "The number of tunnels to the other nodes by whether they are encrypted with IPsec"},
+	//labels
+	[]string{"encrypted"},
+)
+
+// MetricIPsecCertificateExpiry is a prometheus metric that holds the expiry
+// time of the node's IPsec certificate
+var MetricIPsecCertificateExpiry = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: MetricOvnkubeNamespace,
+	Subsystem: MetricOvnkubeSubsystemNode,
+	Name:      "ipsec_certificate_expiry_timestamp_seconds",
+	Help:      "The time the node's IPsec certificate expires at, in seconds since the epoch",
+})
+
 var registerNodeMetricsOnce sync.Once
 
 func RegisterNodeMetrics() {
 	registerNodeMetricsOnce.Do(func() {
 		prometheus.MustRegister(MetricCNIRequestDuration)
 		prometheus.MustRegister(MetricNodeReadyDuration)
+		prometheus.MustRegister(MetricManagementPortHealthy)
+		prometheus.MustRegister(MetricManagementPortRepairs)
+		prometheus.MustRegister(MetricIPsecTunnels)
+		prometheus.MustRegister(MetricIPsecCertificateExpiry)
 		prometheus.MustRegister(prometheus.NewCounterFunc(
 			prometheus.CounterOpts{
 				Namespace: MetricOvnkubeNamespace,
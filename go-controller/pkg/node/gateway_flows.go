@@ -0,0 +1,137 @@
+package node
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	"k8s.io/klog"
+)
+
+// flowSyncInterval is how often the desired flows are re-applied to the
+// bridge, so that flows removed or modified behind our back are restored.
+const flowSyncInterval = 15 * time.Second
+
+// flowManager keeps the desired set of OpenFlow flows of a bridge in memory
+// and programs it with 'ovs-ofctl replace-flows', which atomically adds,
+// modifies and deletes flows so the bridge ends up with exactly that set.
+type flowManager struct {
+	sync.Mutex
+	bridge string
+	// flows every bridge has, including the table 0 NORMAL action flow
+	defaultFlows []string
+	// flows of each NodePort service, keyed by service namespace/name
+	serviceFlows map[string][]string
+}
+
+func newFlowManager(bridge string) *flowManager {
+	return &flowManager{
+		bridge:       bridge,
+		defaultFlows: []string{"table=0, priority=0, actions=NORMAL"},
+		serviceFlows: make(map[string][]string),
+	}
+}
+
+// setDefaultFlows replaces the default flows of the bridge. The NORMAL
+// action flow is always kept.
+func (fm *flowManager) setDefaultFlows(flows []string) {
+	fm.Lock()
+	defer fm.Unlock()
+	fm.defaultFlows = append([]string{"table=0, priority=0, actions=NORMAL"}, flows...)
+}
+
+// updateServiceFlows sets the flows of a service; nil flows remove them.
+// Returns true if the service's flows changed.
+func (fm *flowManager) updateServiceFlows(key string, flows []string) bool {
+	fm.Lock()
+	defer fm.Unlock()
+	if reflect.DeepEqual(fm.serviceFlows[key], flows) {
+		return false
+	}
+	if len(flows) == 0 {
+		delete(fm.serviceFlows, key)
+	} else {
+		fm.serviceFlows[key] = flows
+	}
+	return true
+}
+
+// setServiceFlows replaces the flows of all services at once.
+func (fm *flowManager) setServiceFlows(serviceFlows map[string][]string) {
+	fm.Lock()
+	defer fm.Unlock()
+	fm.serviceFlows = serviceFlows
+}
+
+// flows returns the desired flows of the bridge, default flows first and
+// service flows in service key order. fm must be locked.
+func (fm *flowManager) flows() []string {
+	keys := make([]string, 0, len(fm.serviceFlows))
+	for key := range fm.serviceFlows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	flows := append([]string{}, fm.defaultFlows...)
+	for _, key := range keys {
+		flows = append(flows, fm.serviceFlows[key]...)
+	}
+	return flows
+}
+
+type debugFlows struct {
+	Bridge       string              `json:"bridge"`
+	DefaultFlows []string            `json:"defaultFlows"`
+	ServiceFlows map[string][]string `json:"serviceFlows"`
+}
+
+// debugState returns the desired flows for the debug endpoint of the
+// metrics server
+func (fm *flowManager) debugState(filter *metrics.DebugFilter) interface{} {
+	fm.Lock()
+	defer fm.Unlock()
+	flows := &debugFlows{
+		Bridge:       fm.bridge,
+		DefaultFlows: append([]string{}, fm.defaultFlows...),
+		ServiceFlows: make(map[string][]string),
+	}
+	for key, serviceFlows := range fm.serviceFlows {
+		parts := strings.SplitN(key, "/", 2)
+		if len(parts) == 2 && !filter.MatchesService(parts[0], parts[1]) {
+			continue
+		}
+		flows.ServiceFlows[key] = append([]string{}, serviceFlows...)
+	}
+	return flows
+}
+
+// syncFlows programs the desired flows on the bridge.
+func (fm *flowManager) syncFlows() error {
+	fm.Lock()
+	defer fm.Unlock()
+	_, stderr, err := util.ReplaceOFFlows(fm.bridge, fm.flows())
+	if err != nil {
+		return fmt.Errorf("failed to replace-flows on bridge %q stderr:%s (%v)", fm.bridge, stderr, err)
+	}
+	return nil
+}
+
+// run periodically re-applies the desired flows until stopChan is closed.
+func (fm *flowManager) run(stopChan chan struct{}) {
+	for {
+		select {
+		case <-time.After(flowSyncInterval):
+			if err := fm.syncFlows(); err != nil {
+				klog.Errorf("Failed to sync flows: %v", err)
+			}
+		case <-stopChan:
+			return
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package node
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newNodePortService(name, clusterIP string, nodePorts ...int32) *v1.Service {
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "namespace1",
+		},
+		Spec: v1.ServiceSpec{
+			Type:      v1.ServiceTypeNodePort,
+			ClusterIP: clusterIP,
+		},
+	}
+	for _, nodePort := range nodePorts {
+		service.Spec.Ports = append(service.Spec.Ports, v1.ServicePort{
+			Protocol: v1.ProtocolTCP,
+			NodePort: nodePort,
+		})
+	}
+	return service
+}
+
+var _ = Describe("Gateway flow manager", func() {
+	var fexec *ovntest.FakeExec
+	nodeIPv4 := []*net.IPNet{ovntest.MustParseIPNet("10.0.0.5/24")}
+
+	BeforeEach(func() {
+		fexec = ovntest.NewFakeExec()
+		err := util.SetExec(fexec)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("keeps default flows first and service flows ordered by service", func() {
+		fm := newFlowManager("breth0")
+		fm.setDefaultFlows([]string{"cookie=0xdeff105, priority=0, table=1, actions=output:NORMAL"})
+
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovs-ofctl -O OpenFlow13 --bundle replace-flows breth0 -",
+			"ovs-ofctl -O OpenFlow13 --bundle replace-flows breth0 -",
+		})
+		syncServices([]interface{}{
+			newNodePortService("svc-b", "172.16.1.2", 30001),
+			newNodePortService("svc-a", "172.16.1.1", 30000, 30002),
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-ip", Namespace: "namespace1"},
+				Spec:       v1.ServiceSpec{Type: v1.ServiceTypeClusterIP},
+			},
+		}, "7", "5", nodeIPv4, fm)
+		Expect(fm.flows()).To(Equal([]string{
+			"table=0, priority=0, actions=NORMAL",
+			"cookie=0xdeff105, priority=0, table=1, actions=output:NORMAL",
+			"priority=100, in_port=7, tcp, tp_dst=30000, actions=5",
+			"priority=100, in_port=7, tcp, tp_dst=30002, actions=5",
+			"priority=100, in_port=7, tcp, tp_dst=30001, actions=5",
+		}))
+
+		// Re-adding an already synced service does not reprogram the bridge
+		updateServiceFlows(fm, "namespace1/svc-b", getServiceFlows(newNodePortService("svc-b", "172.16.1.2", 30001), "7", "5", nodeIPv4))
+
+		// Removing a service reprograms the bridge without its flows
+		updateServiceFlows(fm, "namespace1/svc-a", nil)
+		Expect(fm.flows()).To(Equal([]string{
+			"table=0, priority=0, actions=NORMAL",
+			"cookie=0xdeff105, priority=0, table=1, actions=output:NORMAL",
+			"priority=100, in_port=7, tcp, tp_dst=30001, actions=5",
+		}))
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+	})
+
+	It("programs NodePort flows for each IP family of the gateway", func() {
+		fm := newFlowManager("breth0")
+
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovs-ofctl -O OpenFlow13 --bundle replace-flows breth0 -",
+		})
+		service := newNodePortService("svc-v6", "fd00:10:96::1", 30000)
+		service.Spec.Ports = append(service.Spec.Ports, v1.ServicePort{
+			Protocol: v1.ProtocolUDP,
+			NodePort: 30001,
+		})
+		syncServices([]interface{}{
+			newNodePortService("svc-v4", "172.16.1.1", 30002),
+			service,
+		}, "7", "5", []*net.IPNet{ovntest.MustParseIPNet("fd00::5/64")}, fm)
+		Expect(fm.flows()).To(Equal([]string{
+			"table=0, priority=0, actions=NORMAL",
+			"priority=100, in_port=7, tcp6, tp_dst=30002, actions=5",
+			"priority=100, in_port=7, tcp6, tp_dst=30000, actions=5",
+			"priority=100, in_port=7, udp6, tp_dst=30001, actions=5",
+		}))
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+	})
+
+	It("programs ip and ipv6 NodePort flows on dual-stack gateways", func() {
+		fm := newFlowManager("breth0")
+
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovs-ofctl -O OpenFlow13 --bundle replace-flows breth0 -",
+		})
+		service := newNodePortService("svc-dual", "172.16.1.1", 30000)
+		service.Spec.ClusterIPs = []string{"172.16.1.1", "fd00:10:96::1"}
+		syncServices([]interface{}{service}, "7", "5", []*net.IPNet{
+			ovntest.MustParseIPNet("10.0.0.5/24"),
+			ovntest.MustParseIPNet("fd00::5/64"),
+		}, fm)
+		Expect(fm.flows()).To(Equal([]string{
+			"table=0, priority=0, actions=NORMAL",
+			"priority=100, in_port=7, tcp, tp_dst=30000, actions=5",
+			"priority=100, in_port=7, tcp6, tp_dst=30000, actions=5",
+		}))
+		Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+	})
+
+	It("programs default flows for both IP families on dual-stack nodes", func() {
+		config.IPv4Mode = true
+		config.IPv6Mode = true
+		defer func() {
+			config.IPv4Mode = false
+			config.IPv6Mode = false
+		}()
+
+		flows := getDefaultFlows("5", "7")
+		Expect(flows).To(ContainElement(
+			"cookie=0xdeff105, priority=110, in_port=7, icmp6, icmp_type=135, actions=output:5, LOCAL"))
+		Expect(flows).To(ContainElement(
+			"cookie=0xdeff105, priority=110, in_port=7, icmp6, icmp_type=136, actions=output:5, LOCAL"))
+		for _, protocol := range []string{"ip", "ipv6"} {
+			Expect(flows).To(ContainElement(fmt.Sprintf(
+				"cookie=0xdeff105, priority=100, in_port=5, %s, actions=ct(commit, zone=%d), output:7",
+				protocol, config.Default.ConntrackZone)))
+			Expect(flows).To(ContainElement(fmt.Sprintf(
+				"cookie=0xdeff105, priority=50, in_port=7, %s, actions=ct(zone=%d, table=1)",
+				protocol, config.Default.ConntrackZone)))
+		}
+	})
+})
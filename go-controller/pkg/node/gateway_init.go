@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"net"
 	"runtime"
+	"sort"
 	"strings"
 
+	kapi "k8s.io/api/core/v1"
 	"k8s.io/klog"
 	utilnet "k8s.io/utils/net"
 
@@ -37,19 +39,49 @@ func bridgedGatewayNodeSetup(nodeName, bridgeName, bridgeInterface string, syncB
 
 	// ovn-bridge-mappings maps a physical network name to a local ovs bridge
 	// that provides connectivity to that network.
-	_, stderr, err := util.RunOVSVsctl("set", "Open_vSwitch", ".",
-		fmt.Sprintf("external_ids:ovn-bridge-mappings=%s:%s", util.PhysicalNetworkName, bridgeName))
-	if err != nil {
-		return "", nil, fmt.Errorf("Failed to set ovn-bridge-mappings for ovs bridge %s"+
-			", stderr:%s (%v)", bridgeName, stderr, err)
+	if err := setBridgeMappings(bridgeName); err != nil {
+		return "", nil, err
 	}
 
 	ifaceID := bridgeName + "_" + nodeName
 	return ifaceID, macAddress, nil
 }
 
-// getIPv4Address returns the ipv4 address for the network interface 'iface'.
-func getIPv4Address(iface string) (*net.IPNet, error) {
+// setBridgeMappings sets the ovn-bridge-mappings of the node, mapping the
+// gateway's physical network to gatewayBridge, if any, followed by the
+// provider network bridge mappings.
+func setBridgeMappings(gatewayBridge string) error {
+	var bridgeMappings []string
+	if gatewayBridge != "" {
+		bridgeMappings = append(bridgeMappings, util.PhysicalNetworkName+":"+gatewayBridge)
+	}
+	networks := make([]string, 0, len(config.ProviderNetworks.BridgeMappings))
+	for network := range config.ProviderNetworks.BridgeMappings {
+		if network == util.PhysicalNetworkName {
+			return fmt.Errorf("provider network name %q is reserved for the gateway", network)
+		}
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+	for _, network := range networks {
+		bridgeMappings = append(bridgeMappings, network+":"+config.ProviderNetworks.BridgeMappings[network])
+	}
+	if len(bridgeMappings) == 0 {
+		return nil
+	}
+
+	_, stderr, err := util.RunOVSVsctl("set", "Open_vSwitch", ".",
+		"external_ids:ovn-bridge-mappings="+strings.Join(bridgeMappings, ","))
+	if err != nil {
+		return fmt.Errorf("Failed to set ovn-bridge-mappings %s, stderr:%s (%v)",
+			strings.Join(bridgeMappings, ","), stderr, err)
+	}
+	return nil
+}
+
+// getNetworkInterfaceIPAddresses returns an IP address of the network
+// interface 'iface' for each IP family used by the cluster.
+func getNetworkInterfaceIPAddresses(iface string) ([]*net.IPNet, error) {
 	intf, err := net.InterfaceByName(iface)
 	if err != nil {
 		return nil, err
@@ -59,18 +91,55 @@ func getIPv4Address(iface string) (*net.IPNet, error) {
 	if err != nil {
 		return nil, err
 	}
+	var ipv4Address, ipv6Address *net.IPNet
 	for _, addr := range addrs {
-		switch ip := addr.(type) {
-		case *net.IPNet:
-			if !utilnet.IsIPv6CIDR(ip) {
-				return ip, nil
+		ip, ok := addr.(*net.IPNet)
+		if !ok || ip.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if utilnet.IsIPv6CIDR(ip) {
+			if ipv6Address == nil {
+				ipv6Address = ip
 			}
+		} else if ipv4Address == nil {
+			ipv4Address = ip
+		}
+	}
+
+	var ipAddresses []*net.IPNet
+	if config.IPv4Mode {
+		if ipv4Address == nil {
+			return nil, fmt.Errorf("%s does not have an IPv4 address", iface)
 		}
+		ipAddresses = append(ipAddresses, ipv4Address)
 	}
-	return nil, nil
+	if config.IPv6Mode {
+		if ipv6Address == nil {
+			return nil, fmt.Errorf("%s does not have an IPv6 address", iface)
+		}
+		ipAddresses = append(ipAddresses, ipv6Address)
+	}
+	return ipAddresses, nil
 }
 
-func (n *OvnNode) initGateway(subnet *net.IPNet, nodeAnnotator kube.Annotator,
+// getGatewayNextHops returns the configured gateway next hops, one for each
+// IP family used by the cluster.
+func getGatewayNextHops() ([]net.IP, error) {
+	var nextHops []net.IP
+	if config.Gateway.NextHop == "" {
+		return nil, nil
+	}
+	for _, nextHopStr := range strings.Split(config.Gateway.NextHop, ",") {
+		nextHop := net.ParseIP(strings.TrimSpace(nextHopStr))
+		if nextHop == nil {
+			return nil, fmt.Errorf("invalid gateway next hop %q", nextHopStr)
+		}
+		nextHops = append(nextHops, nextHop)
+	}
+	return nextHops, nil
+}
+
+func (n *OvnNode) initGateway(subnets []*net.IPNet, nodeAnnotator kube.Annotator,
 	waiter *startupWaiter) error {
 
 	if config.Gateway.NodeportEnable {
@@ -84,27 +153,35 @@ func (n *OvnNode) initGateway(subnet *net.IPNet, nodeAnnotator kube.Annotator,
 	var prFn postWaitFunc
 	switch config.Gateway.Mode {
 	case config.GatewayModeLocal:
-		err = initLocalnetGateway(n.name, subnet, n.watchFactory, nodeAnnotator)
+		err = initLocalnetGateway(n.name, subnets, n.watchFactory, nodeAnnotator)
 	case config.GatewayModeShared:
-		gatewayNextHop := net.ParseIP(config.Gateway.NextHop)
+		var gatewayNextHops []net.IP
+		gatewayNextHops, err = getGatewayNextHops()
+		if err != nil {
+			return err
+		}
 		gatewayIntf := config.Gateway.Interface
-		if gatewayNextHop == nil || gatewayIntf == "" {
+		if len(gatewayNextHops) == 0 || gatewayIntf == "" {
 			// We need to get the interface details from the default gateway.
-			defaultGatewayIntf, defaultGatewayNextHop, err := getDefaultGatewayInterfaceDetails()
+			defaultGatewayIntf, defaultGatewayNextHops, err := getDefaultGatewayInterfaceDetails()
 			if err != nil {
 				return err
 			}
 
-			if gatewayNextHop == nil {
-				gatewayNextHop = defaultGatewayNextHop
+			if len(gatewayNextHops) == 0 {
+				gatewayNextHops = defaultGatewayNextHops
 			}
 
 			if gatewayIntf == "" {
 				gatewayIntf = defaultGatewayIntf
 			}
 		}
-		prFn, err = n.initSharedGateway(subnet, gatewayNextHop, gatewayIntf, nodeAnnotator)
+		prFn, err = n.initSharedGateway(subnets, gatewayNextHops, gatewayIntf, nodeAnnotator)
 	case config.GatewayModeDisabled:
+		// provider networks are reachable even without a gateway
+		if err = setBridgeMappings(""); err != nil {
+			return err
+		}
 		err = util.SetL3GatewayConfig(nodeAnnotator, &util.L3GatewayConfig{
 			Mode: config.GatewayModeDisabled,
 		})
@@ -118,18 +195,47 @@ func (n *OvnNode) initGateway(subnet *net.IPNet, nodeAnnotator kube.Annotator,
 	return nil
 }
 
+// cleanupGateway removes the bridges, flows and iptables rules set up for
+// the given gateway mode.
+func cleanupGateway(mode config.GatewayMode) error {
+	switch mode {
+	case config.GatewayModeLocal:
+		return cleanupLocalnetGateway()
+	case config.GatewayModeShared:
+		return cleanupSharedGateway()
+	}
+	return nil
+}
+
+// cleanupPreviousGatewayMode cleans up the gateway of the mode recorded in
+// the node's L3 gateway annotation if it differs from the configured mode,
+// so that the gateway mode can be changed by restarting ovnkube-node. The
+// master rebuilds the node's gateway router once the new mode is annotated.
+func cleanupPreviousGatewayMode(node *kapi.Node) error {
+	l3GatewayConfig, err := util.ParseNodeL3GatewayAnnotation(node)
+	if err != nil {
+		// the node has not set up a gateway yet
+		return nil
+	}
+	if l3GatewayConfig.Mode == config.Gateway.Mode {
+		return nil
+	}
+
+	klog.Infof("Gateway mode of node %s changed from %q to %q, cleaning up the %q gateway",
+		node.Name, l3GatewayConfig.Mode, config.Gateway.Mode, l3GatewayConfig.Mode)
+	if err := cleanupGateway(l3GatewayConfig.Mode); err != nil {
+		return fmt.Errorf("failed to clean up %q gateway of node %s: %v", l3GatewayConfig.Mode, node.Name, err)
+	}
+	return nil
+}
+
 // CleanupClusterNode cleans up OVS resources on the k8s node on ovnkube-node daemonset deletion.
 // This is going to be a best effort cleanup.
 func CleanupClusterNode(name string) error {
 	var err error
 
 	klog.V(5).Infof("Cleaning up gateway resources on node: %q", name)
-	switch config.Gateway.Mode {
-	case config.GatewayModeLocal:
-		err = cleanupLocalnetGateway()
-	case config.GatewayModeShared:
-		err = cleanupSharedGateway()
-	}
+	err = cleanupGateway(config.Gateway.Mode)
 	if err != nil {
 		klog.Errorf("Failed to cleanup Gateway, error: %v", err)
 	}
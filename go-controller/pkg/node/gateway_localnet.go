@@ -121,7 +121,7 @@ func localnetGatewayNAT(ipt util.IPTablesHelper, ifname string, ip net.IP) error
 	return addIptRules(ipt, rules)
 }
 
-func initLocalnetGateway(nodeName string, subnet *net.IPNet, wf *factory.WatchFactory, nodeAnnotator kube.Annotator) error {
+func initLocalnetGateway(nodeName string, subnets []*net.IPNet, wf *factory.WatchFactory, nodeAnnotator kube.Annotator) error {
 	// Create a localnet OVS bridge.
 	localnetBridgeName := "br-local"
 	_, stderr, err := util.RunOVSVsctl("--may-exist", "add-br",
@@ -156,26 +156,34 @@ func initLocalnetGateway(nodeName string, subnet *net.IPNet, wf *factory.WatchFa
 		return err
 	}
 
-	var gatewayIP, gatewayNextHop net.IP
-	var gatewaySubnetMask net.IPMask
-	if utilnet.IsIPv6CIDR(subnet) {
-		gatewayIP = net.ParseIP(v6localnetGatewayIP)
-		gatewayNextHop = net.ParseIP(v6localnetGatewayNextHop)
-		gatewaySubnetMask = net.CIDRMask(v6localnetGatewaySubnetPrefix, 128)
-	} else {
-		gatewayIP = net.ParseIP(v4localnetGatewayIP)
-		gatewayNextHop = net.ParseIP(v4localnetGatewayNextHop)
-		gatewaySubnetMask = net.CIDRMask(v4localnetGatewaySubnetPrefix, 32)
+	// Flush any addresses on localnetBridgeNextHopPort before adding the new ones.
+	if err = util.LinkAddrFlush(link); err != nil {
+		return err
 	}
-	gatewayIPCIDR := &net.IPNet{IP: gatewayIP, Mask: gatewaySubnetMask}
-	gatewayNextHopCIDR := &net.IPNet{IP: gatewayNextHop, Mask: gatewaySubnetMask}
 
-	// Flush any addresses on localnetBridgeNextHopPort and add the new IP address.
-	if err = util.LinkAddrFlush(link); err == nil {
-		err = util.LinkAddrAdd(link, gatewayNextHopCIDR)
-	}
-	if err != nil {
-		return err
+	var gatewayIPs []net.IP
+	var gatewayIPCIDRs []*net.IPNet
+	var gatewayNextHops []net.IP
+	for _, subnet := range subnets {
+		var gatewayIP, gatewayNextHop net.IP
+		var gatewaySubnetMask net.IPMask
+		if utilnet.IsIPv6CIDR(subnet) {
+			gatewayIP = net.ParseIP(v6localnetGatewayIP)
+			gatewayNextHop = net.ParseIP(v6localnetGatewayNextHop)
+			gatewaySubnetMask = net.CIDRMask(v6localnetGatewaySubnetPrefix, 128)
+		} else {
+			gatewayIP = net.ParseIP(v4localnetGatewayIP)
+			gatewayNextHop = net.ParseIP(v4localnetGatewayNextHop)
+			gatewaySubnetMask = net.CIDRMask(v4localnetGatewaySubnetPrefix, 32)
+		}
+		gatewayNextHopCIDR := &net.IPNet{IP: gatewayNextHop, Mask: gatewaySubnetMask}
+		if err = util.LinkAddrAdd(link, gatewayNextHopCIDR); err != nil {
+			return err
+		}
+
+		gatewayIPs = append(gatewayIPs, gatewayIP)
+		gatewayIPCIDRs = append(gatewayIPCIDRs, &net.IPNet{IP: gatewayIP, Mask: gatewaySubnetMask})
+		gatewayNextHops = append(gatewayNextHops, gatewayNextHop)
 	}
 
 	chassisID, err := util.GetNodeChassisID()
@@ -188,40 +196,45 @@ func initLocalnetGateway(nodeName string, subnet *net.IPNet, wf *factory.WatchFa
 		ChassisID:      chassisID,
 		InterfaceID:    ifaceID,
 		MACAddress:     macAddress,
-		IPAddresses:    []*net.IPNet{gatewayIPCIDR},
-		NextHops:       []net.IP{gatewayNextHop},
+		IPAddresses:    gatewayIPCIDRs,
+		NextHops:       gatewayNextHops,
 		NodePortEnable: config.Gateway.NodeportEnable,
 	})
 	if err != nil {
 		return err
 	}
 
-	if utilnet.IsIPv6CIDR(subnet) {
-		// TODO - IPv6 hack ... for some reason neighbor discovery isn't working here, so hard code a
-		// MAC binding for the gateway IP address for now - need to debug this further
-		err = util.LinkNeighAdd(link, gatewayIP, macAddress)
-		if err == nil {
-			klog.Infof("Added MAC binding for %s on %s", gatewayIP, localnetGatewayNextHopPort)
-		} else {
-			klog.Errorf("Error in adding MAC binding for %s on %s: %v", gatewayIP, localnetGatewayNextHopPort, err)
+	for i, subnet := range subnets {
+		gatewayIP := gatewayIPs[i]
+		if utilnet.IsIPv6CIDR(subnet) {
+			// TODO - IPv6 hack ... for some reason neighbor discovery isn't working here, so hard code a
+			// MAC binding for the gateway IP address for now - need to debug this further
+			err = util.LinkNeighAdd(link, gatewayIP, macAddress)
+			if err == nil {
+				klog.Infof("Added MAC binding for %s on %s", gatewayIP, localnetGatewayNextHopPort)
+			} else {
+				klog.Errorf("Error in adding MAC binding for %s on %s: %v", gatewayIP, localnetGatewayNextHopPort, err)
+			}
 		}
-	}
 
-	ipt, err := localnetIPTablesHelper(subnet)
-	if err != nil {
-		return err
-	}
+		ipt, err := localnetIPTablesHelper(subnet)
+		if err != nil {
+			return err
+		}
 
-	err = localnetGatewayNAT(ipt, localnetGatewayNextHopPort, gatewayIP)
-	if err != nil {
-		return fmt.Errorf("Failed to add NAT rules for localnet gateway (%v)", err)
-	}
+		err = localnetGatewayNAT(ipt, localnetGatewayNextHopPort, gatewayIP)
+		if err != nil {
+			return fmt.Errorf("Failed to add NAT rules for localnet gateway (%v)", err)
+		}
 
-	if config.Gateway.NodeportEnable {
-		err = localnetNodePortWatcher(ipt, wf, gatewayIP)
+		if config.Gateway.NodeportEnable {
+			if err = localnetNodePortWatcher(ipt, wf, gatewayIP); err != nil {
+				return err
+			}
+		}
 	}
 
-	return err
+	return nil
 }
 
 // localnetIPTablesHelper gets an IPTablesHelper for IPv4 or IPv6 as appropriate
@@ -239,6 +252,9 @@ func localnetIPTablesHelper(subnet *net.IPNet) (util.IPTablesHelper, error) {
 	return ipt, nil
 }
 
+// localnetIptRules returns the rules DNATing the NodePorts of a service to
+// gatewayIP. The rules go in the iptables of the IP family of gatewayIP, so
+// the NodePorts are served on every IP family of the gateway.
 func localnetIptRules(svc *kapi.Service, gatewayIP string) []iptRule {
 	rules := make([]iptRule, 0)
 	for _, svcPort := range svc.Spec.Ports {
@@ -363,10 +379,32 @@ func cleanupLocalnetGateway() error {
 	if err != nil {
 		return fmt.Errorf("Failed to get ovn-bridge-mappings stderr:%s (%v)", stderr, err)
 	}
-	bridgeName := strings.Split(stdout, ":")[1]
-	_, stderr, err = util.RunOVSVsctl("--", "--if-exists", "del-br", bridgeName)
-	if err != nil {
-		return fmt.Errorf("Failed to ovs-vsctl del-br %s stderr:%s (%v)", bridgeName, stderr, err)
+	// skip the bridges of the provider networks
+	for _, bridgeMapping := range strings.Split(stdout, ",") {
+		m := strings.Split(bridgeMapping, ":")
+		if len(m) != 2 || m[0] != util.PhysicalNetworkName {
+			continue
+		}
+		bridgeName := m[1]
+		_, stderr, err = util.RunOVSVsctl("--", "--if-exists", "del-br", bridgeName)
+		if err != nil {
+			return fmt.Errorf("Failed to ovs-vsctl del-br %s stderr:%s (%v)", bridgeName, stderr, err)
+		}
 	}
-	return err
+
+	// Remove the NAT rules of the localnet gateway port
+	for proto, gatewayIP := range map[iptables.Protocol]string{
+		iptables.ProtocolIPv4: v4localnetGatewayIP,
+		iptables.ProtocolIPv6: v6localnetGatewayIP,
+	} {
+		ipt, err := util.GetIPTablesHelper(proto)
+		if err != nil {
+			continue
+		}
+		for _, r := range generateGatewayNATRules(localnetGatewayNextHopPort, net.ParseIP(gatewayIP)) {
+			_ = ipt.Delete(r.table, r.chain, r.args...)
+		}
+	}
+	deleteNodePortIptableChain()
+	return nil
 }
@@ -0,0 +1,55 @@
+// +build linux
+
+package node
+
+import (
+	"github.com/coreos/go-iptables/iptables"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Localnet gateway NodePort rules", func() {
+	It("DNATs NodePorts to the gateway IP of each IP family of the gateway", func() {
+		service := newNodePortService("svc-dual", "172.16.1.1", 30000)
+		service.Spec.ClusterIPs = []string{"172.16.1.1", "fd00:10:96::1"}
+
+		fakeIPTables := make(map[iptables.Protocol]*util.FakeIPTables)
+		for proto, gatewayIP := range map[iptables.Protocol]string{
+			iptables.ProtocolIPv4: v4localnetGatewayIP,
+			iptables.ProtocolIPv6: v6localnetGatewayIP,
+		} {
+			ipt, err := util.NewFakeWithProtocol(proto)
+			Expect(err).NotTo(HaveOccurred())
+			fakeIPTables[proto] = ipt
+			npw := &localnetNodePortWatcherData{ipt: ipt, gatewayIP: gatewayIP}
+			Expect(npw.addService(service)).To(Succeed())
+		}
+
+		Expect(fakeIPTables[iptables.ProtocolIPv4].MatchState(map[string]util.FakeTable{
+			"nat": {
+				iptableNodePortChain: []string{
+					"-p TCP --dport 30000 -j DNAT --to-destination 169.254.33.2:30000",
+				},
+			},
+			"filter": {
+				iptableNodePortChain: []string{
+					"-p TCP --dport 30000 -j ACCEPT",
+				},
+			},
+		})).To(Succeed())
+		Expect(fakeIPTables[iptables.ProtocolIPv6].MatchState(map[string]util.FakeTable{
+			"nat": {
+				iptableNodePortChain: []string{
+					"-p TCP --dport 30000 -j DNAT --to-destination [fd99::2]:30000",
+				},
+			},
+			"filter": {
+				iptableNodePortChain: []string{
+					"-p TCP --dport 30000 -j ACCEPT",
+				},
+			},
+		})).To(Succeed())
+	})
+})
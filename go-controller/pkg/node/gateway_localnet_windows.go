@@ -10,7 +10,7 @@ import (
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
 )
 
-func initLocalnetGateway(nodeName string, subnet *net.IPNet,
+func initLocalnetGateway(nodeName string, subnets []*net.IPNet,
 	wf *factory.WatchFactory, nodeAnnotator kube.Annotator) error {
 	// TODO: Implement this
 	return fmt.Errorf("Not implemented yet on Windows")
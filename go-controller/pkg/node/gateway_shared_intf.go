@@ -4,17 +4,19 @@ import (
 	"fmt"
 	"net"
 	"reflect"
-	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	kapi "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog"
+	utilnet "k8s.io/utils/net"
 )
 
 const (
@@ -23,61 +25,111 @@ const (
 	defaultOpenFlowCookie = "0xdeff105"
 )
 
-func addService(service *kapi.Service, inport, outport, gwBridge string, nodeIP *net.IPNet) {
-	if !util.ServiceTypeHasNodePort(service) {
-		return
-	}
+// getServiceFlows returns the flows steering the NodePort traffic of a
+// service arriving on inport to outport, for each IP family of the gateway's
+// node IPs.
+func getServiceFlows(service *kapi.Service, inport, outport string, nodeIPs []*net.IPNet) []string {
+	var flows []string
+	for _, isIPv6 := range gatewayIPFamilies(nodeIPs) {
+		for _, svcPort := range service.Spec.Ports {
+			if svcPort.NodePort == 0 {
+				continue
+			}
+			_, err := util.ValidateProtocol(svcPort.Protocol)
+			if err != nil {
+				klog.Errorf("Skipping service flows. Invalid service port %s: %v", svcPort.Name, err)
+				continue
+			}
+			protocol := strings.ToLower(string(svcPort.Protocol))
+			if isIPv6 {
+				protocol += "6"
+			}
 
-	for _, svcPort := range service.Spec.Ports {
-		_, err := util.ValidateProtocol(svcPort.Protocol)
-		if err != nil {
-			klog.Errorf("Skipping service add. Invalid service port %s: %v", svcPort.Name, err)
-			continue
+			flows = append(flows,
+				fmt.Sprintf("priority=100, in_port=%s, %s, tp_dst=%d, actions=%s",
+					inport, protocol, svcPort.NodePort, outport))
 		}
-		protocol := strings.ToLower(string(svcPort.Protocol))
+	}
+	return flows
+}
 
-		_, stderr, err := util.RunOVSOfctl("add-flow", gwBridge,
-			fmt.Sprintf("priority=100, in_port=%s, %s, tp_dst=%d, actions=%s",
-				inport, protocol, svcPort.NodePort, outport))
-		if err != nil {
-			klog.Errorf("Failed to add openflow flow on %s for nodePort "+
-				"%d, stderr: %q, error: %v", gwBridge,
-				svcPort.NodePort, stderr, err)
+// gatewayIPFamilies returns the IP families of the gateway's node IPs, IPv4
+// first, as whether each family is IPv6.
+func gatewayIPFamilies(nodeIPs []*net.IPNet) []bool {
+	var hasIPv4, hasIPv6 bool
+	for _, nodeIP := range nodeIPs {
+		if utilnet.IsIPv6CIDR(nodeIP) {
+			hasIPv6 = true
+		} else {
+			hasIPv4 = true
 		}
 	}
+	var families []bool
+	if hasIPv4 {
+		families = append(families, false)
+	}
+	if hasIPv6 {
+		families = append(families, true)
+	}
+	return families
+}
 
-	addSharedGatewayIptRules(service, nodeIP)
+func serviceKey(service *kapi.Service) string {
+	return service.Namespace + "/" + service.Name
 }
 
-func deleteService(service *kapi.Service, inport, gwBridge string, nodeIP *net.IPNet) {
+// updateServiceFlows sets the flows of a service and programs the bridge if
+// they changed.
+func updateServiceFlows(fm *flowManager, key string, flows []string) {
+	if !fm.updateServiceFlows(key, flows) {
+		return
+	}
+	if err := fm.syncFlows(); err != nil {
+		klog.Errorf("Failed to program flows of service %s: %v", key, err)
+	}
+}
+
+func addService(service *kapi.Service, inport, outport string, nodeIPs []*net.IPNet, fm *flowManager) {
 	if !util.ServiceTypeHasNodePort(service) {
 		return
 	}
 
-	for _, svcPort := range service.Spec.Ports {
-		_, err := util.ValidateProtocol(svcPort.Protocol)
-		if err != nil {
-			klog.Errorf("Skipping service delete. Invalid service port %s: %v", svcPort.Name, err)
-			continue
-		}
+	updateServiceFlows(fm, serviceKey(service), getServiceFlows(service, inport, outport, nodeIPs))
+	addSharedGatewayIptRules(service, nodeIPs)
+}
 
-		protocol := strings.ToLower(string(svcPort.Protocol))
+func updateService(svcOld, svcNew *kapi.Service, inport, outport string, nodeIPs []*net.IPNet, fm *flowManager) {
+	if !util.ServiceTypeHasNodePort(svcOld) && !util.ServiceTypeHasNodePort(svcNew) {
+		return
+	}
 
-		_, stderr, err := util.RunOVSOfctl("del-flows", gwBridge,
-			fmt.Sprintf("in_port=%s, %s, tp_dst=%d",
-				inport, protocol, svcPort.NodePort))
-		if err != nil {
-			klog.Errorf("Failed to delete openflow flow on %s for nodePort "+
-				"%d, stderr: %q, error: %v", gwBridge,
-				svcPort.NodePort, stderr, err)
-		}
+	if util.ServiceTypeHasNodePort(svcOld) {
+		delSharedGatewayIptRules(svcOld, nodeIPs)
+	}
+	var flows []string
+	if util.ServiceTypeHasNodePort(svcNew) {
+		flows = getServiceFlows(svcNew, inport, outport, nodeIPs)
+	}
+	updateServiceFlows(fm, serviceKey(svcNew), flows)
+	if util.ServiceTypeHasNodePort(svcNew) {
+		addSharedGatewayIptRules(svcNew, nodeIPs)
+	}
+}
+
+func deleteService(service *kapi.Service, nodeIPs []*net.IPNet, fm *flowManager) {
+	if !util.ServiceTypeHasNodePort(service) {
+		return
 	}
 
-	delSharedGatewayIptRules(service, nodeIP)
+	updateServiceFlows(fm, serviceKey(service), nil)
+	delSharedGatewayIptRules(service, nodeIPs)
 }
 
-func syncServices(services []interface{}, inport, gwBridge string) {
-	nodePorts := make(map[string]bool)
+// syncServices replaces the flows of all services with those of the existing
+// NodePort services, removing the flows of services deleted while we were
+// not watching.
+func syncServices(services []interface{}, inport, outport string, nodeIPs []*net.IPNet, fm *flowManager) {
+	serviceFlows := make(map[string][]string)
 	for _, serviceInterface := range services {
 		service, ok := serviceInterface.(*kapi.Service)
 		if !ok {
@@ -86,76 +138,31 @@ func syncServices(services []interface{}, inport, gwBridge string) {
 			continue
 		}
 
-		if !util.ServiceTypeHasNodePort(service) ||
-			len(service.Spec.Ports) == 0 {
+		if !util.ServiceTypeHasNodePort(service) {
 			continue
 		}
-
-		for _, svcPort := range service.Spec.Ports {
-			port := svcPort.NodePort
-			if port == 0 {
-				continue
-			}
-
-			proto, err := util.ValidateProtocol(svcPort.Protocol)
-			if err != nil {
-				klog.Errorf("syncServices error for service port %s: %v", svcPort.Name, err)
-				continue
-			}
-			protocol := strings.ToLower(string(proto))
-			nodePortKey := fmt.Sprintf("%s_%d", protocol, port)
-			nodePorts[nodePortKey] = true
+		if flows := getServiceFlows(service, inport, outport, nodeIPs); len(flows) > 0 {
+			serviceFlows[serviceKey(service)] = flows
 		}
 	}
 
-	stdout, stderr, err := util.RunOVSOfctl("dump-flows",
-		gwBridge)
-	if err != nil {
-		klog.Errorf("dump-flows failed: %q (%v)", stderr, err)
-		return
+	fm.setServiceFlows(serviceFlows)
+	if err := fm.syncFlows(); err != nil {
+		klog.Errorf("Failed to sync service flows: %v", err)
 	}
-	flows := strings.Split(stdout, "\n")
-
-	re, err := regexp.Compile(`tp_dst=(.*?)[, ]`)
-	if err != nil {
-		klog.Errorf("regexp compile failed: %v", err)
-		return
-	}
-
-	for _, flow := range flows {
-		group := re.FindStringSubmatch(flow)
-		if group == nil {
-			continue
-		}
-
-		var key string
-		if strings.Contains(flow, "tcp") {
-			key = fmt.Sprintf("tcp_%s", group[1])
-		} else if strings.Contains(flow, "udp") {
-			key = fmt.Sprintf("udp_%s", group[1])
-		} else if strings.Contains(flow, "sctp") {
-			key = fmt.Sprintf("sctp_%s", group[1])
-		} else {
-			continue
-		}
-
-		if _, ok := nodePorts[key]; !ok {
-			pair := strings.Split(key, "_")
-			protocol, port := pair[0], pair[1]
+}
 
-			stdout, _, err := util.RunOVSOfctl(
-				"del-flows", gwBridge,
-				fmt.Sprintf("in_port=%s, %s, tp_dst=%s",
-					inport, protocol, port))
-			if err != nil {
-				klog.Errorf("del-flows of %s failed: %q",
-					gwBridge, stdout)
-			}
-		}
-	}
+// gatewayAddresses are the addresses of the shared gateway interface and the
+// next hops of the gateway, which change when the node's IP or default
+// gateway changes.
+type gatewayAddresses struct {
+	sync.Mutex
+	ipAddresses []*net.IPNet
+	nextHops    []net.IP
 }
 
-func nodePortWatcher(nodeName, gwBridge, gwIntf string, nodeIP []*net.IPNet, wf *factory.WatchFactory) error {
+func nodePortWatcher(nodeName, gwBridge, gwIntf string, ga *gatewayAddresses, fm *flowManager,
+	wf *factory.WatchFactory) error {
 	// the name of the patch port created by ovn-controller is of the form
 	// patch-<logical_port_name_of_localnet_port>-to-br-int
 	patchPort := "patch-" + gwBridge + "_" + nodeName + "-to-br-int"
@@ -188,7 +195,9 @@ func nodePortWatcher(nodeName, gwBridge, gwIntf string, nodeIP []*net.IPNet, wf
 	_, err = wf.AddServiceHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			service := obj.(*kapi.Service)
-			addService(service, ofportPhys, ofportPatch, gwBridge, nodeIP[0])
+			ga.Lock()
+			defer ga.Unlock()
+			addService(service, ofportPhys, ofportPatch, ga.ipAddresses, fm)
 		},
 		UpdateFunc: func(old, new interface{}) {
 			svcNew := new.(*kapi.Service)
@@ -196,25 +205,73 @@ func nodePortWatcher(nodeName, gwBridge, gwIntf string, nodeIP []*net.IPNet, wf
 			if reflect.DeepEqual(svcNew.Spec, svcOld.Spec) {
 				return
 			}
-			deleteService(svcOld, ofportPhys, gwBridge, nodeIP[0])
-			addService(svcNew, ofportPhys, ofportPatch, gwBridge, nodeIP[0])
+			ga.Lock()
+			defer ga.Unlock()
+			updateService(svcOld, svcNew, ofportPhys, ofportPatch, ga.ipAddresses, fm)
 		},
 		DeleteFunc: func(obj interface{}) {
 			service := obj.(*kapi.Service)
-			deleteService(service, ofportPhys, gwBridge, nodeIP[0])
+			ga.Lock()
+			defer ga.Unlock()
+			deleteService(service, ga.ipAddresses, fm)
 		},
 	}, func(services []interface{}) {
-		syncServices(services, ofportPhys, gwBridge)
+		ga.Lock()
+		defer ga.Unlock()
+		syncServices(services, ofportPhys, ofportPatch, ga.ipAddresses, fm)
 	})
 
 	return err
 }
 
+// getDefaultFlows returns the default flows of the shared gateway bridge for
+// each IP family used by the cluster.
+func getDefaultFlows(ofportPatch, ofportPhys string) []string {
+	var flows []string
+	var protocols []string
+	if config.IPv4Mode {
+		protocols = append(protocols, "ip")
+	}
+	if config.IPv6Mode {
+		protocols = append(protocols, "ipv6")
+		// table 0, IPv6 neighbor solicitations and advertisements from external
+		// are for the addresses the host and the L3 gateway share, so deliver
+		// them to both without going through conntrack.
+		for _, icmpType := range []int{135, 136} {
+			flows = append(flows,
+				fmt.Sprintf("cookie=%s, priority=110, in_port=%s, icmp6, icmp_type=%d, "+
+					"actions=output:%s, LOCAL", defaultOpenFlowCookie, ofportPhys, icmpType, ofportPatch))
+		}
+	}
+	for _, protocol := range protocols {
+		flows = append(flows,
+			// table 0, packets coming from pods headed externally. Commit connections
+			// so that reverse direction goes back to the pods.
+			fmt.Sprintf("cookie=%s, priority=100, in_port=%s, %s, "+
+				"actions=ct(commit, zone=%d), output:%s",
+				defaultOpenFlowCookie, ofportPatch, protocol, config.Default.ConntrackZone, ofportPhys),
+			// table 0, packets coming from external. Send it through conntrack and
+			// resubmit to table 1 to know the state of the connection.
+			fmt.Sprintf("cookie=%s, priority=50, in_port=%s, %s, "+
+				"actions=ct(zone=%d, table=1)", defaultOpenFlowCookie, ofportPhys, protocol, config.Default.ConntrackZone))
+	}
+	flows = append(flows,
+		// table 1, established and related connections go to pod
+		fmt.Sprintf("cookie=%s, priority=100, table=1, ct_state=+trk+est, "+
+			"actions=output:%s", defaultOpenFlowCookie, ofportPatch),
+		fmt.Sprintf("cookie=%s, priority=100, table=1, ct_state=+trk+rel, "+
+			"actions=output:%s", defaultOpenFlowCookie, ofportPatch),
+		// table 1, all other connections do normal processing
+		fmt.Sprintf("cookie=%s, priority=0, table=1, actions=output:NORMAL", defaultOpenFlowCookie))
+	return flows
+}
+
 // since we share the host's k8s node IP, add OpenFlow flows
 // -- to steer the NodePort traffic arriving on the host to the OVN logical topology and
 // -- to also connection track the outbound north-south traffic through l3 gateway so that
 //    the return traffic can be steered back to OVN logical topology
-func addDefaultConntrackRules(nodeName, gwBridge, gwIntf string, stopChan chan struct{}) error {
+// The flows are programmed, and kept in sync, by the returned flow manager.
+func addDefaultConntrackRules(nodeName, gwBridge, gwIntf string, stopChan chan struct{}) (*flowManager, error) {
 	// the name of the patch port created by ovn-controller is of the form
 	// patch-<logical_port_name_of_localnet_port>-to-br-int
 	localnetLpName := gwBridge + "_" + nodeName
@@ -224,7 +281,7 @@ func addDefaultConntrackRules(nodeName, gwBridge, gwIntf string, stopChan chan s
 	ofportPatch, stderr, err := util.RunOVSVsctl("wait-until", "Interface", patchPort, "ofport>0",
 		"--", "get", "Interface", patchPort, "ofport")
 	if err != nil {
-		return fmt.Errorf("Failed while waiting on patch port %q to be created by ovn-controller and "+
+		return nil, fmt.Errorf("Failed while waiting on patch port %q to be created by ovn-controller and "+
 			"while getting ofport. stderr: %q, error: %v", patchPort, stderr, err)
 	}
 
@@ -232,74 +289,26 @@ func addDefaultConntrackRules(nodeName, gwBridge, gwIntf string, stopChan chan s
 	ofportPhys, stderr, err := util.RunOVSVsctl("--if-exists", "get",
 		"interface", gwIntf, "ofport")
 	if err != nil {
-		return fmt.Errorf("Failed to get ofport of %s, stderr: %q, error: %v",
+		return nil, fmt.Errorf("Failed to get ofport of %s, stderr: %q, error: %v",
 			gwIntf, stderr, err)
 	}
 
-	// replace the left over OpenFlow flows with the NORMAL action flow
-	_, stderr, err = util.AddNormalActionOFFlow(gwBridge)
-	if err != nil {
-		return fmt.Errorf("failed to replace-flows on bridge %q stderr:%s (%v)", gwBridge, stderr, err)
-	}
-
-	nFlows := 0
-	// table 0, packets coming from pods headed externally. Commit connections
-	// so that reverse direction goes back to the pods.
-	_, stderr, err = util.RunOVSOfctl("add-flow", gwBridge,
-		fmt.Sprintf("cookie=%s, priority=100, in_port=%s, ip, "+
-			"actions=ct(commit, zone=%d), output:%s",
-			defaultOpenFlowCookie, ofportPatch, config.Default.ConntrackZone, ofportPhys))
-	if err != nil {
-		return fmt.Errorf("Failed to add openflow flow to %s, stderr: %q, "+
-			"error: %v", gwBridge, stderr, err)
-	}
-	nFlows++
-
-	// table 0, packets coming from external. Send it through conntrack and
-	// resubmit to table 1 to know the state of the connection.
-	_, stderr, err = util.RunOVSOfctl("add-flow", gwBridge,
-		fmt.Sprintf("cookie=%s, priority=50, in_port=%s, ip, "+
-			"actions=ct(zone=%d, table=1)", defaultOpenFlowCookie, ofportPhys, config.Default.ConntrackZone))
-	if err != nil {
-		return fmt.Errorf("Failed to add openflow flow to %s, stderr: %q, "+
-			"error: %v", gwBridge, stderr, err)
-	}
-	nFlows++
+	fm := newFlowManager(gwBridge)
+	fm.setDefaultFlows(getDefaultFlows(ofportPatch, ofportPhys))
+	metrics.RegisterDebugState("node/gateway-flows", fm.debugState)
 
-	// table 1, established and related connections go to pod
-	_, stderr, err = util.RunOVSOfctl("add-flow", gwBridge,
-		fmt.Sprintf("cookie=%s, priority=100, table=1, ct_state=+trk+est, "+
-			"actions=output:%s", defaultOpenFlowCookie, ofportPatch))
-	if err != nil {
-		return fmt.Errorf("Failed to add openflow flow to %s, stderr: %q, "+
-			"error: %v", gwBridge, stderr, err)
-	}
-	nFlows++
-
-	_, stderr, err = util.RunOVSOfctl("add-flow", gwBridge,
-		fmt.Sprintf("cookie=%s, priority=100, table=1, ct_state=+trk+rel, "+
-			"actions=output:%s", defaultOpenFlowCookie, ofportPatch))
-	if err != nil {
-		return fmt.Errorf("Failed to add openflow flow to %s, stderr: %q, "+
-			"error: %v", gwBridge, stderr, err)
-	}
-	nFlows++
-
-	// table 1, all other connections do normal processing
-	_, stderr, err = util.RunOVSOfctl("add-flow", gwBridge,
-		fmt.Sprintf("cookie=%s, priority=0, table=1, actions=output:NORMAL", defaultOpenFlowCookie))
-	if err != nil {
-		return fmt.Errorf("Failed to add openflow flow to %s, stderr: %q, "+
-			"error: %v", gwBridge, stderr, err)
+	// replace the left over OpenFlow flows with the default flows
+	if err := fm.syncFlows(); err != nil {
+		return nil, err
 	}
-	nFlows++
+	go fm.run(stopChan)
 
-	// add health check function to check default OpenFlow flows are on the shared gateway bridge
-	go checkDefaultConntrackRules(gwBridge, gwIntf, patchPort, ofportPhys, ofportPatch, nFlows, stopChan)
-	return nil
+	// add health check function to check the ports the default OpenFlow flows use are unchanged
+	go checkDefaultConntrackRules(gwIntf, patchPort, ofportPhys, ofportPatch, stopChan)
+	return fm, nil
 }
 
-func (n *OvnNode) initSharedGateway(subnet *net.IPNet, gwNextHop net.IP, gwIntf string,
+func (n *OvnNode) initSharedGateway(subnets []*net.IPNet, gwNextHops []net.IP, gwIntf string,
 	nodeAnnotator kube.Annotator) (postWaitFunc, error) {
 	var bridgeName string
 	var uplinkName string
@@ -332,15 +341,16 @@ func (n *OvnNode) initSharedGateway(subnet *net.IPNet, gwNextHop net.IP, gwIntf
 		bridgeName = gwIntf
 	}
 
-	// Now, we get IP address from OVS bridge. If IP does not exist,
+	// Now, we get IP addresses from OVS bridge. If IP does not exist,
 	// error out.
-	ipAddress, err := getIPv4Address(gwIntf)
+	ipAddresses, err := getNetworkInterfaceIPAddresses(gwIntf)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to get interface details for %s (%v)",
 			gwIntf, err)
 	}
-	if ipAddress == nil {
-		return nil, fmt.Errorf("%s does not have a ipv4 address", gwIntf)
+	if len(gwNextHops) != len(ipAddresses) {
+		return nil, fmt.Errorf("gateway next hops %s do not match the addresses %s of %s",
+			util.JoinIPs(gwNextHops, ","), util.JoinIPNets(ipAddresses, ","), gwIntf)
 	}
 
 	ifaceID, macAddress, err := bridgedGatewayNodeSetup(n.name, bridgeName, gwIntf, brCreated)
@@ -358,8 +368,8 @@ func (n *OvnNode) initSharedGateway(subnet *net.IPNet, gwNextHop net.IP, gwIntf
 		ChassisID:      chassisID,
 		InterfaceID:    ifaceID,
 		MACAddress:     macAddress,
-		IPAddresses:    []*net.IPNet{ipAddress},
-		NextHops:       []net.IP{gwNextHop},
+		IPAddresses:    ipAddresses,
+		NextHops:       gwNextHops,
 		NodePortEnable: config.Gateway.NodeportEnable,
 		VLANID:         &config.Gateway.VLANID,
 	})
@@ -370,21 +380,104 @@ func (n *OvnNode) initSharedGateway(subnet *net.IPNet, gwNextHop net.IP, gwIntf
 	return func() error {
 		// Program cluster.GatewayIntf to let non-pod traffic to go to host
 		// stack
-		if err := addDefaultConntrackRules(n.name, bridgeName, uplinkName, n.stopChan); err != nil {
+		fm, err := addDefaultConntrackRules(n.name, bridgeName, uplinkName, n.stopChan)
+		if err != nil {
 			return err
 		}
 
+		ga := &gatewayAddresses{ipAddresses: ipAddresses, nextHops: gwNextHops}
 		if config.Gateway.NodeportEnable {
 			// Program cluster.GatewayIntf to let nodePort traffic to go to pods.
-			if err := nodePortWatcher(n.name, bridgeName, uplinkName, []*net.IPNet{ipAddress},
-				n.watchFactory); err != nil {
+			if err := nodePortWatcher(n.name, bridgeName, uplinkName, ga,
+				fm, n.watchFactory); err != nil {
 				return err
 			}
 		}
+
+		err = watchGatewayAddresses(gwIntf, n.stopChan, func() {
+			if err := n.updateGatewayAddresses(gwIntf, ga); err != nil {
+				klog.Errorf("Failed to update the gateway addresses of node %s: %v", n.name, err)
+			}
+		})
+		if err != nil {
+			klog.Warningf("Failed to watch the addresses of gateway interface %s, "+
+				"ovnkube-node must be restarted when they change: %v", gwIntf, err)
+		}
 		return nil
 	}, nil
 }
 
+// updateGatewayAddresses re-reads the addresses of the gateway interface and,
+// unless they are configured, the gateway next hops. If they changed, the
+// node's L3 gateway annotation is updated so that the master reconfigures the
+// gateway router, and the NodePort iptables rules are moved to the new
+// addresses.
+func (n *OvnNode) updateGatewayAddresses(gwIntf string, ga *gatewayAddresses) error {
+	ipAddresses, err := getNetworkInterfaceIPAddresses(gwIntf)
+	if err != nil {
+		return fmt.Errorf("failed to get interface details for %s: %v", gwIntf, err)
+	}
+	nextHops, err := getGatewayNextHops()
+	if err != nil {
+		return err
+	}
+	if len(nextHops) == 0 {
+		_, nextHops, err = getDefaultGatewayInterfaceDetails()
+		if err != nil {
+			return err
+		}
+	}
+	if len(nextHops) != len(ipAddresses) {
+		return fmt.Errorf("gateway next hops %s do not match the addresses %s of %s",
+			util.JoinIPs(nextHops, ","), util.JoinIPNets(ipAddresses, ","), gwIntf)
+	}
+
+	ga.Lock()
+	defer ga.Unlock()
+	if util.JoinIPNets(ipAddresses, ",") == util.JoinIPNets(ga.ipAddresses, ",") &&
+		util.JoinIPs(nextHops, ",") == util.JoinIPs(ga.nextHops, ",") {
+		return nil
+	}
+	klog.Infof("Gateway addresses of node %s changed from %s via %s to %s via %s", n.name,
+		util.JoinIPNets(ga.ipAddresses, ","), util.JoinIPs(ga.nextHops, ","),
+		util.JoinIPNets(ipAddresses, ","), util.JoinIPs(nextHops, ","))
+
+	node, err := n.Kube.GetNode(n.name)
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %v", n.name, err)
+	}
+	l3GatewayConfig, err := util.ParseNodeL3GatewayAnnotation(node)
+	if err != nil {
+		return err
+	}
+	l3GatewayConfig.IPAddresses = ipAddresses
+	l3GatewayConfig.NextHops = nextHops
+	nodeAnnotator := kube.NewNodeAnnotator(n.Kube, node)
+	if err := util.SetL3GatewayConfig(nodeAnnotator, l3GatewayConfig); err != nil {
+		return err
+	}
+	if err := nodeAnnotator.Run(); err != nil {
+		return fmt.Errorf("failed to set the L3 gateway annotation of node %s: %v", n.name, err)
+	}
+
+	if config.Gateway.NodeportEnable {
+		services, err := n.watchFactory.GetServices()
+		if err != nil {
+			return fmt.Errorf("failed to get k8s services: %v", err)
+		}
+		for _, service := range services {
+			if !util.ServiceTypeHasNodePort(service) {
+				continue
+			}
+			delSharedGatewayIptRules(service, ga.ipAddresses)
+			addSharedGatewayIptRules(service, ipAddresses)
+		}
+	}
+	ga.ipAddresses = ipAddresses
+	ga.nextHops = nextHops
+	return nil
+}
+
 func cleanupSharedGateway() error {
 	// NicToBridge() may be created before-hand, only delete the patch port here
 	stdout, stderr, err := util.RunOVSVsctl("--columns=name", "--no-heading", "find", "port",
@@ -12,38 +12,43 @@ import (
 	utilnet "k8s.io/utils/net"
 )
 
-func createNodePortIptableChain() error {
-	for _, proto := range []iptables.Protocol{iptables.ProtocolIPv4, iptables.ProtocolIPv6} {
-		ipt, err := util.GetIPTablesHelper(proto)
-		if err != nil {
-			return err
-		}
-		// delete all the existing OVN-KUBE-NODEPORT rules
-		_ = ipt.ClearChain("nat", iptableNodePortChain)
-		_ = ipt.ClearChain("filter", iptableNodePortChain)
-
-		rules := make([]iptRule, 0)
-		rules = append(rules, iptRule{
+// getNodePortIptableJumpRules returns the rules jumping to the NodePort chain.
+func getNodePortIptableJumpRules() []iptRule {
+	return []iptRule{
+		{
 			table: "nat",
 			chain: "OUTPUT",
 			args:  []string{"-j", iptableNodePortChain},
-		})
-		rules = append(rules, iptRule{
+		},
+		{
 			table: "nat",
 			chain: "PREROUTING",
 			args:  []string{"-j", iptableNodePortChain},
-		})
-		rules = append(rules, iptRule{
+		},
+		{
 			table: "filter",
 			chain: "OUTPUT",
 			args:  []string{"-j", iptableNodePortChain},
-		})
-		rules = append(rules, iptRule{
+		},
+		{
 			table: "filter",
 			chain: "FORWARD",
 			args:  []string{"-j", iptableNodePortChain},
-		})
+		},
+	}
+}
+
+func createNodePortIptableChain() error {
+	for _, proto := range []iptables.Protocol{iptables.ProtocolIPv4, iptables.ProtocolIPv6} {
+		ipt, err := util.GetIPTablesHelper(proto)
+		if err != nil {
+			return err
+		}
+		// delete all the existing OVN-KUBE-NODEPORT rules
+		_ = ipt.ClearChain("nat", iptableNodePortChain)
+		_ = ipt.ClearChain("filter", iptableNodePortChain)
 
+		rules := getNodePortIptableJumpRules()
 		if err := addIptRules(ipt, rules); err != nil {
 			return fmt.Errorf("failed to add iptable rules %v: %v", rules, err)
 		}
@@ -57,6 +62,10 @@ func deleteNodePortIptableChain() {
 		if err != nil {
 			return
 		}
+		// delete the jumps to the OVN-NODEPORT chain, which may not all exist
+		for _, r := range getNodePortIptableJumpRules() {
+			_ = ipt.Delete(r.table, r.chain, r.args...)
+		}
 		// delete all the existing OVN-NODEPORT rules
 		_ = ipt.ClearChain("nat", iptableNodePortChain)
 		_ = ipt.ClearChain("filter", iptableNodePortChain)
@@ -65,9 +74,22 @@ func deleteNodePortIptableChain() {
 	}
 }
 
+// getSharedGatewayIptRules returns the rules DNATing the NodePorts of a
+// service on nodeIP to the service's cluster IP of the IP family of nodeIP,
+// if the service has one.
 func getSharedGatewayIptRules(service *kapi.Service, nodeIP *net.IPNet) []iptRule {
 	rules := make([]iptRule, 0)
 
+	var clusterIP string
+	for _, ip := range util.GetClusterIPs(service) {
+		if utilnet.IsIPv6String(ip) == utilnet.IsIPv6CIDR(nodeIP) {
+			clusterIP = ip
+			break
+		}
+	}
+	if clusterIP == "" {
+		return rules
+	}
 	for _, svcPort := range service.Spec.Ports {
 		protocol, err := util.ValidateProtocol(svcPort.Protocol)
 		if err != nil {
@@ -82,7 +104,7 @@ func getSharedGatewayIptRules(service *kapi.Service, nodeIP *net.IPNet) []iptRul
 			chain: iptableNodePortChain,
 			args: []string{
 				"-p", string(protocol), "--dport", nodePort, "-d", nodeIP.IP.String(),
-				"-j", "DNAT", "--to-destination", net.JoinHostPort(service.Spec.ClusterIP, port),
+				"-j", "DNAT", "--to-destination", net.JoinHostPort(clusterIP, port),
 			},
 		})
 		rules = append(rules, iptRule{
@@ -97,31 +119,37 @@ func getSharedGatewayIptRules(service *kapi.Service, nodeIP *net.IPNet) []iptRul
 	return rules
 }
 
-func addSharedGatewayIptRules(service *kapi.Service, nodeIP *net.IPNet) {
+// getIPTablesHelperForIP returns the iptables helper of the IP family of ip.
+func getIPTablesHelperForIP(ip *net.IPNet) util.IPTablesHelper {
 	var ipt util.IPTablesHelper
-
-	rules := getSharedGatewayIptRules(service, nodeIP)
 	// we've already checked/created iptableHelper in initNodePortIptableChain, no need to check error here.
-	if utilnet.IsIPv6String(service.Spec.ClusterIP) {
+	if utilnet.IsIPv6CIDR(ip) {
 		ipt, _ = util.GetIPTablesHelper(iptables.ProtocolIPv6)
 	} else {
 		ipt, _ = util.GetIPTablesHelper(iptables.ProtocolIPv4)
 	}
-	if err := addIptRules(ipt, rules); err != nil {
-		klog.Errorf("Failed to set up iptables rules for nodePort service %s/%s: %v",
-			service.Namespace, service.Name, err)
-	}
+	return ipt
 }
 
-func delSharedGatewayIptRules(service *kapi.Service, nodeIP *net.IPNet) {
-	var ipt util.IPTablesHelper
+func addSharedGatewayIptRules(service *kapi.Service, nodeIPs []*net.IPNet) {
+	for _, nodeIP := range nodeIPs {
+		rules := getSharedGatewayIptRules(service, nodeIP)
+		if len(rules) == 0 {
+			continue
+		}
+		if err := addIptRules(getIPTablesHelperForIP(nodeIP), rules); err != nil {
+			klog.Errorf("Failed to set up iptables rules for nodePort service %s/%s: %v",
+				service.Namespace, service.Name, err)
+		}
+	}
+}
 
-	rules := getSharedGatewayIptRules(service, nodeIP)
-	// we've already checked/created iptableHelper in initNodePortIptableChain, no need to check error here.
-	if utilnet.IsIPv6String(service.Spec.ClusterIP) {
-		ipt, _ = util.GetIPTablesHelper(iptables.ProtocolIPv6)
-	} else {
-		ipt, _ = util.GetIPTablesHelper(iptables.ProtocolIPv4)
+func delSharedGatewayIptRules(service *kapi.Service, nodeIPs []*net.IPNet) {
+	for _, nodeIP := range nodeIPs {
+		rules := getSharedGatewayIptRules(service, nodeIP)
+		if len(rules) == 0 {
+			continue
+		}
+		delIptRules(getIPTablesHelperForIP(nodeIP), rules)
 	}
-	delIptRules(ipt, rules)
 }
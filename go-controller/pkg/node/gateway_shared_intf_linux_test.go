@@ -0,0 +1,118 @@
+// +build linux
+
+package node
+
+import (
+	"context"
+	"net"
+
+	"github.com/coreos/go-iptables/iptables"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shared gateway address changes", func() {
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+		config.IPv4Mode = true
+		config.Gateway.Mode = config.GatewayModeShared
+		config.Gateway.NodeportEnable = false
+		config.Gateway.NextHop = "127.0.0.254"
+	})
+
+	AfterEach(func() {
+		config.IPv4Mode = false
+	})
+
+	It("updates the L3 gateway annotation with the new gateway addresses", func() {
+		oldIP := ovntest.MustParseIPNet("10.0.0.5/24")
+		oldNextHop := net.ParseIP("10.0.0.1")
+
+		existingNode := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+		fakeClient := fake.NewSimpleClientset(&v1.NodeList{
+			Items: []v1.Node{existingNode},
+		})
+		n := &OvnNode{name: existingNode.Name, Kube: &kube.Kube{KClient: fakeClient}}
+
+		nodeAnnotator := kube.NewNodeAnnotator(n.Kube, &existingNode)
+		err := util.SetL3GatewayConfig(nodeAnnotator, &util.L3GatewayConfig{
+			Mode:        config.GatewayModeShared,
+			ChassisID:   "cb9ec8fa-b409-4ef3-9f42-d9283c47aac6",
+			InterfaceID: "breth0_node1",
+			MACAddress:  ovntest.MustParseMAC("11:22:33:44:55:66"),
+			IPAddresses: []*net.IPNet{oldIP},
+			NextHops:    []net.IP{oldNextHop},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeAnnotator.Run()).To(Succeed())
+
+		// The loopback interface stands in for the gateway interface
+		ga := &gatewayAddresses{ipAddresses: []*net.IPNet{oldIP}, nextHops: []net.IP{oldNextHop}}
+		Expect(n.updateGatewayAddresses("lo", ga)).To(Succeed())
+
+		updatedNode, err := fakeClient.CoreV1().Nodes().Get(context.TODO(), existingNode.Name, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		l3GatewayConfig, err := util.ParseNodeL3GatewayAnnotation(updatedNode)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l3GatewayConfig.InterfaceID).To(Equal("breth0_node1"))
+		Expect(util.JoinIPNets(l3GatewayConfig.IPAddresses, ",")).To(Equal("127.0.0.1/8"))
+		Expect(util.JoinIPs(l3GatewayConfig.NextHops, ",")).To(Equal("127.0.0.254"))
+		Expect(util.JoinIPNets(ga.ipAddresses, ",")).To(Equal("127.0.0.1/8"))
+		Expect(util.JoinIPs(ga.nextHops, ",")).To(Equal("127.0.0.254"))
+	})
+})
+
+var _ = Describe("Shared gateway NodePort iptables rules", func() {
+	It("DNATs the NodePorts of each node IP to the cluster IP of its family", func() {
+		fakeIPTables := make(map[iptables.Protocol]*util.FakeIPTables)
+		for _, proto := range []iptables.Protocol{iptables.ProtocolIPv4, iptables.ProtocolIPv6} {
+			ipt, err := util.NewFakeWithProtocol(proto)
+			Expect(err).NotTo(HaveOccurred())
+			util.SetIPTablesHelper(proto, ipt)
+			fakeIPTables[proto] = ipt
+		}
+
+		service := newNodePortService("svc-dual", "172.16.1.1", 30000)
+		service.Spec.ClusterIPs = []string{"172.16.1.1", "fd00:10:96::1"}
+		service.Spec.Ports[0].Port = 8080
+		addSharedGatewayIptRules(service, []*net.IPNet{
+			ovntest.MustParseIPNet("10.0.0.5/24"),
+			ovntest.MustParseIPNet("fd00::5/64"),
+		})
+
+		Expect(fakeIPTables[iptables.ProtocolIPv4].MatchState(map[string]util.FakeTable{
+			"nat": {
+				iptableNodePortChain: []string{
+					"-p TCP --dport 30000 -d 10.0.0.5 -j DNAT --to-destination 172.16.1.1:8080",
+				},
+			},
+			"filter": {
+				iptableNodePortChain: []string{
+					"-p TCP --dport 30000 -d 10.0.0.5 -j ACCEPT",
+				},
+			},
+		})).To(Succeed())
+		Expect(fakeIPTables[iptables.ProtocolIPv6].MatchState(map[string]util.FakeTable{
+			"nat": {
+				iptableNodePortChain: []string{
+					"-p TCP --dport 30000 -d fd00::5 -j DNAT --to-destination [fd00:10:96::1]:8080",
+				},
+			},
+			"filter": {
+				iptableNodePortChain: []string{
+					"-p TCP --dport 30000 -d fd00::5 -j ACCEPT",
+				},
+			},
+		})).To(Succeed())
+	})
+})
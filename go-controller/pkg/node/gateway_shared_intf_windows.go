@@ -12,8 +12,8 @@ func createNodePortIptableChain() error {
 func deleteNodePortIptableChain() {
 }
 
-func addSharedGatewayIptRules(service *kapi.Service, nodeIP *net.IPNet) {
+func addSharedGatewayIptRules(service *kapi.Service, nodeIPs []*net.IPNet) {
 }
 
-func delSharedGatewayIptRules(service *kapi.Service, nodeIP *net.IPNet) {
+func delSharedGatewayIptRules(service *kapi.Service, nodeIPs []*net.IPNet) {
 }
@@ -1,7 +1,6 @@
 package node
 
 import (
-	"fmt"
 	"os"
 	"strings"
 	"time"
@@ -119,27 +118,13 @@ func checkForStaleOVSInterfaces(stopChan chan struct{}) {
 	}
 }
 
-// checkDefaultOpenFlow checks for the existence of default OpenFlow rules and
-// exits if the output is not as expected
-func checkDefaultConntrackRules(gwBridge string, physIntf, patchIntf, ofportPhys, ofportPatch string,
-	nFlows int, stopChan chan struct{}) {
-	flowCount := fmt.Sprintf("flow_count=%d", nFlows)
+// checkDefaultConntrackRules checks that the ofports the default OpenFlow
+// rules use are unchanged and exits otherwise. The rules themselves are kept
+// in sync by the gateway's flow manager.
+func checkDefaultConntrackRules(physIntf, patchIntf, ofportPhys, ofportPatch string, stopChan chan struct{}) {
 	for {
 		select {
 		case <-time.After(15 * time.Second):
-			out, _, err := util.RunOVSOfctl("dump-aggregate", gwBridge,
-				fmt.Sprintf("cookie=%s/-1", defaultOpenFlowCookie))
-			if err != nil {
-				klog.Errorf("failed to dump aggregate statistics of the default OpenFlow rules: %v", err)
-				continue
-			}
-
-			if !strings.Contains(out, flowCount) {
-				klog.Errorf("fatal error: unexpected default OpenFlows count, expect %d output: %v\n",
-					nFlows, out)
-				os.Exit(1)
-			}
-
 			// it could be that the ovn-controller recreated the patch between the host OVS bridge and
 			// the integration bridge, as a result the ofport number changed for that patch interface
 			curOfportPatch, stderr, err := util.RunOVSVsctl("--if-exists", "get", "Interface", patchIntf, "ofport")
@@ -6,17 +6,48 @@ import (
 	"fmt"
 	"net"
 	"syscall"
+	"time"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	"github.com/vishvananda/netlink"
+	"k8s.io/klog"
 )
 
 // getDefaultGatewayInterfaceDetails returns the interface name on
-// which the default gateway (for route to 0.0.0.0) is configured.
-// It also returns the default gateway itself.
-func getDefaultGatewayInterfaceDetails() (string, net.IP, error) {
-	routes, err := netlink.RouteList(nil, syscall.AF_INET)
+// which the default gateway (for route to 0.0.0.0 or ::/0) is configured.
+// It also returns the default gateways themselves, one for each IP family
+// used by the cluster.
+func getDefaultGatewayInterfaceDetails() (string, []net.IP, error) {
+	var families []int
+	if config.IPv4Mode {
+		families = append(families, syscall.AF_INET)
+	}
+	if config.IPv6Mode {
+		families = append(families, syscall.AF_INET6)
+	}
+
+	var intfName string
+	var gatewayIPs []net.IP
+	for _, family := range families {
+		familyIntfName, gatewayIP, err := getDefaultGatewayInterfaceByFamily(family)
+		if err != nil {
+			return "", nil, err
+		}
+		if intfName == "" {
+			intfName = familyIntfName
+		} else if intfName != familyIntfName {
+			return "", nil, fmt.Errorf("IPv4 and IPv6 default gateways are on different interfaces %s and %s",
+				intfName, familyIntfName)
+		}
+		gatewayIPs = append(gatewayIPs, gatewayIP)
+	}
+	return intfName, gatewayIPs, nil
+}
+
+func getDefaultGatewayInterfaceByFamily(family int) (string, net.IP, error) {
+	routes, err := netlink.RouteList(nil, family)
 	if err != nil {
 		return "", nil, fmt.Errorf("Failed to get routing table in node")
 	}
@@ -34,6 +65,9 @@ func getDefaultGatewayInterfaceDetails() (string, net.IP, error) {
 			}
 		}
 	}
+	if family == syscall.AF_INET6 {
+		return "", nil, fmt.Errorf("Failed to get IPv6 default gateway interface")
+	}
 	return "", nil, fmt.Errorf("Failed to get default gateway interface")
 }
 
@@ -54,3 +88,94 @@ func getIntfName(gatewayIntf string) (string, error) {
 	}
 	return intfName, nil
 }
+
+// watchGatewayAddresses calls update whenever an address of gatewayIntf or a
+// default route is added or removed, until stopChan is closed.
+func watchGatewayAddresses(gatewayIntf string, stopChan chan struct{}, update func()) error {
+	link, err := netlink.LinkByName(gatewayIntf)
+	if err != nil {
+		return fmt.Errorf("failed to get link %s: %v", gatewayIntf, err)
+	}
+	linkIndex := link.Attrs().Index
+
+	subscribe := func() (chan netlink.AddrUpdate, chan netlink.RouteUpdate, error) {
+		addrChan := make(chan netlink.AddrUpdate)
+		routeChan := make(chan netlink.RouteUpdate)
+		if err := netlink.AddrSubscribe(addrChan, stopChan); err != nil {
+			return nil, nil, fmt.Errorf("failed to subscribe to address updates: %v", err)
+		}
+		if err := netlink.RouteSubscribe(routeChan, stopChan); err != nil {
+			return nil, nil, fmt.Errorf("failed to subscribe to route updates: %v", err)
+		}
+		return addrChan, routeChan, nil
+	}
+
+	addrChan, routeChan, err := subscribe()
+	if err != nil {
+		return err
+	}
+	go func() {
+		for {
+			select {
+			case addrUpdate, ok := <-addrChan:
+				if ok {
+					if addrUpdate.LinkIndex == linkIndex {
+						update()
+					}
+					continue
+				}
+			case routeUpdate, ok := <-routeChan:
+				if ok {
+					if routeUpdate.Dst == nil {
+						update()
+					}
+					continue
+				}
+			case <-stopChan:
+				return
+			}
+
+			// The kernel closed one of the subscriptions, for instance
+			// because its socket buffer overflowed. Subscribe again and
+			// catch up on the changes that may have been missed.
+			klog.Warningf("Netlink subscription for gateway interface %s closed, resubscribing", gatewayIntf)
+			for {
+				select {
+				case <-time.After(time.Second):
+				case <-stopChan:
+					return
+				}
+				if addrChan, routeChan, err = subscribe(); err == nil {
+					break
+				}
+				klog.Errorf("Failed to watch the addresses of gateway interface %s: %v", gatewayIntf, err)
+			}
+			update()
+		}
+	}()
+	return nil
+}
+
+// getInterfaceMTUByIP returns the MTU of the interface owning ip
+func getInterfaceMTUByIP(ip net.IP) (int, error) {
+	family := syscall.AF_INET
+	if ip.To4() == nil {
+		family = syscall.AF_INET6
+	}
+	links, err := netlink.LinkList()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list links: %v", err)
+	}
+	for _, link := range links {
+		addrs, err := netlink.AddrList(link, family)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list the addresses of %s: %v", link.Attrs().Name, err)
+		}
+		for _, addr := range addrs {
+			if addr.IP.Equal(ip) {
+				return link.Attrs().MTU, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no interface owns %s", ip)
+}
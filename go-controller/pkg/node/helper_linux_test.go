@@ -0,0 +1,53 @@
+// +build linux
+
+package node
+
+import (
+	"github.com/urfave/cli/v2"
+	"github.com/vishvananda/netlink"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Overlay MTU detection", func() {
+	var app *cli.App
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+	})
+
+	It("subtracts the encapsulation overhead from the MTU of the encapsulation interface", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := config.InitConfig(ctx, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			lo, err := netlink.LinkByName("lo")
+			Expect(err).NotTo(HaveOccurred())
+
+			node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+			err = detectOverlayMTU(node)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Default.MTU).To(Equal(lo.Attrs().MTU - 50))
+			return nil
+		}
+
+		err := app.Run([]string{
+			app.Name,
+			"--mtu-auto-detect",
+			"--encap-type=vxlan",
+			"--encap-ip=127.0.0.1",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
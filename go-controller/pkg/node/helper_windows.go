@@ -10,13 +10,27 @@ import (
 )
 
 // getDefaultGatewayInterfaceDetails returns the interface name on
-// which the default gateway (for route to 0.0.0.0) is configured.
-// It also returns the default gateway itself.
-func getDefaultGatewayInterfaceDetails() (string, net.IP, error) {
+// which the default gateway (for route to 0.0.0.0 or ::/0) is configured.
+// It also returns the default gateways themselves, one for each IP family
+// used by the cluster.
+func getDefaultGatewayInterfaceDetails() (string, []net.IP, error) {
 	// TODO: Implement this
 	return "", nil, fmt.Errorf("Not implemented yet on Windows")
 }
 
+// watchGatewayAddresses calls update whenever an address of gatewayIntf or a
+// default route is added or removed, until stopChan is closed.
+func watchGatewayAddresses(gatewayIntf string, stopChan chan struct{}, update func()) error {
+	// TODO: Implement this
+	return fmt.Errorf("Not implemented yet on Windows")
+}
+
+// getInterfaceMTUByIP returns the MTU of the interface owning ip
+func getInterfaceMTUByIP(ip net.IP) (int, error) {
+	// TODO: Implement this
+	return 0, fmt.Errorf("Not implemented yet on Windows")
+}
+
 func getIntfName(gatewayIntf string) (string, error) {
 	// Is intfName a port of gatewayIntf?
 	intfName, err := util.GetNicName(gatewayIntf)
@@ -0,0 +1,277 @@
+package node
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509/pkix"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+	"k8s.io/klog"
+)
+
+const (
+	// ipsecCertRenewFraction is the fraction of the lifetime of the IPsec
+	// certificate after which it is renewed
+	ipsecCertRenewFraction = 0.8
+	// ipsecKeyBits is the size of the RSA keys of the IPsec certificates
+	ipsecKeyBits = 2048
+)
+
+var (
+	// ipsecCSRPollInterval is how often the certificate signing request is
+	// checked for the signed certificate
+	ipsecCSRPollInterval = 2 * time.Second
+	// ipsecCSRTimeout is how long the master has to approve the certificate
+	// signing request and the signer to sign it
+	ipsecCSRTimeout = 5 * time.Minute
+)
+
+// ipsecCertManager obtains the node's IPsec certificate through the
+// Kubernetes certificate signing request API, configures OVS IPsec with it,
+// and renews it before it expires.
+type ipsecCertManager struct {
+	node      *OvnNode
+	chassisID string
+
+	keyPath   string
+	certPath  string
+	notBefore time.Time
+	notAfter  time.Time
+}
+
+// startIPsec configures OVS IPsec with a certificate for the node's chassis,
+// reusing the configured one if it is not due for renewal, and starts the
+// renewal of the certificate and the reporting of the tunnels' encryption.
+func (n *OvnNode) startIPsec(chassisID string) error {
+	m := &ipsecCertManager{
+		node:      n,
+		chassisID: chassisID,
+	}
+	if err := os.MkdirAll(config.IPsec.CertDir, 0700); err != nil {
+		return fmt.Errorf("failed to create IPsec certificate directory %s: %v", config.IPsec.CertDir, err)
+	}
+
+	if err := m.loadCertificate(); err != nil {
+		klog.Infof("Requesting a new IPsec certificate: %v", err)
+		if err := m.renewCertificate(); err != nil {
+			return err
+		}
+	} else if m.renewalDue(time.Now()) {
+		if err := m.renewCertificate(); err != nil {
+			return err
+		}
+	}
+
+	go m.run(n.stopChan)
+	return nil
+}
+
+// loadCertificate loads the IPsec certificate OVS is configured with
+func (m *ipsecCertManager) loadCertificate() error {
+	stdout, stderr, err := util.RunOVSVsctl("--if-exists", "get", "Open_vSwitch", ".",
+		"other_config:certificate", "other_config:private_key")
+	if err != nil {
+		return fmt.Errorf("failed to get the IPsec certificate configuration, stderr: %q, error: %v", stderr, err)
+	}
+	paths := strings.Fields(stdout)
+	if len(paths) != 2 {
+		return fmt.Errorf("no IPsec certificate configured")
+	}
+	certPath := strings.Trim(paths[0], "\"")
+	keyPath := strings.Trim(paths[1], "\"")
+
+	certs, err := cert.CertsFromFile(certPath)
+	if err != nil {
+		return fmt.Errorf("failed to read IPsec certificate %s: %v", certPath, err)
+	}
+	if certs[0].Subject.CommonName != m.chassisID {
+		return fmt.Errorf("IPsec certificate %s is for chassis %s", certPath, certs[0].Subject.CommonName)
+	}
+	if _, err := os.Stat(keyPath); err != nil {
+		return fmt.Errorf("failed to find IPsec private key: %v", err)
+	}
+
+	m.certPath = certPath
+	m.keyPath = keyPath
+	m.notBefore = certs[0].NotBefore
+	m.notAfter = certs[0].NotAfter
+	metrics.MetricIPsecCertificateExpiry.Set(float64(m.notAfter.Unix()))
+	return nil
+}
+
+// renewalDue returns true if the certificate is due for renewal at now
+func (m *ipsecCertManager) renewalDue(now time.Time) bool {
+	lifetime := m.notAfter.Sub(m.notBefore)
+	return now.After(m.notBefore.Add(time.Duration(float64(lifetime) * ipsecCertRenewFraction)))
+}
+
+// renewCertificate generates a new private key, has a certificate for it
+// signed through a certificate signing request, and configures OVS with
+// them. The files of the previous key and certificate are removed once OVS
+// uses the new ones.
+func (m *ipsecCertManager) renewCertificate() error {
+	key, err := rsa.GenerateKey(rand.Reader, ipsecKeyBits)
+	if err != nil {
+		return fmt.Errorf("failed to generate IPsec private key: %v", err)
+	}
+	csrPEM, err := cert.MakeCSR(key, &pkix.Name{CommonName: m.chassisID}, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create IPsec certificate signing request: %v", err)
+	}
+	certPEM, err := m.requestCertificate(csrPEM)
+	if err != nil {
+		return err
+	}
+	certs, err := cert.ParseCertsPEM(certPEM)
+	if err != nil {
+		return fmt.Errorf("failed to parse IPsec certificate: %v", err)
+	}
+	keyPEM, err := keyutil.MarshalPrivateKeyToPEM(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode IPsec private key: %v", err)
+	}
+
+	// ovs-monitor-ipsec reconfigures the tunnels when the paths change, so
+	// each key and certificate gets files of its own
+	suffix := fmt.Sprintf("%d", time.Now().Unix())
+	keyPath := filepath.Join(config.IPsec.CertDir, "ipsec-privkey-"+suffix+".pem")
+	certPath := filepath.Join(config.IPsec.CertDir, "ipsec-cert-"+suffix+".pem")
+	if err := keyutil.WriteKey(keyPath, keyPEM); err != nil {
+		return fmt.Errorf("failed to write IPsec private key: %v", err)
+	}
+	if err := cert.WriteCert(certPath, certPEM); err != nil {
+		return fmt.Errorf("failed to write IPsec certificate: %v", err)
+	}
+
+	_, stderr, err := util.RunOVSVsctl("set", "Open_vSwitch", ".",
+		"other_config:certificate="+certPath,
+		"other_config:private_key="+keyPath,
+		"other_config:ca_cert="+config.IPsec.CACert)
+	if err != nil {
+		return fmt.Errorf("failed to configure the IPsec certificate, stderr: %q, error: %v", stderr, err)
+	}
+
+	for _, oldPath := range []string{m.certPath, m.keyPath} {
+		if oldPath != "" {
+			if err := os.Remove(oldPath); err != nil && !os.IsNotExist(err) {
+				klog.Warningf("Failed to remove old IPsec file %s: %v", oldPath, err)
+			}
+		}
+	}
+	m.certPath = certPath
+	m.keyPath = keyPath
+	m.notBefore = certs[0].NotBefore
+	m.notAfter = certs[0].NotAfter
+	metrics.MetricIPsecCertificateExpiry.Set(float64(m.notAfter.Unix()))
+	klog.Infof("Configured IPsec certificate %s valid until %v", certPath, m.notAfter)
+	return nil
+}
+
+// requestCertificate creates a certificate signing request for the node's
+// IPsec certificate and waits for the master to approve it and the signer
+// to sign it.
+func (m *ipsecCertManager) requestCertificate(csrPEM []byte) ([]byte, error) {
+	csrClient := m.node.Kube.CertificateSigningRequests()
+	signerName := config.IPsec.SignerName
+	csr, err := csrClient.Create(context.TODO(), &certificatesv1beta1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "ovn-ipsec-" + m.node.name + "-",
+			Labels:       map[string]string{util.IPsecCSRNodeLabel: m.node.name},
+		},
+		Spec: certificatesv1beta1.CertificateSigningRequestSpec{
+			Request:    csrPEM,
+			SignerName: &signerName,
+			Usages:     util.IPsecCSRUsages,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IPsec certificate signing request: %v", err)
+	}
+	defer func() {
+		// the signed certificate is kept on the node, the request is
+		// not needed anymore
+		if err := csrClient.Delete(context.TODO(), csr.Name, metav1.DeleteOptions{}); err != nil {
+			klog.Warningf("Failed to delete certificate signing request %s: %v", csr.Name, err)
+		}
+	}()
+
+	var certPEM []byte
+	err = wait.PollImmediate(ipsecCSRPollInterval, ipsecCSRTimeout, func() (bool, error) {
+		signed, err := csrClient.Get(context.TODO(), csr.Name, metav1.GetOptions{})
+		if err != nil {
+			klog.Warningf("Failed to get certificate signing request %s: %v", csr.Name, err)
+			return false, nil
+		}
+		for _, condition := range signed.Status.Conditions {
+			if condition.Type == certificatesv1beta1.CertificateDenied {
+				return false, fmt.Errorf("denied: %s", condition.Message)
+			}
+		}
+		certPEM = signed.Status.Certificate
+		return len(certPEM) > 0, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IPsec certificate from certificate signing request %s: %v",
+			csr.Name, err)
+	}
+	return certPEM, nil
+}
+
+// updateIPsecTunnelMetrics counts the node's tunnels by whether they are
+// encrypted. OVN sets the name of the remote chassis on the tunnels it
+// encrypts so that ovs-monitor-ipsec can authenticate the remote end.
+func updateIPsecTunnelMetrics() error {
+	stdout, stderr, err := util.RunOVSVsctl("--data=bare", "--no-heading", "--columns=options",
+		"find", "interface", "type="+config.Default.EncapType)
+	if err != nil {
+		return fmt.Errorf("failed to list tunnels, stderr: %q, error: %v", stderr, err)
+	}
+	var encrypted, unencrypted int
+	for _, options := range strings.Split(stdout, "\n") {
+		if strings.TrimSpace(options) == "" {
+			continue
+		}
+		if strings.Contains(options, "remote_name=") {
+			encrypted++
+		} else {
+			unencrypted++
+		}
+	}
+	metrics.MetricIPsecTunnels.WithLabelValues("true").Set(float64(encrypted))
+	metrics.MetricIPsecTunnels.WithLabelValues("false").Set(float64(unencrypted))
+	return nil
+}
+
+// run renews the certificate when it is due and reports the encryption of
+// the tunnels until stopChan is closed
+func (m *ipsecCertManager) run(stopChan chan struct{}) {
+	for {
+		select {
+		case <-time.After(30 * time.Second):
+			if err := updateIPsecTunnelMetrics(); err != nil {
+				klog.Errorf(err.Error())
+			}
+			if m.renewalDue(time.Now()) {
+				if err := m.renewCertificate(); err != nil {
+					klog.Errorf("Failed to renew IPsec certificate expiring at %v: %v", m.notAfter, err)
+				}
+			}
+		case <-stopChan:
+			return
+		}
+	}
+}
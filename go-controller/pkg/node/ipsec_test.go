@@ -0,0 +1,156 @@
+package node
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/cert"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IPsec certificates", func() {
+	const (
+		nodeName  string = "node1"
+		chassisID string = "cb9ec8fa-b409-4ef3-9f42-d9283c47aac6"
+	)
+
+	var (
+		fakeClient *fake.Clientset
+		manager    *ipsecCertManager
+		stop       chan struct{}
+	)
+
+	// signCSRs plays the approver and signer, issuing a certificate for each
+	// pending request with sign until stop is closed
+	signCSRs := func(fakeClient *fake.Clientset, stop chan struct{},
+		sign func(csr *certificatesv1beta1.CertificateSigningRequest)) {
+		defer GinkgoRecover()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			csrs, err := fakeClient.CertificatesV1beta1().CertificateSigningRequests().List(context.TODO(), metav1.ListOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			for i := range csrs.Items {
+				csr := &csrs.Items[i]
+				if len(csr.Status.Certificate) == 0 && len(csr.Status.Conditions) == 0 {
+					sign(csr)
+					_, err := fakeClient.CertificatesV1beta1().CertificateSigningRequests().UpdateStatus(context.TODO(), csr, metav1.UpdateOptions{})
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		ipsecCSRPollInterval = 10 * time.Millisecond
+		ipsecCSRTimeout = 5 * time.Second
+		stop = make(chan struct{})
+
+		fakeClient = fake.NewSimpleClientset()
+		// the fake client does not generate names
+		fakeClient.PrependReactor("create", "certificatesigningrequests",
+			func(action k8stesting.Action) (bool, runtime.Object, error) {
+				csr := action.(k8stesting.CreateAction).GetObject().(*certificatesv1beta1.CertificateSigningRequest)
+				csr.Name = csr.GenerateName + "x7k2p"
+				return false, nil, nil
+			})
+		manager = &ipsecCertManager{
+			node:      &OvnNode{name: nodeName, Kube: &kube.Kube{KClient: fakeClient}},
+			chassisID: chassisID,
+		}
+	})
+
+	AfterEach(func() {
+		close(stop)
+	})
+
+	It("obtains the certificate of the chassis through a certificate signing request", func() {
+		caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		caCert, err := cert.NewSelfSignedCACert(cert.Config{CommonName: "ovn-ipsec-ca"}, caKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		go signCSRs(fakeClient, stop, func(csr *certificatesv1beta1.CertificateSigningRequest) {
+			defer GinkgoRecover()
+			Expect(csr.Name).To(Equal("ovn-ipsec-node1-x7k2p"))
+			Expect(csr.Labels).To(HaveKeyWithValue(util.IPsecCSRNodeLabel, nodeName))
+			Expect(csr.Spec.SignerName).NotTo(BeNil())
+			Expect(*csr.Spec.SignerName).To(Equal("kubernetes.io/legacy-unknown"))
+			request, err := util.ParseIPsecCSR(csr)
+			Expect(err).NotTo(HaveOccurred())
+
+			template := &x509.Certificate{
+				SerialNumber: big.NewInt(2),
+				Subject:      request.Subject,
+				NotBefore:    time.Now(),
+				NotAfter:     time.Now().Add(time.Hour),
+				KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+				ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageIPSECTunnel},
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, caCert, request.PublicKey, caKey)
+			Expect(err).NotTo(HaveOccurred())
+			csr.Status.Certificate = pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: der})
+		})
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		csrPEM, err := cert.MakeCSR(key, &pkix.Name{CommonName: chassisID}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		certPEM, err := manager.requestCertificate(csrPEM)
+		Expect(err).NotTo(HaveOccurred())
+		certs, err := cert.ParseCertsPEM(certPEM)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certs[0].Subject.CommonName).To(Equal(chassisID))
+		Expect(certs[0].CheckSignatureFrom(caCert)).To(Succeed())
+
+		// The request is deleted once the certificate is issued
+		csrs, err := fakeClient.CertificatesV1beta1().CertificateSigningRequests().List(context.TODO(), metav1.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(csrs.Items).To(BeEmpty())
+	})
+
+	It("fails when the certificate signing request is denied", func() {
+		go signCSRs(fakeClient, stop, func(csr *certificatesv1beta1.CertificateSigningRequest) {
+			csr.Status.Conditions = []certificatesv1beta1.CertificateSigningRequestCondition{{
+				Type:    certificatesv1beta1.CertificateDenied,
+				Message: "not a node of this cluster",
+			}}
+		})
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		csrPEM, err := cert.MakeCSR(key, &pkix.Name{CommonName: chassisID}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = manager.requestCertificate(csrPEM)
+		Expect(err).To(MatchError("failed to get IPsec certificate from certificate signing request " +
+			"ovn-ipsec-node1-x7k2p: denied: not a node of this cluster"))
+	})
+
+	It("renews the certificate after 80% of its lifetime", func() {
+		manager.notBefore = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		manager.notAfter = manager.notBefore.Add(100 * time.Hour)
+		Expect(manager.renewalDue(manager.notBefore.Add(79 * time.Hour))).To(BeFalse())
+		Expect(manager.renewalDue(manager.notBefore.Add(81 * time.Hour))).To(BeTrue())
+	})
+})
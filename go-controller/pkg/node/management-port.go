@@ -7,7 +7,13 @@ import (
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog"
 )
 
@@ -24,13 +30,7 @@ func (n *OvnNode) createManagementPort(hostSubnets []*net.IPNet, nodeAnnotator k
 	nodeName := strings.ToLower(n.name)
 
 	// Create a OVS internal interface.
-	legacyMgmtIntfName := util.GetLegacyK8sMgmtIntfName(nodeName)
-	stdout, stderr, err := util.RunOVSVsctl(
-		"--", "--if-exists", "del-port", "br-int", legacyMgmtIntfName,
-		"--", "--may-exist", "add-port", "br-int", util.K8sMgmtIntfName,
-		"--", "set", "interface", util.K8sMgmtIntfName,
-		"type=internal", "mtu_request="+fmt.Sprintf("%d", config.Default.MTU),
-		"external-ids:iface-id=k8s-"+nodeName)
+	stdout, stderr, err := util.RunOVSVsctl(managementPortInterfaceArgs(nodeName)...)
 	if err != nil {
 		klog.Errorf("Failed to add port to br-int, stdout: %q, stderr: %q, error: %v", stdout, stderr, err)
 		return err
@@ -49,7 +49,12 @@ func (n *OvnNode) createManagementPort(hostSubnets []*net.IPNet, nodeAnnotator k
 		return err
 	}
 
-	err = createPlatformManagementPort(util.K8sMgmtIntfName, hostSubnets, n.stopChan)
+	health := &managementPortHealth{
+		node:       n,
+		nodeName:   nodeName,
+		macAddress: macAddress,
+	}
+	err = createPlatformManagementPort(util.K8sMgmtIntfName, hostSubnets, health, n.stopChan)
 	if err != nil {
 		return err
 	}
@@ -62,6 +67,187 @@ func (n *OvnNode) createManagementPort(hostSubnets []*net.IPNet, nodeAnnotator k
 	return nil
 }
 
+// managementPortInterfaceArgs returns the ovs-vsctl arguments creating the
+// OVS internal interface of the management port
+func managementPortInterfaceArgs(nodeName string) []string {
+	return []string{
+		"--", "--if-exists", "del-port", "br-int", util.GetLegacyK8sMgmtIntfName(nodeName),
+		"--", "--may-exist", "add-port", "br-int", util.K8sMgmtIntfName,
+		"--", "set", "interface", util.K8sMgmtIntfName,
+		"type=internal", "mtu_request=" + fmt.Sprintf("%d", config.Default.MTU),
+		"external-ids:iface-id=k8s-" + nodeName,
+	}
+}
+
+const (
+	// managementPortReadyCondition is the type of the node condition
+	// reporting the health of the node's management port
+	managementPortReadyCondition kapi.NodeConditionType = "ManagementPortReady"
+	// managementPortRepairedReason is the reason of the events emitted when
+	// the management port configuration is repaired
+	managementPortRepairedReason = "ManagementPortRepaired"
+)
+
+// managementPortHealth checks and repairs the parts of the management port
+// that live outside of the host networking stack, and reports the health of
+// the management port as a metric, a node condition and node events.
+type managementPortHealth struct {
+	node *OvnNode
+	// nodeName is the lowercase node name of the management port's
+	// logical port
+	nodeName   string
+	macAddress net.HardwareAddr
+	// reported is the health reported by the node condition, nil until
+	// the condition is first set
+	reported *bool
+}
+
+// checkInterface makes sure the management port's OVS interface exists
+// under its expected name, and recreates it with its original MAC address
+// if it is missing or was renamed. It returns the repairs it made.
+func (h *managementPortHealth) checkInterface() ([]string, error) {
+	ifaceID := "k8s-" + h.nodeName
+	stdout, stderr, err := util.RunOVSVsctl("--data=bare", "--no-heading", "--columns=name",
+		"find", "interface", "external-ids:iface-id="+ifaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the management port interface, stderr: %q, error: %v",
+			stderr, err)
+	}
+	names := strings.Fields(stdout)
+	if len(names) == 1 && names[0] == util.K8sMgmtIntfName {
+		return nil, nil
+	}
+
+	var repairs []string
+	var cmdArgs []string
+	for _, name := range names {
+		if name != util.K8sMgmtIntfName {
+			repairs = append(repairs, fmt.Sprintf("management port interface renamed to %s, recreating it as %s",
+				name, util.K8sMgmtIntfName))
+			cmdArgs = append(cmdArgs, "--", "--if-exists", "del-port", "br-int", name)
+		}
+	}
+	if len(repairs) == 0 {
+		repairs = append(repairs, fmt.Sprintf("missing management port interface %s, recreating it",
+			util.K8sMgmtIntfName))
+	}
+	cmdArgs = append(cmdArgs, managementPortInterfaceArgs(h.nodeName)...)
+	cmdArgs = append(cmdArgs, fmt.Sprintf("mac=%s", strings.ReplaceAll(h.macAddress.String(), ":", "\\:")))
+	_, stderr, err = util.RunOVSVsctl(cmdArgs...)
+	if err != nil {
+		return repairs, fmt.Errorf("failed to recreate the management port interface, stderr: %q, error: %v",
+			stderr, err)
+	}
+	return repairs, nil
+}
+
+// checkLogicalPort makes sure the management port's logical port is bound to
+// this node's chassis in the southbound database.
+func (h *managementPortHealth) checkLogicalPort() error {
+	logicalPort := "k8s-" + h.nodeName
+	chassis, stderr, err := util.RunOVNSbctl("--data=bare", "--no-heading", "--columns=chassis",
+		"find", "port_binding", "logical_port="+logicalPort)
+	if err != nil {
+		return fmt.Errorf("failed to get the binding of logical port %s, stderr: %q, error: %v",
+			logicalPort, stderr, err)
+	}
+	if chassis == "" {
+		return fmt.Errorf("logical port %s is not up", logicalPort)
+	}
+	chassisName, stderr, err := util.RunOVNSbctl("get", "chassis", chassis, "name")
+	if err != nil {
+		return fmt.Errorf("failed to get the name of chassis %s, stderr: %q, error: %v",
+			chassis, stderr, err)
+	}
+	chassisID, err := util.GetNodeChassisID()
+	if err != nil {
+		return err
+	}
+	if chassisName != chassisID {
+		return fmt.Errorf("logical port %s is bound to chassis %s instead of %s",
+			logicalPort, chassisName, chassisID)
+	}
+	return nil
+}
+
+// report publishes the result of a health check: the repairs made are
+// logged and emitted as an event on the node, and err, if not nil, is why
+// the management port is unhealthy.
+func (h *managementPortHealth) report(repairs []string, err error) {
+	for _, repair := range repairs {
+		klog.Warningf(repair)
+	}
+	if len(repairs) > 0 {
+		metrics.MetricManagementPortRepairs.Add(float64(len(repairs)))
+		if h.node.recorder != nil {
+			nodeRef := &kapi.ObjectReference{
+				Kind: "Node",
+				Name: h.node.name,
+				UID:  types.UID(h.node.name),
+			}
+			h.node.recorder.Eventf(nodeRef, kapi.EventTypeWarning, managementPortRepairedReason,
+				"Repaired the management port: %s", strings.Join(repairs, "; "))
+		}
+	}
+
+	healthy := err == nil
+	if healthy {
+		metrics.MetricManagementPortHealthy.Set(1)
+	} else {
+		klog.Errorf(err.Error())
+		metrics.MetricManagementPortHealthy.Set(0)
+	}
+
+	if h.reported != nil && *h.reported == healthy {
+		return
+	}
+	if err := h.setNodeCondition(err); err != nil {
+		klog.Errorf("Failed to set the %s condition of node %s: %v", managementPortReadyCondition,
+			h.node.name, err)
+		return
+	}
+	h.reported = &healthy
+}
+
+// setNodeCondition sets the management port condition of the node, healthy
+// if err is nil.
+func (h *managementPortHealth) setNodeCondition(err error) error {
+	condition := kapi.NodeCondition{
+		Type:               managementPortReadyCondition,
+		Status:             kapi.ConditionTrue,
+		Reason:             "ManagementPortHealthy",
+		Message:            "The management port is configured",
+		LastHeartbeatTime:  metav1.Now(),
+		LastTransitionTime: metav1.Now(),
+	}
+	if err != nil {
+		condition.Status = kapi.ConditionFalse
+		condition.Reason = "ManagementPortUnhealthy"
+		condition.Message = err.Error()
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		node, err := h.node.Kube.GetNode(h.node.name)
+		if err != nil {
+			return err
+		}
+		// Informer cache should not be mutated, so get a copy of the object
+		node = node.DeepCopy()
+		found := false
+		for i := range node.Status.Conditions {
+			if node.Status.Conditions[i].Type == managementPortReadyCondition {
+				node.Status.Conditions[i] = condition
+				found = true
+				break
+			}
+		}
+		if !found {
+			node.Status.Conditions = append(node.Status.Conditions, condition)
+		}
+		return h.node.Kube.UpdateNodeStatus(node)
+	})
+}
+
 // managementPortReady will check to see if OpenFlow rules for management port has been created
 func managementPortReady() (bool, error) {
 	// Get the OVS interface name for the Management Port
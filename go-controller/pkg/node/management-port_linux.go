@@ -256,7 +256,8 @@ func setupManagementPortConfig(cfg *managementPortConfig) ([]string, error) {
 // createPlatformManagementPort creates a management port attached to the node switch
 // that lets the node access its pods via their private IP address. This is used
 // for health checking and other management tasks.
-func createPlatformManagementPort(interfaceName string, localSubnets []*net.IPNet, stopChan chan struct{}) error {
+func createPlatformManagementPort(interfaceName string, localSubnets []*net.IPNet, health *managementPortHealth,
+	stopChan chan struct{}) error {
 	var cfg *managementPortConfig
 	var err error
 
@@ -273,7 +274,7 @@ func createPlatformManagementPort(interfaceName string, localSubnets []*net.IPNe
 	}
 
 	// start the management port health check
-	go checkManagementPortHealth(cfg, stopChan)
+	go checkManagementPortHealth(cfg, health, stopChan)
 	return nil
 }
 
@@ -298,20 +299,30 @@ func DelMgtPortIptRules() {
 }
 
 // checks to make sure that following configurations are present on the k8s node
-// 1. route entries to cluster CIDR and service CIDR through management port
-// 2. ARP entry for the node subnet's gateway ip
-// 3. IPtables chain and rule for SNATing packets entering the logical topology
-func checkManagementPortHealth(cfg *managementPortConfig, stopChan chan struct{}) {
+// 1. the OVS interface of the management port
+// 2. route entries to cluster CIDR and service CIDR through management port
+// 3. ARP entry for the node subnet's gateway ip
+// 4. IPtables chain and rule for SNATing packets entering the logical topology
+// 5. the binding of the management port's logical port to this chassis
+func checkManagementPortHealth(cfg *managementPortConfig, health *managementPortHealth, stopChan chan struct{}) {
 	for {
 		select {
 		case <-time.After(30 * time.Second):
-			warnings, err := setupManagementPortConfig(cfg)
-			for _, warning := range warnings {
-				klog.Warningf(warning)
+			repairs, err := health.checkInterface()
+			if err == nil && len(repairs) > 0 {
+				// the recreated interface is a new link without any of
+				// the host configuration
+				cfg.link, err = util.LinkSetUp(cfg.ifName)
 			}
-			if err != nil {
-				klog.Errorf(err.Error())
+			if err == nil {
+				var warnings []string
+				warnings, err = setupManagementPortConfig(cfg)
+				repairs = append(repairs, warnings...)
+			}
+			if err == nil {
+				err = health.checkLogicalPort()
 			}
+			health.report(repairs, err)
 		case <-stopChan:
 			return
 		}
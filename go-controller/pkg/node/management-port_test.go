@@ -0,0 +1,172 @@
+package node
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Management port health", func() {
+	const (
+		nodeName   string = "node1"
+		mgtPortMAC string = "00:00:00:55:66:77"
+	)
+
+	var (
+		app   *cli.App
+		fexec *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fexec = ovntest.NewFakeExec()
+	})
+
+	It("recreates a renamed management port interface with its MAC address", func() {
+		app.Action = func(ctx *cli.Context) error {
+			err := util.SetExec(fexec)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = config.InitConfig(ctx, fexec, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovs-vsctl --timeout=15 --data=bare --no-heading --columns=name find interface external-ids:iface-id=k8s-" + nodeName,
+				Output: "mp-renamed",
+			})
+			fexec.AddFakeCmdsNoOutputNoError([]string{
+				"ovs-vsctl --timeout=15 -- --if-exists del-port br-int mp-renamed " +
+					"-- --if-exists del-port br-int k8s-" + nodeName + " " +
+					"-- --may-exist add-port br-int " + util.K8sMgmtIntfName + " " +
+					"-- set interface " + util.K8sMgmtIntfName + " type=internal mtu_request=1400 " +
+					"external-ids:iface-id=k8s-" + nodeName + ` mac=00\:00\:00\:55\:66\:77`,
+			})
+
+			health := &managementPortHealth{
+				nodeName:   nodeName,
+				macAddress: ovntest.MustParseMAC(mgtPortMAC),
+			}
+			repairs, err := health.checkInterface()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repairs).To(HaveLen(1))
+			Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name, "--mtu=1400"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("leaves a healthy management port interface alone", func() {
+		app.Action = func(ctx *cli.Context) error {
+			err := util.SetExec(fexec)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = config.InitConfig(ctx, fexec, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovs-vsctl --timeout=15 --data=bare --no-heading --columns=name find interface external-ids:iface-id=k8s-" + nodeName,
+				Output: util.K8sMgmtIntfName,
+			})
+
+			health := &managementPortHealth{
+				nodeName:   nodeName,
+				macAddress: ovntest.MustParseMAC(mgtPortMAC),
+			}
+			repairs, err := health.checkInterface()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repairs).To(BeEmpty())
+			Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("detects a logical port bound to another chassis", func() {
+		app.Action = func(ctx *cli.Context) error {
+			err := util.SetExec(fexec)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = config.InitConfig(ctx, fexec, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-sbctl --timeout=15 --data=bare --no-heading --columns=chassis find port_binding logical_port=k8s-" + nodeName,
+				Output: "chassis-uuid",
+			})
+			fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-sbctl --timeout=15 get chassis chassis-uuid name",
+				Output: "other-chassis",
+			})
+			fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovs-vsctl --timeout=15 --if-exists get Open_vSwitch . external_ids:system-id",
+				Output: "this-chassis",
+			})
+
+			health := &managementPortHealth{nodeName: nodeName}
+			err = health.checkLogicalPort()
+			Expect(err).To(MatchError("logical port k8s-node1 is bound to chassis other-chassis instead of this-chassis"))
+			Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("reports repairs as node events and health as a node condition", func() {
+		fakeClient := fake.NewSimpleClientset(&v1.NodeList{
+			Items: []v1.Node{{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}},
+		})
+		recorder := record.NewFakeRecorder(10)
+		health := &managementPortHealth{
+			node: &OvnNode{
+				name:     nodeName,
+				Kube:     &kube.Kube{KClient: fakeClient},
+				recorder: recorder,
+			},
+			nodeName: nodeName,
+		}
+
+		getCondition := func() v1.NodeCondition {
+			node, err := fakeClient.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(node.Status.Conditions).To(HaveLen(1))
+			return node.Status.Conditions[0]
+		}
+
+		health.report([]string{"missing route"}, fmt.Errorf("logical port k8s-node1 is not up"))
+		Expect(recorder.Events).To(Receive(Equal("Warning ManagementPortRepaired Repaired the management port: missing route")))
+		condition := getCondition()
+		Expect(condition.Type).To(Equal(managementPortReadyCondition))
+		Expect(condition.Status).To(Equal(v1.ConditionFalse))
+		Expect(condition.Message).To(Equal("logical port k8s-node1 is not up"))
+
+		health.report(nil, nil)
+		Expect(recorder.Events).NotTo(Receive())
+		Expect(getCondition().Status).To(Equal(v1.ConditionTrue))
+	})
+})
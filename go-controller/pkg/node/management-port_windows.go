@@ -18,7 +18,8 @@ import (
 // createPlatformManagementPort creates a management port attached to the node switch
 // that lets the node access its pods via their private IP address. This is used
 // for health checking and other management tasks.
-func createPlatformManagementPort(interfaceName string, hostSubnets []*net.IPNet, stopChan chan struct{}) error {
+func createPlatformManagementPort(interfaceName string, hostSubnets []*net.IPNet, health *managementPortHealth,
+	stopChan chan struct{}) error {
 	if len(hostSubnets) != 1 || !utilnet.IsIPv6CIDR(hostSubnets[0]) {
 		klog.Fatal("IPv6/Dual-stack not supported on Windows")
 	}
@@ -22,6 +22,8 @@ import (
 	kapi "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	utilnet "k8s.io/utils/net"
 )
 
 // OvnNode is the object holder for utilities meant for node management
@@ -30,37 +32,80 @@ type OvnNode struct {
 	Kube         kube.Interface
 	watchFactory *factory.WatchFactory
 	stopChan     chan struct{}
+	recorder     record.EventRecorder
 }
 
 // NewNode creates a new controller for node management
 func NewNode(kubeClient kubernetes.Interface, wf *factory.WatchFactory, name string, stopChan chan struct{}) *OvnNode {
-	return &OvnNode{
+	n := &OvnNode{
 		name:         name,
 		Kube:         &kube.Kube{KClient: kubeClient},
 		watchFactory: wf,
 		stopChan:     stopChan,
 	}
-}
-
-func setupOVNNode(node *kapi.Node) error {
-	var err error
-
-	nodeName, err := util.GetNodeHostname(node)
-	if err != nil {
-		return fmt.Errorf("failed to obtain hostname from node %q: %v", node.Name, err)
+	// the events are not posted without a client
+	if kubeClient != nil {
+		n.recorder = util.EventRecorder(kubeClient)
 	}
+	return n
+}
 
+// getEncapIP returns the IP address of the node's encapsulation endpoint
+func getEncapIP(node *kapi.Node) (string, error) {
 	nodeIP := config.Default.EncapIP
 	if nodeIP == "" {
+		var err error
 		nodeIP, err = util.GetNodeIP(node)
 		if err != nil {
-			return fmt.Errorf("failed to obtain local IP from node %q: %v", node.Name, err)
+			return "", fmt.Errorf("failed to obtain local IP from node %q: %v", node.Name, err)
 		}
 	} else {
 		if ip := net.ParseIP(nodeIP); ip == nil {
-			return fmt.Errorf("invalid encapsulation IP provided %q", nodeIP)
+			return "", fmt.Errorf("invalid encapsulation IP provided %q", nodeIP)
 		}
 	}
+	return nodeIP, nil
+}
+
+// detectOverlayMTU sets the MTU of the overlay networks to the MTU of the
+// interface owning the node's encapsulation IP less the overhead of the
+// encapsulation.
+func detectOverlayMTU(node *kapi.Node) error {
+	encapIP, err := getEncapIP(node)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(encapIP)
+	if ip == nil {
+		return fmt.Errorf("invalid encapsulation IP %q", encapIP)
+	}
+	interfaceMTU, err := getInterfaceMTUByIP(ip)
+	if err != nil {
+		return fmt.Errorf("failed to detect the MTU of the overlay networks: %v", err)
+	}
+	overhead, err := util.EncapOverhead(config.Default.EncapType, utilnet.IsIPv6(ip))
+	if err != nil {
+		return fmt.Errorf("failed to detect the MTU of the overlay networks: %v", err)
+	}
+
+	config.Default.MTU = interfaceMTU - overhead
+	klog.Infof("Using MTU %d for the overlay networks: the MTU %d of the interface owning %s less %d bytes of %s encapsulation",
+		config.Default.MTU, interfaceMTU, encapIP, overhead, config.Default.EncapType)
+	return nil
+}
+
+func setupOVNNode(node *kapi.Node) error {
+	var err error
+
+	nodeName, err := util.GetNodeHostname(node)
+	if err != nil {
+		return fmt.Errorf("failed to obtain hostname from node %q: %v", node.Name, err)
+	}
+
+	nodeIP, err := getEncapIP(node)
+	if err != nil {
+		return err
+	}
 
 	_, stderr, err := util.RunOVSVsctl("set",
 		"Open_vSwitch",
@@ -174,6 +219,11 @@ func (n *OvnNode) Start() error {
 	if err != nil {
 		return err
 	}
+	if config.Default.MTUAutoDetect {
+		if err := detectOverlayMTU(node); err != nil {
+			return err
+		}
+	}
 
 	// First wait for the node logical switch to be created by the Master, timeout is 300s.
 	err = wait.PollImmediate(500*time.Millisecond, 300*time.Second, func() (bool, error) {
@@ -201,9 +251,12 @@ func (n *OvnNode) Start() error {
 	nodeAnnotator := kube.NewNodeAnnotator(n.Kube, node)
 	waiter := newStartupWaiter()
 
-	// Initialize gateway resources on the node
-	// FIXME DUAL-STACK
-	if err := n.initGateway(subnets[0], nodeAnnotator, waiter); err != nil {
+	// Clean up the gateway of a previous gateway mode before
+	// initializing the gateway resources on the node
+	if err := cleanupPreviousGatewayMode(node); err != nil {
+		return err
+	}
+	if err := n.initGateway(subnets, nodeAnnotator, waiter); err != nil {
 		return err
 	}
 
@@ -212,10 +265,34 @@ func (n *OvnNode) Start() error {
 		return err
 	}
 
+	// Publish the MTU of the overlay networks so that the master can
+	// detect nodes disagreeing on it
+	if err := util.SetNodeMTU(nodeAnnotator, config.Default.MTU); err != nil {
+		return err
+	}
+
+	// Publish the chassis ID the master checks the node's IPsec
+	// certificate requests against
+	var chassisID string
+	if config.IPsec.Enabled {
+		if chassisID, err = util.GetNodeChassisID(); err != nil {
+			return err
+		}
+		if err := util.SetNodeChassisID(nodeAnnotator, chassisID); err != nil {
+			return err
+		}
+	}
+
 	if err := nodeAnnotator.Run(); err != nil {
 		return fmt.Errorf("Failed to set node %s annotations: %v", n.name, err)
 	}
 
+	if config.IPsec.Enabled {
+		if err := n.startIPsec(chassisID); err != nil {
+			return err
+		}
+	}
+
 	// Wait for management port and gateway resources to be created by the master
 	klog.Infof("Waiting for gateway and management port readiness...")
 	start := time.Now()
@@ -84,7 +84,7 @@ func removeFromAddressSet(hashName string, address string) {
 	}
 }
 
-func createAddressSet(name string, hashName string,
+func createAddressSet(owner *OwnerReference, name string, hashName string,
 	addresses []string) {
 	klog.V(5).Infof("createAddressSet with %s and %s", name, addresses)
 	addressSet, stderr, err := util.RunOVNNbctl("--data=bare",
@@ -122,16 +122,14 @@ func createAddressSet(name string, hashName string,
 	}
 
 	// addressSet has not been created yet. Create it.
-	if len(addresses) == 0 {
-		_, stderr, err = util.RunOVNNbctl("create", "address_set",
-			fmt.Sprintf("name=%s", hashName),
-			fmt.Sprintf("external-ids:name=%s", name))
-	} else {
-		_, stderr, err = util.RunOVNNbctl("create", "address_set",
-			fmt.Sprintf("name=%s", hashName),
-			fmt.Sprintf("external-ids:name=%s", name),
-			fmt.Sprintf("addresses=%s", ips))
+	args := []string{"create", "address_set",
+		fmt.Sprintf("name=%s", hashName),
+		fmt.Sprintf("external-ids:name=%s", name)}
+	args = append(args, owner.externalIDs()...)
+	if len(addresses) != 0 {
+		args = append(args, fmt.Sprintf("addresses=%s", ips))
 	}
+	_, stderr, err = util.RunOVNNbctl(args...)
 	if err != nil {
 		klog.Errorf("failed to create address_set %s, stderr: %q (%v)",
 			name, stderr, err)
@@ -150,7 +148,7 @@ func deleteAddressSet(hashName string) {
 	}
 }
 
-func createPortGroup(name string, hashName string) (string, error) {
+func createPortGroup(owner *OwnerReference, name string, hashName string) (string, error) {
 	klog.V(5).Infof("createPortGroup with %s", name)
 	portGroup, stderr, err := util.RunOVNNbctl("--data=bare",
 		"--no-heading", "--columns=_uuid", "find", "port_group",
@@ -164,9 +162,11 @@ func createPortGroup(name string, hashName string) (string, error) {
 		return portGroup, nil
 	}
 
-	portGroup, stderr, err = util.RunOVNNbctl("create", "port_group",
+	args := []string{"create", "port_group",
 		fmt.Sprintf("name=%s", hashName),
-		fmt.Sprintf("external-ids:name=%s", name))
+		fmt.Sprintf("external-ids:name=%s", name)}
+	args = append(args, owner.externalIDs()...)
+	portGroup, stderr, err = util.RunOVNNbctl(args...)
 	if err != nil {
 		return "", fmt.Errorf("failed to create port_group %s, "+
 			"stderr: %q (%v)", name, stderr, err)
@@ -0,0 +1,275 @@
+package ovn
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+)
+
+// The internal state of the controller served by the debug endpoint of the
+// metrics server. Each dump copies the state under the locks protecting it.
+
+type debugPort struct {
+	Name          string     `json:"name"`
+	UUID          string     `json:"uuid"`
+	LogicalSwitch string     `json:"logicalSwitch"`
+	IP            string     `json:"ip"`
+	MAC           string     `json:"mac"`
+	Expires       *time.Time `json:"expires,omitempty"`
+}
+
+type debugNetworkPolicy struct {
+	PortGroupName string   `json:"portGroupName"`
+	PortGroupUUID string   `json:"portGroupUUID"`
+	LocalPods     []string `json:"localPods"`
+	Deleted       bool     `json:"deleted,omitempty"`
+}
+
+type debugNamespace struct {
+	// AddressSet maps the pod IPs in the namespace's address set to the
+	// pods' logical ports
+	AddressSet                map[string]string              `json:"addressSet"`
+	NetworkPolicies           map[string]*debugNetworkPolicy `json:"networkPolicies"`
+	HybridOverlayExternalGW   string                         `json:"hybridOverlayExternalGW,omitempty"`
+	HybridOverlayVTEP         string                         `json:"hybridOverlayVTEP,omitempty"`
+	MulticastEnabled          bool                           `json:"multicastEnabled"`
+	MulticastGroups           []string                       `json:"multicastGroups,omitempty"`
+	MulticastSourceNamespaces []string                       `json:"multicastSourceNamespaces,omitempty"`
+	QoSDefaults               map[string]string              `json:"qosDefaults,omitempty"`
+	RoutingExternalGWs        []string                       `json:"routingExternalGWs,omitempty"`
+	RoutingExternalPodGWs     map[string][]string            `json:"routingExternalPodGWs,omitempty"`
+	ProviderNetwork           string                         `json:"providerNetwork,omitempty"`
+}
+
+type debugLoadBalancerVIP struct {
+	Endpoints []string `json:"endpoints"`
+	RejectACL string   `json:"rejectACL,omitempty"`
+}
+
+type debugServiceVIP struct {
+	VIP      string `json:"vip"`
+	Protocol string `json:"protocol"`
+	Service  string `json:"service"`
+}
+
+type debugServices struct {
+	// LoadBalancers maps the load balancers to the configuration of their
+	// VIPs
+	LoadBalancers map[string]map[string]*debugLoadBalancerVIP `json:"loadBalancers"`
+	VIPs          []*debugServiceVIP                          `json:"vips"`
+}
+
+type debugDefaultDeny struct {
+	// Ingress and Egress map the logical ports to the number of policies
+	// selecting them
+	Ingress map[string]int `json:"ingress"`
+	Egress  map[string]int `json:"egress"`
+}
+
+func ipsToStrings(ips []net.IP) []string {
+	strs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		strs = append(strs, ip.String())
+	}
+	return strs
+}
+
+func ipToString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// portMatches returns true if the filter selects the pod of a logical port
+func portMatches(filter *metrics.DebugFilter, portName string) bool {
+	parts := strings.SplitN(portName, "_", 2)
+	if len(parts) != 2 {
+		return filter.Namespace == "" && filter.Pod == ""
+	}
+	return filter.MatchesPod(parts[0], parts[1])
+}
+
+func (oc *Controller) debugPorts(filter *metrics.DebugFilter) interface{} {
+	oc.logicalPortCache.RLock()
+	defer oc.logicalPortCache.RUnlock()
+	ports := make([]*debugPort, 0, len(oc.logicalPortCache.cache))
+	for name, info := range oc.logicalPortCache.cache {
+		if !portMatches(filter, name) {
+			continue
+		}
+		port := &debugPort{
+			Name:          info.name,
+			UUID:          info.uuid,
+			LogicalSwitch: info.logicalSwitch,
+			IP:            ipToString(info.ip),
+			MAC:           info.mac.String(),
+		}
+		if !info.expires.IsZero() {
+			expires := info.expires
+			port.Expires = &expires
+		}
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Name < ports[j].Name })
+	return ports
+}
+
+func (oc *Controller) debugNamespaces(filter *metrics.DebugFilter) interface{} {
+	oc.namespacesMutex.Lock()
+	names := make([]string, 0, len(oc.namespaces))
+	for name := range oc.namespaces {
+		if filter.MatchesNamespace(name) {
+			names = append(names, name)
+		}
+	}
+	oc.namespacesMutex.Unlock()
+
+	namespaces := make(map[string]*debugNamespace, len(names))
+	for _, name := range names {
+		nsInfo := oc.getNamespaceLocked(name)
+		if nsInfo == nil {
+			continue
+		}
+		ns := &debugNamespace{
+			AddressSet:                make(map[string]string),
+			NetworkPolicies:           make(map[string]*debugNetworkPolicy),
+			HybridOverlayExternalGW:   ipToString(nsInfo.hybridOverlayExternalGW),
+			HybridOverlayVTEP:         ipToString(nsInfo.hybridOverlayVTEP),
+			MulticastEnabled:          nsInfo.multicastEnabled,
+			MulticastGroups:           ipsToStrings(nsInfo.multicastPolicy.groups),
+			MulticastSourceNamespaces: nsInfo.multicastPolicy.sourceNamespaces,
+			QoSDefaults:               make(map[string]string),
+			RoutingExternalGWs:        ipsToStrings(nsInfo.routingExternalGWs.gws),
+			RoutingExternalPodGWs:     make(map[string][]string),
+			ProviderNetwork:           nsInfo.providerNetwork,
+		}
+		for ip, portName := range nsInfo.addressSet {
+			if portMatches(filter, portName) {
+				ns.AddressSet[ip] = portName
+			}
+		}
+		for key, value := range nsInfo.qosDefaults {
+			ns.QoSDefaults[key] = value
+		}
+		for pod, gwInfo := range nsInfo.routingExternalPodGWs {
+			ns.RoutingExternalPodGWs[pod] = ipsToStrings(gwInfo.gws)
+		}
+		for policyName, np := range nsInfo.networkPolicies {
+			np.Lock()
+			policy := &debugNetworkPolicy{
+				PortGroupName: np.portGroupName,
+				PortGroupUUID: np.portGroupUUID,
+				LocalPods:     make([]string, 0, len(np.localPods)),
+				Deleted:       np.deleted,
+			}
+			for portName := range np.localPods {
+				if portMatches(filter, portName) {
+					policy.LocalPods = append(policy.LocalPods, portName)
+				}
+			}
+			np.Unlock()
+			sort.Strings(policy.LocalPods)
+			ns.NetworkPolicies[policyName] = policy
+		}
+		nsInfo.Unlock()
+		namespaces[name] = ns
+	}
+	return namespaces
+}
+
+func (oc *Controller) debugServices(filter *metrics.DebugFilter) interface{} {
+	services := &debugServices{
+		LoadBalancers: make(map[string]map[string]*debugLoadBalancerVIP),
+		VIPs:          make([]*debugServiceVIP, 0),
+	}
+
+	// the VIPs of the selected services
+	selectedVIPs := make(map[string]bool)
+	oc.serviceVIPToNameLock.Lock()
+	for key, name := range oc.serviceVIPToName {
+		if !filter.MatchesService(name.Namespace, name.Name) {
+			continue
+		}
+		selectedVIPs[key.vip] = true
+		services.VIPs = append(services.VIPs, &debugServiceVIP{
+			VIP:      key.vip,
+			Protocol: string(key.protocol),
+			Service:  name.String(),
+		})
+	}
+	oc.serviceVIPToNameLock.Unlock()
+	sort.Slice(services.VIPs, func(i, j int) bool {
+		if services.VIPs[i].VIP != services.VIPs[j].VIP {
+			return services.VIPs[i].VIP < services.VIPs[j].VIP
+		}
+		return services.VIPs[i].Protocol < services.VIPs[j].Protocol
+	})
+
+	filtered := filter.Namespace != "" || filter.Service != ""
+	oc.serviceLBLock.Lock()
+	for lb, vips := range oc.serviceLBMap {
+		lbVIPs := make(map[string]*debugLoadBalancerVIP)
+		for vip, conf := range vips {
+			if filtered && !selectedVIPs[vip] {
+				continue
+			}
+			lbVIPs[vip] = &debugLoadBalancerVIP{
+				Endpoints: append([]string{}, conf.endpoints...),
+				RejectACL: conf.rejectACL,
+			}
+		}
+		if len(lbVIPs) > 0 || !filtered {
+			services.LoadBalancers[lb] = lbVIPs
+		}
+	}
+	oc.serviceLBLock.Unlock()
+	return services
+}
+
+func (oc *Controller) debugLogicalSwitches(filter *metrics.DebugFilter) interface{} {
+	oc.lsMutex.Lock()
+	defer oc.lsMutex.Unlock()
+	switches := make(map[string][]string, len(oc.logicalSwitchCache))
+	for name, subnets := range oc.logicalSwitchCache {
+		strs := make([]string, 0, len(subnets))
+		for _, subnet := range subnets {
+			strs = append(strs, subnet.String())
+		}
+		switches[name] = strs
+	}
+	return switches
+}
+
+func (oc *Controller) debugDefaultDeny(filter *metrics.DebugFilter) interface{} {
+	oc.lspMutex.Lock()
+	defer oc.lspMutex.Unlock()
+	defaultDeny := &debugDefaultDeny{
+		Ingress: make(map[string]int),
+		Egress:  make(map[string]int),
+	}
+	for portName, count := range oc.lspIngressDenyCache {
+		if portMatches(filter, portName) {
+			defaultDeny.Ingress[portName] = count
+		}
+	}
+	for portName, count := range oc.lspEgressDenyCache {
+		if portMatches(filter, portName) {
+			defaultDeny.Egress[portName] = count
+		}
+	}
+	return defaultDeny
+}
+
+// registerDebugState registers the caches of the controller with the debug
+// endpoint of the metrics server
+func (oc *Controller) registerDebugState() {
+	metrics.RegisterDebugState("master/ports", oc.debugPorts)
+	metrics.RegisterDebugState("master/namespaces", oc.debugNamespaces)
+	metrics.RegisterDebugState("master/services", oc.debugServices)
+	metrics.RegisterDebugState("master/logical-switches", oc.debugLogicalSwitches)
+	metrics.RegisterDebugState("master/default-deny", oc.debugDefaultDeny)
+}
@@ -0,0 +1,82 @@
+package ovn
+
+import (
+	"github.com/urfave/cli/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+
+	knet "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OVN controller debug state", func() {
+	var (
+		app     *cli.App
+		fakeOvn *FakeOVN
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fakeOvn = NewFakeOVN(ovntest.NewFakeExec())
+	})
+
+	AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	It("dumps the ports and namespaces of the selected pods", func() {
+		app.Action = func(ctx *cli.Context) error {
+			fakeOvn.start(ctx)
+			oc := fakeOvn.controller
+
+			oc.logicalPortCache.add("node1", "node1", "ns1_pod1", fakeUUID,
+				ovntest.MustParseMAC("0a:58:0a:80:01:03"), ovntest.MustParseIP("10.128.1.3"))
+			oc.logicalPortCache.add("node1", "node1", "ns1_pod2", fakeUUID,
+				ovntest.MustParseMAC("0a:58:0a:80:01:04"), ovntest.MustParseIP("10.128.1.4"))
+			oc.logicalPortCache.add("node1", "node1", "ns2_pod1", fakeUUID,
+				ovntest.MustParseMAC("0a:58:0a:80:01:05"), ovntest.MustParseIP("10.128.1.5"))
+
+			nsInfo := oc.createNamespaceLocked("ns1")
+			nsInfo.addressSet["10.128.1.3"] = "ns1_pod1"
+			nsInfo.addressSet["10.128.1.4"] = "ns1_pod2"
+			np := NewNamespacePolicy(newNetworkPolicy("policy1", "ns1", metav1.LabelSelector{},
+				[]knet.NetworkPolicyIngressRule{}, []knet.NetworkPolicyEgressRule{}))
+			np.portGroupName = hashedPortGroup("ns1_policy1")
+			np.localPods["ns1_pod1"] = nil
+			np.localPods["ns1_pod2"] = nil
+			nsInfo.networkPolicies["policy1"] = np
+			nsInfo.Unlock()
+
+			filter := &metrics.DebugFilter{Namespace: "ns1", Pod: "pod1"}
+			ports := oc.debugPorts(filter).([]*debugPort)
+			Expect(ports).To(HaveLen(1))
+			Expect(ports[0].Name).To(Equal("ns1_pod1"))
+			Expect(ports[0].IP).To(Equal("10.128.1.3"))
+			Expect(ports[0].MAC).To(Equal("0a:58:0a:80:01:03"))
+
+			namespaces := oc.debugNamespaces(filter).(map[string]*debugNamespace)
+			Expect(namespaces).To(HaveLen(1))
+			Expect(namespaces["ns1"].AddressSet).To(Equal(map[string]string{"10.128.1.3": "ns1_pod1"}))
+			Expect(namespaces["ns1"].NetworkPolicies["policy1"].PortGroupName).To(Equal(hashedPortGroup("ns1_policy1")))
+			Expect(namespaces["ns1"].NetworkPolicies["policy1"].LocalPods).To(Equal([]string{"ns1_pod1"}))
+
+			Expect(oc.debugPorts(&metrics.DebugFilter{})).To(HaveLen(3))
+			Expect(oc.debugNamespaces(&metrics.DebugFilter{Namespace: "ns2"})).To(BeEmpty())
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
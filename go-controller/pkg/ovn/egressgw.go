@@ -0,0 +1,404 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	utilnet "k8s.io/utils/net"
+)
+
+const (
+	// routingNamespaceAnnotation is set on a gateway pod and holds a
+	// comma-separated list of namespaces whose pods should use the gateway
+	// pod as their next hop for egress traffic
+	routingNamespaceAnnotation = "k8s.ovn.org/routing-namespaces"
+	// routingNetworkAnnotation is set on a gateway pod and names the network
+	// attachment whose IPs are used as next hops. Host-networked gateway pods
+	// may omit it, in which case the pod IPs are used.
+	routingNetworkAnnotation = "k8s.ovn.org/routing-network"
+	// routingExternalGWsAnnotation is set on a consumer namespace and holds a
+	// comma-separated list of static next hops for its pods' egress traffic
+	routingExternalGWsAnnotation = "k8s.ovn.org/routing-external-gws"
+	// bfdAnnotation, when set on a gateway pod or on a namespace with static
+	// gateways, enables BFD liveness detection for the gateway next hops
+	bfdAnnotation = "k8s.ovn.org/bfd-enabled"
+)
+
+// gatewayInfo holds the next hops provided by a single gateway source (a
+// gateway pod or a namespace's static gateway list)
+type gatewayInfo struct {
+	gws        []net.IP
+	bfdEnabled bool
+}
+
+func podIsExternalGW(pod *kapi.Pod) bool {
+	_, ok := pod.Annotations[routingNamespaceAnnotation]
+	return ok
+}
+
+func exGWPodKey(pod *kapi.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
+// exGWRetryKey returns the key of the gateway operations of a pod in the pod
+// retry queue. They are retried apart from the operations on the pod's
+// logical port.
+func exGWRetryKey(pod *kapi.Pod) string {
+	return "exgw/" + string(pod.UID)
+}
+
+// parseRoutingNamespaces returns the namespaces listed in a gateway pod's
+// routing-namespaces annotation
+func parseRoutingNamespaces(pod *kapi.Pod) []string {
+	var namespaces []string
+	for _, ns := range strings.Split(pod.Annotations[routingNamespaceAnnotation], ",") {
+		ns = strings.TrimSpace(ns)
+		if ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+// parseRoutingExternalGWAnnotation parses a comma-separated list of gateway IPs
+func parseRoutingExternalGWAnnotation(annotation string) ([]net.IP, error) {
+	var gws []net.IP
+	for _, gwStr := range strings.Split(annotation, ",") {
+		gwStr = strings.TrimSpace(gwStr)
+		if gwStr == "" {
+			continue
+		}
+		gw := net.ParseIP(gwStr)
+		if gw == nil {
+			return nil, fmt.Errorf("invalid gateway IP %q in annotation %q", gwStr, annotation)
+		}
+		gws = append(gws, gw)
+	}
+	return gws, nil
+}
+
+// getNamespaceExternalGWs returns the static gateways requested by a namespace
+func getNamespaceExternalGWs(ns *kapi.Namespace) (gatewayInfo, error) {
+	gws, err := parseRoutingExternalGWAnnotation(ns.Annotations[routingExternalGWsAnnotation])
+	if err != nil {
+		return gatewayInfo{}, err
+	}
+	_, bfdEnabled := ns.Annotations[bfdAnnotation]
+	return gatewayInfo{gws: gws, bfdEnabled: bfdEnabled}, nil
+}
+
+// getPodExternalGWs returns the next hops provided by a gateway pod
+func getPodExternalGWs(pod *kapi.Pod) (gatewayInfo, error) {
+	_, bfdEnabled := pod.Annotations[bfdAnnotation]
+	gw := gatewayInfo{bfdEnabled: bfdEnabled}
+
+	if network := pod.Annotations[routingNetworkAnnotation]; network != "" {
+		statuses, err := util.GetPodNetworkStatus(pod)
+		if err != nil {
+			return gw, err
+		}
+		for _, status := range statuses {
+			if status.Name != network {
+				continue
+			}
+			for _, ipStr := range status.IPs {
+				ip := net.ParseIP(ipStr)
+				if ip == nil {
+					return gw, fmt.Errorf("invalid IP %q in network status of %s", ipStr, network)
+				}
+				gw.gws = append(gw.gws, ip)
+			}
+			return gw, nil
+		}
+		return gw, fmt.Errorf("network %s not found in network status of gateway pod", network)
+	}
+
+	if !pod.Spec.HostNetwork {
+		return gw, fmt.Errorf("gateway pod must be host-networked or set the %s annotation",
+			routingNetworkAnnotation)
+	}
+	for _, podIP := range pod.Status.PodIPs {
+		if ip := net.ParseIP(podIP.IP); ip != nil {
+			gw.gws = append(gw.gws, ip)
+		}
+	}
+	if len(gw.gws) == 0 && pod.Status.PodIP != "" {
+		if ip := net.ParseIP(pod.Status.PodIP); ip != nil {
+			gw.gws = append(gw.gws, ip)
+		}
+	}
+	return gw, nil
+}
+
+// exGWRoutePrefix returns the prefix of the src-ip route that sends a pod's
+// egress traffic to an external gateway
+func exGWRoutePrefix(podIP net.IP) string {
+	if utilnet.IsIPv6(podIP) {
+		return podIP.String() + "/128"
+	}
+	return podIP.String() + "/32"
+}
+
+// addGWRoutesForPod adds ECMP routes on the node's gateway router that send
+// the pod's egress traffic to each of the external gateways
+func addGWRoutesForPod(gateways []gatewayInfo, podIP net.IP, node string) error {
+	gatewayRouter := gwRouterPrefix + node
+	prefix := exGWRoutePrefix(podIP)
+	for _, gateway := range gateways {
+		for _, gw := range gateway.gws {
+			if utilnet.IsIPv6(gw) != utilnet.IsIPv6(podIP) {
+				continue
+			}
+			args := []string{"--may-exist", "--policy=src-ip", "--ecmp-symmetric-reply"}
+			if gateway.bfdEnabled {
+				args = append(args, "--bfd")
+			}
+			args = append(args, "lr-route-add", gatewayRouter, prefix, gw.String(), "rtoe-"+gatewayRouter)
+			stdout, stderr, err := util.RunOVNNbctl(args...)
+			if err != nil {
+				return fmt.Errorf("failed to add external gateway route %s via %s on %s, "+
+					"stdout: %q, stderr: %q, error: %v", prefix, gw, gatewayRouter, stdout, stderr, err)
+			}
+		}
+	}
+	return nil
+}
+
+// deleteGWRoutesForPod removes the routes added by addGWRoutesForPod, and any
+// BFD sessions that are no longer used by a route
+func deleteGWRoutesForPod(gateways []gatewayInfo, podIP net.IP, node string) {
+	gatewayRouter := gwRouterPrefix + node
+	prefix := exGWRoutePrefix(podIP)
+	for _, gateway := range gateways {
+		for _, gw := range gateway.gws {
+			if utilnet.IsIPv6(gw) != utilnet.IsIPv6(podIP) {
+				continue
+			}
+			stdout, stderr, err := util.RunOVNNbctl("--if-exists", "--policy=src-ip", "lr-route-del",
+				gatewayRouter, prefix, gw.String(), "rtoe-"+gatewayRouter)
+			if err != nil {
+				klog.Errorf("Failed to delete external gateway route %s via %s on %s, "+
+					"stdout: %q, stderr: %q, error: %v", prefix, gw, gatewayRouter, stdout, stderr, err)
+				continue
+			}
+			if gateway.bfdEnabled {
+				cleanupBFD(gw, gatewayRouter)
+			}
+		}
+	}
+}
+
+// cleanupBFD destroys the BFD session to a gateway on the gateway router's
+// external port once no static route uses the gateway as its next hop
+func cleanupBFD(gw net.IP, gatewayRouter string) {
+	routes, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find",
+		"logical_router_static_route", "nexthop=\""+gw.String()+"\"", "output_port=\"rtoe-"+gatewayRouter+"\"")
+	if err != nil {
+		klog.Errorf("Failed to find routes via %s, stderr: %q, error: %v", gw, stderr, err)
+		return
+	}
+	if routes != "" {
+		return
+	}
+	sessions, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading", "--columns=_uuid", "find",
+		"bfd", "dst_ip=\""+gw.String()+"\"", "logical_port=\"rtoe-"+gatewayRouter+"\"")
+	if err != nil {
+		klog.Errorf("Failed to find BFD sessions to %s, stderr: %q, error: %v", gw, stderr, err)
+		return
+	}
+	for _, uuid := range strings.Fields(sessions) {
+		if _, stderr, err := util.RunOVNNbctl("--if-exists", "destroy", "bfd", uuid); err != nil {
+			klog.Errorf("Failed to destroy BFD session %s to %s, stderr: %q, error: %v", uuid, gw, stderr, err)
+		}
+	}
+}
+
+// externalGateways returns all the gateways in use by a namespace. nsInfo
+// must be locked.
+func (nsInfo *namespaceInfo) externalGateways() []gatewayInfo {
+	gateways := make([]gatewayInfo, 0, len(nsInfo.routingExternalPodGWs)+1)
+	if len(nsInfo.routingExternalGWs.gws) > 0 {
+		gateways = append(gateways, nsInfo.routingExternalGWs)
+	}
+	for _, gateway := range nsInfo.routingExternalPodGWs {
+		gateways = append(gateways, gateway)
+	}
+	return gateways
+}
+
+// forEachNamespacePort calls f with the IP and node of each of the
+// namespace's pods that has a logical port. nsInfo must be locked.
+func (oc *Controller) forEachNamespacePort(nsInfo *namespaceInfo, f func(podIP net.IP, node string)) {
+	for _, portName := range nsInfo.addressSet {
+		portInfo, err := oc.logicalPortCache.get(portName)
+		if err != nil {
+			// The pod's routes are added when its logical port is created
+			continue
+		}
+		f(portInfo.ip, portInfo.node)
+	}
+}
+
+// addGWRoutesForNode adds the external gateway routes of every pod on the
+// node, e.g. once the node's gateway router has been rebuilt
+func (oc *Controller) addGWRoutesForNode(node string) {
+	oc.namespacesMutex.Lock()
+	namespaces := make([]string, 0, len(oc.namespaces))
+	for namespace := range oc.namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	oc.namespacesMutex.Unlock()
+
+	for _, namespace := range namespaces {
+		nsInfo := oc.getNamespaceLocked(namespace)
+		if nsInfo == nil {
+			continue
+		}
+		gateways := nsInfo.externalGateways()
+		oc.forEachNamespacePort(nsInfo, func(podIP net.IP, podNode string) {
+			if podNode != node {
+				return
+			}
+			if err := addGWRoutesForPod(gateways, podIP, node); err != nil {
+				klog.Errorf("Failed to add external gateway routes for pod %s in namespace %s: %v",
+					podIP, namespace, err)
+			}
+		})
+		nsInfo.Unlock()
+	}
+}
+
+// addGWRoutesForNamespace adds routes via gateway for every pod in the
+// namespace. nsInfo must be locked.
+func (oc *Controller) addGWRoutesForNamespace(namespace string, nsInfo *namespaceInfo, gateway gatewayInfo) {
+	oc.forEachNamespacePort(nsInfo, func(podIP net.IP, node string) {
+		if err := addGWRoutesForPod([]gatewayInfo{gateway}, podIP, node); err != nil {
+			klog.Errorf("Failed to add external gateway routes for pod %s in namespace %s: %v",
+				podIP, namespace, err)
+		}
+	})
+}
+
+// unusedNextHops returns gateway without the next hops that one of the
+// gateways in use provides as well
+func unusedNextHops(gateway gatewayInfo, inUse []gatewayInfo) gatewayInfo {
+	unused := gatewayInfo{bfdEnabled: gateway.bfdEnabled}
+	for _, gw := range gateway.gws {
+		used := false
+		for _, other := range inUse {
+			for _, otherGW := range other.gws {
+				if gw.Equal(otherGW) {
+					used = true
+				}
+			}
+		}
+		if !used {
+			unused.gws = append(unused.gws, gw)
+		}
+	}
+	return unused
+}
+
+// deleteGWRoutesForNamespace removes the routes via gateway for every pod in
+// the namespace. The gateway must have been removed from nsInfo already: the
+// routes via next hops another gateway of the namespace provides are kept.
+// nsInfo must be locked.
+func (oc *Controller) deleteGWRoutesForNamespace(nsInfo *namespaceInfo, gateway gatewayInfo) {
+	gateway = unusedNextHops(gateway, nsInfo.externalGateways())
+	if len(gateway.gws) == 0 {
+		return
+	}
+	oc.forEachNamespacePort(nsInfo, func(podIP net.IP, node string) {
+		deleteGWRoutesForPod([]gatewayInfo{gateway}, podIP, node)
+	})
+}
+
+// updateNamespaceExternalGWs reconciles the static gateways of a namespace
+// with its annotations. nsInfo must be locked.
+func (oc *Controller) updateNamespaceExternalGWs(ns *kapi.Namespace, nsInfo *namespaceInfo) {
+	gateway, err := getNamespaceExternalGWs(ns)
+	if err != nil {
+		klog.Errorf("Could not parse external gateways of namespace %s: %v", ns.Name, err)
+		return
+	}
+	if reflect.DeepEqual(gateway, nsInfo.routingExternalGWs) {
+		return
+	}
+	oldGateway := nsInfo.routingExternalGWs
+	nsInfo.routingExternalGWs = gatewayInfo{}
+	oc.deleteGWRoutesForNamespace(nsInfo, oldGateway)
+	nsInfo.routingExternalGWs = gateway
+	oc.addGWRoutesForNamespace(ns.Name, nsInfo, gateway)
+}
+
+// addPodExternalGW routes the egress traffic of the namespaces served by a
+// gateway pod through it
+func (oc *Controller) addPodExternalGW(pod *kapi.Pod) error {
+	gateway, err := getPodExternalGWs(pod)
+	if err != nil {
+		return fmt.Errorf("failed to get next hops of gateway pod %s: %v", exGWPodKey(pod), err)
+	}
+	klog.Infof("External gateway pod %s serving namespaces %s with next hops %v",
+		exGWPodKey(pod), pod.Annotations[routingNamespaceAnnotation], gateway.gws)
+
+	for _, namespace := range parseRoutingNamespaces(pod) {
+		nsInfo, err := oc.requireNamespaceLocked(namespace)
+		if err != nil {
+			return fmt.Errorf("failed to add gateway pod %s to namespace %s: %v", exGWPodKey(pod), namespace, err)
+		}
+		oldGateway, ok := nsInfo.routingExternalPodGWs[exGWPodKey(pod)]
+		if !ok || !reflect.DeepEqual(oldGateway, gateway) {
+			if ok {
+				delete(nsInfo.routingExternalPodGWs, exGWPodKey(pod))
+				oc.deleteGWRoutesForNamespace(nsInfo, oldGateway)
+			}
+			nsInfo.routingExternalPodGWs[exGWPodKey(pod)] = gateway
+			oc.addGWRoutesForNamespace(namespace, nsInfo, gateway)
+		}
+		nsInfo.Unlock()
+	}
+	return nil
+}
+
+// deletePodExternalGW removes a gateway pod from the given namespaces
+func (oc *Controller) deletePodExternalGW(pod *kapi.Pod, namespaces []string) {
+	for _, namespace := range namespaces {
+		nsInfo := oc.getNamespaceLocked(namespace)
+		if nsInfo == nil {
+			continue
+		}
+		if gateway, ok := nsInfo.routingExternalPodGWs[exGWPodKey(pod)]; ok {
+			klog.Infof("Removing external gateway pod %s from namespace %s", exGWPodKey(pod), namespace)
+			delete(nsInfo.routingExternalPodGWs, exGWPodKey(pod))
+			oc.deleteGWRoutesForNamespace(nsInfo, gateway)
+		}
+		nsInfo.Unlock()
+	}
+}
+
+// updatePodExternalGW handles changes to a gateway pod's annotations or IPs
+func (oc *Controller) updatePodExternalGW(oldPod, newPod *kapi.Pod) error {
+	if !podIsExternalGW(newPod) {
+		oc.deletePodExternalGW(oldPod, parseRoutingNamespaces(oldPod))
+		return nil
+	}
+
+	newNamespaces := make(map[string]bool)
+	for _, namespace := range parseRoutingNamespaces(newPod) {
+		newNamespaces[namespace] = true
+	}
+	var removed []string
+	for _, namespace := range parseRoutingNamespaces(oldPod) {
+		if !newNamespaces[namespace] {
+			removed = append(removed, namespace)
+		}
+	}
+	oc.deletePodExternalGW(oldPod, removed)
+	return oc.addPodExternalGW(newPod)
+}
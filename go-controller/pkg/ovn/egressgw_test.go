@@ -0,0 +1,392 @@
+package ovn
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OVN External Gateway Operations", func() {
+	var (
+		app     *cli.App
+		fakeOvn *FakeOVN
+		fExec   *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fExec = ovntest.NewFakeExec()
+		fakeOvn = NewFakeOVN(fExec)
+	})
+
+	AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	It("adds routes for existing pods in a namespace with static gateways", func() {
+		app.Action = func(ctx *cli.Context) error {
+			test := namespace{}
+			namespaceT := *newNamespace("namespace1")
+			namespaceT.Annotations[routingExternalGWsAnnotation] = "9.0.0.1,fd00::1"
+			namespaceT.Annotations[bfdAnnotation] = ""
+			tP := newTPod(
+				"node1",
+				"10.128.1.0/24",
+				"10.128.1.2",
+				"10.128.1.1",
+				"myPod",
+				"10.128.1.4",
+				"11:22:33:44:55:66",
+				namespaceT.Name,
+			)
+
+			test.baseCmds(fExec, namespaceT)
+			test.addCmdsWithPods(fExec, tP, namespaceT)
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --may-exist --policy=src-ip --ecmp-symmetric-reply --bfd lr-route-add GR_node1 10.128.1.4/32 9.0.0.1 rtoe-GR_node1",
+			})
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{
+					Items: []v1.Namespace{
+						namespaceT,
+					},
+				},
+				&v1.PodList{
+					Items: []v1.Pod{
+						*newPod(namespaceT.Name, tP.podName, tP.nodeName, tP.podIP),
+					},
+				},
+			)
+			podMAC := ovntest.MustParseMAC(tP.podMAC)
+			fakeOvn.controller.logicalPortCache.add(tP.nodeName, tP.nodeName, tP.portName, fakeUUID, podMAC, ovntest.MustParseIP(tP.podIP))
+			fakeOvn.controller.WatchNamespaces()
+
+			_, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(context.TODO(), namespaceT.Name, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("re-adds the routes of the pods on a node whose gateway router was rebuilt", func() {
+		app.Action = func(ctx *cli.Context) error {
+			test := namespace{}
+			namespaceT := *newNamespace("namespace1")
+			namespaceT.Annotations[routingExternalGWsAnnotation] = "9.0.0.1"
+			tP := newTPod(
+				"node1",
+				"10.128.1.0/24",
+				"10.128.1.2",
+				"10.128.1.1",
+				"myPod",
+				"10.128.1.4",
+				"11:22:33:44:55:66",
+				namespaceT.Name,
+			)
+			otherTP := newTPod(
+				"node2",
+				"10.128.2.0/24",
+				"10.128.2.2",
+				"10.128.2.1",
+				"otherPod",
+				"10.128.2.4",
+				"11:22:33:44:55:77",
+				namespaceT.Name,
+			)
+
+			test.baseCmds(fExec, namespaceT)
+			test.addCmdsWithPods(fExec, tP, namespaceT)
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{
+					Items: []v1.Namespace{
+						namespaceT,
+					},
+				},
+				&v1.PodList{
+					Items: []v1.Pod{
+						*newPod(namespaceT.Name, tP.podName, tP.nodeName, tP.podIP),
+					},
+				},
+			)
+			fakeOvn.controller.WatchNamespaces()
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			podMAC := ovntest.MustParseMAC(tP.podMAC)
+			fakeOvn.controller.logicalPortCache.add(tP.nodeName, tP.nodeName, tP.portName, fakeUUID, podMAC, ovntest.MustParseIP(tP.podIP))
+			otherPodMAC := ovntest.MustParseMAC(otherTP.podMAC)
+			portInfo := fakeOvn.controller.logicalPortCache.add(otherTP.nodeName, otherTP.nodeName, otherTP.portName, fakeUUID, otherPodMAC, ovntest.MustParseIP(otherTP.podIP))
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --may-exist --policy=src-ip --ecmp-symmetric-reply lr-route-add GR_node2 10.128.2.4/32 9.0.0.1 rtoe-GR_node2",
+			})
+			test.addPodCmds(fExec, otherTP, namespaceT, false)
+			err := fakeOvn.controller.addPodToNamespace(namespaceT.Name, portInfo)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			// The gateway router of node1 was rebuilt for a gateway mode
+			// change, only the routes of the pods on node1 are re-added
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --may-exist --policy=src-ip --ecmp-symmetric-reply lr-route-add GR_node1 10.128.1.4/32 9.0.0.1 rtoe-GR_node1",
+			})
+			fakeOvn.controller.addGWRoutesForNode("node1")
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("adds and removes routes via a gateway pod", func() {
+		app.Action = func(ctx *cli.Context) error {
+			test := namespace{}
+			namespaceT := *newNamespace("namespace1")
+			tP := newTPod(
+				"node1",
+				"10.128.1.0/24",
+				"10.128.1.2",
+				"10.128.1.1",
+				"myPod",
+				"10.128.1.4",
+				"11:22:33:44:55:66",
+				namespaceT.Name,
+			)
+			gwPod := newPod("gwns", "gwpod", "node2", "172.16.0.5")
+			gwPod.Spec.HostNetwork = true
+			gwPod.Annotations = map[string]string{
+				routingNamespaceAnnotation: namespaceT.Name,
+				bfdAnnotation:              "",
+			}
+
+			test.baseCmds(fExec, namespaceT)
+			test.addCmdsWithPods(fExec, tP, namespaceT)
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{
+					Items: []v1.Namespace{
+						namespaceT,
+					},
+				},
+				&v1.PodList{
+					Items: []v1.Pod{
+						*newPod(namespaceT.Name, tP.podName, tP.nodeName, tP.podIP),
+					},
+				},
+			)
+			podMAC := ovntest.MustParseMAC(tP.podMAC)
+			fakeOvn.controller.logicalPortCache.add(tP.nodeName, tP.nodeName, tP.portName, fakeUUID, podMAC, ovntest.MustParseIP(tP.podIP))
+			fakeOvn.controller.WatchNamespaces()
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --may-exist --policy=src-ip --ecmp-symmetric-reply --bfd lr-route-add GR_node1 10.128.1.4/32 172.16.0.5 rtoe-GR_node1",
+			})
+			err := fakeOvn.controller.addPodExternalGW(gwPod)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists --policy=src-ip lr-route-del GR_node1 10.128.1.4/32 172.16.0.5 rtoe-GR_node1",
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_router_static_route nexthop=\"172.16.0.5\" output_port=\"rtoe-GR_node1\"",
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find bfd dst_ip=\"172.16.0.5\" logical_port=\"rtoe-GR_node1\"",
+				Output: fakeUUID,
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists destroy bfd " + fakeUUID,
+			})
+			fakeOvn.controller.deletePodExternalGW(gwPod, parseRoutingNamespaces(gwPod))
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("keeps the routes via a next hop that another gateway still provides", func() {
+		app.Action = func(ctx *cli.Context) error {
+			test := namespace{}
+			namespaceT := *newNamespace("namespace1")
+			namespaceT.Annotations[routingExternalGWsAnnotation] = "172.16.0.5"
+			tP := newTPod(
+				"node1",
+				"10.128.1.0/24",
+				"10.128.1.2",
+				"10.128.1.1",
+				"myPod",
+				"10.128.1.4",
+				"11:22:33:44:55:66",
+				namespaceT.Name,
+			)
+			gwPod := newPod("gwns", "gwpod", "node2", "172.16.0.5")
+			gwPod.Spec.HostNetwork = true
+			gwPod.Annotations = map[string]string{routingNamespaceAnnotation: namespaceT.Name}
+
+			test.baseCmds(fExec, namespaceT)
+			test.addCmdsWithPods(fExec, tP, namespaceT)
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --may-exist --policy=src-ip --ecmp-symmetric-reply lr-route-add GR_node1 10.128.1.4/32 172.16.0.5 rtoe-GR_node1",
+			})
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{
+					Items: []v1.Namespace{
+						namespaceT,
+					},
+				},
+				&v1.PodList{
+					Items: []v1.Pod{
+						*newPod(namespaceT.Name, tP.podName, tP.nodeName, tP.podIP),
+					},
+				},
+			)
+			podMAC := ovntest.MustParseMAC(tP.podMAC)
+			fakeOvn.controller.logicalPortCache.add(tP.nodeName, tP.nodeName, tP.portName, fakeUUID, podMAC, ovntest.MustParseIP(tP.podIP))
+			fakeOvn.controller.WatchNamespaces()
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --may-exist --policy=src-ip --ecmp-symmetric-reply lr-route-add GR_node1 10.128.1.4/32 172.16.0.5 rtoe-GR_node1",
+			})
+			err := fakeOvn.controller.addPodExternalGW(gwPod)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			// The gateway pod still provides the next hop the namespace
+			// stops listing, so the route is kept
+			namespaceT.Annotations = map[string]string{}
+			_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(context.TODO(), &namespaceT, metav1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() bool {
+				nsInfo := fakeOvn.controller.getNamespaceLocked(namespaceT.Name)
+				defer nsInfo.Unlock()
+				return len(nsInfo.routingExternalGWs.gws) == 0
+			}).Should(BeTrue())
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			// Once nothing provides the next hop anymore, the route goes
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists --policy=src-ip lr-route-del GR_node1 10.128.1.4/32 172.16.0.5 rtoe-GR_node1",
+			})
+			fakeOvn.controller.deletePodExternalGW(gwPod, parseRoutingNamespaces(gwPod))
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("retries adding a gateway pod until the namespaces it serves exist", func() {
+		app.Action = func(ctx *cli.Context) error {
+			test := namespace{}
+			namespaceT := *newNamespace("namespace1")
+			gwPod := newPod("gwns", "gwpod", "node2", "172.16.0.5")
+			gwPod.Spec.HostNetwork = true
+			gwPod.Annotations = map[string]string{routingNamespaceAnnotation: namespaceT.Name}
+
+			test.baseCmds(fExec)
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_switch_port external_ids:pod=true",
+			})
+
+			fakeOvn.start(ctx,
+				&v1.PodList{
+					Items: []v1.Pod{
+						*gwPod,
+					},
+				},
+			)
+			fakeOvn.controller.WatchNamespaces()
+			fakeOvn.controller.WatchPods()
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			test.addCmds(fExec, namespaceT)
+			_, err := fakeOvn.fakeClient.CoreV1().Namespaces().Create(context.TODO(), &namespaceT, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() bool {
+				nsInfo := fakeOvn.controller.getNamespaceLocked(namespaceT.Name)
+				if nsInfo == nil {
+					return false
+				}
+				defer nsInfo.Unlock()
+				_, ok := nsInfo.routingExternalPodGWs[exGWPodKey(gwPod)]
+				return ok
+			}, 3*time.Second).Should(BeTrue())
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			// The logical port of a gateway pod on the pod network does not
+			// wait for the namespaces the pod serves
+			podNetworkGWPod := newPod("gwns", "gwpod2", "node2", "10.128.2.5")
+			podNetworkGWPod.Annotations = map[string]string{
+				routingNamespaceAnnotation: "namespace2",
+				routingNetworkAnnotation:   "sriov",
+			}
+			Expect(fakeOvn.controller.podDependencies(podNetworkGWPod)).To(ConsistOf(
+				factory.Dependency{Kind: factory.NodeSwitchDependency, Name: "node2"}))
+
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("OVN External Gateway annotation parsing", func() {
+	It("gets gateway pod next hops from the routing network status", func() {
+		gwPod := newPod("gwns", "gwpod", "node2", "10.128.2.5")
+		gwPod.Annotations = map[string]string{
+			routingNamespaceAnnotation: "namespace1",
+			routingNetworkAnnotation:   "dummy",
+			util.NetworkStatusAnnotation: `[{"name":"ovn-kubernetes","ips":["10.128.2.5"],"default":true},` +
+				`{"name":"dummy","interface":"net1","ips":["11.0.0.5","fd00::5"]}]`,
+		}
+		gateway, err := getPodExternalGWs(gwPod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gateway.bfdEnabled).To(BeFalse())
+		Expect(gateway.gws).To(Equal([]net.IP{net.ParseIP("11.0.0.5"), net.ParseIP("fd00::5")}))
+
+		// A pod network gateway without a routing network has no usable next hop
+		delete(gwPod.Annotations, routingNetworkAnnotation)
+		_, err = getPodExternalGWs(gwPod)
+		Expect(err).To(HaveOccurred())
+
+		gwPod.Spec.HostNetwork = true
+		gwPod.Annotations[bfdAnnotation] = ""
+		gateway, err = getPodExternalGWs(gwPod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gateway.bfdEnabled).To(BeTrue())
+		Expect(gateway.gws).To(Equal([]net.IP{net.ParseIP("10.128.2.5")}))
+	})
+})
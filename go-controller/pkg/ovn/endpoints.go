@@ -3,6 +3,7 @@ package ovn
 import (
 	"fmt"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/logging"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	kapi "k8s.io/api/core/v1"
@@ -20,12 +21,13 @@ func (ovn *Controller) getLbEndpoints(ep *kapi.Endpoints) map[kapi.Protocol]map[
 		kapi.ProtocolUDP:  make(map[string]lbEndpoints),
 		kapi.ProtocolSCTP: make(map[string]lbEndpoints),
 	}
+	logCtx := logging.Service(ep.Namespace, ep.Name)
 	for _, s := range ep.Subsets {
 		for _, ip := range s.Addresses {
 			for _, port := range s.Ports {
 				var ips []string
 				if _, err := util.ValidateProtocol(port.Protocol); err != nil {
-					klog.Errorf("Invalid endpoint port: %s: %v", port.Name, err)
+					klog.Errorf("%s Invalid endpoint port: %s: %v", logCtx, port.Name, err)
 					continue
 				}
 				if lbEps, ok := protoPortMap[port.Protocol][port.Name]; ok {
@@ -37,46 +39,48 @@ func (ovn *Controller) getLbEndpoints(ep *kapi.Endpoints) map[kapi.Protocol]map[
 			}
 		}
 	}
-	klog.V(5).Infof("Endpoint Protocol Map is: %v", protoPortMap)
+	klog.V(5).Infof("%s Endpoint Protocol Map is: %v", logCtx, protoPortMap)
 	return protoPortMap
 }
 
 // AddEndpoints adds endpoints and creates corresponding resources in OVN
 func (ovn *Controller) AddEndpoints(ep *kapi.Endpoints) error {
-	klog.V(5).Infof("Adding endpoints: %s for namespace: %s", ep.Name, ep.Namespace)
+	logCtx := logging.Service(ep.Namespace, ep.Name).Operation("add")
+	klog.V(5).Infof("%s Adding endpoints: %s for namespace: %s", logCtx, ep.Name, ep.Namespace)
 	// get service
 	// TODO: cache the service
 	svc, err := ovn.watchFactory.GetService(ep.Namespace, ep.Name)
 	if err != nil {
 		// This is not necessarily an error. For e.g when there are endpoints
 		// without a corresponding service.
-		klog.V(5).Infof("no service found for endpoint %s in namespace %s",
-			ep.Name, ep.Namespace)
+		klog.V(5).Infof("%s no service found for endpoint %s in namespace %s",
+			logCtx, ep.Name, ep.Namespace)
 		return nil
 	}
-	if !util.IsClusterIPSet(svc) {
-		klog.V(5).Infof("Skipping service %s due to clusterIP = %q",
-			svc.Name, svc.Spec.ClusterIP)
+	clusterIPs := util.GetClusterIPs(svc)
+	if len(clusterIPs) == 0 {
+		klog.V(5).Infof("%s Skipping service %s due to clusterIP = %q",
+			logCtx, svc.Name, svc.Spec.ClusterIP)
 		return nil
 	}
-	klog.V(5).Infof("Matching service %s found for ep: %s, with cluster IP: %s", svc.Name, ep.Name,
-		svc.Spec.ClusterIP)
+	klog.V(5).Infof("%s Matching service %s found for ep: %s, with cluster IPs: %v", logCtx, svc.Name, ep.Name,
+		clusterIPs)
 
 	protoPortMap := ovn.getLbEndpoints(ep)
-	klog.V(5).Infof("Matching service %s ports: %v", svc.Name, svc.Spec.Ports)
+	klog.V(5).Infof("%s Matching service %s ports: %v", logCtx, svc.Name, svc.Spec.Ports)
 	for _, svcPort := range svc.Spec.Ports {
 		lbEps, isFound := protoPortMap[svcPort.Protocol][svcPort.Name]
 		if !isFound {
 			continue
 		}
 		if !ovn.SCTPSupport && svcPort.Protocol == kapi.ProtocolSCTP {
-			klog.Errorf("Rejecting endpoint creation for unsupported SCTP protocol: %s, %s", ep.Namespace, ep.Name)
+			klog.Errorf("%s Rejecting endpoint creation for unsupported SCTP protocol: %s, %s", logCtx, ep.Namespace, ep.Name)
 			continue
 		}
 		if util.ServiceTypeHasNodePort(svc) {
 			err = ovn.createGatewayVIPs(svcPort.Protocol, svcPort.NodePort, lbEps.IPs, lbEps.Port)
 			if err != nil {
-				klog.Errorf("Error in creating Node Port for svc %s, node port: %d - %v\n", svc.Name, svcPort.NodePort, err)
+				klog.Errorf("%s Error in creating Node Port for svc %s, node port: %d - %v\n", logCtx, svc.Name, svcPort.NodePort, err)
 				continue
 			}
 		}
@@ -84,15 +88,17 @@ func (ovn *Controller) AddEndpoints(ep *kapi.Endpoints) error {
 			var loadBalancer string
 			loadBalancer, err = ovn.getLoadBalancer(svcPort.Protocol)
 			if err != nil {
-				klog.Errorf("Failed to get loadbalancer for %s (%v)", svcPort.Protocol, err)
+				klog.Errorf("%s Failed to get loadbalancer for %s (%v)", logCtx, svcPort.Protocol, err)
 				continue
 			}
-			if err = ovn.createLoadBalancerVIPs(loadBalancer, []string{svc.Spec.ClusterIP}, svcPort.Port, lbEps.IPs, lbEps.Port); err != nil {
-				klog.Errorf("Error in creating Cluster IP for svc %s, target port: %d - %v\n", svc.Name, lbEps.Port, err)
+			if err = ovn.createLoadBalancerVIPs(loadBalancer, clusterIPs, svcPort.Port, lbEps.IPs, lbEps.Port); err != nil {
+				klog.Errorf("%s Error in creating Cluster IP for svc %s, target port: %d - %v\n", logCtx, svc.Name, lbEps.Port, err)
 				continue
 			}
-			vip := util.JoinHostPortInt32(svc.Spec.ClusterIP, svcPort.Port)
-			ovn.AddServiceVIPToName(vip, svcPort.Protocol, svc.Namespace, svc.Name)
+			for _, clusterIP := range clusterIPs {
+				vip := util.JoinHostPortInt32(clusterIP, svcPort.Port)
+				ovn.AddServiceVIPToName(vip, svcPort.Protocol, svc.Namespace, svc.Name)
+			}
 			ovn.handleExternalIPs(svc, svcPort, lbEps.IPs, lbEps.Port, false)
 		}
 	}
@@ -112,7 +118,7 @@ func (ovn *Controller) handleNodePortLB(node *kapi.Node) error {
 	for _, ns := range namespaces {
 		endpoints, err := ovn.watchFactory.GetEndpoints(ns.Name)
 		if err != nil {
-			klog.Errorf("failed to get k8s endpoints: %v", err)
+			klog.Errorf("%s failed to get k8s endpoints: %v", logging.Namespace(ns.Name), err)
 			continue
 		}
 		for _, ep := range endpoints {
@@ -135,7 +141,8 @@ func (ovn *Controller) handleNodePortLB(node *kapi.Node) error {
 				}
 				err = ovn.createLoadBalancerVIPs(k8sNSLb, physicalIPs, svcPort.NodePort, lbEps.IPs, lbEps.Port)
 				if err != nil {
-					klog.Errorf("failed to create VIP in load balancer %s - %v", k8sNSLb, err)
+					klog.Errorf("%s failed to create VIP in load balancer %s - %v",
+						logging.Service(svc.Namespace, svc.Name).Operation("update"), k8sNSLb, err)
 					continue
 				}
 			}
@@ -155,7 +162,7 @@ func (ovn *Controller) updateExternalIPsLB() {
 	for _, ns := range namespaces {
 		endpoints, err := ovn.watchFactory.GetEndpoints(ns.Name)
 		if err != nil {
-			klog.Errorf("failed to get k8s endpoints: %v", err)
+			klog.Errorf("%s failed to get k8s endpoints: %v", logging.Namespace(ns.Name), err)
 			continue
 		}
 		for _, ep := range endpoints {
@@ -189,67 +196,73 @@ func (ovn *Controller) updateExternalIPsLB() {
 // the behavior changes to remove the load balancer VIP for services with external ips
 func (ovn *Controller) handleExternalIPs(svc *kapi.Service, svcPort kapi.ServicePort, ips []string, targetPort int32,
 	removeLoadBalancerVIP bool) {
-	klog.V(5).Infof("handling external IPs for svc %v", svc.Name)
+	logCtx := logging.Service(svc.Namespace, svc.Name)
+	klog.V(5).Infof("%s handling external IPs for svc %v", logCtx, svc.Name)
 	if len(svc.Spec.ExternalIPs) == 0 {
 		return
 	}
 	lb := ovn.getDefaultGatewayLoadBalancer(svcPort.Protocol)
 	if lb == "" {
-		klog.Warningf("No default gateway found for protocol %s\n\tNote: 'nodeport' flag needs to be enabled for default gateway", svcPort.Protocol)
+		klog.Warningf("%s No default gateway found for protocol %s\n\tNote: 'nodeport' flag needs to be enabled for default gateway",
+			logCtx, svcPort.Protocol)
 		return
 	}
 
 	if removeLoadBalancerVIP {
 		for _, extIP := range svc.Spec.ExternalIPs {
 			vip := util.JoinHostPortInt32(extIP, svcPort.Port)
-			klog.V(5).Infof("Removing external VIP: %s from load balancer: %s", vip, lb)
+			klog.V(5).Infof("%s Removing external VIP: %s from load balancer: %s", logCtx, vip, lb)
 			ovn.deleteLoadBalancerVIP(lb, vip)
 		}
 	} else {
 		err := ovn.createLoadBalancerVIPs(lb, svc.Spec.ExternalIPs, svcPort.Port, ips, targetPort)
 		if err != nil {
-			klog.Errorf("Error in creating external IPs for service: %s", svc.Name)
+			klog.Errorf("%s Error in creating external IPs for service: %s", logCtx, svc.Name)
 		}
 	}
 }
 
 func (ovn *Controller) deleteEndpoints(ep *kapi.Endpoints) error {
-	klog.V(5).Infof("Deleting endpoints: %s for namespace: %s", ep.Name, ep.Namespace)
+	logCtx := logging.Service(ep.Namespace, ep.Name).Operation("delete")
+	klog.V(5).Infof("%s Deleting endpoints: %s for namespace: %s", logCtx, ep.Name, ep.Namespace)
 	svc, err := ovn.watchFactory.GetService(ep.Namespace, ep.Name)
 	if err != nil {
 		// This is not necessarily an error. For e.g when a service is deleted,
 		// you will get endpoint delete event and the call to fetch service
 		// will fail.
-		klog.V(5).Infof("no service found for endpoint %s in namespace %s", ep.Name, ep.Namespace)
+		klog.V(5).Infof("%s no service found for endpoint %s in namespace %s", logCtx, ep.Name, ep.Namespace)
 		return nil
 	}
-	if !util.IsClusterIPSet(svc) {
+	clusterIPs := util.GetClusterIPs(svc)
+	if len(clusterIPs) == 0 {
 		return nil
 	}
 	for _, svcPort := range svc.Spec.Ports {
 		var lb string
 		lb, err = ovn.getLoadBalancer(svcPort.Protocol)
 		if err != nil {
-			klog.Errorf("Failed to get load-balancer for %s (%v)", lb, err)
+			klog.Errorf("%s Failed to get load-balancer for %s (%v)", logCtx, lb, err)
 			continue
 		}
 
-		// apply reject ACL if necessary before deleting endpoints (avoids unwanted traffic events hitting OVN/OVS)
-		if ovn.svcQualifiesForReject(svc) {
-			aclUUID, err := ovn.createLoadBalancerRejectACL(lb, svc.Spec.ClusterIP, svcPort.Port, svcPort.Protocol)
-			if err != nil {
-				klog.Errorf("Failed to create reject ACL for load balancer: %s, error: %v", lb, err)
+		for _, clusterIP := range clusterIPs {
+			// apply reject ACL if necessary before deleting endpoints (avoids unwanted traffic events hitting OVN/OVS)
+			if ovn.svcQualifiesForReject(svc) {
+				aclUUID, err := ovn.createLoadBalancerRejectACL(svc, lb, clusterIP, svcPort.Port, svcPort.Protocol)
+				if err != nil {
+					klog.Errorf("%s Failed to create reject ACL for load balancer: %s, error: %v", logCtx, lb, err)
+				}
+				klog.V(5).Infof("%s Reject ACL created for load balancer: %s, %s", logCtx, lb, aclUUID)
 			}
-			klog.V(5).Infof("Reject ACL created for load balancer: %s, %s", lb, aclUUID)
-		}
 
-		// clear endpoints from the LB
-		err := ovn.configureLoadBalancer(lb, svc.Spec.ClusterIP, svcPort.Port, nil)
-		if err != nil {
-			klog.Errorf("Error in deleting endpoints for lb %s: %v", lb, err)
+			// clear endpoints from the LB
+			err := ovn.configureLoadBalancer(lb, clusterIP, svcPort.Port, nil)
+			if err != nil {
+				klog.Errorf("%s Error in deleting endpoints for lb %s: %v", logCtx, lb, err)
+			}
+			vip := util.JoinHostPortInt32(clusterIP, svcPort.Port)
+			ovn.removeServiceEndpoints(lb, vip)
 		}
-		vip := util.JoinHostPortInt32(svc.Spec.ClusterIP, svcPort.Port)
-		ovn.removeServiceEndpoints(lb, vip)
 
 		if util.ServiceTypeHasNodePort(svc) {
 			ovn.deleteGatewayVIPs(svcPort.Protocol, svcPort.NodePort)
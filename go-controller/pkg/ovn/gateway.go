@@ -7,10 +7,12 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/logging"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	kapi "k8s.io/api/core/v1"
 	"k8s.io/klog"
+	utilnet "k8s.io/utils/net"
 )
 
 const (
@@ -205,3 +207,89 @@ func getGatewayLoadBalancers(gatewayRouter string) (string, string, string, erro
 	}
 	return lbTCP, lbUDP, lbSCTP, nil
 }
+
+// staleGatewayIPs returns the physical IPs of a node's gateway that are no
+// longer in its L3 gateway annotation, after ovnkube-node noticed that the
+// addresses of the gateway interface changed.
+func staleGatewayIPs(oldNode, newNode *kapi.Node) []string {
+	oldL3GatewayConfig, err := util.ParseNodeL3GatewayAnnotation(oldNode)
+	if err != nil {
+		return nil
+	}
+	l3GatewayConfig, err := util.ParseNodeL3GatewayAnnotation(newNode)
+	if err != nil {
+		return nil
+	}
+	if oldL3GatewayConfig.Mode != l3GatewayConfig.Mode {
+		// the gateway router is rebuilt from scratch
+		return nil
+	}
+
+	var staleIPs []string
+	for _, oldIP := range oldL3GatewayConfig.IPAddresses {
+		found := false
+		for _, ip := range l3GatewayConfig.IPAddresses {
+			if ip.IP.Equal(oldIP.IP) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			staleIPs = append(staleIPs, oldIP.IP.String())
+		}
+	}
+	return staleIPs
+}
+
+// updateGatewayIPs moves the NodePort VIPs of a node's gateway router from
+// its stale physical IPs to the ones gatewayInit configured, and removes the
+// distributed router's routes to the stale host addresses.
+func (ovn *Controller) updateGatewayIPs(node *kapi.Node, staleIPs []string) error {
+	for _, ip := range staleIPs {
+		hostAddr := ip + "/32"
+		if utilnet.IsIPv6String(ip) {
+			hostAddr = ip + "/128"
+		}
+		_, stderr, err := util.RunOVNNbctl("--if-exists", "lr-route-del", ovnClusterRouter, hostAddr)
+		if err != nil {
+			return fmt.Errorf("failed to delete static route to stale host address %s of node %s "+
+				"stderr: %q, error: %v", hostAddr, node.Name, stderr, err)
+		}
+	}
+
+	services, err := ovn.watchFactory.GetServices()
+	if err != nil {
+		return fmt.Errorf("failed to get k8s services: %v", err)
+	}
+	physicalGateway := gwRouterPrefix + node.Name
+	for _, service := range services {
+		if !util.ServiceTypeHasNodePort(service) {
+			continue
+		}
+		ep, err := ovn.watchFactory.GetEndpoint(service.Namespace, service.Name)
+		if err != nil || len(ep.Subsets) == 0 {
+			ep = nil
+		}
+		for _, svcPort := range service.Spec.Ports {
+			protocol, err := util.ValidateProtocol(svcPort.Protocol)
+			if err != nil || svcPort.NodePort == 0 {
+				continue
+			}
+			if !ovn.SCTPSupport && protocol == kapi.ProtocolSCTP {
+				continue
+			}
+			loadBalancer, _ := ovn.getGatewayLoadBalancer(physicalGateway, protocol)
+			if loadBalancer == "" {
+				continue
+			}
+			for _, ip := range staleIPs {
+				ovn.deleteLoadBalancerVIP(loadBalancer, util.JoinHostPortInt32(ip, svcPort.NodePort))
+			}
+			logCtx := logging.Service(service.Namespace, service.Name).Operation("update")
+			if err := ovn.createGatewayNodePortVIPs(logCtx, service, ep, physicalGateway, protocol, svcPort.NodePort); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -244,6 +244,8 @@ func gatewayInit(nodeName string, clusterIPSubnet []*net.IPNet, hostSubnets []*n
 	}
 
 	// Add static routes in GR with physical gateway as the default next hop.
+	// --may-exist replaces the next hop of an existing default route, so the
+	// route follows changes of the node's next hop.
 	for _, nextHop := range l3GatewayConfig.NextHops {
 		var allIPs string
 		if utilnet.IsIPv6(nextHop) {
@@ -293,6 +295,8 @@ func gatewayInit(nodeName string, clusterIPSubnet []*net.IPNet, hostSubnets []*n
 				gatewayRouter, err)
 		}
 
+		// --may-exist replaces the external IP of an existing SNAT rule of
+		// the subnet, so the rule follows changes of the node's IP.
 		stdout, stderr, err = util.RunOVNNbctl("--may-exist", "lr-nat-add",
 			gatewayRouter, "snat", externalIP.String(), entry.String())
 		if err != nil {
@@ -3,10 +3,15 @@ package ovn
 import (
 	"net"
 
+	"github.com/urfave/cli/v2"
+
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -405,3 +410,98 @@ node4 chassis=912d592c-904c-40cd-9ef1-c2e5b49a33dd lb_force_snat_ip=100.64.0.4`,
 		Expect(fexec.CalledMatchesExpected()).To(BeTrue())
 	})
 })
+
+var _ = Describe("Gateway address changes", func() {
+	newGatewayNode := func(mode, ipAddress, nextHop string) *v1.Node {
+		return &v1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			Annotations: map[string]string{
+				"k8s.ovn.org/l3-gateway-config": `{"default":{"mode":"` + mode + `",` +
+					`"mac-address":"11:22:33:44:55:66","ip-address":"` + ipAddress + `","next-hop":"` + nextHop + `"}}`,
+				"k8s.ovn.org/node-chassis-id": "cb9ec8fa-b409-4ef3-9f42-d9283c47aac6",
+			},
+		}}
+	}
+
+	It("detects physical IPs a gateway no longer has", func() {
+		oldNode := newGatewayNode("shared", "10.0.0.5/24", "10.0.0.1")
+
+		Expect(staleGatewayIPs(oldNode, newGatewayNode("shared", "10.0.0.6/24", "10.0.0.1"))).To(Equal([]string{"10.0.0.5"}))
+		Expect(staleGatewayIPs(oldNode, newGatewayNode("shared", "10.0.0.5/24", "10.0.0.254"))).To(BeEmpty())
+		Expect(staleGatewayIPs(oldNode, newGatewayNode("local", "10.0.0.6/24", "10.0.0.1"))).To(BeEmpty())
+		Expect(staleGatewayIPs(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}, oldNode)).To(BeEmpty())
+	})
+
+	Context("with a running controller", func() {
+		var (
+			app     *cli.App
+			fakeOvn *FakeOVN
+			fExec   *ovntest.FakeExec
+		)
+
+		BeforeEach(func() {
+			// Restore global default values before each testcase
+			config.PrepareTestConfig()
+
+			app = cli.NewApp()
+			app.Name = "test"
+			app.Flags = config.Flags
+
+			fExec = ovntest.NewFakeExec()
+			fakeOvn = NewFakeOVN(fExec)
+		})
+
+		AfterEach(func() {
+			fakeOvn.shutdown()
+		})
+
+		It("moves NodePort VIPs to the new physical IP of a gateway", func() {
+			app.Action = func(ctx *cli.Context) error {
+				service := newService("service1", "namespace1", "172.30.0.10",
+					[]v1.ServicePort{
+						{
+							Port:     80,
+							NodePort: 30080,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeNodePort,
+				)
+				fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --if-exists lr-route-del ovn_cluster_router 10.0.0.5/32",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --if-exists remove load_balancer tcp_load_balancer_id_1 vips \"10.0.0.5:30080\"",
+				})
+				// The service has no endpoints, so it gets a reject ACL on the
+				// new physical IP if the load balancer is on any switch
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:TCP_lb_gateway_router=GR_node1",
+					Output: "tcp_load_balancer_id_1",
+				})
+				fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+					Cmd:    "ovn-nbctl --timeout=15 get logical_router GR_node1 external_ids:physical_ips",
+					Output: "10.0.0.6",
+				})
+				fExec.AddFakeCmdsNoOutputNoError([]string{
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}tcp_load_balancer_id_1",
+					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}tcp_load_balancer_id_1",
+				})
+
+				err := fakeOvn.controller.updateGatewayIPs(newGatewayNode("shared", "10.0.0.6/24", "10.0.0.1"), []string{"10.0.0.5"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
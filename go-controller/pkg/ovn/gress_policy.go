@@ -255,7 +255,7 @@ func (gp *gressPolicy) addACLAllow(match, l4Match, portGroupUUID string, ipBlock
 		return nil
 	}
 
-	_, stderr, err = util.RunOVNNbctl("--id=@acl", "create",
+	args := []string{"--id=@acl", "create",
 		"acl", fmt.Sprintf("priority=%s", defaultAllowPriority),
 		fmt.Sprintf("direction=%s", direction), match,
 		fmt.Sprintf("action=%s", action),
@@ -264,8 +264,10 @@ func (gp *gressPolicy) addACLAllow(match, l4Match, portGroupUUID string, ipBlock
 		fmt.Sprintf("external-ids:namespace=%s", gp.policyNamespace),
 		fmt.Sprintf("external-ids:policy=%s", gp.policyName),
 		fmt.Sprintf("external-ids:%s_num=%d", gp.policyType, gp.idx),
-		fmt.Sprintf("external-ids:policy_type=%s", gp.policyType),
-		"--", "add", "port_group", portGroupUUID, "acls", "@acl")
+		fmt.Sprintf("external-ids:policy_type=%s", gp.policyType)}
+	args = append(args, networkPolicyOwner(gp.policyNamespace, gp.policyName).externalIDs()...)
+	args = append(args, "--", "add", "port_group", portGroupUUID, "acls", "@acl")
+	_, stderr, err = util.RunOVNNbctl(args...)
 	if err != nil {
 		return fmt.Errorf("failed to create the acl allow rule for "+
 			"namespace=%s, policy=%s, stderr: %q (%v)", gp.policyNamespace,
@@ -332,15 +334,16 @@ func (gp *gressPolicy) addIPBlockACLDeny(except, priority, portGroupName, portGr
 		return nil
 	}
 
-	_, stderr, err = util.RunOVNNbctl("--id=@acl", "create", "acl",
+	args := []string{"--id=@acl", "create", "acl",
 		fmt.Sprintf("priority=%s", priority),
 		fmt.Sprintf("direction=%s", direction), match, "action=drop",
 		fmt.Sprintf("external-ids:ipblock-deny-policy-type=%s", gp.policyType),
 		fmt.Sprintf("external-ids:%s_num=%d", gp.policyType, gp.idx),
 		fmt.Sprintf("external-ids:namespace=%s", gp.policyNamespace),
-		fmt.Sprintf("external-ids:policy=%s", gp.policyName),
-		"--", "add", "port_group", portGroupUUID,
-		"acls", "@acl")
+		fmt.Sprintf("external-ids:policy=%s", gp.policyName)}
+	args = append(args, networkPolicyOwner(gp.policyNamespace, gp.policyName).externalIDs()...)
+	args = append(args, "--", "add", "port_group", portGroupUUID, "acls", "@acl")
+	_, stderr, err = util.RunOVNNbctl(args...)
 	if err != nil {
 		return fmt.Errorf("error executing create ACL command, stderr: %q, %+v",
 			stderr, err)
@@ -0,0 +1,180 @@
+package ovn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+)
+
+// ipsecCSRApprovalReason is the reason of the approval condition the master
+// adds to the IPsec certificate signing requests of the nodes
+const ipsecCSRApprovalReason = "OVNIPsecAutoApproved"
+
+const (
+	// nodeUserPrefix prefixes the user names of the nodes
+	nodeUserPrefix = "system:node:"
+	// nodesGroup is the group of the nodes
+	nodesGroup = "system:nodes"
+	// serviceAccountUserPrefix prefixes the user names of the service accounts
+	serviceAccountUserPrefix = "system:serviceaccount:"
+	// podNameExtra and podUIDExtra are the extra user info of the service
+	// account tokens bound to a pod
+	podNameExtra = "authentication.kubernetes.io/pod-name"
+	podUIDExtra  = "authentication.kubernetes.io/pod-uid"
+)
+
+// setIPsec turns the encryption of the tunnels between the chassis on or off
+func setIPsec(enabled bool) error {
+	_, stderr, err := util.RunOVNNbctl("set", "nb_global", ".", fmt.Sprintf("ipsec=%t", enabled))
+	if err != nil {
+		return fmt.Errorf("failed to set nb_global ipsec=%t, stderr: %q, error: %v", enabled, stderr, err)
+	}
+	return nil
+}
+
+// WatchIPsecCSRs approves the certificate signing requests the nodes create
+// for their IPsec certificates
+func (oc *Controller) WatchIPsecCSRs() error {
+	csrClient := oc.kube.CertificateSigningRequests()
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = util.IPsecCSRNodeLabel
+			return csrClient.List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = util.IPsecCSRNodeLabel
+			return csrClient.Watch(context.TODO(), options)
+		},
+	}
+	_, informer := cache.NewInformer(listWatch, &certificatesv1beta1.CertificateSigningRequest{}, 30*time.Second,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				oc.approveIPsecCSR(obj.(*certificatesv1beta1.CertificateSigningRequest))
+			},
+			UpdateFunc: func(old, new interface{}) {
+				oc.approveIPsecCSR(new.(*certificatesv1beta1.CertificateSigningRequest))
+			},
+		})
+	go informer.Run(oc.stopChan)
+	if !cache.WaitForCacheSync(oc.stopChan, informer.HasSynced) {
+		return fmt.Errorf("error syncing IPsec certificate signing request informer cache")
+	}
+	return nil
+}
+
+// approveIPsecCSR approves a node's IPsec certificate signing request if it
+// requests a certificate for the node's chassis and nothing else. Requests
+// failing the checks are left for an administrator to decide on.
+func (oc *Controller) approveIPsecCSR(csr *certificatesv1beta1.CertificateSigningRequest) {
+	if len(csr.Status.Certificate) > 0 {
+		return
+	}
+	for _, condition := range csr.Status.Conditions {
+		if condition.Type == certificatesv1beta1.CertificateApproved ||
+			condition.Type == certificatesv1beta1.CertificateDenied {
+			return
+		}
+	}
+
+	if err := oc.checkIPsecCSR(csr); err != nil {
+		klog.Warningf("Not approving IPsec certificate signing request %s: %v", csr.Name, err)
+		return
+	}
+
+	csr = csr.DeepCopy()
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1beta1.CertificateSigningRequestCondition{
+		Type:           certificatesv1beta1.CertificateApproved,
+		Reason:         ipsecCSRApprovalReason,
+		Message:        "Approved the IPsec certificate of the node's OVN chassis",
+		LastUpdateTime: metav1.Now(),
+	})
+	if _, err := oc.kube.CertificateSigningRequests().UpdateApproval(context.TODO(), csr, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Failed to approve IPsec certificate signing request %s: %v", csr.Name, err)
+		return
+	}
+	klog.Infof("Approved IPsec certificate signing request %s", csr.Name)
+}
+
+// checkIPsecCSR checks that a certificate signing request is for the IPsec
+// certificate of the chassis of the node it is labeled with, from the IPsec
+// signer, and that the node requested it. Anyone allowed to create
+// certificate signing requests can set the label.
+func (oc *Controller) checkIPsecCSR(csr *certificatesv1beta1.CertificateSigningRequest) error {
+	if csr.Spec.SignerName == nil || *csr.Spec.SignerName != config.IPsec.SignerName {
+		signerName := ""
+		if csr.Spec.SignerName != nil {
+			signerName = *csr.Spec.SignerName
+		}
+		return fmt.Errorf("signer %q is not the IPsec signer %q", signerName, config.IPsec.SignerName)
+	}
+	nodeName := csr.Labels[util.IPsecCSRNodeLabel]
+	if err := oc.checkIPsecCSRRequester(csr, nodeName); err != nil {
+		return err
+	}
+	node, err := oc.watchFactory.GetNode(nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to get node %q: %v", nodeName, err)
+	}
+	chassisID, err := util.ParseNodeChassisID(node)
+	if err != nil {
+		return err
+	}
+
+	request, err := util.ParseIPsecCSR(csr)
+	if err != nil {
+		return err
+	}
+	if request.Subject.CommonName != chassisID {
+		return fmt.Errorf("common name %q is not the chassis ID %q of node %q",
+			request.Subject.CommonName, chassisID, nodeName)
+	}
+	return nil
+}
+
+// checkIPsecCSRRequester checks that the requester of a certificate signing
+// request is node nodeName: either the node itself, or the ovnkube-node
+// service account with a token bound to a pod running on the node
+func (oc *Controller) checkIPsecCSRRequester(csr *certificatesv1beta1.CertificateSigningRequest, nodeName string) error {
+	username := csr.Spec.Username
+	if username == nodeUserPrefix+nodeName {
+		for _, group := range csr.Spec.Groups {
+			if group == nodesGroup {
+				return nil
+			}
+		}
+		return fmt.Errorf("requester %q is not in group %q", username, nodesGroup)
+	}
+
+	if config.IPsec.NodeServiceAccount == "" || username != serviceAccountUserPrefix+config.IPsec.NodeServiceAccount {
+		return fmt.Errorf("requester %q is neither node %q nor service account %q",
+			username, nodeName, config.IPsec.NodeServiceAccount)
+	}
+	podNames := csr.Spec.Extra[podNameExtra]
+	if len(podNames) != 1 {
+		return fmt.Errorf("requester %q did not use a token bound to a pod", username)
+	}
+	namespace := strings.Split(config.IPsec.NodeServiceAccount, ":")[0]
+	pod, err := oc.watchFactory.GetPod(namespace, podNames[0])
+	if err != nil {
+		return fmt.Errorf("failed to get pod %s/%s of requester %q: %v", namespace, podNames[0], username, err)
+	}
+	if podUIDs := csr.Spec.Extra[podUIDExtra]; len(podUIDs) != 1 || podUIDs[0] != string(pod.UID) {
+		return fmt.Errorf("requester %q used a token bound to a pod other than %s/%s", username, namespace, pod.Name)
+	}
+	if pod.Spec.NodeName != nodeName {
+		return fmt.Errorf("pod %s/%s of requester %q runs on node %q, not on node %q",
+			namespace, pod.Name, username, pod.Spec.NodeName, nodeName)
+	}
+	return nil
+}
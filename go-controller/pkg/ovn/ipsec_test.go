@@ -0,0 +1,154 @@
+package ovn
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509/pkix"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/cert"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IPsec certificate signing request approval", func() {
+	const chassisID string = "cb9ec8fa-b409-4ef3-9f42-d9283c47aac6"
+
+	var app *cli.App
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+	})
+
+	newCSR := func(name, commonName, username string, groups ...string) *certificatesv1beta1.CertificateSigningRequest {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		csrPEM, err := cert.MakeCSR(key, &pkix.Name{CommonName: commonName}, nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		signerName := "kubernetes.io/legacy-unknown"
+		return &certificatesv1beta1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{util.IPsecCSRNodeLabel: "node1"},
+			},
+			Spec: certificatesv1beta1.CertificateSigningRequestSpec{
+				Request:    csrPEM,
+				SignerName: &signerName,
+				Usages:     util.IPsecCSRUsages,
+				Username:   username,
+				Groups:     groups,
+			},
+		}
+	}
+
+	newServiceAccountCSR := func(name string, pod *v1.Pod) *certificatesv1beta1.CertificateSigningRequest {
+		csr := newCSR(name, chassisID, "system:serviceaccount:ovn-kubernetes:ovn",
+			"system:serviceaccounts", "system:serviceaccounts:ovn-kubernetes")
+		csr.Spec.Extra = map[string]certificatesv1beta1.ExtraValue{
+			podNameExtra: {pod.Name},
+			podUIDExtra:  {string(pod.UID)},
+		}
+		return csr
+	}
+
+	newNodePod := func(name, nodeName string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "ovn-kubernetes",
+				UID:       types.UID(name + "-uid"),
+			},
+			Spec: v1.PodSpec{NodeName: nodeName},
+		}
+	}
+
+	It("approves the requests of the node for the certificate of its chassis only", func() {
+		app.Action = func(ctx *cli.Context) error {
+			fExec := ovntest.NewFakeExec()
+			err := util.SetExec(fExec)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = config.InitConfig(ctx, fExec, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			node1Pod := newNodePod("ovnkube-node-1", "node1")
+			node2Pod := newNodePod("ovnkube-node-2", "node2")
+			otherSigner := newCSR("other-signer", chassisID, "system:node:node1", "system:nodes")
+			apiServerClientSigner := "kubernetes.io/kube-apiserver-client"
+			otherSigner.Spec.SignerName = &apiServerClientSigner
+			noSigner := newCSR("no-signer", chassisID, "system:node:node1", "system:nodes")
+			noSigner.Spec.SignerName = nil
+			fakeClient := fake.NewSimpleClientset(
+				&v1.Node{ObjectMeta: metav1.ObjectMeta{
+					Name:        "node1",
+					Annotations: map[string]string{"k8s.ovn.org/node-chassis-id": chassisID},
+				}},
+				node1Pod,
+				node2Pod,
+				newCSR("good", chassisID, "system:node:node1", "system:nodes", "system:authenticated"),
+				newServiceAccountCSR("good-service-account", node1Pod),
+				newCSR("other-chassis", "4f5c4a4b-1b1e-4a4c-9d57-e0f9a8e2b1c3", "system:node:node1", "system:nodes"),
+				newCSR("other-node", chassisID, "system:node:node2", "system:nodes"),
+				newCSR("other-user", chassisID, "alice", "system:authenticated"),
+				newServiceAccountCSR("other-node-service-account", node2Pod),
+				otherSigner,
+				noSigner,
+			)
+			stop := make(chan struct{})
+			wf, err := factory.NewWatchFactory(fakeClient, stop)
+			Expect(err).NotTo(HaveOccurred())
+			defer close(stop)
+
+			clusterController := NewOvnController(fakeClient, nil, wf, stop)
+			err = clusterController.WatchIPsecCSRs()
+			Expect(err).NotTo(HaveOccurred())
+
+			getConditions := func(name string) []certificatesv1beta1.CertificateSigningRequestCondition {
+				csr, err := fakeClient.CertificatesV1beta1().CertificateSigningRequests().Get(context.TODO(), name, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				return csr.Status.Conditions
+			}
+			Eventually(func() []certificatesv1beta1.CertificateSigningRequestCondition {
+				return getConditions("good")
+			}).Should(HaveLen(1))
+			condition := getConditions("good")[0]
+			Expect(condition.Type).To(Equal(certificatesv1beta1.CertificateApproved))
+			Expect(condition.Reason).To(Equal(ipsecCSRApprovalReason))
+			Eventually(func() []certificatesv1beta1.CertificateSigningRequestCondition {
+				return getConditions("good-service-account")
+			}).Should(HaveLen(1))
+			Expect(getConditions("good-service-account")[0].Type).To(Equal(certificatesv1beta1.CertificateApproved))
+			// Anyone allowed to create certificate signing requests can label
+			// them with a node, so the requests of the other users are not
+			// approved. Neither are requests for other signers, which could
+			// issue Kubernetes credentials.
+			for _, name := range []string{"other-chassis", "other-node", "other-user", "other-node-service-account",
+				"other-signer", "no-signer"} {
+				Consistently(func() []certificatesv1beta1.CertificateSigningRequestCondition {
+					return getConditions(name)
+				}).Should(BeEmpty(), "request %s was approved", name)
+			}
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
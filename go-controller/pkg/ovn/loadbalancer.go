@@ -185,7 +185,7 @@ func (ovn *Controller) getLogicalSwitchesForLoadBalancer(lb string) ([]string, e
 	return nil, fmt.Errorf("router detected with load balancer that is not a GR")
 }
 
-func (ovn *Controller) createLoadBalancerRejectACL(lb string, sourceIP string, sourcePort int32, proto kapi.Protocol) (string, error) {
+func (ovn *Controller) createLoadBalancerRejectACL(service *kapi.Service, lb string, sourceIP string, sourcePort int32, proto kapi.Protocol) (string, error) {
 	ovn.serviceLBLock.Lock()
 	defer ovn.serviceLBLock.Unlock()
 	switches, err := ovn.getLogicalSwitchesForLoadBalancer(lb)
@@ -236,6 +236,7 @@ func (ovn *Controller) createLoadBalancerRejectACL(lb string, sourceIP string, s
 
 	cmd := []string{"--id=@acl", "create", "acl", "direction=from-lport", "priority=1000", aclMatch, "action=reject",
 		fmt.Sprintf("name=%s", strings.ReplaceAll(aclName, ":", "\\:"))}
+	cmd = append(cmd, serviceOwner(service.Namespace, service.Name).externalIDs()...)
 	for _, ls := range switches {
 		cmd = append(cmd, "--", "add", "logical_switch", ls, "acls", "@acl")
 	}
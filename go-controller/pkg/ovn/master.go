@@ -12,6 +12,7 @@ import (
 
 	kapi "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/leaderelection"
@@ -22,6 +23,7 @@ import (
 
 	homaster "github.com/ovn-org/ovn-kubernetes/go-controller/hybrid-overlay/pkg/controller"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 )
@@ -153,10 +155,11 @@ func (oc *Controller) StartClusterMaster(masterNodeName string) error {
 				"Disabling Multicast Support")
 			oc.multicastSupport = false
 		}
-		if config.IPv6Mode {
-			klog.Warningf("Multicast support enabled, but can not be used along with IPv6. Disabling Multicast Support")
-			oc.multicastSupport = false
-		}
+	}
+
+	if err := setIPsec(config.IPsec.Enabled); err != nil {
+		klog.Errorf("Failed to configure IPsec: %v", err)
+		return err
 	}
 
 	if err := oc.SetupMaster(masterNodeName); err != nil {
@@ -257,6 +260,12 @@ func (oc *Controller) SetupMaster(masterNodeName string) error {
 			return err
 		}
 	}
+
+	// Create the logical switches pods attach to provider networks through
+	if err = setupProviderNetworks(); err != nil {
+		klog.Errorf(err.Error())
+		return err
+	}
 	return nil
 }
 
@@ -479,12 +488,14 @@ func (oc *Controller) ensureNodeLogicalNetwork(nodeName string, hostSubnets []*n
 		"--", "set", "logical_switch", nodeName,
 	}
 
-	var v4Gateway net.IP
+	var v4Gateway, v6Gateway net.IP
 	for _, hostSubnet := range hostSubnets {
 		gwIfAddr := util.GetNodeGatewayIfAddr(hostSubnet)
 		lrpArgs = append(lrpArgs, gwIfAddr.String())
 
 		if utilnet.IsIPv6CIDR(hostSubnet) {
+			v6Gateway = gwIfAddr.IP
+
 			lsArgs = append(lsArgs,
 				"other-config:ipv6_prefix="+hostSubnet.IP.String(),
 			)
@@ -518,7 +529,7 @@ func (oc *Controller) ensureNodeLogicalNetwork(nodeName string, hostSubnets []*n
 		return err
 	}
 
-	// If supported, enable IGMP snooping and querier on the node.
+	// If supported, enable IGMP/MLD snooping and querier on the node.
 	if oc.multicastSupport {
 		stdout, stderr, err = util.RunOVNNbctl("set", "logical_switch",
 			nodeName, "other-config:mcast_snoop=\"true\"")
@@ -528,15 +539,23 @@ func (oc *Controller) ensureNodeLogicalNetwork(nodeName string, hostSubnets []*n
 			return err
 		}
 
-		// Configure querier only if we have an IPv4 address, otherwise
-		// disable querier.
-		if v4Gateway != nil {
-			stdout, stderr, err = util.RunOVNNbctl("set", "logical_switch",
+		// Configure querier only if we have an IPv4 or IPv6 address,
+		// otherwise disable querier. MLD queries are sourced from the
+		// router port's link local address.
+		if v4Gateway != nil || v6Gateway != nil {
+			querierArgs := []string{"set", "logical_switch",
 				nodeName, "other-config:mcast_querier=\"true\"",
-				"other-config:mcast_eth_src=\""+nodeLRPMAC.String()+"\"",
-				"other-config:mcast_ip4_src=\""+v4Gateway.String()+"\"")
+				"other-config:mcast_eth_src=\"" + nodeLRPMAC.String() + "\"",
+			}
+			if v4Gateway != nil {
+				querierArgs = append(querierArgs, "other-config:mcast_ip4_src=\""+v4Gateway.String()+"\"")
+			}
+			if v6Gateway != nil {
+				querierArgs = append(querierArgs, "other-config:mcast_ip6_src=\""+util.HWAddrToIPv6LLA(nodeLRPMAC).String()+"\"")
+			}
+			stdout, stderr, err = util.RunOVNNbctl(querierArgs...)
 			if err != nil {
-				klog.Errorf("Failed to enable IGMP Querier on logical switch %v, stdout: %q, stderr: %q, error: %v",
+				klog.Errorf("Failed to enable IGMP/MLD Querier on logical switch %v, stdout: %q, stderr: %q, error: %v",
 					nodeName, stdout, stderr, err)
 				return err
 			}
@@ -548,7 +567,7 @@ func (oc *Controller) ensureNodeLogicalNetwork(nodeName string, hostSubnets []*n
 					nodeName, stdout, stderr, err)
 				return err
 			}
-			klog.Infof("Disabled IGMP Querier on logical switch %v (No IPv4 Source IP available)",
+			klog.Infof("Disabled IGMP Querier on logical switch %v (No source IP available)",
 				nodeName)
 		}
 	}
@@ -625,6 +644,7 @@ func (oc *Controller) ensureNodeLogicalNetwork(nodeName string, hostSubnets []*n
 			util.JoinIPNets(existing, ","), util.JoinIPNets(hostSubnets, ","))
 	}
 	oc.logicalSwitchCache[nodeName] = hostSubnets
+	oc.watchFactory.MarkReady(factory.NodeSwitchDependency, nodeName)
 
 	return nil
 }
@@ -790,6 +810,49 @@ func (oc *Controller) clearInitialNodeNetworkUnavailableCondition(origNode, newN
 	}
 }
 
+// checkNodeMTU warns when the MTU of the overlay networks published by a
+// node differs from the one of another node, since the packets the pods of
+// the node with the larger MTU send to the pods of the other are dropped
+// when they exceed the smaller MTU.
+func (oc *Controller) checkNodeMTU(node *kapi.Node) {
+	mtu, err := util.ParseNodeMTU(node)
+	if err != nil {
+		klog.Warningf(err.Error())
+		return
+	}
+	if mtu == 0 {
+		// the node does not publish its MTU
+		return
+	}
+
+	nodes, err := oc.watchFactory.GetNodes()
+	if err != nil {
+		klog.Errorf("Failed to get nodes: %v", err)
+		return
+	}
+	for _, otherNode := range nodes {
+		if otherNode.Name == node.Name {
+			continue
+		}
+		otherMTU, err := util.ParseNodeMTU(otherNode)
+		if err != nil || otherMTU == 0 || otherMTU == mtu {
+			continue
+		}
+		klog.Warningf("Node %s uses MTU %d for the overlay networks but node %s uses MTU %d, "+
+			"packets between their pods larger than the smaller MTU will be dropped",
+			node.Name, mtu, otherNode.Name, otherMTU)
+		nodeRef := &kapi.ObjectReference{
+			Kind: "Node",
+			Name: node.Name,
+			UID:  types.UID(node.Name),
+		}
+		oc.recorder.Eventf(nodeRef, kapi.EventTypeWarning, "MTUMismatch",
+			"Node uses MTU %d for the overlay networks but node %s uses MTU %d",
+			mtu, otherNode.Name, otherMTU)
+		return
+	}
+}
+
 // this is the worker function that does the periodic sync of nodes from kube API
 // and sbdb and deletes chassis that are stale
 func (oc *Controller) syncNodesPeriodic() {
@@ -3,19 +3,60 @@ package ovn
 import (
 	"fmt"
 	"net"
-	"time"
+	"strings"
 
 	hotypes "github.com/ovn-org/ovn-kubernetes/go-controller/hybrid-overlay/pkg/types"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/logging"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	kapi "k8s.io/api/core/v1"
-	utilwait "k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog"
 )
 
 const (
 	// Annotation used to enable/disable multicast in the namespace
 	nsMulticastAnnotation = "k8s.ovn.org/multicast-enabled"
+	// Annotation used to restrict multicast in the namespace to a
+	// comma-separated list of groups
+	nsMulticastGroupsAnnotation = "k8s.ovn.org/multicast-groups"
+	// Annotation used to allow multicast traffic from pods in a
+	// comma-separated list of other namespaces into the namespace
+	nsMulticastSourceNamespacesAnnotation = "k8s.ovn.org/multicast-source-namespaces"
 )
 
+// parseMulticastAllowPolicy returns the multicast allow policy requested by
+// the namespace's multicast annotations.
+func parseMulticastAllowPolicy(ns *kapi.Namespace) (multicastAllowPolicy, error) {
+	policy := multicastAllowPolicy{}
+	if groups, ok := ns.Annotations[nsMulticastGroupsAnnotation]; ok {
+		for _, groupStr := range strings.Split(groups, ",") {
+			groupStr = strings.TrimSpace(groupStr)
+			if groupStr == "" {
+				continue
+			}
+			group := net.ParseIP(groupStr)
+			if group == nil || !group.IsMulticast() {
+				return policy, fmt.Errorf("invalid multicast group %q in %s annotation",
+					groupStr, nsMulticastGroupsAnnotation)
+			}
+			policy.groups = append(policy.groups, group)
+		}
+		if len(policy.groups) == 0 {
+			return policy, fmt.Errorf("no multicast group in %s annotation",
+				nsMulticastGroupsAnnotation)
+		}
+	}
+	if namespaces, ok := ns.Annotations[nsMulticastSourceNamespacesAnnotation]; ok {
+		for _, sourceNs := range strings.Split(namespaces, ",") {
+			sourceNs = strings.TrimSpace(sourceNs)
+			if sourceNs != "" && sourceNs != ns.Name {
+				policy.sourceNamespaces = append(policy.sourceNamespaces, sourceNs)
+			}
+		}
+	}
+	return policy, nil
+}
+
 func (oc *Controller) syncNamespaces(namespaces []interface{}) {
 	expectedNs := make(map[string]bool)
 	for _, nsInterface := range namespaces {
@@ -46,6 +87,13 @@ func (oc *Controller) addPodToNamespace(ns string, portInfo *lpInfo) error {
 	}
 	defer nsInfo.Unlock()
 
+	// Route the pod's egress traffic through the namespace's external
+	// gateways, if any. The routes are idempotent so this is done even if
+	// the pod is already known.
+	if err := addGWRoutesForPod(nsInfo.externalGateways(), portInfo.ip, portInfo.node); err != nil {
+		return err
+	}
+
 	// If pod has already been added, nothing to do.
 	address := portInfo.ip.String()
 	if nsInfo.addressSet[address] != "" {
@@ -73,6 +121,8 @@ func (oc *Controller) deletePodFromNamespace(ns string, portInfo *lpInfo) error
 	}
 	defer nsInfo.Unlock()
 
+	deleteGWRoutesForPod(nsInfo.externalGateways(), portInfo.ip, portInfo.node)
+
 	address := portInfo.ip.String()
 	if nsInfo.addressSet[address] == "" {
 		return nil
@@ -93,56 +143,127 @@ func (oc *Controller) deletePodFromNamespace(ns string, portInfo *lpInfo) error
 
 // Creates an explicit "allow" policy for multicast traffic within the
 // namespace if multicast is enabled. Otherwise, removes the "allow" policy.
-// Traffic will be dropped by the default multicast deny ACL.
+// Traffic will be dropped by the default multicast deny ACL. If the allowed
+// groups or source namespaces change while multicast is enabled, the "allow"
+// policy is updated accordingly.
 func (oc *Controller) multicastUpdateNamespace(ns *kapi.Namespace, nsInfo *namespaceInfo) {
 	if !oc.multicastSupport {
 		return
 	}
 
+	logCtx := logging.Namespace(ns.Name).Operation("update")
 	enabled := (ns.Annotations[nsMulticastAnnotation] == "true")
 	enabledOld := nsInfo.multicastEnabled
 
+	policy := multicastAllowPolicy{}
+	if enabled {
+		var err error
+		policy, err = parseMulticastAllowPolicy(ns)
+		if err != nil {
+			klog.Errorf("%s Failed to parse multicast policy of namespace %s: %v", logCtx, ns.Name, err)
+			return
+		}
+		// The ACLs can only refer to the address sets of the namespaces
+		// that exist, the others are added once they are created
+		policy.sourceNamespaces = oc.knownNamespaces(policy.sourceNamespaces)
+	}
+
 	if enabledOld == enabled {
+		if enabled && !policy.equal(nsInfo.multicastPolicy) {
+			if err := updateMulticastAllowPolicy(ns.Name, nsInfo.multicastPolicy, policy); err != nil {
+				klog.Errorf("%s %v", logCtx, err)
+				return
+			}
+			nsInfo.multicastPolicy = policy
+		}
 		return
 	}
 
 	var err error
 	if enabled {
-		err = oc.createMulticastAllowPolicy(ns.Name, nsInfo)
+		err = oc.createMulticastAllowPolicy(ns.Name, nsInfo, policy)
 	} else {
-		err = deleteMulticastAllowPolicy(ns.Name)
+		err = deleteMulticastAllowPolicy(ns.Name, nsInfo.multicastPolicy)
 	}
 	if err != nil {
-		klog.Errorf(err.Error())
+		klog.Errorf("%s %v", logCtx, err)
 		return
 	}
 
 	nsInfo.multicastEnabled = enabled
+	nsInfo.multicastPolicy = policy
 }
 
 // Cleans up the multicast policy for this namespace if multicast was
 // previously allowed.
 func (oc *Controller) multicastDeleteNamespace(ns *kapi.Namespace, nsInfo *namespaceInfo) {
 	if nsInfo.multicastEnabled {
-		if err := deleteMulticastAllowPolicy(ns.Name); err != nil {
-			klog.Errorf(err.Error())
+		if err := deleteMulticastAllowPolicy(ns.Name, nsInfo.multicastPolicy); err != nil {
+			klog.Errorf("%s %v", logging.Namespace(ns.Name).Operation("delete"), err)
 		}
 	}
 	nsInfo.multicastEnabled = false
+	nsInfo.multicastPolicy = multicastAllowPolicy{}
+}
+
+// Updates the multicast allow policies of the namespaces after
+// 'sourceNs' was added or deleted, for the policies to allow or stop
+// allowing the multicast traffic from its pods.
+func (oc *Controller) multicastUpdateSourceNamespace(sourceNs string) {
+	if !oc.multicastSupport {
+		return
+	}
+
+	oc.namespacesMutex.Lock()
+	namespaces := make([]string, 0, len(oc.namespaces))
+	for namespace := range oc.namespaces {
+		if namespace != sourceNs {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	oc.namespacesMutex.Unlock()
+
+	for _, namespace := range namespaces {
+		ns, err := oc.watchFactory.GetNamespace(namespace)
+		if err != nil {
+			continue
+		}
+		nsInfo := oc.getNamespaceLocked(namespace)
+		if nsInfo == nil {
+			continue
+		}
+		if nsInfo.multicastEnabled {
+			oc.multicastUpdateNamespace(ns, nsInfo)
+		}
+		nsInfo.Unlock()
+	}
+}
+
+// knownNamespaces returns the namespaces that exist out of 'namespaces'
+func (oc *Controller) knownNamespaces(namespaces []string) []string {
+	oc.namespacesMutex.Lock()
+	defer oc.namespacesMutex.Unlock()
+	var known []string
+	for _, namespace := range namespaces {
+		if _, ok := oc.namespaces[namespace]; ok {
+			known = append(known, namespace)
+		}
+	}
+	return known
 }
 
 // AddNamespace creates corresponding addressset in ovn db
 func (oc *Controller) AddNamespace(ns *kapi.Namespace) {
-	klog.V(5).Infof("Adding namespace: %s", ns.Name)
+	logCtx := logging.Namespace(ns.Name).Operation("add")
+	klog.V(5).Infof("%s Adding namespace: %s", logCtx, ns.Name)
 	nsInfo := oc.createNamespaceLocked(ns.Name)
-	defer nsInfo.Unlock()
 
 	// Get all the pods in the namespace and append their IP to the
 	// address_set
 	existingPods, err := oc.watchFactory.GetPods(ns.Name)
 	addresses := make([]string, 0, len(existingPods))
 	if err != nil {
-		klog.Errorf("Failed to get all the pods (%v)", err)
+		klog.Errorf("%s Failed to get all the pods (%v)", logCtx, err)
 	} else {
 		for _, pod := range existingPods {
 			if pod.Status.PodIP != "" && !pod.Spec.HostNetwork {
@@ -157,7 +278,7 @@ func (oc *Controller) AddNamespace(ns *kapi.Namespace) {
 	if annotation != "" {
 		parsedAnnotation := net.ParseIP(annotation)
 		if parsedAnnotation == nil {
-			klog.Errorf("Could not parse hybrid overlay external gw annotation")
+			klog.Errorf("%s Could not parse hybrid overlay external gw annotation", logCtx)
 		} else {
 			nsInfo.hybridOverlayExternalGW = parsedAnnotation
 		}
@@ -166,25 +287,36 @@ func (oc *Controller) AddNamespace(ns *kapi.Namespace) {
 	if annotation != "" {
 		parsedAnnotation := net.ParseIP(annotation)
 		if parsedAnnotation == nil {
-			klog.Errorf("Could not parse hybrid overlay VTEP annotation")
+			klog.Errorf("%s Could not parse hybrid overlay VTEP annotation", logCtx)
 		} else {
 			nsInfo.hybridOverlayVTEP = parsedAnnotation
 		}
 	}
 
+	nsInfo.providerNetwork = ns.Annotations[util.ProviderNetworkAnnotation]
+
 	// Create an address_set for the namespace.  All the pods' IP address
 	// in the namespace will be added to the address_set
-	createAddressSet(ns.Name, hashedAddressSet(ns.Name), addresses)
+	createAddressSet(namespaceOwner(ns.Name), ns.Name, hashedAddressSet(ns.Name), addresses)
 
 	oc.multicastUpdateNamespace(ns, nsInfo)
+	oc.updateNamespaceExternalGWs(ns, nsInfo)
+	oc.updateNamespaceQoS(ns, nsInfo)
+	nsInfo.Unlock()
+
+	oc.multicastUpdateSourceNamespace(ns.Name)
+
+	// Handle the events waiting for the namespace
+	oc.watchFactory.MarkReady(factory.NamespaceDependency, ns.Name)
 }
 
 func (oc *Controller) updateNamespace(old, newer *kapi.Namespace) {
-	klog.V(5).Infof("Updating namespace: %s", old.Name)
+	logCtx := logging.Namespace(old.Name).Operation("update")
+	klog.V(5).Infof("%s Updating namespace: %s", logCtx, old.Name)
 
 	nsInfo := oc.getNamespaceLocked(old.Name)
 	if nsInfo == nil {
-		klog.Warningf("Update event for unknown namespace %q", old.Name)
+		klog.Warningf("%s Update event for unknown namespace %q", logCtx, old.Name)
 		return
 	}
 	defer nsInfo.Unlock()
@@ -193,7 +325,7 @@ func (oc *Controller) updateNamespace(old, newer *kapi.Namespace) {
 	if annotation != "" {
 		parsedAnnotation := net.ParseIP(annotation)
 		if parsedAnnotation == nil {
-			klog.Errorf("Could not parse hybrid overlay external gw annotation")
+			klog.Errorf("%s Could not parse hybrid overlay external gw annotation", logCtx)
 		} else {
 			nsInfo.hybridOverlayExternalGW = parsedAnnotation
 		}
@@ -202,42 +334,48 @@ func (oc *Controller) updateNamespace(old, newer *kapi.Namespace) {
 	if annotation != "" {
 		parsedAnnotation := net.ParseIP(annotation)
 		if parsedAnnotation == nil {
-			klog.Errorf("Could not parse hybrid overlay VTEP annotation")
+			klog.Errorf("%s Could not parse hybrid overlay VTEP annotation", logCtx)
 		} else {
 			nsInfo.hybridOverlayVTEP = parsedAnnotation
 		}
 	}
+	nsInfo.providerNetwork = newer.Annotations[util.ProviderNetworkAnnotation]
 	oc.multicastUpdateNamespace(newer, nsInfo)
+	oc.updateNamespaceExternalGWs(newer, nsInfo)
+	oc.updateNamespaceQoS(newer, nsInfo)
 }
 
 func (oc *Controller) deleteNamespace(ns *kapi.Namespace) {
-	klog.V(5).Infof("Deleting namespace: %s", ns.Name)
+	klog.V(5).Infof("%s Deleting namespace: %s", logging.Namespace(ns.Name).Operation("delete"), ns.Name)
+	oc.watchFactory.MarkNotReady(factory.NamespaceDependency, ns.Name)
 
 	nsInfo := oc.deleteNamespaceLocked(ns.Name)
 	if nsInfo == nil {
 		return
 	}
-	defer nsInfo.Unlock()
 
-	deleteAddressSet(hashedAddressSet(ns.Name))
+	gateways := nsInfo.externalGateways()
+	nsInfo.routingExternalGWs = gatewayInfo{}
+	nsInfo.routingExternalPodGWs = make(map[string]gatewayInfo)
+	for _, gateway := range gateways {
+		oc.deleteGWRoutesForNamespace(nsInfo, gateway)
+	}
 	oc.multicastDeleteNamespace(ns, nsInfo)
+	nsInfo.Unlock()
+
+	// The address set is deleted once no multicast ACL refers to it
+	oc.multicastUpdateSourceNamespace(ns.Name)
+	deleteAddressSet(hashedAddressSet(ns.Name))
 }
 
-// waitForNamespaceLocked waits up to 10 seconds for a Namespace to be known; use this
-// rather than getNamespaceLocked when calling from a thread where you might be processing
-// an event in a namespace before the Namespace factory thread has processed the Namespace
-// addition.
-func (oc *Controller) waitForNamespaceLocked(namespace string) (*namespaceInfo, error) {
-	var nsInfo *namespaceInfo
-
-	err := utilwait.PollImmediate(100*time.Millisecond, 10*time.Second,
-		func() (bool, error) {
-			nsInfo = oc.getNamespaceLocked(namespace)
-			return nsInfo != nil, nil
-		},
-	)
-	if err != nil {
-		return nil, fmt.Errorf("timeout waiting for namespace event")
+// requireNamespaceLocked is like getNamespaceLocked but returns an error if the
+// Namespace is not known. Handlers that need the Namespace declare it as a
+// factory.NamespaceDependency so that their events are only handled once the
+// Namespace addition has been processed.
+func (oc *Controller) requireNamespaceLocked(namespace string) (*namespaceInfo, error) {
+	nsInfo := oc.getNamespaceLocked(namespace)
+	if nsInfo == nil {
+		return nil, fmt.Errorf("namespace %s is not known", namespace)
 	}
 	return nsInfo, nil
 }
@@ -275,9 +413,10 @@ func (oc *Controller) createNamespaceLocked(ns string) *namespaceInfo {
 	defer oc.namespacesMutex.Unlock()
 
 	nsInfo := &namespaceInfo{
-		addressSet:       make(map[string]string),
-		networkPolicies:  make(map[string]*namespacePolicy),
-		multicastEnabled: false,
+		addressSet:            make(map[string]string),
+		networkPolicies:       make(map[string]*namespacePolicy),
+		multicastEnabled:      false,
+		routingExternalPodGWs: make(map[string]gatewayInfo),
 	}
 	nsInfo.Lock()
 	oc.namespaces[ns] = nsInfo
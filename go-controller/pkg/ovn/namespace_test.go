@@ -1,6 +1,7 @@
 package ovn
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/urfave/cli/v2"
@@ -155,10 +156,10 @@ var _ = Describe("OVN Namespace Operations", func() {
 					},
 				)
 				podMAC := ovntest.MustParseMAC("11:22:33:44:55:66")
-				fakeOvn.controller.logicalPortCache.add(tP.nodeName, tP.portName, fakeUUID, podMAC, ovntest.MustParseIP(tP.podIP))
+				fakeOvn.controller.logicalPortCache.add(tP.nodeName, tP.nodeName, tP.portName, fakeUUID, podMAC, ovntest.MustParseIP(tP.podIP))
 				fakeOvn.controller.WatchNamespaces()
 
-				_, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(namespaceT.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(context.TODO(), namespaceT.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
 
@@ -185,7 +186,7 @@ var _ = Describe("OVN Namespace Operations", func() {
 				})
 				fakeOvn.controller.WatchNamespaces()
 
-				_, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(namespaceT.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(context.TODO(), namespaceT.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
 
@@ -216,14 +217,14 @@ var _ = Describe("OVN Namespace Operations", func() {
 				})
 				fakeOvn.controller.WatchNamespaces()
 
-				namespace, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(namespaceT.Name, metav1.GetOptions{})
+				namespace, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(context.TODO(), namespaceT.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(namespace).NotTo(BeNil())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
 				test.delCmds(fExec, namespaceT)
 
-				err = fakeOvn.fakeClient.CoreV1().Namespaces().Delete(namespaceT.Name, metav1.NewDeleteOptions(1))
+				err = fakeOvn.fakeClient.CoreV1().Namespaces().Delete(context.TODO(), namespaceT.Name, *metav1.NewDeleteOptions(1))
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -0,0 +1,593 @@
+package ovn
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	knet "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog"
+)
+
+// The types of the northbound database objects the check compares with the
+// Kubernetes objects
+const (
+	nbdbDriftLogicalSwitchPort = "logical_switch_port"
+	nbdbDriftAddressSet        = "address_set"
+	nbdbDriftPortGroup         = "port_group"
+	nbdbDriftLoadBalancer      = "load_balancer"
+)
+
+var nbdbDriftTypes = []string{nbdbDriftLogicalSwitchPort, nbdbDriftAddressSet,
+	nbdbDriftPortGroup, nbdbDriftLoadBalancer}
+
+// NBDBDrift is a difference between the northbound database and the
+// Kubernetes objects
+type NBDBDrift struct {
+	// Type is the type of the northbound database object
+	Type string
+	// Name is the name of the northbound database object
+	Name string
+	// Reason describes the difference
+	Reason string
+
+	// object is the Kubernetes object the difference is reported on, if any
+	object runtime.Object
+	// needsMaster is true if the difference can only be repaired by a
+	// running master, as it is repaired by handling a Kubernetes object
+	// again
+	needsMaster bool
+	repair      func() error
+}
+
+func (d *NBDBDrift) String() string {
+	return fmt.Sprintf("%s %s: %s", d.Type, d.Name, d.Reason)
+}
+
+// nbdbExpectedPod is a pod that has a logical switch port
+type nbdbExpectedPod struct {
+	pod *kapi.Pod
+	ip  string
+}
+
+// CheckNBDB compares the northbound database with the Kubernetes objects in
+// the watch factory. It returns the logical switch ports, address sets,
+// policy port groups and their ports, and cluster load balancer VIPs that
+// are stale or missing, finding them through the external_ids the
+// controller writes. The ACLs are not compared.
+func (oc *Controller) CheckNBDB() ([]*NBDBDrift, error) {
+	pods, err := oc.watchFactory.GetPods("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pods: %v", err)
+	}
+	namespaces, err := oc.watchFactory.GetNamespaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespaces: %v", err)
+	}
+	policies, err := oc.watchFactory.GetObjects(&knet.NetworkPolicy{}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network policies: %v", err)
+	}
+	services, err := oc.watchFactory.GetServices()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %v", err)
+	}
+
+	// the pods that have a logical switch port, by port name
+	expectedPods := make(map[string]*nbdbExpectedPod)
+	for _, pod := range pods {
+		if !podScheduled(pod) || !podWantsNetwork(pod) {
+			continue
+		}
+		annotation, err := util.UnmarshalPodAnnotation(pod.Annotations)
+		if err != nil {
+			continue
+		}
+		expected := &nbdbExpectedPod{pod: pod}
+		if len(annotation.IPs) > 0 {
+			expected.ip = annotation.IPs[0].IP.String()
+		}
+		expectedPods[podLogicalPortName(pod)] = expected
+	}
+
+	var drifts []*NBDBDrift
+	portUUIDs, portDrifts, err := oc.checkNBDBLogicalPorts(expectedPods)
+	if err != nil {
+		return nil, err
+	}
+	drifts = append(drifts, portDrifts...)
+
+	setDrifts, err := checkNBDBAddressSets(expectedPods, namespaces, policies)
+	if err != nil {
+		return nil, err
+	}
+	drifts = append(drifts, setDrifts...)
+
+	groupDrifts, err := checkNBDBPortGroups(expectedPods, portUUIDs, policies)
+	if err != nil {
+		return nil, err
+	}
+	drifts = append(drifts, groupDrifts...)
+
+	lbDrifts, err := oc.checkNBDBLoadBalancers(services)
+	if err != nil {
+		return nil, err
+	}
+	drifts = append(drifts, lbDrifts...)
+
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].String() < drifts[j].String()
+	})
+	return drifts, nil
+}
+
+// checkNBDBLogicalPorts compares the logical switch ports of the pods with
+// the pods. It returns the UUIDs of the ports of the expected pods too.
+func (oc *Controller) checkNBDBLogicalPorts(expectedPods map[string]*nbdbExpectedPod) (map[string]string, []*NBDBDrift, error) {
+	rows, err := findNBRows("logical_switch_port", []string{"_uuid", "name"},
+		"external_ids:pod=true")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var drifts []*NBDBDrift
+	portUUIDs := make(map[string]string)
+	for _, row := range rows {
+		uuid, name := row.atom("_uuid"), row.atom("name")
+		if expectedPods[name] != nil {
+			portUUIDs[name] = uuid
+			continue
+		}
+		drifts = append(drifts, &NBDBDrift{
+			Type:   nbdbDriftLogicalSwitchPort,
+			Name:   name,
+			Reason: "stale port of a pod that does not exist",
+			repair: func() error {
+				_, stderr, err := util.RunOVNNbctl("--if-exists", "lsp-del", name)
+				if err != nil {
+					return fmt.Errorf("failed to delete logical port %s, stderr: %q (%v)", name, stderr, err)
+				}
+				return nil
+			},
+		})
+	}
+
+	for name, expected := range expectedPods {
+		if portUUIDs[name] != "" {
+			continue
+		}
+		pod := expected.pod
+		drifts = append(drifts, &NBDBDrift{
+			Type:        nbdbDriftLogicalSwitchPort,
+			Name:        name,
+			Reason:      "missing port of an annotated pod",
+			object:      pod,
+			needsMaster: true,
+			repair: func() error {
+				return oc.retryPods.Do(string(pod.UID), func() error {
+					return oc.addLogicalPort(pod)
+				})
+			},
+		})
+	}
+	return portUUIDs, drifts, nil
+}
+
+// checkNBDBAddressSets compares the address sets of the namespaces and of
+// the network policies with the namespaces, their pods and the policies
+func checkNBDBAddressSets(expectedPods map[string]*nbdbExpectedPod,
+	namespaces []*kapi.Namespace, policies []interface{}) ([]*NBDBDrift, error) {
+	rows, err := findNBRows("address_set", []string{"external_ids", "addresses"})
+	if err != nil {
+		return nil, err
+	}
+
+	expectedNs := make(map[string]*kapi.Namespace)
+	for _, ns := range namespaces {
+		expectedNs[ns.Name] = ns
+	}
+	// the address sets of a policy are named namespace.policy.suffixes, and
+	// a policy name can have dots
+	expectedPolicies := make(map[string][]string)
+	for _, obj := range policies {
+		policy := obj.(*knet.NetworkPolicy)
+		expectedPolicies[policy.Namespace] = append(expectedPolicies[policy.Namespace], policy.Name+".")
+	}
+	policyExists := func(ns, suffixes string) bool {
+		for _, prefix := range expectedPolicies[ns] {
+			if strings.HasPrefix(suffixes, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	expectedAddresses := make(map[string]map[string]bool)
+	for _, expected := range expectedPods {
+		if expected.ip == "" {
+			continue
+		}
+		ns := expected.pod.Namespace
+		if expectedAddresses[ns] == nil {
+			expectedAddresses[ns] = make(map[string]bool)
+		}
+		expectedAddresses[ns][expected.ip] = true
+	}
+
+	var drifts []*NBDBDrift
+	existingNs := make(map[string]bool)
+	for _, row := range rows {
+		name := row.smap("external_ids")["name"]
+		if name == "" {
+			continue
+		}
+		hashName := hashedAddressSet(name)
+		names := strings.SplitN(name, ".", 2)
+		if len(names) == 2 {
+			// the address set of a network policy
+			if policyExists(names[0], names[1]) {
+				continue
+			}
+			drifts = append(drifts, &NBDBDrift{
+				Type:   nbdbDriftAddressSet,
+				Name:   name,
+				Reason: "stale address set of a network policy that does not exist",
+				repair: func() error {
+					deleteAddressSet(hashName)
+					return nil
+				},
+			})
+			continue
+		}
+
+		ns := expectedNs[name]
+		if ns == nil {
+			drifts = append(drifts, &NBDBDrift{
+				Type:   nbdbDriftAddressSet,
+				Name:   name,
+				Reason: "stale address set of a namespace that does not exist",
+				repair: func() error {
+					deleteAddressSet(hashName)
+					return nil
+				},
+			})
+			continue
+		}
+		existingNs[name] = true
+
+		existing := make(map[string]bool)
+		for _, address := range row.set("addresses") {
+			existing[address] = true
+			if expectedAddresses[name][address] {
+				continue
+			}
+			address := address
+			drifts = append(drifts, &NBDBDrift{
+				Type:   nbdbDriftAddressSet,
+				Name:   name,
+				Reason: fmt.Sprintf("stale address %s", address),
+				object: ns,
+				repair: func() error {
+					removeFromAddressSet(hashName, address)
+					return nil
+				},
+			})
+		}
+		for address := range expectedAddresses[name] {
+			if existing[address] {
+				continue
+			}
+			address := address
+			drifts = append(drifts, &NBDBDrift{
+				Type:   nbdbDriftAddressSet,
+				Name:   name,
+				Reason: fmt.Sprintf("missing address %s", address),
+				object: ns,
+				repair: func() error {
+					addToAddressSet(hashName, address)
+					return nil
+				},
+			})
+		}
+	}
+
+	for name, ns := range expectedNs {
+		if existingNs[name] {
+			continue
+		}
+		name, ns := name, ns
+		drifts = append(drifts, &NBDBDrift{
+			Type:   nbdbDriftAddressSet,
+			Name:   name,
+			Reason: "missing address set of a namespace",
+			object: ns,
+			repair: func() error {
+				addresses := make([]string, 0, len(expectedAddresses[name]))
+				for address := range expectedAddresses[name] {
+					addresses = append(addresses, address)
+				}
+				sort.Strings(addresses)
+				createAddressSet(namespaceOwner(ns.Name), ns.Name, hashedAddressSet(ns.Name), addresses)
+				return nil
+			},
+		})
+	}
+	return drifts, nil
+}
+
+// checkNBDBPortGroups compares the port groups of the network policies with
+// the policies and the pods they select
+func checkNBDBPortGroups(expectedPods map[string]*nbdbExpectedPod, portUUIDs map[string]string,
+	policies []interface{}) ([]*NBDBDrift, error) {
+	rows, err := findNBRows("port_group", []string{"name", "external_ids", "ports"})
+	if err != nil {
+		return nil, err
+	}
+
+	expectedPolicies := make(map[string]*knet.NetworkPolicy)
+	for _, obj := range policies {
+		policy := obj.(*knet.NetworkPolicy)
+		expectedPolicies[fmt.Sprintf("%s_%s", policy.Namespace, policy.Name)] = policy
+	}
+	portNames := make(map[string]string, len(portUUIDs))
+	for name, uuid := range portUUIDs {
+		portNames[uuid] = name
+	}
+
+	var drifts []*NBDBDrift
+	for _, row := range rows {
+		hashName, name := row.atom("name"), row.smap("external_ids")["name"]
+		// Only the port groups of network policies are named
+		// namespace_policy, as neither can have an underscore
+		if !strings.Contains(name, "_") {
+			continue
+		}
+		policy := expectedPolicies[name]
+		if policy == nil {
+			drifts = append(drifts, &NBDBDrift{
+				Type:   nbdbDriftPortGroup,
+				Name:   name,
+				Reason: "stale port group of a network policy that does not exist",
+				repair: func() error {
+					deletePortGroup(hashName)
+					return nil
+				},
+			})
+			continue
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+		if err != nil {
+			klog.Errorf("Invalid pod selector of network policy %s/%s: %v",
+				policy.Namespace, policy.Name, err)
+			continue
+		}
+		existing := make(map[string]bool)
+		for _, uuid := range row.set("ports") {
+			portName := portNames[uuid]
+			if portName == "" {
+				// not the port of a pod, or one already reported
+				continue
+			}
+			existing[portName] = true
+			pod := expectedPods[portName].pod
+			if pod.Namespace == policy.Namespace && selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			uuid := uuid
+			drifts = append(drifts, &NBDBDrift{
+				Type:   nbdbDriftPortGroup,
+				Name:   name,
+				Reason: fmt.Sprintf("stale port %s", portName),
+				object: policy,
+				repair: func() error {
+					_, stderr, err := util.RunOVNNbctl("remove", "port_group", hashName, "ports", uuid)
+					if err != nil {
+						return fmt.Errorf("failed to remove port %s from port group %s, stderr: %q (%v)",
+							uuid, hashName, stderr, err)
+					}
+					return nil
+				},
+			})
+		}
+		for portName, uuid := range portUUIDs {
+			pod := expectedPods[portName].pod
+			if existing[portName] || pod.Namespace != policy.Namespace || !selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			uuid := uuid
+			drifts = append(drifts, &NBDBDrift{
+				Type:   nbdbDriftPortGroup,
+				Name:   name,
+				Reason: fmt.Sprintf("missing port %s", portName),
+				object: policy,
+				repair: func() error {
+					_, stderr, err := util.RunOVNNbctl("add", "port_group", hashName, "ports", uuid)
+					if err != nil {
+						return fmt.Errorf("failed to add port %s to port group %s, stderr: %q (%v)",
+							uuid, hashName, stderr, err)
+					}
+					return nil
+				},
+			})
+		}
+	}
+	return drifts, nil
+}
+
+// checkNBDBLoadBalancers compares the VIPs of the cluster load balancers
+// with the cluster IPs and ports of the services. A VIP is missing if the
+// service has endpoints for the port, the services without endpoints are
+// rejected by ACLs instead.
+func (oc *Controller) checkNBDBLoadBalancers(services []*kapi.Service) ([]*NBDBDrift, error) {
+	expectedVIPs := make(map[kapi.Protocol]map[string]bool)
+	// the VIPs of the services with endpoints, and the services
+	requiredVIPs := make(map[kapi.Protocol]map[string]*kapi.Service)
+	for _, service := range services {
+		if !util.ServiceTypeHasClusterIP(service) {
+			continue
+		}
+		var protoPortMap map[kapi.Protocol]map[string]lbEndpoints
+		ep, err := oc.watchFactory.GetEndpoint(service.Namespace, service.Name)
+		if err == nil {
+			protoPortMap = oc.getLbEndpoints(ep)
+		}
+		for _, svcPort := range service.Spec.Ports {
+			protocol, err := util.ValidateProtocol(svcPort.Protocol)
+			if err != nil || svcPort.Port == 0 {
+				continue
+			}
+			if expectedVIPs[protocol] == nil {
+				expectedVIPs[protocol] = make(map[string]bool)
+				requiredVIPs[protocol] = make(map[string]*kapi.Service)
+			}
+			_, hasEndpoints := protoPortMap[protocol][svcPort.Name]
+			for _, clusterIP := range util.GetClusterIPs(service) {
+				vip := util.JoinHostPortInt32(clusterIP, svcPort.Port)
+				expectedVIPs[protocol][vip] = true
+				if hasEndpoints {
+					requiredVIPs[protocol][vip] = service
+				}
+			}
+		}
+	}
+
+	var drifts []*NBDBDrift
+	for _, protocol := range []kapi.Protocol{kapi.ProtocolTCP, kapi.ProtocolUDP, kapi.ProtocolSCTP} {
+		lb, err := oc.getLoadBalancer(protocol)
+		if err != nil {
+			// SCTP load balancers only exist if OVN supports SCTP
+			klog.V(5).Infof("No %s cluster load balancer: %v", protocol, err)
+			continue
+		}
+		vips, err := oc.getLoadBalancerVIPs(lb)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the VIPs of load balancer %s: %v", lb, err)
+		}
+		for vip := range vips {
+			if expectedVIPs[protocol][vip] {
+				continue
+			}
+			vip := vip
+			drifts = append(drifts, &NBDBDrift{
+				Type:   nbdbDriftLoadBalancer,
+				Name:   lb,
+				Reason: fmt.Sprintf("stale %s VIP %s of a service that does not exist", protocol, vip),
+				repair: func() error {
+					oc.deleteLoadBalancerVIP(lb, vip)
+					return nil
+				},
+			})
+		}
+		for vip, service := range requiredVIPs[protocol] {
+			if _, ok := vips[vip]; ok {
+				continue
+			}
+			service := service
+			drifts = append(drifts, &NBDBDrift{
+				Type:        nbdbDriftLoadBalancer,
+				Name:        lb,
+				Reason:      fmt.Sprintf("missing %s VIP %s of service %s/%s", protocol, vip, service.Namespace, service.Name),
+				object:      service,
+				needsMaster: true,
+				repair: func() error {
+					ep, err := oc.watchFactory.GetEndpoint(service.Namespace, service.Name)
+					if err != nil {
+						return fmt.Errorf("failed to get endpoints of service %s/%s: %v", service.Namespace, service.Name, err)
+					}
+					return oc.retryEndpoints.Do(retryKey(ep), func() error {
+						return oc.AddEndpoints(ep)
+					})
+				},
+			})
+		}
+	}
+	return drifts, nil
+}
+
+// ReportNBDBDrifts logs the differences between the northbound database and
+// the Kubernetes objects, counts them in the metrics and posts an event on
+// the Kubernetes objects they belong to
+func (oc *Controller) ReportNBDBDrifts(drifts []*NBDBDrift) {
+	counts := make(map[string]int)
+	for _, d := range drifts {
+		counts[d.Type]++
+		klog.Warningf("Northbound database drift: %s", d)
+		if d.object != nil {
+			oc.recorder.Eventf(d.object, kapi.EventTypeWarning, "NBDBDrift",
+				"The northbound database differs from the object: %s", d)
+		}
+	}
+	for _, driftType := range nbdbDriftTypes {
+		metrics.MetricMasterNBDBDrift.WithLabelValues(driftType).Set(float64(counts[driftType]))
+	}
+}
+
+// RepairNBDB repairs the differences between the northbound database and
+// the Kubernetes objects. The ones that need the master's state are only
+// repaired if inMaster is true.
+func (oc *Controller) RepairNBDB(drifts []*NBDBDrift, inMaster bool) {
+	for _, d := range drifts {
+		if d.needsMaster && !inMaster {
+			klog.Infof("Leaving %s to the master", d)
+			continue
+		}
+		if err := d.repair(); err != nil {
+			klog.Errorf("Failed to repair %s: %v", d, err)
+			continue
+		}
+		klog.Infof("Repaired %s", d)
+		metrics.MetricMasterNBDBRepairs.WithLabelValues(d.Type).Inc()
+	}
+}
+
+// checkNBDB checks the northbound database, acting on the differences that
+// the previous check found too, as the others may be changes the handlers
+// are still making. It returns the differences it found.
+func (oc *Controller) checkNBDB(previous map[string]bool) map[string]bool {
+	drifts, err := oc.CheckNBDB()
+	if err != nil {
+		klog.Errorf("Failed to check the northbound database: %v", err)
+		return previous
+	}
+	found := make(map[string]bool, len(drifts))
+	var confirmed []*NBDBDrift
+	for _, d := range drifts {
+		key := d.String()
+		found[key] = true
+		if previous[key] {
+			confirmed = append(confirmed, d)
+		}
+	}
+	oc.ReportNBDBDrifts(confirmed)
+	if config.NBDBCheck.Repair {
+		oc.RepairNBDB(confirmed, true)
+	}
+	return found
+}
+
+// checkNBDBPeriodic adds a goroutine that checks the northbound database
+// every config.NBDBCheck.Interval seconds
+func (oc *Controller) checkNBDBPeriodic() {
+	go func() {
+		ticker := time.NewTicker(time.Duration(config.NBDBCheck.Interval) * time.Second)
+		defer ticker.Stop()
+		var previous map[string]bool
+		for {
+			select {
+			case <-ticker.C:
+				previous = oc.checkNBDB(previous)
+			case <-oc.stopChan:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,228 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	util "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	v1 "k8s.io/api/core/v1"
+	knet "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OVN NB database check", func() {
+	var (
+		app     *cli.App
+		fakeOvn *FakeOVN
+		fExec   *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fExec = ovntest.NewFakeExec()
+		fakeOvn = NewFakeOVN(fExec)
+	})
+
+	AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	It("reports and repairs the differences with the kubernetes objects", func() {
+		app.Action = func(ctx *cli.Context) error {
+			pod := newPod("ns1", "pod1", "node1", "10.128.1.3")
+			var err error
+			pod.Annotations, err = util.MarshalPodAnnotation(&util.PodAnnotation{
+				IPs: []*net.IPNet{ovntest.MustParseIPNet("10.128.1.3/24")},
+				MAC: ovntest.MustParseMAC("0a:58:0a:80:01:03"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			policy := newNetworkPolicy("policy1", "ns1",
+				metav1.LabelSelector{MatchLabels: map[string]string{"name": "pod1"}},
+				[]knet.NetworkPolicyIngressRule{}, []knet.NetworkPolicyEgressRule{})
+			service := newService("svc1", "ns1", "172.30.0.10",
+				[]v1.ServicePort{{Protocol: v1.ProtocolTCP, Port: 80}}, v1.ServiceTypeClusterIP)
+
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,name", "logical_switch_port", "external_ids:pod=true",
+				`{"data":[[["uuid","uuid-pod1"],"ns1_pod1"],[["uuid","uuid-gone"],"ns1_gone"]],"headings":["_uuid","name"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("external_ids,addresses", "address_set", "",
+				`{"data":[`+
+					`[["map",[["name","ns1"]]],["set",["10.128.1.3","10.128.1.9"]]],`+
+					`[["map",[["name","gone"]]],["set",[]]],`+
+					`[["map",[["name","ns1.policy1.ingress.0"]]],["set",[]]],`+
+					`[["map",[["name","ns1.oldpolicy.ingress.0"]]],"10.128.1.3"]`+
+					`],"headings":["external_ids","addresses"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("name,external_ids,ports", "port_group", "",
+				`{"data":[`+
+					`["a1",["map",[["name","ns1_policy1"]]],["set",[]]],`+
+					`["a2",["map",[["name","ns1_oldpolicy"]]],["uuid","uuid-pod1"]],`+
+					`["mcastPortGroupDeny",["map",[["name","mcastPortGroupDeny"]]],["set",[["uuid","uuid-pod1"],["uuid","uuid-gone"]]]]`+
+					`],"headings":["name","external_ids","ports"]}`))
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+				Output: k8sTCPLoadBalancerIP,
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + k8sTCPLoadBalancerIP + " vips",
+				Output: `{"172.30.0.10:80"="10.128.1.3:8080", "172.30.0.99:80"="10.128.1.4:8080"}`,
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+			})
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{Items: []v1.Namespace{*newNamespace("ns1")}},
+				&v1.PodList{Items: []v1.Pod{*pod}},
+				&knet.NetworkPolicyList{Items: []knet.NetworkPolicy{*policy}},
+				&v1.ServiceList{Items: []v1.Service{*service}},
+			)
+
+			drifts, err := fakeOvn.controller.CheckNBDB()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			var found []string
+			for _, d := range drifts {
+				found = append(found, d.String())
+			}
+			Expect(found).To(Equal([]string{
+				"address_set gone: stale address set of a namespace that does not exist",
+				"address_set ns1.oldpolicy.ingress.0: stale address set of a network policy that does not exist",
+				"address_set ns1: stale address 10.128.1.9",
+				"load_balancer " + k8sTCPLoadBalancerIP + ": stale TCP VIP 172.30.0.99:80 of a service that does not exist",
+				"logical_switch_port ns1_gone: stale port of a pod that does not exist",
+				"port_group ns1_oldpolicy: stale port group of a network policy that does not exist",
+				"port_group ns1_policy1: missing port ns1_pod1",
+			}))
+
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists destroy address_set " + hashedAddressSet("gone"),
+				"ovn-nbctl --timeout=15 --if-exists destroy address_set " + hashedAddressSet("ns1.oldpolicy.ingress.0"),
+				fmt.Sprintf(`ovn-nbctl --timeout=15 remove address_set %s addresses "10.128.1.9"`, hashedAddressSet("ns1")),
+				`ovn-nbctl --timeout=15 --if-exists remove load_balancer ` + k8sTCPLoadBalancerIP + ` vips "172.30.0.99:80"`,
+				"ovn-nbctl --timeout=15 --if-exists lsp-del ns1_gone",
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=a2",
+				Output: fakeUUID,
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --if-exists destroy port_group " + fakeUUID,
+				"ovn-nbctl --timeout=15 add port_group a1 ports uuid-pod1",
+			})
+
+			fakeOvn.controller.RepairNBDB(drifts, false)
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("leaves the missing ports of pods to the master", func() {
+		app.Action = func(ctx *cli.Context) error {
+			pod := newPod("ns1", "pod1", "node1", "10.128.1.3")
+			var err error
+			pod.Annotations, err = util.MarshalPodAnnotation(&util.PodAnnotation{
+				IPs: []*net.IPNet{ovntest.MustParseIPNet("10.128.1.3/24")},
+				MAC: ovntest.MustParseMAC("0a:58:0a:80:01:03"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,name", "logical_switch_port", "external_ids:pod=true",
+				`{"data":[],"headings":["_uuid","name"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("external_ids,addresses", "address_set", "",
+				`{"data":[[["map",[["name","ns1"]]],"10.128.1.3"]],"headings":["external_ids","addresses"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("name,external_ids,ports", "port_group", "",
+				`{"data":[],"headings":["name","external_ids","ports"]}`))
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+			})
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{Items: []v1.Namespace{*newNamespace("ns1")}},
+				&v1.PodList{Items: []v1.Pod{*pod}},
+			)
+
+			drifts, err := fakeOvn.controller.CheckNBDB()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drifts).To(HaveLen(1))
+			Expect(drifts[0].String()).To(Equal("logical_switch_port ns1_pod1: missing port of an annotated pod"))
+
+			// No command is run to repair it outside of the master
+			fakeOvn.controller.RepairNBDB(drifts, false)
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("reports the missing VIPs of the services with endpoints", func() {
+		app.Action = func(ctx *cli.Context) error {
+			service := newService("svc1", "ns1", "172.30.0.10",
+				[]v1.ServicePort{{Protocol: v1.ProtocolTCP, Port: 80}}, v1.ServiceTypeClusterIP)
+			// Services without endpoints have no VIP, they are rejected
+			noEndpointsService := newService("svc2", "ns1", "172.30.0.11",
+				[]v1.ServicePort{{Protocol: v1.ProtocolTCP, Port: 80}}, v1.ServiceTypeClusterIP)
+			endpoints := newEndpoints("svc1", "ns1",
+				[]v1.EndpointAddress{{IP: "10.128.1.3"}},
+				[]v1.EndpointPort{{Protocol: v1.ProtocolTCP, Port: 8080}})
+
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,name", "logical_switch_port", "external_ids:pod=true",
+				`{"data":[],"headings":["_uuid","name"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("external_ids,addresses", "address_set", "",
+				`{"data":[[["map",[["name","ns1"]]],["set",[]]]],"headings":["external_ids","addresses"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("name,external_ids,ports", "port_group", "",
+				`{"data":[],"headings":["name","external_ids","ports"]}`))
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
+				Output: k8sTCPLoadBalancerIP,
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --data=bare --no-heading get load_balancer " + k8sTCPLoadBalancerIP + " vips",
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-udp=yes",
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-sctp=yes",
+			})
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{Items: []v1.Namespace{*newNamespace("ns1")}},
+				&v1.ServiceList{Items: []v1.Service{*service, *noEndpointsService}},
+				&v1.EndpointsList{Items: []v1.Endpoints{*endpoints}},
+			)
+
+			drifts, err := fakeOvn.controller.CheckNBDB()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+			Expect(drifts).To(HaveLen(1))
+			Expect(drifts[0].String()).To(Equal("load_balancer " + k8sTCPLoadBalancerIP +
+				": missing TCP VIP 172.30.0.10:80 of service ns1/svc1"))
+
+			// The endpoints are added again by the master only
+			fakeOvn.controller.RepairNBDB(drifts, false)
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
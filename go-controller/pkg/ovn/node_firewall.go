@@ -0,0 +1,296 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	nodefirewallv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodefirewall/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+	utilnet "k8s.io/utils/net"
+)
+
+const (
+	// nodeFirewallExternalID tags the ACLs rendered from NodeFirewalls with
+	// the name of the node whose external switch they are on
+	nodeFirewallExternalID = "node-firewall-node"
+	// nodeFirewallMaxPriority is the priority of the ACL of the first
+	// NodeFirewall rule of a node, the ACLs of the following rules have
+	// decreasing priorities. It is below the priority of the service reject
+	// ACLs so that NodePorts without endpoints are still rejected.
+	nodeFirewallMaxPriority = 999
+	// nodeFirewallDefaultPriority is the priority of the ACL allowing the
+	// traffic matching no NodeFirewall rule
+	nodeFirewallDefaultPriority = 100
+)
+
+// nodeFirewallACL is an ACL of a node's external switch rendered from a
+// NodeFirewall rule
+type nodeFirewallACL struct {
+	priority int
+	match    string
+	action   string
+}
+
+// nodeFirewallPortMatches returns the transport matches of the ports of a
+// NodeFirewall rule, one per protocol, or a single empty match if the rule
+// has no ports.
+func nodeFirewallPortMatches(ports []nodefirewallv1.NodeFirewallPort) []string {
+	if len(ports) == 0 {
+		return []string{""}
+	}
+
+	var protocols []string
+	protocolPorts := make(map[string][]string)
+	for _, port := range ports {
+		protocol, err := util.ValidateProtocol(port.Protocol)
+		if err != nil {
+			klog.Warningf("Ignoring NodeFirewall port: %v", err)
+			continue
+		}
+		proto := strings.ToLower(string(protocol))
+		dstPorts, ok := protocolPorts[proto]
+		if !ok {
+			protocols = append(protocols, proto)
+		}
+		if port.Port == 0 || (ok && dstPorts == nil) {
+			// all ports of the protocol
+			protocolPorts[proto] = nil
+		} else {
+			protocolPorts[proto] = append(dstPorts, fmt.Sprintf("%d", port.Port))
+		}
+	}
+
+	matches := make([]string, 0, len(protocols))
+	for _, proto := range protocols {
+		dstPorts := protocolPorts[proto]
+		switch len(dstPorts) {
+		case 0:
+			matches = append(matches, proto)
+		case 1:
+			matches = append(matches, fmt.Sprintf("%s && %s.dst == %s", proto, proto, dstPorts[0]))
+		default:
+			matches = append(matches, fmt.Sprintf("%s && %s.dst == {%s}", proto, proto, strings.Join(dstPorts, ", ")))
+		}
+	}
+	return matches
+}
+
+// nodeFirewallACLs renders the rules of the NodeFirewalls selecting a node as
+// ACLs on the traffic entering the node's external switch through its
+// localnet port and headed to the node's physical IPs. The rules are applied
+// in the order of the firewalls' names. Once a node has rules, the traffic
+// matching none of them is allowed by a stateful ACL, which also lets the
+// replies to connections initiated from the cluster through.
+func nodeFirewallACLs(localnetPort string, physicalIPs []*net.IPNet, firewalls []*nodefirewallv1.NodeFirewall) []nodeFirewallACL {
+	var acls []nodeFirewallACL
+	priority := nodeFirewallMaxPriority
+	for _, firewall := range firewalls {
+		for _, rule := range firewall.Spec.Rules {
+			var action string
+			switch rule.Action {
+			case nodefirewallv1.NodeFirewallActionAllow:
+				action = "allow-related"
+			case nodefirewallv1.NodeFirewallActionDeny:
+				action = "drop"
+			default:
+				klog.Warningf("Ignoring NodeFirewall %s rule with invalid action %q", firewall.Name, rule.Action)
+				continue
+			}
+
+			_, sourceCIDR, err := net.ParseCIDR(rule.SourceCIDR)
+			if err != nil {
+				klog.Warningf("Ignoring NodeFirewall %s rule with invalid source CIDR %q", firewall.Name, rule.SourceCIDR)
+				continue
+			}
+			l3Prefix := "ip4"
+			if utilnet.IsIPv6CIDR(sourceCIDR) {
+				l3Prefix = "ip6"
+			}
+			var physicalIP net.IP
+			for _, ip := range physicalIPs {
+				if utilnet.IsIPv6CIDR(ip) == utilnet.IsIPv6CIDR(sourceCIDR) {
+					physicalIP = ip.IP
+					break
+				}
+			}
+			if physicalIP == nil {
+				// the node has no address of the rule's IP family
+				continue
+			}
+
+			if priority <= nodeFirewallDefaultPriority {
+				klog.Errorf("Too many NodeFirewall rules for %s, ignoring the rules from NodeFirewall %s",
+					localnetPort, firewall.Name)
+				return acls
+			}
+			match := fmt.Sprintf("inport == \\\"%s\\\" && %s.src == %s && %s.dst == %s",
+				localnetPort, l3Prefix, sourceCIDR, l3Prefix, physicalIP)
+			for _, portMatch := range nodeFirewallPortMatches(rule.Ports) {
+				aclMatch := match
+				if portMatch != "" {
+					aclMatch += " && " + portMatch
+				}
+				acls = append(acls, nodeFirewallACL{priority: priority, match: aclMatch, action: action})
+			}
+			priority--
+		}
+	}
+
+	if len(acls) > 0 {
+		acls = append(acls, nodeFirewallACL{
+			priority: nodeFirewallDefaultPriority,
+			match:    fmt.Sprintf("inport == \\\"%s\\\" && ip", localnetPort),
+			action:   "allow-related",
+		})
+	}
+	return acls
+}
+
+// setNodeFirewallACLs replaces the NodeFirewall ACLs of a node's external
+// switch with acls in a single transaction.
+func setNodeFirewallACLs(nodeName string, acls []nodeFirewallACL) error {
+	externalSwitch := externalSwitchPrefix + nodeName
+	stdout, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading",
+		"--columns=_uuid", "find", "acl", "external_ids:"+nodeFirewallExternalID+"="+nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to find the NodeFirewall ACLs of node %s, stderr: %q, error: %v",
+			nodeName, stderr, err)
+	}
+
+	var cmdArgs []string
+	for _, aclUUID := range strings.Fields(stdout) {
+		cmdArgs = append(cmdArgs, "--", "remove", "logical_switch", externalSwitch, "acls", aclUUID)
+	}
+	for i, acl := range acls {
+		aclID := fmt.Sprintf("@acl%d", i)
+		cmdArgs = append(cmdArgs, "--", "--id="+aclID, "create", "acl",
+			"direction=from-lport", fmt.Sprintf("priority=%d", acl.priority),
+			fmt.Sprintf("match=\"%s\"", acl.match), "action="+acl.action,
+			"external_ids:"+nodeFirewallExternalID+"="+nodeName,
+			"--", "add", "logical_switch", externalSwitch, "acls", aclID)
+	}
+	if len(cmdArgs) == 0 {
+		return nil
+	}
+
+	stdout, stderr, err = util.RunOVNNbctl(cmdArgs...)
+	if err != nil {
+		return fmt.Errorf("failed to set the NodeFirewall ACLs of node %s, stdout: %q, stderr: %q, error: %v",
+			nodeName, stdout, stderr, err)
+	}
+	return nil
+}
+
+// nodeFirewallSelectsNode returns true if a NodeFirewall applies to a node
+func nodeFirewallSelectsNode(firewall *nodefirewallv1.NodeFirewall, node *kapi.Node) bool {
+	selector, err := metav1.LabelSelectorAsSelector(&firewall.Spec.NodeSelector)
+	if err != nil {
+		klog.Warningf("Ignoring NodeFirewall %s with invalid node selector: %v", firewall.Name, err)
+		return false
+	}
+	return selector.Matches(labels.Set(node.Labels))
+}
+
+// syncNodeFirewall renders the NodeFirewalls selecting a node on the external
+// switch of the node's gateway router.
+func (oc *Controller) syncNodeFirewall(node *kapi.Node) error {
+	if oc.nodeFirewallInformer == nil || !oc.nodeFirewallInformer.HasSynced() {
+		// the rules of all nodes are rendered once the firewalls are known
+		return nil
+	}
+	l3GatewayConfig, err := util.ParseNodeL3GatewayAnnotation(node)
+	if err != nil || l3GatewayConfig.Mode == config.GatewayModeDisabled {
+		// the node has no gateway router
+		return nil
+	}
+
+	var firewalls []*nodefirewallv1.NodeFirewall
+	for _, obj := range oc.nodeFirewallInformer.GetStore().List() {
+		firewall := obj.(*nodefirewallv1.NodeFirewall)
+		if nodeFirewallSelectsNode(firewall, node) {
+			firewalls = append(firewalls, firewall)
+		}
+	}
+	sort.Slice(firewalls, func(i, j int) bool {
+		return firewalls[i].Name < firewalls[j].Name
+	})
+
+	oc.nodeFirewallLock.Lock()
+	defer oc.nodeFirewallLock.Unlock()
+	return setNodeFirewallACLs(node.Name,
+		nodeFirewallACLs(l3GatewayConfig.InterfaceID, l3GatewayConfig.IPAddresses, firewalls))
+}
+
+// syncNodeFirewallNodes re-renders the NodeFirewall rules of the nodes
+// selected by any of the given firewalls.
+func (oc *Controller) syncNodeFirewallNodes(firewalls ...*nodefirewallv1.NodeFirewall) {
+	nodes, err := oc.watchFactory.GetNodes()
+	if err != nil {
+		klog.Errorf("Failed to get nodes: %v", err)
+		return
+	}
+	for _, node := range nodes {
+		for _, firewall := range firewalls {
+			if nodeFirewallSelectsNode(firewall, node) {
+				if err := oc.syncNodeFirewall(node); err != nil {
+					klog.Errorf(err.Error())
+				}
+				break
+			}
+		}
+	}
+}
+
+// WatchNodeFirewalls starts the watching of the NodeFirewall resources and
+// renders them on the nodes they select
+func (oc *Controller) WatchNodeFirewalls() error {
+	if err := oc.watchFactory.AddInformer(&nodefirewallv1.NodeFirewall{}, oc.nodeFirewallInformer, false); err != nil {
+		return err
+	}
+
+	_, err := oc.watchFactory.AddObjectHandler(&nodefirewallv1.NodeFirewall{}, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			firewall := obj.(*nodefirewallv1.NodeFirewall)
+			klog.V(5).Infof("Added event for NodeFirewall %q", firewall.Name)
+			oc.syncNodeFirewallNodes(firewall)
+		},
+		UpdateFunc: func(old, new interface{}) {
+			oldFirewall := old.(*nodefirewallv1.NodeFirewall)
+			firewall := new.(*nodefirewallv1.NodeFirewall)
+			if reflect.DeepEqual(oldFirewall.Spec, firewall.Spec) {
+				return
+			}
+			klog.V(5).Infof("Updated event for NodeFirewall %q", firewall.Name)
+			oc.syncNodeFirewallNodes(oldFirewall, firewall)
+		},
+		DeleteFunc: func(obj interface{}) {
+			firewall := obj.(*nodefirewallv1.NodeFirewall)
+			klog.V(5).Infof("Delete event for NodeFirewall %q", firewall.Name)
+			oc.syncNodeFirewallNodes(firewall)
+		},
+	}, func([]interface{}) {
+		// Render the rules of all nodes, which also removes the rules of
+		// the firewalls deleted while we were not watching
+		nodes, err := oc.watchFactory.GetNodes()
+		if err != nil {
+			klog.Errorf("Failed to get nodes: %v", err)
+			return
+		}
+		for _, node := range nodes {
+			if err := oc.syncNodeFirewall(node); err != nil {
+				klog.Errorf(err.Error())
+			}
+		}
+	})
+	return err
+}
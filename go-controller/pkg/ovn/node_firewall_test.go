@@ -0,0 +1,246 @@
+package ovn
+
+import (
+	"net"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	nodefirewallv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodefirewall/v1"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeNodeFirewallClient lists the given firewalls and watches a fake watcher
+type fakeNodeFirewallClient struct {
+	firewalls []nodefirewallv1.NodeFirewall
+	watcher   *watch.FakeWatcher
+}
+
+func (c *fakeNodeFirewallClient) List(opts metav1.ListOptions) (*nodefirewallv1.NodeFirewallList, error) {
+	return &nodefirewallv1.NodeFirewallList{Items: c.firewalls}, nil
+}
+
+func (c *fakeNodeFirewallClient) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	return c.watcher, nil
+}
+
+func newNodeFirewall(name string, nodeLabels map[string]string, rules ...nodefirewallv1.NodeFirewallRule) *nodefirewallv1.NodeFirewall {
+	return &nodefirewallv1.NodeFirewall{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: nodefirewallv1.NodeFirewallSpec{
+			NodeSelector: metav1.LabelSelector{MatchLabels: nodeLabels},
+			Rules:        rules,
+		},
+	}
+}
+
+var _ = Describe("Node firewall rendering", func() {
+	var fExec *ovntest.FakeExec
+
+	BeforeEach(func() {
+		fExec = ovntest.NewFakeExec()
+		err := util.SetExec(fExec)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("renders the rules in order followed by a default allow", func() {
+		firewalls := []*nodefirewallv1.NodeFirewall{
+			newNodeFirewall("a", nil,
+				nodefirewallv1.NodeFirewallRule{
+					Action:     nodefirewallv1.NodeFirewallActionAllow,
+					SourceCIDR: "192.168.1.0/24",
+				},
+			),
+			newNodeFirewall("b", nil,
+				nodefirewallv1.NodeFirewallRule{
+					Action:     nodefirewallv1.NodeFirewallActionDeny,
+					SourceCIDR: "192.168.0.0/16",
+					Ports: []nodefirewallv1.NodeFirewallPort{
+						{Protocol: v1.ProtocolTCP, Port: 30080},
+						{Protocol: v1.ProtocolTCP, Port: 30443},
+						{Protocol: v1.ProtocolUDP},
+					},
+				},
+				nodefirewallv1.NodeFirewallRule{
+					Action:     nodefirewallv1.NodeFirewallActionDeny,
+					SourceCIDR: "not-a-cidr",
+				},
+				nodefirewallv1.NodeFirewallRule{
+					Action:     nodefirewallv1.NodeFirewallActionDeny,
+					SourceCIDR: "fd00:10::/64",
+					Ports:      []nodefirewallv1.NodeFirewallPort{{Protocol: v1.ProtocolSCTP, Port: 30132}},
+				},
+			),
+		}
+
+		acls := nodeFirewallACLs("breth0_node1", []*net.IPNet{
+			ovntest.MustParseIPNet("10.0.0.5/24"),
+			ovntest.MustParseIPNet("fd00:10::5/64"),
+		}, firewalls)
+		Expect(acls).To(Equal([]nodeFirewallACL{
+			{
+				priority: 999,
+				match:    `inport == \"breth0_node1\" && ip4.src == 192.168.1.0/24 && ip4.dst == 10.0.0.5`,
+				action:   "allow-related",
+			},
+			{
+				priority: 998,
+				match:    `inport == \"breth0_node1\" && ip4.src == 192.168.0.0/16 && ip4.dst == 10.0.0.5 && tcp && tcp.dst == {30080, 30443}`,
+				action:   "drop",
+			},
+			{
+				priority: 998,
+				match:    `inport == \"breth0_node1\" && ip4.src == 192.168.0.0/16 && ip4.dst == 10.0.0.5 && udp`,
+				action:   "drop",
+			},
+			{
+				priority: 997,
+				match:    `inport == \"breth0_node1\" && ip6.src == fd00:10::/64 && ip6.dst == fd00:10::5 && sctp && sctp.dst == 30132`,
+				action:   "drop",
+			},
+			{
+				priority: 100,
+				match:    `inport == \"breth0_node1\" && ip`,
+				action:   "allow-related",
+			},
+		}))
+
+		// A node without addresses of the rules' IP family has no rules
+		Expect(nodeFirewallACLs("breth0_node1", []*net.IPNet{
+			ovntest.MustParseIPNet("fd00:10::5/64"),
+		}, firewalls[:1])).To(BeEmpty())
+	})
+
+	It("replaces the ACLs of the external switch in one transaction", func() {
+		fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+			Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl external_ids:node-firewall-node=node1",
+			Output: "acl-uuid-1\nacl-uuid-2",
+		})
+		fExec.AddFakeCmdsNoOutputNoError([]string{
+			"ovn-nbctl --timeout=15 -- remove logical_switch ext_node1 acls acl-uuid-1 " +
+				"-- remove logical_switch ext_node1 acls acl-uuid-2 " +
+				"-- --id=@acl0 create acl direction=from-lport priority=999 " +
+				`match="inport == \"breth0_node1\" && ip4.src == 192.168.1.0/24 && ip4.dst == 10.0.0.5" action=drop ` +
+				"external_ids:node-firewall-node=node1 -- add logical_switch ext_node1 acls @acl0",
+		})
+
+		err := setNodeFirewallACLs("node1", []nodeFirewallACL{
+			{
+				priority: 999,
+				match:    `inport == \"breth0_node1\" && ip4.src == 192.168.1.0/24 && ip4.dst == 10.0.0.5`,
+				action:   "drop",
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+	})
+})
+
+var _ = Describe("Node firewall watching", func() {
+	var app *cli.App
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+	})
+
+	It("renders the firewalls on the selected nodes and removes them when deleted", func() {
+		app.Action = func(ctx *cli.Context) error {
+			fExec := ovntest.NewFakeExec()
+			_, err := config.InitConfig(ctx, fExec, nil)
+			Expect(err).NotTo(HaveOccurred())
+			err = util.SetExec(fExec)
+			Expect(err).NotTo(HaveOccurred())
+
+			newGatewayNode := func(name string, labels map[string]string) v1.Node {
+				return v1.Node{ObjectMeta: metav1.ObjectMeta{
+					Name:   name,
+					Labels: labels,
+					Annotations: map[string]string{
+						"k8s.ovn.org/l3-gateway-config": `{"default":{"mode":"shared","interface-id":"breth0_` + name + `",` +
+							`"mac-address":"11:22:33:44:55:66","ip-address":"10.0.0.5/24","next-hop":"10.0.0.1"}}`,
+						"k8s.ovn.org/node-chassis-id": "cb9ec8fa-b409-4ef3-9f42-d9283c47aac6",
+					},
+				}}
+			}
+			// node2 has no gateway router to render the firewall on
+			fakeClient := fake.NewSimpleClientset(&v1.NodeList{
+				Items: []v1.Node{
+					newGatewayNode("node1", map[string]string{"edge": "true"}),
+					{ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{"edge": "true"}}},
+				},
+			})
+			firewall := newNodeFirewall("edge", map[string]string{"edge": "true"},
+				nodefirewallv1.NodeFirewallRule{
+					Action:     nodefirewallv1.NodeFirewallActionDeny,
+					SourceCIDR: "192.168.0.0/16",
+				},
+			)
+			firewallClient := &fakeNodeFirewallClient{
+				firewalls: []nodefirewallv1.NodeFirewall{*firewall},
+				watcher:   watch.NewFake(),
+			}
+
+			node1ACLs := "-- --id=@acl0 create acl direction=from-lport priority=999 " +
+				`match="inport == \"breth0_node1\" && ip4.src == 192.168.0.0/16 && ip4.dst == 10.0.0.5" action=drop ` +
+				"external_ids:node-firewall-node=node1 -- add logical_switch ext_node1 acls @acl0 " +
+				"-- --id=@acl1 create acl direction=from-lport priority=100 " +
+				`match="inport == \"breth0_node1\" && ip" action=allow-related ` +
+				"external_ids:node-firewall-node=node1 -- add logical_switch ext_node1 acls @acl1"
+			// The initial sync renders all nodes
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl external_ids:node-firewall-node=node1",
+				"ovn-nbctl --timeout=15 " + node1ACLs,
+			})
+			// Then the existing firewall is added, re-rendering node1
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl external_ids:node-firewall-node=node1",
+				Output: "acl-uuid-1\nacl-uuid-2",
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 -- remove logical_switch ext_node1 acls acl-uuid-1 " +
+					"-- remove logical_switch ext_node1 acls acl-uuid-2 " + node1ACLs,
+			})
+
+			stop := make(chan struct{})
+			wf, err := factory.NewWatchFactory(fakeClient, stop)
+			Expect(err).NotTo(HaveOccurred())
+			defer close(stop)
+
+			clusterController := NewOvnController(fakeClient, firewallClient, wf, stop)
+			err = clusterController.WatchNodeFirewalls()
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			// Deleting the firewall removes its ACLs
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl external_ids:node-firewall-node=node1",
+				Output: "acl-uuid-3\nacl-uuid-4",
+			})
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 -- remove logical_switch ext_node1 acls acl-uuid-3 " +
+					"-- remove logical_switch ext_node1 acls acl-uuid-4",
+			})
+			firewallClient.watcher.Delete(firewall)
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
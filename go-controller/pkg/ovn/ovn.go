@@ -11,9 +11,13 @@ import (
 	"time"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	nodefirewallv1 "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/crd/nodefirewall/v1"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/logging"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/allocator"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/ovn/retry"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	kapi "k8s.io/api/core/v1"
@@ -21,6 +25,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
@@ -45,7 +50,7 @@ type loadBalancerConf struct {
 }
 
 // namespaceInfo contains information related to a Namespace. Use oc.getNamespaceLocked()
-// or oc.waitForNamespaceLocked() to get a locked namespaceInfo for a Namespace, and call
+// or oc.requireNamespaceLocked() to get a locked namespaceInfo for a Namespace, and call
 // nsInfo.Unlock() on it when you are done with it. (No code outside of the code that
 // manages the oc.namespaces map is ever allowed to hold an unlocked namespaceInfo.)
 type namespaceInfo struct {
@@ -64,6 +69,21 @@ type namespaceInfo struct {
 	hybridOverlayVTEP       net.IP
 
 	multicastEnabled bool
+	// groups and source namespaces of the multicast allow policy, if
+	// multicast is enabled
+	multicastPolicy multicastAllowPolicy
+
+	// QoS annotations of the namespace, used as defaults for its pods
+	qosDefaults map[string]string
+
+	// static external gateways from the namespace's routing-external-gws
+	// annotation
+	routingExternalGWs gatewayInfo
+	// external gateways provided by gateway pods, keyed by pod namespace/name
+	routingExternalPodGWs map[string]gatewayInfo
+
+	// provider network new pods of the namespace are attached to, if any
+	providerNetwork string
 }
 
 // Controller structure is the object which holds the controls for starting
@@ -97,7 +117,7 @@ type Controller struct {
 	logicalPortCache *portCache
 
 	// Info about known namespaces. You must use oc.getNamespaceLocked() or
-	// oc.waitForNamespaceLocked() to read this map, and oc.createNamespaceLocked()
+	// oc.requireNamespaceLocked() to read this map, and oc.createNamespaceLocked()
 	// or oc.deleteNamespaceLocked() to modify it. namespacesMutex is only held
 	// from inside those functions.
 	namespaces      map[string]*namespaceInfo
@@ -138,6 +158,20 @@ type Controller struct {
 
 	// event recorder used to post events to k8s
 	recorder record.EventRecorder
+
+	// informer of the NodeFirewall resources, nil if node firewalls are
+	// disabled
+	nodeFirewallInformer cache.SharedIndexInformer
+	// serializes the rendering of the NodeFirewall ACLs of the nodes
+	nodeFirewallLock sync.Mutex
+
+	// retry the failed operations on pods, services, endpoints, network
+	// policies and nodes
+	retryPods      *retry.Queue
+	retryServices  *retry.Queue
+	retryEndpoints *retry.Queue
+	retryPolicies  *retry.Queue
+	retryNodes     *retry.Queue
 }
 
 const (
@@ -153,7 +187,17 @@ const (
 
 // NewOvnController creates a new OVN controller for creating logical network
 // infrastructure and policy
-func NewOvnController(kubeClient kubernetes.Interface, wf *factory.WatchFactory, stopChan <-chan struct{}) *Controller {
+func NewOvnController(kubeClient kubernetes.Interface, nodeFirewallClient nodefirewallv1.Interface,
+	wf *factory.WatchFactory, stopChan <-chan struct{}) *Controller {
+	var nodeFirewallInformer cache.SharedIndexInformer
+	if nodeFirewallClient != nil {
+		nodeFirewallInformer = nodefirewallv1.NewInformer(nodeFirewallClient, 0)
+	}
+	retryMetrics := &retry.Metrics{
+		Retries:          metrics.MetricMasterResourceRetries,
+		Pending:          metrics.MetricMasterResourceRetriesPending,
+		OldestFailureAge: metrics.MetricMasterResourceOldestFailure,
+	}
 	return &Controller{
 		kube:                     &kube.Kube{KClient: kubeClient},
 		watchFactory:             wf,
@@ -176,12 +220,19 @@ func NewOvnController(kubeClient kubernetes.Interface, wf *factory.WatchFactory,
 		serviceLBMap:             make(map[string]map[string]*loadBalancerConf),
 		serviceLBLock:            sync.Mutex{},
 		recorder:                 util.EventRecorder(kubeClient),
+		nodeFirewallInformer:     nodeFirewallInformer,
+		retryPods:                retry.NewQueue("pod", retryMetrics, stopChan),
+		retryServices:            retry.NewQueue("service", retryMetrics, stopChan),
+		retryEndpoints:           retry.NewQueue("endpoints", retryMetrics, stopChan),
+		retryPolicies:            retry.NewQueue("networkpolicy", retryMetrics, stopChan),
+		retryNodes:               retry.NewQueue("node", retryMetrics, stopChan),
 	}
 }
 
 // Run starts the actual watching.
 func (oc *Controller) Run() error {
 	oc.syncPeriodic()
+	oc.registerDebugState()
 	// WatchNodes must be started first so that its initial Add will
 	// create all node logical switches, which other watches may depend on.
 	// https://github.com/ovn-org/ovn-kubernetes/pull/859
@@ -196,10 +247,26 @@ func (oc *Controller) Run() error {
 		}
 	}
 
+	if oc.nodeFirewallInformer != nil {
+		if err := oc.WatchNodeFirewalls(); err != nil {
+			return err
+		}
+	}
+
+	if config.IPsec.Enabled {
+		if err := oc.WatchIPsecCSRs(); err != nil {
+			return err
+		}
+	}
+
 	if config.Kubernetes.OVNEmptyLbEvents {
 		go oc.ovnControllerEventChecker()
 	}
 
+	if config.NBDBCheck.Interval > 0 {
+		oc.checkNBDBPeriodic()
+	}
+
 	return nil
 }
 
@@ -381,47 +448,121 @@ func podScheduled(pod *kapi.Pod) bool {
 	return pod.Spec.NodeName != ""
 }
 
+// retryKey returns the key of a namespaced object in the retry queues
+func retryKey(obj metav1.Object) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// podDependencies returns what the handling of a pod waits for: the pod's
+// namespace if the pod's network depends on the namespace's annotations, and
+// the logical switch of its node. The namespaces a gateway pod routes the
+// traffic of are not waited for, adding the gateway to them is retried until
+// they exist.
+func (oc *Controller) podDependencies(obj interface{}) []factory.Dependency {
+	pod := obj.(*kapi.Pod)
+	if !podWantsNetwork(pod) || !podScheduled(pod) {
+		return nil
+	}
+	var deps []factory.Dependency
+
+	_, providerNetworkAnnotated := pod.Annotations[util.ProviderNetworkAnnotation]
+	if config.HybridOverlay.Enabled || (len(config.ProviderNetworks.Networks) > 0 && !providerNetworkAnnotated) {
+		deps = append(deps, factory.Dependency{Kind: factory.NamespaceDependency, Name: pod.Namespace})
+	}
+	// Pods on a provider network are not attached to their node's switch.
+	// The network may be set on the namespace, in which case this is
+	// called again once the namespace is known.
+	if network, err := oc.getPodProviderNetwork(pod); err != nil || network == nil {
+		deps = append(deps, factory.Dependency{Kind: factory.NodeSwitchDependency, Name: pod.Spec.NodeName})
+	}
+	return deps
+}
+
+// measureSync returns sync recording the duration of the sync of the existing
+// objects of resource
+func measureSync(resource string, sync func([]interface{})) func([]interface{}) {
+	return func(objs []interface{}) {
+		_ = metrics.MeasureHandler(resource, "sync", func() error {
+			sync(objs)
+			return nil
+		})
+	}
+}
+
 // WatchPods starts the watching of Pod resource and calls back the appropriate handler logic
 func (oc *Controller) WatchPods() error {
-	var retryPods sync.Map
-	_, err := oc.watchFactory.AddPodHandler(cache.ResourceEventHandlerFuncs{
+	_, err := oc.watchFactory.AddPodHandler(oc.watchFactory.WithDependencies(oc.podDependencies, cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			pod := obj.(*kapi.Pod)
-			if !podWantsNetwork(pod) {
+			if podIsExternalGW(pod) {
+				err := oc.retryPods.Do(exGWRetryKey(pod), func() error {
+					return oc.addPodExternalGW(pod)
+				})
+				if err != nil {
+					klog.Errorf("%s %v", logging.Pod(pod.Namespace, pod.Name).Operation("add"), err)
+				}
+			}
+			// Unscheduled pods are handled later in UpdateFunc
+			if !podWantsNetwork(pod) || !podScheduled(pod) {
 				return
 			}
 
-			if podScheduled(pod) {
-				if err := oc.addLogicalPort(pod); err != nil {
-					klog.Errorf(err.Error())
-					retryPods.Store(pod.UID, true)
-				}
-			} else {
-				// Handle unscheduled pods later in UpdateFunc
-				retryPods.Store(pod.UID, true)
+			err := oc.retryPods.Do(string(pod.UID), func() error {
+				return metrics.MeasureHandler("pod", "add", func() error {
+					return oc.addLogicalPort(pod)
+				})
+			})
+			if err != nil {
+				klog.Errorf("%s %v", logging.Pod(pod.Namespace, pod.Name).Operation("add"), err)
 			}
 		},
 		UpdateFunc: func(old, newer interface{}) {
+			oldPod := old.(*kapi.Pod)
 			pod := newer.(*kapi.Pod)
+			if podIsExternalGW(oldPod) || podIsExternalGW(pod) {
+				err := oc.retryPods.Do(exGWRetryKey(pod), func() error {
+					return oc.updatePodExternalGW(oldPod, pod)
+				})
+				if err != nil {
+					klog.Errorf("%s %v", logging.Pod(pod.Namespace, pod.Name).Operation("update"), err)
+				}
+			}
 			if !podWantsNetwork(pod) {
 				return
 			}
 
-			_, retry := retryPods.Load(pod.UID)
-			if podScheduled(pod) && retry {
-				if err := oc.addLogicalPort(pod); err != nil {
-					klog.Errorf(err.Error())
-				} else {
-					retryPods.Delete(pod.UID)
+			oc.updatePodQoSAnnotations(oldPod, pod)
+
+			// Add the pod once it is scheduled, or with its newer state if
+			// adding it failed
+			key := string(pod.UID)
+			if podScheduled(pod) && (!podScheduled(oldPod) || oc.retryPods.Pending(key)) {
+				err := oc.retryPods.Do(key, func() error {
+					return metrics.MeasureHandler("pod", "update", func() error {
+						return oc.addLogicalPort(pod)
+					})
+				})
+				if err != nil {
+					klog.Errorf("%s %v", logging.Pod(pod.Namespace, pod.Name).Operation("update"), err)
 				}
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			pod := obj.(*kapi.Pod)
-			oc.deleteLogicalPort(pod)
-			retryPods.Delete(pod.UID)
+			if podIsExternalGW(pod) {
+				_ = oc.retryPods.Do(exGWRetryKey(pod), func() error {
+					oc.deletePodExternalGW(pod, parseRoutingNamespaces(pod))
+					return nil
+				})
+			}
+			_ = oc.retryPods.Do(string(pod.UID), func() error {
+				return metrics.MeasureHandler("pod", "delete", func() error {
+					oc.deleteLogicalPort(pod)
+					return nil
+				})
+			})
 		},
-	}, oc.syncPods)
+	}), measureSync("pod", oc.syncPods))
 	return err
 }
 
@@ -431,87 +572,150 @@ func (oc *Controller) WatchServices() error {
 	_, err := oc.watchFactory.AddServiceHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			service := obj.(*kapi.Service)
-			err := oc.createService(service)
+			err := oc.retryServices.Do(retryKey(service), func() error {
+				return metrics.MeasureHandler("service", "add", func() error {
+					return oc.createService(service)
+				})
+			})
 			if err != nil {
-				klog.Errorf("Error in adding service: %v", err)
+				klog.Errorf("%s Error in adding service: %v", logging.Service(service.Namespace, service.Name).Operation("add"), err)
 			}
+			// The retries of the service pick up its endpoints, so they
+			// need not wait for the service to be created in OVN
+			oc.watchFactory.MarkReady(factory.ServiceDependency, retryKey(service))
 		},
 		UpdateFunc: func(old, new interface{}) {
 			svcOld := old.(*kapi.Service)
 			svcNew := new.(*kapi.Service)
-			err := oc.updateService(svcOld, svcNew)
+			err := oc.retryServices.Do(retryKey(svcNew), func() error {
+				return metrics.MeasureHandler("service", "update", func() error {
+					return oc.updateService(svcOld, svcNew)
+				})
+			})
 			if err != nil {
-				klog.Errorf("Error while updating service: %v", err)
+				klog.Errorf("%s Error while updating service: %v", logging.Service(svcNew.Namespace, svcNew.Name).Operation("update"), err)
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			service := obj.(*kapi.Service)
-			oc.deleteService(service)
+			oc.watchFactory.MarkNotReady(factory.ServiceDependency, retryKey(service))
+			_ = oc.retryServices.Do(retryKey(service), func() error {
+				return metrics.MeasureHandler("service", "delete", func() error {
+					oc.deleteService(service)
+					return nil
+				})
+			})
 		},
-	}, oc.syncServices)
+	}, measureSync("service", oc.syncServices))
 	return err
 }
 
 // WatchEndpoints starts the watching of Endpoint resource and calls back the appropriate handler logic
 func (oc *Controller) WatchEndpoints() error {
-	_, err := oc.watchFactory.AddEndpointsHandler(cache.ResourceEventHandlerFuncs{
+	// Endpoints wait for their service, endpoints without a service are
+	// never handled
+	endpointsDependencies := func(obj interface{}) []factory.Dependency {
+		return []factory.Dependency{{Kind: factory.ServiceDependency, Name: retryKey(obj.(*kapi.Endpoints))}}
+	}
+	_, err := oc.watchFactory.AddEndpointsHandler(oc.watchFactory.WithDependencies(endpointsDependencies, cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			ep := obj.(*kapi.Endpoints)
-			err := oc.AddEndpoints(ep)
+			err := oc.retryEndpoints.Do(retryKey(ep), func() error {
+				return metrics.MeasureHandler("endpoints", "add", func() error {
+					return oc.AddEndpoints(ep)
+				})
+			})
 			if err != nil {
-				klog.Errorf("Error in adding load balancer: %v", err)
+				klog.Errorf("%s Error in adding load balancer: %v", logging.Service(ep.Namespace, ep.Name).Operation("add"), err)
 			}
 		},
 		UpdateFunc: func(old, new interface{}) {
 			epNew := new.(*kapi.Endpoints)
 			epOld := old.(*kapi.Endpoints)
+			key := retryKey(epNew)
 			if reflect.DeepEqual(epNew.Subsets, epOld.Subsets) {
 				return
 			}
 			if len(epNew.Subsets) == 0 {
-				err := oc.deleteEndpoints(epNew)
+				err := oc.retryEndpoints.Do(key, func() error {
+					return metrics.MeasureHandler("endpoints", "update", func() error {
+						return oc.deleteEndpoints(epNew)
+					})
+				})
 				if err != nil {
-					klog.Errorf("Error in deleting endpoints - %v", err)
+					klog.Errorf("%s Error in deleting endpoints - %v", logging.Service(epNew.Namespace, epNew.Name).Operation("update"), err)
 				}
 			} else {
-				err := oc.AddEndpoints(epNew)
+				err := oc.retryEndpoints.Do(key, func() error {
+					return metrics.MeasureHandler("endpoints", "update", func() error {
+						return oc.AddEndpoints(epNew)
+					})
+				})
 				if err != nil {
-					klog.Errorf("Error in modifying endpoints: %v", err)
+					klog.Errorf("%s Error in modifying endpoints: %v", logging.Service(epNew.Namespace, epNew.Name).Operation("update"), err)
 				}
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			ep := obj.(*kapi.Endpoints)
-			err := oc.deleteEndpoints(ep)
+			err := oc.retryEndpoints.Do(retryKey(ep), func() error {
+				return metrics.MeasureHandler("endpoints", "delete", func() error {
+					return oc.deleteEndpoints(ep)
+				})
+			})
 			if err != nil {
-				klog.Errorf("Error in deleting endpoints - %v", err)
+				klog.Errorf("%s Error in deleting endpoints - %v", logging.Service(ep.Namespace, ep.Name).Operation("delete"), err)
 			}
 		},
-	}, nil)
+	}), nil)
 	return err
 }
 
 // WatchNetworkPolicy starts the watching of network policy resource and calls
 // back the appropriate handler logic
 func (oc *Controller) WatchNetworkPolicy() error {
-	_, err := oc.watchFactory.AddPolicyHandler(cache.ResourceEventHandlerFuncs{
+	// Policies wait for their namespace
+	policyDependencies := func(obj interface{}) []factory.Dependency {
+		policy := obj.(*kapisnetworking.NetworkPolicy)
+		return []factory.Dependency{{Kind: factory.NamespaceDependency, Name: policy.Namespace}}
+	}
+	_, err := oc.watchFactory.AddPolicyHandler(oc.watchFactory.WithDependencies(policyDependencies, cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			policy := obj.(*kapisnetworking.NetworkPolicy)
-			oc.addNetworkPolicy(policy)
+			err := oc.retryPolicies.Do(retryKey(policy), func() error {
+				return metrics.MeasureHandler("networkpolicy", "add", func() error {
+					return oc.addNetworkPolicy(policy)
+				})
+			})
+			if err != nil {
+				klog.Errorf("%s %v", logging.Policy(policy.Namespace, policy.Name).Operation("add"), err)
+			}
 		},
 		UpdateFunc: func(old, newer interface{}) {
 			oldPolicy := old.(*kapisnetworking.NetworkPolicy)
 			newPolicy := newer.(*kapisnetworking.NetworkPolicy)
 			if !reflect.DeepEqual(oldPolicy, newPolicy) {
-				oc.deleteNetworkPolicy(oldPolicy)
-				oc.addNetworkPolicy(newPolicy)
+				err := oc.retryPolicies.Do(retryKey(newPolicy), func() error {
+					return metrics.MeasureHandler("networkpolicy", "update", func() error {
+						oc.deleteNetworkPolicy(oldPolicy)
+						return oc.addNetworkPolicy(newPolicy)
+					})
+				})
+				if err != nil {
+					klog.Errorf("%s %v", logging.Policy(newPolicy.Namespace, newPolicy.Name).Operation("update"), err)
+				}
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			policy := obj.(*kapisnetworking.NetworkPolicy)
-			oc.deleteNetworkPolicy(policy)
+			_ = oc.retryPolicies.Do(retryKey(policy), func() error {
+				return metrics.MeasureHandler("networkpolicy", "delete", func() error {
+					oc.deleteNetworkPolicy(policy)
+					return nil
+				})
+			})
 		},
-	}, oc.syncNetworkPolicies)
+	}), measureSync("networkpolicy", oc.syncNetworkPolicies))
 	return err
 }
 
@@ -521,17 +725,26 @@ func (oc *Controller) WatchNamespaces() error {
 	_, err := oc.watchFactory.AddNamespaceHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			ns := obj.(*kapi.Namespace)
-			oc.AddNamespace(ns)
+			_ = metrics.MeasureHandler("namespace", "add", func() error {
+				oc.AddNamespace(ns)
+				return nil
+			})
 		},
 		UpdateFunc: func(old, newer interface{}) {
 			oldNs, newNs := old.(*kapi.Namespace), newer.(*kapi.Namespace)
-			oc.updateNamespace(oldNs, newNs)
+			_ = metrics.MeasureHandler("namespace", "update", func() error {
+				oc.updateNamespace(oldNs, newNs)
+				return nil
+			})
 		},
 		DeleteFunc: func(obj interface{}) {
 			ns := obj.(*kapi.Namespace)
-			oc.deleteNamespace(ns)
+			_ = metrics.MeasureHandler("namespace", "delete", func() error {
+				oc.deleteNamespace(ns)
+				return nil
+			})
 		},
-	}, oc.syncNamespaces)
+	}, measureSync("namespace", oc.syncNamespaces))
 	return err
 }
 
@@ -556,11 +769,95 @@ func (oc *Controller) syncNodeGateway(node *kapi.Node, hostSubnets []*net.IPNet)
 	return nil
 }
 
+// cleanupNodeGateway removes the gateway router of a node and, if the node
+// was serving the external IP load balancers, moves them to another node.
+func (oc *Controller) cleanupNodeGateway(node *kapi.Node) error {
+	hostSubnets, _ := util.ParseNodeHostSubnetAnnotation(node)
+	if err := gatewayCleanup(node.Name, hostSubnets); err != nil {
+		return fmt.Errorf("error cleaning up gateway for node %s: %v", node.Name, err)
+	}
+	oc.migrateExternalIPsLB(node.Name)
+	return nil
+}
+
+// migrateExternalIPsLB moves the external IP load balancers to another node
+// if the gateway router of the given node was serving them
+func (oc *Controller) migrateExternalIPsLB(nodeName string) {
+	if oc.defGatewayRouter == gwRouterPrefix+nodeName {
+		delete(oc.loadbalancerGWCache, kapi.ProtocolTCP)
+		delete(oc.loadbalancerGWCache, kapi.ProtocolUDP)
+		delete(oc.loadbalancerGWCache, kapi.ProtocolSCTP)
+		oc.defGatewayRouter = ""
+		oc.updateExternalIPsLB()
+	}
+}
+
 // WatchNodes starts the watching of node resource and calls
 // back the appropriate handler logic
 func (oc *Controller) WatchNodes() error {
-	var gatewaysFailed sync.Map
-	var mgmtPortFailed sync.Map
+	// The parts of the nodes' setup that are still to be done, because the
+	// node was just added, changed or setting it up failed. The pending
+	// gateways map to the node's stale gateway IPs. The nodes whose gateway
+	// router was removed for a gateway mode change are pending the external
+	// gateway routes of their pods until the router is rebuilt.
+	var subnetsPending sync.Map
+	var mgmtPortsPending sync.Map
+	var gatewaysPending sync.Map
+	var exGWRoutesPending sync.Map
+	var firewallsPending sync.Map
+
+	// syncNode sets up the pending parts of a node and returns an error if
+	// any of them failed, leaving them pending for the retry
+	syncNode := func(node *kapi.Node) error {
+		var hostSubnets []*net.IPNet
+		if _, pending := subnetsPending.Load(node.Name); pending {
+			var err error
+			hostSubnets, err = oc.addNode(node)
+			if err != nil {
+				return fmt.Errorf("error creating subnet for node %s: %v", node.Name, err)
+			}
+			subnetsPending.Delete(node.Name)
+		}
+
+		var errs []error
+		if _, pending := mgmtPortsPending.Load(node.Name); pending {
+			if err := oc.syncNodeManagementPort(node, hostSubnets); err != nil {
+				errs = append(errs, fmt.Errorf("error creating management port for node %s: %v", node.Name, err))
+			} else {
+				mgmtPortsPending.Delete(node.Name)
+			}
+		}
+
+		if staleIPs, pending := gatewaysPending.Load(node.Name); pending {
+			if err := oc.syncNodeGateway(node, hostSubnets); err != nil {
+				errs = append(errs, err)
+			} else {
+				gatewaysPending.Delete(node.Name)
+				if _, pending := exGWRoutesPending.Load(node.Name); pending {
+					oc.addGWRoutesForNode(node.Name)
+					exGWRoutesPending.Delete(node.Name)
+				}
+				// The addresses of the node's gateway interface changed.
+				// The gateway router now has the new physical IPs, so
+				// move the NodePort VIPs over to them.
+				if staleIPs := staleIPs.([]string); len(staleIPs) > 0 {
+					if err := oc.updateGatewayIPs(node, staleIPs); err != nil {
+						klog.Errorf("error updating gateway IPs of node %s: %v", node.Name, err)
+					}
+				}
+			}
+		}
+
+		if _, pending := firewallsPending.Load(node.Name); pending {
+			if err := oc.syncNodeFirewall(node); err != nil {
+				errs = append(errs, err)
+			} else {
+				firewallsPending.Delete(node.Name)
+			}
+		}
+		return utilerrors.NewAggregate(errs)
+	}
+
 	_, err := oc.watchFactory.AddNodeHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			node := obj.(*kapi.Node)
@@ -569,26 +866,27 @@ func (oc *Controller) WatchNodes() error {
 				defer oc.lsMutex.Unlock()
 				//setting the value to nil in the cache means it was not assigned a hostSubnet by ovn-kube
 				oc.logicalSwitchCache[node.Name] = nil
+				oc.watchFactory.MarkReady(factory.NodeSwitchDependency, node.Name)
 				return
 			}
 
 			klog.V(5).Infof("Added event for Node %q", node.Name)
-			hostSubnets, err := oc.addNode(node)
+			subnetsPending.Store(node.Name, true)
+			mgmtPortsPending.Store(node.Name, true)
+			gatewaysPending.Store(node.Name, []string(nil))
+			firewallsPending.Store(node.Name, true)
+			err := oc.retryNodes.Do(node.Name, func() error {
+				return metrics.MeasureHandler("node", "add", func() error {
+					return syncNode(node)
+				})
+			})
 			if err != nil {
-				klog.Errorf("error creating subnet for node %s: %v", node.Name, err)
-				return
-			}
-
-			err = oc.syncNodeManagementPort(node, hostSubnets)
-			if err != nil {
-				klog.Warningf("error creating management port for node %s: %v", node.Name, err)
-				mgmtPortFailed.Store(node.Name, true)
-			}
-
-			if err := oc.syncNodeGateway(node, hostSubnets); err != nil {
+				// The node may not have published its management port
+				// and gateway yet, its update will set them up
 				klog.Warningf(err.Error())
-				gatewaysFailed.Store(node.Name, true)
 			}
+
+			oc.checkNodeMTU(node)
 		},
 		UpdateFunc: func(old, new interface{}) {
 			oldNode := old.(*kapi.Node)
@@ -605,28 +903,46 @@ func (oc *Controller) WatchNodes() error {
 
 			klog.V(5).Infof("Updated event for Node %q", node.Name)
 
-			_, failed := mgmtPortFailed.Load(node.Name)
-			if failed || macAddressChanged(oldNode, node) {
-				err := oc.syncNodeManagementPort(node, nil)
-				if err != nil {
-					klog.Errorf("error updating management port for node %s: %v", node.Name, err)
-					mgmtPortFailed.Store(node.Name, true)
-				} else {
-					mgmtPortFailed.Delete(node.Name)
-				}
+			if macAddressChanged(oldNode, node) {
+				mgmtPortsPending.Store(node.Name, true)
 			}
 
 			oc.clearInitialNodeNetworkUnavailableCondition(oldNode, node)
 
-			_, failed = gatewaysFailed.Load(node.Name)
-			if failed || gatewayChanged(oldNode, node) {
-				err := oc.syncNodeGateway(node, nil)
-				if err != nil {
+			if gatewayModeChanged(oldNode, node) {
+				// The node switched gateway modes. Remove the gateway router of
+				// the old mode so that it is rebuilt for the new mode below.
+				if err := oc.cleanupNodeGateway(oldNode); err != nil {
 					klog.Errorf(err.Error())
-					gatewaysFailed.Store(node.Name, true)
-				} else {
-					gatewaysFailed.Delete(node.Name)
 				}
+				exGWRoutesPending.Store(node.Name, true)
+			}
+
+			if gatewayChanged(oldNode, node) {
+				staleIPs := staleGatewayIPs(oldNode, node)
+				if pendingIPs, pending := gatewaysPending.Load(node.Name); pending {
+					staleIPs = append(pendingIPs.([]string), staleIPs...)
+				}
+				gatewaysPending.Store(node.Name, staleIPs)
+			}
+
+			// The NodeFirewalls selecting the node, or the gateway they are
+			// rendered for, may have changed
+			if gatewayChanged(oldNode, node) || !reflect.DeepEqual(oldNode.Labels, node.Labels) {
+				firewallsPending.Store(node.Name, true)
+			}
+
+			err = oc.retryNodes.Do(node.Name, func() error {
+				return metrics.MeasureHandler("node", "update", func() error {
+					return syncNode(node)
+				})
+			})
+			if err != nil {
+				klog.Errorf(err.Error())
+			}
+
+			if mtuChanged(oldNode, node) {
+				oc.checkNodeMTU(node)
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
@@ -634,27 +950,28 @@ func (oc *Controller) WatchNodes() error {
 			klog.V(5).Infof("Delete event for Node %q. Removing the node from "+
 				"various caches", node.Name)
 
+			subnetsPending.Delete(node.Name)
+			mgmtPortsPending.Delete(node.Name)
+			gatewaysPending.Delete(node.Name)
+			exGWRoutesPending.Delete(node.Name)
+			firewallsPending.Delete(node.Name)
 			nodeSubnets, _ := util.ParseNodeHostSubnetAnnotation(node)
 			joinSubnets, _ := util.ParseNodeJoinSubnetAnnotation(node)
-			err := oc.deleteNode(node.Name, nodeSubnets, joinSubnets)
+			err := oc.retryNodes.Do(node.Name, func() error {
+				return metrics.MeasureHandler("node", "delete", func() error {
+					return oc.deleteNode(node.Name, nodeSubnets, joinSubnets)
+				})
+			})
 			if err != nil {
 				klog.Error(err)
 			}
 			oc.lsMutex.Lock()
 			delete(oc.logicalSwitchCache, node.Name)
+			oc.watchFactory.MarkNotReady(factory.NodeSwitchDependency, node.Name)
 			oc.lsMutex.Unlock()
-			mgmtPortFailed.Delete(node.Name)
-			gatewaysFailed.Delete(node.Name)
-			// If this node was serving the external IP load balancer for services, migrate to a new node
-			if oc.defGatewayRouter == gwRouterPrefix+node.Name {
-				delete(oc.loadbalancerGWCache, kapi.ProtocolTCP)
-				delete(oc.loadbalancerGWCache, kapi.ProtocolUDP)
-				delete(oc.loadbalancerGWCache, kapi.ProtocolSCTP)
-				oc.defGatewayRouter = ""
-				oc.updateExternalIPsLB()
-			}
+			oc.migrateExternalIPsLB(node.Name)
 		},
-	}, oc.syncNodes)
+	}, measureSync("node", oc.syncNodes))
 	return err
 }
 
@@ -766,6 +1083,22 @@ func gatewayChanged(oldNode, newNode *kapi.Node) bool {
 	return !reflect.DeepEqual(oldL3GatewayConfig, l3GatewayConfig)
 }
 
+// gatewayModeChanged() returns true if the node switched from one enabled
+// gateway mode to another.
+func gatewayModeChanged(oldNode, newNode *kapi.Node) bool {
+	oldL3GatewayConfig, err := util.ParseNodeL3GatewayAnnotation(oldNode)
+	if err != nil {
+		return false
+	}
+	l3GatewayConfig, err := util.ParseNodeL3GatewayAnnotation(newNode)
+	if err != nil {
+		return false
+	}
+	return oldL3GatewayConfig.Mode != config.GatewayModeDisabled &&
+		l3GatewayConfig.Mode != config.GatewayModeDisabled &&
+		oldL3GatewayConfig.Mode != l3GatewayConfig.Mode
+}
+
 // macAddressChanged() compares old annotations to new and returns true if something has changed.
 func macAddressChanged(oldNode, node *kapi.Node) bool {
 	oldMacAddress, _ := util.ParseNodeManagementPortMACAddress(oldNode)
@@ -773,6 +1106,12 @@ func macAddressChanged(oldNode, node *kapi.Node) bool {
 	return !bytes.Equal(oldMacAddress, macAddress)
 }
 
+func mtuChanged(oldNode, node *kapi.Node) bool {
+	oldMTU, _ := util.ParseNodeMTU(oldNode)
+	mtu, _ := util.ParseNodeMTU(node)
+	return oldMTU != mtu
+}
+
 // noHostSubnet() compares the no-hostsubenet-nodes flag with node labels to see if the node is manageing its
 // own network.
 func noHostSubnet(node *kapi.Node) bool {
@@ -59,6 +59,6 @@ func (o *FakeOVN) init() {
 	o.watcher, err = factory.NewWatchFactory(o.fakeClient, o.stopChan)
 	Expect(err).NotTo(HaveOccurred())
 
-	o.controller = NewOvnController(o.fakeClient, o.watcher, o.stopChan)
+	o.controller = NewOvnController(o.fakeClient, nil, o.watcher, o.stopChan)
 	o.controller.multicastSupport = true
 }
@@ -0,0 +1,546 @@
+package ovn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	util "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The controller stamps the kind and the name of the Kubernetes object a row
+// of the NB database is created for in the row's external_ids, so that the
+// rows of an object can be found, and the object owning a row can be found
+// from the row. The rows created before the owners were stamped are matched
+// on the names and the external_ids they were always created with.
+const (
+	ownerKindExternalID = "k8s-owner-kind"
+	ownerExternalID     = "k8s-owner"
+)
+
+// The kinds of the Kubernetes objects owning rows of the NB database
+const (
+	OwnerKindPod           = "Pod"
+	OwnerKindNamespace     = "Namespace"
+	OwnerKindNetworkPolicy = "NetworkPolicy"
+	OwnerKindService       = "Service"
+)
+
+// OwnerReference refers to a Kubernetes object owning rows of the NB database
+type OwnerReference struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func podOwner(namespace, name string) *OwnerReference {
+	return &OwnerReference{Kind: OwnerKindPod, Namespace: namespace, Name: name}
+}
+
+// podOwnerForPort returns the owner of the logical port of a pod, named
+// <namespace>_<name>
+func podOwnerForPort(portName string) *OwnerReference {
+	parts := strings.SplitN(portName, "_", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	return podOwner(parts[0], parts[1])
+}
+
+func namespaceOwner(name string) *OwnerReference {
+	return &OwnerReference{Kind: OwnerKindNamespace, Name: name}
+}
+
+func networkPolicyOwner(namespace, name string) *OwnerReference {
+	return &OwnerReference{Kind: OwnerKindNetworkPolicy, Namespace: namespace, Name: name}
+}
+
+func serviceOwner(namespace, name string) *OwnerReference {
+	return &OwnerReference{Kind: OwnerKindService, Namespace: namespace, Name: name}
+}
+
+// ParseOwnerReference parses a reference to a Kubernetes object of the form
+// <kind>/<namespace>/<name>, or namespace/<name> for namespaces. The kind is
+// case insensitive and can be abbreviated as with kubectl.
+func ParseOwnerReference(ref string) (*OwnerReference, error) {
+	parts := strings.Split(ref, "/")
+	var kind string
+	switch strings.ToLower(parts[0]) {
+	case "pod", "pods", "po":
+		kind = OwnerKindPod
+	case "namespace", "namespaces", "ns":
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("invalid namespace reference %q: expected namespace/<name>", ref)
+		}
+		return namespaceOwner(parts[1]), nil
+	case "networkpolicy", "networkpolicies", "netpol":
+		kind = OwnerKindNetworkPolicy
+	case "service", "services", "svc":
+		kind = OwnerKindService
+	default:
+		return nil, fmt.Errorf("invalid reference %q: unknown kind %q", ref, parts[0])
+	}
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("invalid reference %q: expected %s/<namespace>/<name>", ref, parts[0])
+	}
+	return &OwnerReference{Kind: kind, Namespace: parts[1], Name: parts[2]}, nil
+}
+
+// key returns the namespaced name of the owner stamped on its rows
+func (o *OwnerReference) key() string {
+	if o.Namespace == "" {
+		return o.Name
+	}
+	return o.Namespace + "/" + o.Name
+}
+
+func (o *OwnerReference) String() string {
+	return o.Kind + " " + o.key()
+}
+
+// externalIDs returns the arguments of ovn-nbctl stamping the owner on the
+// row it creates. Rows shared by the whole cluster have no owner.
+func (o *OwnerReference) externalIDs() []string {
+	if o == nil {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("external-ids:%s=%s", ownerKindExternalID, o.Kind),
+		fmt.Sprintf("external-ids:%s=%s", ownerExternalID, o.key()),
+	}
+}
+
+// ownerFromExternalIDs returns the owner stamped on a row, if any
+func ownerFromExternalIDs(externalIDs map[string]string) *OwnerReference {
+	kind, key := externalIDs[ownerKindExternalID], externalIDs[ownerExternalID]
+	if kind == "" || key == "" {
+		return nil
+	}
+	owner := &OwnerReference{Kind: kind, Name: key}
+	if parts := strings.SplitN(key, "/", 2); len(parts) == 2 {
+		owner.Namespace, owner.Name = parts[0], parts[1]
+	}
+	return owner
+}
+
+// OVNRow is a row of the NB database owned by a Kubernetes object. The VIPs of
+// the load balancers are reported as rows of their load balancer named after
+// the VIP.
+type OVNRow struct {
+	Table string
+	UUID  string
+	Name  string
+}
+
+func (r *OVNRow) String() string {
+	if r.Name == "" {
+		return r.Table + " " + r.UUID
+	}
+	return fmt.Sprintf("%s %s (%s)", r.Table, r.UUID, r.Name)
+}
+
+// nbRow is a row of the NB database as output by ovn-nbctl --data=json
+type nbRow map[string]interface{}
+
+// findNBRows returns the columns of the rows of a table of the NB database
+// matching the conditions. The rows keep the structure of the maps and sets,
+// whose values can contain spaces.
+func findNBRows(table string, columns []string, conditions ...string) ([]nbRow, error) {
+	args := []string{"--format=json", "--data=json", "--columns=" + strings.Join(columns, ","), "find", table}
+	args = append(args, conditions...)
+	stdout, stderr, err := util.RunOVNNbctl(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s rows, stderr: %q, error: %v", table, stderr, err)
+	}
+	var output struct {
+		Headings []string        `json:"headings"`
+		Data     [][]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &output); err != nil {
+		return nil, fmt.Errorf("failed to parse the %s rows %q: %v", table, stdout, err)
+	}
+	rows := make([]nbRow, 0, len(output.Data))
+	for _, data := range output.Data {
+		row := make(nbRow, len(output.Headings))
+		for i, heading := range output.Headings {
+			if i < len(data) {
+				row[heading] = data[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// atom returns a string, UUID or integer column of the row
+func (r nbRow) atom(column string) string {
+	switch value := r[column].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []interface{}:
+		// ["uuid", "<uuid>"], or ["set", [<atom>]] for optional columns
+		if len(value) != 2 {
+			return ""
+		}
+		if atom, ok := value[1].(string); ok {
+			return atom
+		}
+		if set, ok := value[1].([]interface{}); ok && len(set) == 1 {
+			return nbRow{"": set[0]}.atom("")
+		}
+	}
+	return ""
+}
+
+// set returns the atoms of a set column of the row
+func (r nbRow) set(column string) []string {
+	value, ok := r[column].([]interface{})
+	if !ok || len(value) != 2 || value[0] != "set" {
+		if atom := r.atom(column); atom != "" {
+			return []string{atom}
+		}
+		return nil
+	}
+	elements, _ := value[1].([]interface{})
+	atoms := make([]string, 0, len(elements))
+	for _, element := range elements {
+		if atom := (nbRow{"": element}).atom(""); atom != "" {
+			atoms = append(atoms, atom)
+		}
+	}
+	return atoms
+}
+
+// smap returns a map column of the row
+func (r nbRow) smap(column string) map[string]string {
+	m := make(map[string]string)
+	value, ok := r[column].([]interface{})
+	if !ok || len(value) != 2 || value[0] != "map" {
+		return m
+	}
+	pairs, _ := value[1].([]interface{})
+	for _, pair := range pairs {
+		kv, ok := pair.([]interface{})
+		if !ok || len(kv) != 2 {
+			continue
+		}
+		key := nbRow{"": kv[0]}.atom("")
+		m[key] = nbRow{"": kv[1]}.atom("")
+	}
+	return m
+}
+
+// ownedTables are the tables whose rows are stamped with their owner, and
+// the column naming their rows
+var ownedTables = []struct {
+	table      string
+	nameColumn string
+}{
+	{"logical_switch_port", "name"},
+	{"address_set", "name"},
+	{"port_group", "name"},
+	{"acl", "name"},
+	{"qos", ""},
+}
+
+// ownedRows collects the rows found for an owner, without duplicates
+type ownedRows struct {
+	rows []*OVNRow
+	seen map[string]bool
+}
+
+func (o *ownedRows) add(table, uuid, name string) {
+	if o.seen == nil {
+		o.seen = make(map[string]bool)
+	}
+	key := table + "/" + uuid + "/" + name
+	if uuid == "" || o.seen[key] {
+		return
+	}
+	o.seen[key] = true
+	o.rows = append(o.rows, &OVNRow{Table: table, UUID: uuid, Name: name})
+}
+
+// addFound adds the rows of table matching the conditions, and the ACLs of
+// the port groups among them
+func (o *ownedRows) addFound(table, nameColumn string, conditions ...string) error {
+	columns := []string{"_uuid"}
+	if nameColumn != "" {
+		columns = append(columns, nameColumn)
+	}
+	if table == "port_group" {
+		columns = append(columns, "acls")
+	}
+	rows, err := findNBRows(table, columns, conditions...)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		o.add(table, row.atom("_uuid"), row.atom(nameColumn))
+		for _, acl := range row.set("acls") {
+			o.add("acl", acl, "")
+		}
+	}
+	return nil
+}
+
+// uuidRe matches the UUIDs of the rows
+var uuidRe = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// policyAddressSetRe matches the names of the address sets of the peers of a
+// network policy rule, <namespace>.<policy>.<ingress|egress>.<index>
+var policyAddressSetRe = regexp.MustCompile(`^([^.]+)\.(.+)\.(ingress|egress)\.\d+$`)
+
+// FindOwnedRows returns the rows of the NB database the controller owns for a
+// Kubernetes object. The client is only needed to find the rows of services,
+// whose load balancer VIPs depend on the service's addresses and ports.
+func FindOwnedRows(kclient kubernetes.Interface, owner *OwnerReference) ([]*OVNRow, error) {
+	found := &ownedRows{}
+	for _, t := range ownedTables {
+		err := found.addFound(t.table, t.nameColumn,
+			fmt.Sprintf("external_ids:%s=%s", ownerKindExternalID, owner.Kind),
+			fmt.Sprintf("external_ids:%s=%s", ownerExternalID, owner.key()))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	switch owner.Kind {
+	case OwnerKindPod:
+		portName := podLogicalPortName(&kapi.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: owner.Namespace, Name: owner.Name}})
+		if err = found.addFound("logical_switch_port", "name", "name="+portName); err == nil {
+			err = found.addFound("qos", "", "external_ids:logical_port="+portName)
+		}
+	case OwnerKindNamespace:
+		if err = found.addFound("address_set", "name", "name="+hashedAddressSet(owner.Name)); err == nil {
+			_, portGroupHash := getMulticastPortGroup(owner.Name)
+			err = found.addFound("port_group", "name", "name="+portGroupHash)
+		}
+	case OwnerKindNetworkPolicy:
+		err = found.addFound("port_group", "name",
+			"name="+hashedPortGroup(fmt.Sprintf("%s_%s", owner.Namespace, owner.Name)))
+		if err == nil {
+			err = found.addPolicyAddressSets(owner)
+		}
+	case OwnerKindService:
+		err = found.addServiceVIPs(kclient, owner)
+	default:
+		err = fmt.Errorf("unknown kind %q", owner.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return found.rows, nil
+}
+
+func (o *ownedRows) addPolicyAddressSets(owner *OwnerReference) error {
+	rows, err := findNBRows("address_set", []string{"_uuid", "name", "external_ids"})
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		m := policyAddressSetRe.FindStringSubmatch(row.smap("external_ids")["name"])
+		if m != nil && m[1] == owner.Namespace && m[2] == owner.Name {
+			o.add("address_set", row.atom("_uuid"), row.atom("name"))
+		}
+	}
+	return nil
+}
+
+// serviceMatchesVIP returns true if a VIP of a load balancer of the protocol
+// belongs to the service
+func serviceMatchesVIP(service *kapi.Service, protocol, vip string) bool {
+	host, portStr, err := net.SplitHostPort(vip)
+	if err != nil {
+		return false
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return false
+	}
+	ips := append(util.GetClusterIPs(service), service.Spec.ExternalIPs...)
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			ips = append(ips, ingress.IP)
+		}
+	}
+	for _, svcPort := range service.Spec.Ports {
+		if protocol != "" && !strings.EqualFold(protocol, string(svcPort.Protocol)) {
+			continue
+		}
+		if svcPort.NodePort != 0 && int(svcPort.NodePort) == port {
+			return true
+		}
+		if int(svcPort.Port) != port {
+			continue
+		}
+		for _, ip := range ips {
+			if net.ParseIP(ip).Equal(net.ParseIP(host)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addServiceVIPs adds the VIPs of the service on all the load balancers, and
+// the ACLs rejecting the traffic to the VIPs without endpoints
+func (o *ownedRows) addServiceVIPs(kclient kubernetes.Interface, owner *OwnerReference) error {
+	if kclient == nil {
+		return fmt.Errorf("a Kubernetes client is required to find the rows of %s", owner)
+	}
+	service, err := kclient.CoreV1().Services(owner.Namespace).Get(context.TODO(), owner.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %v", owner, err)
+	}
+	lbs, err := findNBRows("load_balancer", []string{"_uuid", "protocol", "vips"})
+	if err != nil {
+		return err
+	}
+	for _, lb := range lbs {
+		lbUUID := lb.atom("_uuid")
+		for vip := range lb.smap("vips") {
+			if !serviceMatchesVIP(service, lb.atom("protocol"), vip) {
+				continue
+			}
+			o.add("load_balancer", lbUUID, vip)
+			host, port, _ := net.SplitHostPort(vip)
+			// The name of the reject ACL of a VIP is <load balancer>-<ip>:<port>
+			aclName := fmt.Sprintf("%s-%s:%s", lbUUID, host, port)
+			if err := o.addFound("acl", "name", "name="+strings.ReplaceAll(aclName, ":", "\\:")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// serviceOwnerOfVIP returns the service a VIP of a load balancer of the
+// protocol belongs to
+func serviceOwnerOfVIP(kclient kubernetes.Interface, protocol, vip string) (*OwnerReference, error) {
+	if kclient == nil {
+		return nil, fmt.Errorf("a Kubernetes client is required to find the owner of VIP %s", vip)
+	}
+	services, err := kclient.CoreV1().Services(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %v", err)
+	}
+	for i := range services.Items {
+		service := &services.Items[i]
+		if serviceMatchesVIP(service, protocol, vip) {
+			return serviceOwner(service.Namespace, service.Name), nil
+		}
+	}
+	return nil, fmt.Errorf("no service has VIP %s", vip)
+}
+
+// rejectACLVIP returns the VIP of a reject ACL from its name,
+// <load balancer>-<ip>:<port>
+func rejectACLVIP(name string) string {
+	name = strings.ReplaceAll(name, "\\:", ":")
+	if len(name) < 38 || !uuidRe.MatchString(name[:36]) || name[36] != '-' {
+		return ""
+	}
+	i := strings.LastIndex(name, ":")
+	if i < 37 {
+		return ""
+	}
+	return net.JoinHostPort(name[37:i], name[i+1:])
+}
+
+// ownerOfAddressSet returns the owner of an address set from its unhashed
+// name: <namespace> for namespaces, or the name of the peers of a network
+// policy rule
+func ownerOfAddressSet(name string) *OwnerReference {
+	if m := policyAddressSetRe.FindStringSubmatch(name); m != nil {
+		return networkPolicyOwner(m[1], m[2])
+	}
+	if name != "" && !strings.Contains(name, ".") {
+		return namespaceOwner(name)
+	}
+	return nil
+}
+
+// ownerOfPortGroup returns the owner of a port group from its unhashed name:
+// the multicast port group of a namespace, or <namespace>_<policy>
+func ownerOfPortGroup(name string) *OwnerReference {
+	if strings.HasPrefix(name, "mcastPortGroup-") {
+		return namespaceOwner(strings.TrimPrefix(name, "mcastPortGroup-"))
+	}
+	if parts := strings.SplitN(name, "_", 2); len(parts) == 2 {
+		return networkPolicyOwner(parts[0], parts[1])
+	}
+	return nil
+}
+
+// FindRowOwner returns the Kubernetes object owning a row of a table of the
+// NB database, given its UUID or name. For load_balancer, the row is one of
+// the VIPs of the load balancers, and the client is needed to find the
+// service it belongs to.
+func FindRowOwner(kclient kubernetes.Interface, table, row string) (*OwnerReference, error) {
+	table = strings.ToLower(table)
+	if table == "load_balancer" {
+		return serviceOwnerOfVIP(kclient, "", row)
+	}
+
+	nameColumn := ""
+	for _, t := range ownedTables {
+		if t.table == table {
+			nameColumn = t.nameColumn
+		}
+	}
+	columns := []string{"_uuid", "external_ids"}
+	condition := "_uuid=" + row
+	if nameColumn != "" {
+		columns = append(columns, nameColumn)
+		if !uuidRe.MatchString(row) {
+			condition = "name=" + row
+		}
+	}
+	rows, err := findNBRows(table, columns, condition)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) != 1 {
+		return nil, fmt.Errorf("found %d %s rows for %s", len(rows), table, row)
+	}
+	externalIDs := rows[0].smap("external_ids")
+	if owner := ownerFromExternalIDs(externalIDs); owner != nil {
+		return owner, nil
+	}
+
+	var owner *OwnerReference
+	switch table {
+	case "logical_switch_port":
+		if externalIDs["pod"] == "true" {
+			owner = podOwnerForPort(rows[0].atom("name"))
+		}
+	case "address_set":
+		owner = ownerOfAddressSet(externalIDs["name"])
+	case "port_group":
+		owner = ownerOfPortGroup(externalIDs["name"])
+	case "acl":
+		if externalIDs["namespace"] != "" && externalIDs["policy"] != "" {
+			owner = networkPolicyOwner(externalIDs["namespace"], externalIDs["policy"])
+		} else if vip := rejectACLVIP(rows[0].atom("name")); vip != "" {
+			return serviceOwnerOfVIP(kclient, "", vip)
+		}
+	case "qos":
+		owner = podOwnerForPort(externalIDs["logical_port"])
+	}
+	if owner == nil {
+		return nil, fmt.Errorf("%s %s is not owned by a Kubernetes object", table, row)
+	}
+	return owner, nil
+}
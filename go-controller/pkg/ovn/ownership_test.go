@@ -0,0 +1,160 @@
+package ovn
+
+import (
+	"github.com/urfave/cli/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+
+	v1 "k8s.io/api/core/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	ownerTestLBUUID  = "1a3dfc82-2749-4931-9190-c30e7c0ecea3"
+	ownerTestACLUUID = "7f2d0a9c-61b1-4e0c-9f3e-3d5e7c2b8a41"
+)
+
+func findNBRowsCmd(columns, table, conditions, output string) *ovntest.ExpectedCmd {
+	cmd := "ovn-nbctl --timeout=15 --format=json --data=json --columns=" + columns + " find " + table
+	if conditions != "" {
+		cmd += " " + conditions
+	}
+	return &ovntest.ExpectedCmd{Cmd: cmd, Output: output}
+}
+
+var _ = Describe("OVN NB rows ownership", func() {
+	var (
+		app     *cli.App
+		fakeOvn *FakeOVN
+		fExec   *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fExec = ovntest.NewFakeExec()
+		fakeOvn = NewFakeOVN(fExec)
+	})
+
+	AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	It("parses references to Kubernetes objects", func() {
+		app.Action = func(ctx *cli.Context) error {
+			fakeOvn.start(ctx)
+			owner, err := ParseOwnerReference("netpol/ns1/policy1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owner).To(Equal(networkPolicyOwner("ns1", "policy1")))
+			owner, err = ParseOwnerReference("Namespace/ns1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owner).To(Equal(namespaceOwner("ns1")))
+			_, err = ParseOwnerReference("pod/ns1")
+			Expect(err).To(HaveOccurred())
+			_, err = ParseOwnerReference("node/node1")
+			Expect(err).To(HaveOccurred())
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("finds the rows of a network policy", func() {
+		app.Action = func(ctx *cli.Context) error {
+			const stamped = "external_ids:k8s-owner-kind=NetworkPolicy external_ids:k8s-owner=ns1/policy1"
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,name", "logical_switch_port", stamped,
+				`{"data":[],"headings":["_uuid","name"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,name", "address_set", stamped,
+				`{"data":[[["uuid","uuid-as1"],"a1"]],"headings":["_uuid","name"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,name,acls", "port_group", stamped,
+				`{"data":[[["uuid","uuid-pg"],"a2",["set",[["uuid","uuid-acl1"],["uuid","uuid-acl2"]]]]],"headings":["_uuid","name","acls"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,name", "acl", stamped,
+				`{"data":[[["uuid","uuid-acl1"],["set",[]]]],"headings":["_uuid","name"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid", "qos", stamped,
+				`{"data":[],"headings":["_uuid"]}`))
+			// The rows created before the owners were stamped
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,name,acls", "port_group", "name="+hashedPortGroup("ns1_policy1"),
+				`{"data":[[["uuid","uuid-pg"],"a2",["uuid","uuid-acl1"]]],"headings":["_uuid","name","acls"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,name,external_ids", "address_set", "",
+				`{"data":[`+
+					`[["uuid","uuid-as1"],"a1",["map",[["name","ns1.policy1.ingress.0"]]]],`+
+					`[["uuid","uuid-as2"],"a3",["map",[["name","ns1.policy1.egress.1"]]]],`+
+					`[["uuid","uuid-as3"],"a4",["map",[["name","ns1.policy10.egress.0"]]]],`+
+					`[["uuid","uuid-as4"],"a5",["map",[["name","ns1"]]]]`+
+					`],"headings":["_uuid","name","external_ids"]}`))
+
+			fakeOvn.start(ctx)
+			rows, err := FindOwnedRows(nil, networkPolicyOwner("ns1", "policy1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+			Expect(rows).To(Equal([]*OVNRow{
+				{Table: "address_set", UUID: "uuid-as1", Name: "a1"},
+				{Table: "port_group", UUID: "uuid-pg", Name: "a2"},
+				{Table: "acl", UUID: "uuid-acl1"},
+				{Table: "acl", UUID: "uuid-acl2"},
+				{Table: "address_set", UUID: "uuid-as2", Name: "a3"},
+			}))
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("finds the owners of the rows", func() {
+		app.Action = func(ctx *cli.Context) error {
+			service := newService("svc1", "ns1", "172.30.0.10",
+				[]v1.ServicePort{{Protocol: v1.ProtocolTCP, Port: 80}}, v1.ServiceTypeClusterIP)
+
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,external_ids,name", "logical_switch_port", "name=ns1_pod1",
+				`{"data":[[["uuid","uuid-lsp"],["map",[["k8s-owner","ns1/pod1"],["k8s-owner-kind","Pod"],["namespace","ns1"],["pod","true"]]],"ns1_pod1"]],"headings":["_uuid","external_ids","name"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,external_ids,name", "address_set", "name=a1",
+				`{"data":[[["uuid","uuid-as"],["map",[["name","ns1.policy.1.egress.2"]]],"a1"]],"headings":["_uuid","external_ids","name"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,external_ids,name", "port_group", "name=a2",
+				`{"data":[[["uuid","uuid-pg"],["map",[["name","mcastPortGroup-ns1"]]],"a2"]],"headings":["_uuid","external_ids","name"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,external_ids,name", "acl", "_uuid="+ownerTestACLUUID,
+				`{"data":[[["uuid","`+ownerTestACLUUID+`"],["map",[]],"`+ownerTestLBUUID+`-172.30.0.10\\:80"]],"headings":["_uuid","external_ids","name"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,external_ids", "qos", "_uuid=uuid-qos",
+				`{"data":[[["uuid","uuid-qos"],["map",[["logical_port","ns1_pod1"]]]]],"headings":["_uuid","external_ids"]}`))
+			fExec.AddFakeCmd(findNBRowsCmd("_uuid,external_ids,name", "port_group", "name=ingressDefaultDeny",
+				`{"data":[[["uuid","uuid-deny"],["map",[["name","ingressDefaultDeny"]]],"ingressDefaultDeny"]],"headings":["_uuid","external_ids","name"]}`))
+
+			fakeOvn.start(ctx, &v1.ServiceList{Items: []v1.Service{*service}})
+
+			owner, err := FindRowOwner(nil, "logical_switch_port", "ns1_pod1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owner).To(Equal(podOwner("ns1", "pod1")))
+			owner, err = FindRowOwner(nil, "address_set", "a1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owner).To(Equal(networkPolicyOwner("ns1", "policy.1")))
+			owner, err = FindRowOwner(nil, "port_group", "a2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owner).To(Equal(namespaceOwner("ns1")))
+			owner, err = FindRowOwner(fakeOvn.fakeClient, "acl", ownerTestACLUUID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owner).To(Equal(serviceOwner("ns1", "svc1")))
+			owner, err = FindRowOwner(nil, "qos", "uuid-qos")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owner).To(Equal(podOwner("ns1", "pod1")))
+			_, err = FindRowOwner(nil, "port_group", "ingressDefaultDeny")
+			Expect(err).To(HaveOccurred())
+			owner, err = FindRowOwner(fakeOvn.fakeClient, "load_balancer", "172.30.0.10:80")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(owner).To(Equal(serviceOwner("ns1", "svc1")))
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/logging"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/metrics"
 	util "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	kapi "k8s.io/api/core/v1"
@@ -66,7 +67,8 @@ func (oc *Controller) deleteLogicalPort(pod *kapi.Pod) {
 	}
 
 	podDesc := pod.Namespace + "/" + pod.Name
-	klog.Infof("Deleting pod: %s", podDesc)
+	logCtx := logging.Pod(pod.Namespace, pod.Name).Operation("delete")
+	klog.Infof("%s Deleting pod: %s", logCtx, podDesc)
 
 	logicalPort := podLogicalPortName(pod)
 	portInfo, err := oc.logicalPortCache.get(logicalPort)
@@ -86,29 +88,27 @@ func (oc *Controller) deleteLogicalPort(pod *kapi.Pod) {
 		klog.Errorf(err.Error())
 	}
 
+	oc.deletePodQoSForPod(pod, portInfo)
+
 	out, stderr, err := util.RunOVNNbctl("--if-exists", "lsp-del", logicalPort)
 	if err != nil {
-		klog.Errorf("Error in deleting pod %s logical port "+
+		klog.Errorf("%s Error in deleting pod %s logical port "+
 			"stdout: %q, stderr: %q, (%v)",
-			podDesc, out, stderr, err)
+			logCtx, podDesc, out, stderr, err)
 	}
 
 	oc.logicalPortCache.remove(logicalPort)
 }
 
-func (oc *Controller) waitForNodeLogicalSwitch(nodeName string) (*net.IPNet, error) {
-	// Wait for the node logical switch to be created by the ClusterController.
-	// The node switch will be created when the node's logical network infrastructure
-	// is created by the node watch.
-	var subnets []*net.IPNet
-	if err := wait.PollImmediate(10*time.Millisecond, 30*time.Second, func() (bool, error) {
-		oc.lsMutex.Lock()
-		defer oc.lsMutex.Unlock()
-		var ok bool
-		subnets, ok = oc.logicalSwitchCache[nodeName]
-		return ok, nil
-	}); err != nil {
-		return nil, fmt.Errorf("timed out waiting for logical switch %q subnet: %v", nodeName, err)
+// getNodeLogicalSwitch returns the subnet of the node logical switch created by
+// the node watch. The pod handler declares the switch as a
+// factory.NodeSwitchDependency so that pods are only added once it exists.
+func (oc *Controller) getNodeLogicalSwitch(nodeName string) (*net.IPNet, error) {
+	oc.lsMutex.Lock()
+	defer oc.lsMutex.Unlock()
+	subnets, ok := oc.logicalSwitchCache[nodeName]
+	if !ok || len(subnets) == 0 {
+		return nil, fmt.Errorf("logical switch %q subnet is not known", nodeName)
 	}
 	// FIXME DUAL-STACK
 	return subnets[0], nil
@@ -208,7 +208,7 @@ func getRoutesGatewayIP(pod *kapi.Pod, subnet *net.IPNet, hybridOverlayExternalG
 }
 
 func (oc *Controller) getHybridOverlayExternalGwAnnotation(ns string) (net.IP, error) {
-	nsInfo, err := oc.waitForNamespaceLocked(ns)
+	nsInfo, err := oc.requireNamespaceLocked(ns)
 	if err != nil {
 		return nil, err
 	}
@@ -226,19 +226,32 @@ func (oc *Controller) addLogicalPort(pod *kapi.Pod) error {
 	}
 
 	// Keep track of how long syncs take.
+	logCtx := logging.Pod(pod.Namespace, pod.Name).Operation("add")
 	start := time.Now()
 	defer func() {
-		klog.Infof("[%s/%s] addLogicalPort took %v", pod.Namespace, pod.Name, time.Since(start))
+		klog.Infof("%s addLogicalPort took %v", logCtx, time.Since(start))
 	}()
 
-	logicalSwitch := pod.Spec.NodeName
-	nodeSubnet, err := oc.waitForNodeLogicalSwitch(pod.Spec.NodeName)
+	// Pods attached to a provider network get their addresses from the
+	// network's subnet rather than from the node's subnet
+	providerNetwork, err := oc.getPodProviderNetwork(pod)
 	if err != nil {
 		return err
 	}
+	logicalSwitch := pod.Spec.NodeName
+	var nodeSubnet *net.IPNet
+	if providerNetwork != nil {
+		logicalSwitch = util.ProviderNetworkSwitch(providerNetwork.Name)
+		nodeSubnet = providerNetwork.Subnet
+	} else {
+		nodeSubnet, err = oc.getNodeLogicalSwitch(pod.Spec.NodeName)
+		if err != nil {
+			return err
+		}
+	}
 
 	portName := podLogicalPortName(pod)
-	klog.V(5).Infof("Creating logical port for %s on switch %s", portName, logicalSwitch)
+	klog.V(5).Infof("%s Creating logical port for %s on switch %s", logCtx, portName, logicalSwitch)
 
 	var podMac net.HardwareAddr
 	var podCIDR *net.IPNet
@@ -284,6 +297,7 @@ func (oc *Controller) addLogicalPort(pod *kapi.Pod) error {
 		}
 	}
 	args = append(args, "--", "set", "logical_switch_port", portName, "external-ids:namespace="+pod.Namespace, "external-ids:pod=true")
+	args = append(args, podOwner(pod.Namespace, pod.Name).externalIDs()...)
 
 	out, stderr, err = util.RunOVNNbctl(args...)
 	if err != nil {
@@ -317,7 +331,7 @@ func (oc *Controller) addLogicalPort(pod *kapi.Pod) error {
 	}
 
 	// Add the pod's logical switch port to the port cache
-	portInfo := oc.logicalPortCache.add(logicalSwitch, portName, uuid, podMac, podCIDR.IP)
+	portInfo := oc.logicalPortCache.add(pod.Spec.NodeName, logicalSwitch, portName, uuid, podMac, podCIDR.IP)
 
 	// Set the port security for the logical switch port
 	addresses := fmt.Sprintf("%s %s", podMac, podCIDR.IP)
@@ -334,22 +348,34 @@ func (oc *Controller) addLogicalPort(pod *kapi.Pod) error {
 		}
 	}
 
+	// The pod's port may already have been set up if the pod is annotated
+	if err := oc.ensurePodQoS(pod, portInfo, annotation != nil); err != nil {
+		return err
+	}
+
 	if err := oc.addPodToNamespace(pod.Namespace, portInfo); err != nil {
 		return err
 	}
 
 	if annotation == nil {
-		hybridOverlayExternalGW := net.IP{}
-		if config.HybridOverlay.Enabled {
-			hybridOverlayExternalGW, err = oc.getHybridOverlayExternalGwAnnotation(pod.Namespace)
+		var routes []util.PodRoute
+		var gwIP net.IP
+		if providerNetwork != nil {
+			// the network's router is the pod's default gateway
+			gwIP = providerNetworkGatewayIP(providerNetwork)
+		} else {
+			hybridOverlayExternalGW := net.IP{}
+			if config.HybridOverlay.Enabled {
+				hybridOverlayExternalGW, err = oc.getHybridOverlayExternalGwAnnotation(pod.Namespace)
+				if err != nil {
+					return err
+				}
+			}
+			routes, gwIP, err = getRoutesGatewayIP(pod, nodeSubnet, hybridOverlayExternalGW)
 			if err != nil {
 				return err
 			}
 		}
-		routes, gwIP, err := getRoutesGatewayIP(pod, nodeSubnet, hybridOverlayExternalGW)
-		if err != nil {
-			return err
-		}
 
 		var gwIPs []net.IP
 		if gwIP != nil {
@@ -3,15 +3,19 @@ package ovn
 import (
 	"fmt"
 	"net"
+	"strings"
 	"sync"
 
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/factory"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/logging"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 	kapi "k8s.io/api/core/v1"
 	knet "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog"
+	utilnet "k8s.io/utils/net"
 )
 
 type namespacePolicy struct {
@@ -121,7 +125,7 @@ func getACLMatch(portGroupName, match string, policyType knet.PolicyType) string
 	return "match=\"" + aclMatch + "\""
 }
 
-func addACLPortGroup(portGroupUUID, portGroupName, direction, priority, match, action string, policyType knet.PolicyType) error {
+func addACLPortGroup(owner *OwnerReference, portGroupUUID, portGroupName, direction, priority, match, action string, policyType knet.PolicyType) error {
 	match = getACLMatch(portGroupName, match, policyType)
 	uuid, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading",
 		"--columns=_uuid", "find", "ACL", match, "action="+action,
@@ -135,12 +139,13 @@ func addACLPortGroup(portGroupUUID, portGroupName, direction, priority, match, a
 		return nil
 	}
 
-	_, stderr, err = util.RunOVNNbctl("--id=@acl", "create", "acl",
+	args := []string{"--id=@acl", "create", "acl",
 		fmt.Sprintf("priority=%s", priority),
-		fmt.Sprintf("direction=%s", direction), match, "action="+action,
-		fmt.Sprintf("external-ids:default-deny-policy-type=%s", policyType),
-		"--", "add", "port_group", portGroupUUID,
-		"acls", "@acl")
+		fmt.Sprintf("direction=%s", direction), match, "action=" + action,
+		fmt.Sprintf("external-ids:default-deny-policy-type=%s", policyType)}
+	args = append(args, owner.externalIDs()...)
+	args = append(args, "--", "add", "port_group", portGroupUUID, "acls", "@acl")
+	_, stderr, err = util.RunOVNNbctl(args...)
 	if err != nil {
 		return fmt.Errorf("error executing create ACL command for "+
 			"policy type %s stderr: %q (%v)", policyType, stderr, err)
@@ -206,18 +211,18 @@ func (oc *Controller) createDefaultDenyPortGroup(policyType knet.PolicyType) err
 		}
 		portGroupName = "egressDefaultDeny"
 	}
-	portGroupUUID, err := createPortGroup(portGroupName, portGroupName)
+	portGroupUUID, err := createPortGroup(nil, portGroupName, portGroupName)
 	if err != nil {
 		return fmt.Errorf("Failed to create port_group for %s (%v)",
 			portGroupName, err)
 	}
-	err = addACLPortGroup(portGroupUUID, portGroupName, toLport,
+	err = addACLPortGroup(nil, portGroupUUID, portGroupName, toLport,
 		defaultDenyPriority, "", "drop", policyType)
 	if err != nil {
 		return fmt.Errorf("Failed to create default deny ACL for port group %v", err)
 	}
 
-	err = addACLPortGroup(portGroupUUID, portGroupName, toLport,
+	err = addACLPortGroup(nil, portGroupUUID, portGroupName, toLport,
 		defaultAllowPriority, "arp", "allow", policyType)
 	if err != nil {
 		return fmt.Errorf("Failed to create default allow ARP ACL for port group %v", err)
@@ -231,11 +236,151 @@ func (oc *Controller) createDefaultDenyPortGroup(policyType knet.PolicyType) err
 	return nil
 }
 
+const (
+	// ipv4MulticastMatch matches IPv4 multicast traffic, including IGMP.
+	ipv4MulticastMatch = "ip4.mcast"
+	// ipv6MulticastMatch matches MLD traffic and IPv6 multicast traffic to
+	// dynamic (T=1) groups, RFC 3307 §4.3. Permanent groups such as the
+	// all-nodes and solicited-node groups used by ND are left alone.
+	ipv6MulticastMatch = "(mldv1 || mldv2 || (ip6.dst[120..127] == 0xff && ip6.dst[116] == 1))"
+)
+
+// Returns the match string for multicast traffic of the IP families in use
+// by the cluster.
+func getMulticastMatch() string {
+	if !config.IPv6Mode {
+		return ipv4MulticastMatch
+	}
+	if !config.IPv4Mode {
+		return ipv6MulticastMatch
+	}
+	return "(" + ipv4MulticastMatch + " || " + ipv6MulticastMatch + ")"
+}
+
+// multicastAllowPolicy optionally narrows a namespace's multicast allow
+// policy to a set of groups, and extends it to multicast traffic sent by
+// pods in other namespaces.
+type multicastAllowPolicy struct {
+	// groups the pods in the namespace may send to and receive from. All
+	// groups are allowed if empty.
+	groups []net.IP
+	// namespaces, other than the policy's own, whose pods may send multicast
+	// traffic to the pods in the namespace. Only the namespaces that exist
+	// are listed, the ACLs refer to their address sets.
+	sourceNamespaces []string
+}
+
+func (p multicastAllowPolicy) equal(other multicastAllowPolicy) bool {
+	return util.JoinIPs(p.groups, ",") == util.JoinIPs(other.groups, ",") &&
+		strings.Join(p.sourceNamespaces, ",") == strings.Join(other.sourceNamespaces, ",")
+}
+
+// Returns which IP families multicast policies must match on. Clusters with
+// no IP family configured are treated as IPv4.
+func multicastIPFamilies() (bool, bool) {
+	return config.IPv4Mode || !config.IPv6Mode, config.IPv6Mode
+}
+
+// Returns the groups of the given IP family as an OVN address set
+// expression, or "" if there are none.
+func multicastGroupSet(groups []net.IP, ipv6 bool) string {
+	var familyGroups []string
+	for _, group := range groups {
+		if utilnet.IsIPv6(group) == ipv6 {
+			familyGroups = append(familyGroups, group.String())
+		}
+	}
+	if len(familyGroups) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(familyGroups, ", ") + "}"
+}
+
+// Combines the per IP family matches of a multicast ACL. The result is
+// parenthesized if needed, OVN does not allow mixing it with the "&&" of
+// the ACL's port group match otherwise.
+func joinMulticastMatches(matches []string) string {
+	if len(matches) == 1 {
+		if strings.Contains(matches[0], " || ") {
+			return "(" + matches[0] + ")"
+		}
+		return matches[0]
+	}
+	return "((" + strings.Join(matches, ") || (") + "))"
+}
+
+// Creates the match string used for ACLs allowing outgoing multicast from a
+// namespace. Membership reports are always allowed so that pods can join the
+// allowed groups.
+func getMulticastEgressMatch(policy multicastAllowPolicy) string {
+	if len(policy.groups) == 0 {
+		return getMulticastMatch()
+	}
+
+	var matches []string
+	ipv4, ipv6 := multicastIPFamilies()
+	if ipv4 {
+		match := "igmp"
+		if groupSet := multicastGroupSet(policy.groups, false); groupSet != "" {
+			match += " || ip4.dst == " + groupSet
+		}
+		matches = append(matches, match)
+	}
+	if ipv6 {
+		match := "mldv1 || mldv2"
+		if groupSet := multicastGroupSet(policy.groups, true); groupSet != "" {
+			match += " || ip6.dst == " + groupSet
+		}
+		matches = append(matches, match)
+	}
+	return joinMulticastMatches(matches)
+}
+
 // Creates the match string used for ACLs allowing incoming multicast into a
-// namespace, that is, from IPs that are in the namespace's address set.
-func getMulticastACLMatch(ns string) string {
-	nsAddressSet := hashedAddressSet(ns)
-	return "ip4.src == $" + nsAddressSet + " && ip4.mcast"
+// namespace, that is, from IPs that are in the address set of the namespace
+// or of one of the policy's source namespaces, to one of the policy's groups.
+// Returns "" if no traffic can match, that is, if the policy only has groups
+// of an IP family the cluster does not use.
+func getMulticastACLMatch(ns string, policy multicastAllowPolicy) string {
+	addressSets := []string{"$" + hashedAddressSet(ns)}
+	for _, sourceNs := range policy.sourceNamespaces {
+		addressSets = append(addressSets, "$"+hashedAddressSet(sourceNs))
+	}
+	src := addressSets[0]
+	if len(addressSets) > 1 {
+		src = "{" + strings.Join(addressSets, ", ") + "}"
+	}
+
+	var matches []string
+	ipv4, ipv6 := multicastIPFamilies()
+	if ipv4 {
+		dst := ipv4MulticastMatch
+		if len(policy.groups) > 0 {
+			dst = ""
+			if groupSet := multicastGroupSet(policy.groups, false); groupSet != "" {
+				dst = "ip4.dst == " + groupSet
+			}
+		}
+		if dst != "" {
+			matches = append(matches, "ip4.src == "+src+" && "+dst)
+		}
+	}
+	if ipv6 {
+		dst := ipv6MulticastMatch
+		if len(policy.groups) > 0 {
+			dst = ""
+			if groupSet := multicastGroupSet(policy.groups, true); groupSet != "" {
+				dst = "ip6.dst == " + groupSet
+			}
+		}
+		if dst != "" {
+			matches = append(matches, "ip6.src == "+src+" && "+dst)
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+	return joinMulticastMatches(matches)
 }
 
 // Returns the multicast port group name and hash for namespace 'ns'.
@@ -246,68 +391,104 @@ func getMulticastPortGroup(ns string) (string, string) {
 
 // Creates a policy to allow multicast traffic within 'ns':
 // - a port group containing all logical ports associated with 'ns'
-// - one "from-lport" ACL allowing egress multicast traffic from the pods
-//   in 'ns'
-// - one "to-lport" ACL allowing ingress multicast traffic to pods in 'ns'.
-//   This matches only traffic originated by pods in 'ns' (based on the
-//   namespace address set).
-func (oc *Controller) createMulticastAllowPolicy(ns string, nsInfo *namespaceInfo) error {
+// - multicast allow ACLs for the port group, see addMulticastAllowACLs().
+func (oc *Controller) createMulticastAllowPolicy(ns string, nsInfo *namespaceInfo, policy multicastAllowPolicy) error {
 	portGroupName, portGroupHash := getMulticastPortGroup(ns)
-	portGroupUUID, err := createPortGroup(portGroupName, portGroupHash)
+	portGroupUUID, err := createPortGroup(namespaceOwner(ns), portGroupName, portGroupHash)
 	if err != nil {
 		return fmt.Errorf("Failed to create port_group for %s (%v)",
 			portGroupName, err)
 	}
 
-	err = addACLPortGroup(portGroupUUID, portGroupHash, fromLport,
-		defaultMcastAllowPriority, "ip4.mcast", "allow",
-		knet.PolicyTypeEgress)
-	if err != nil {
-		return fmt.Errorf("Failed to create allow egress multicast ACL for %s (%v)",
-			ns, err)
-	}
-
-	err = addACLPortGroup(portGroupUUID, portGroupHash, toLport,
-		defaultMcastAllowPriority, getMulticastACLMatch(ns), "allow",
-		knet.PolicyTypeIngress)
-	if err != nil {
-		return fmt.Errorf("Failed to create allow ingress multicast ACL for %s (%v)",
-			ns, err)
+	if err = addMulticastAllowACLs(ns, portGroupUUID, portGroupHash, policy); err != nil {
+		return err
 	}
 
 	// Add all ports from this namespace to the multicast allow group.
+	logCtx := logging.Namespace(ns).Operation("update")
 	for _, portName := range nsInfo.addressSet {
 		if portInfo, err := oc.logicalPortCache.get(portName); err != nil {
-			klog.Errorf(err.Error())
+			klog.Errorf("%s %v", logCtx, err)
 		} else if err := podAddAllowMulticastPolicy(ns, portInfo); err != nil {
-			klog.Warningf("failed to add port %s to port group ACL: %v", portName, err)
+			klog.Warningf("%s failed to add port %s to port group ACL: %v", logCtx, portName, err)
 		}
 	}
 
 	return nil
 }
 
+// Replaces the multicast allow ACLs of 'ns' when its policy changes, keeping
+// the port group and its ports.
+func updateMulticastAllowPolicy(ns string, oldPolicy, newPolicy multicastAllowPolicy) error {
+	portGroupName, portGroupHash := getMulticastPortGroup(ns)
+	if err := deleteMulticastAllowACLs(ns, portGroupHash, oldPolicy); err != nil {
+		return err
+	}
+	portGroupUUID, err := createPortGroup(namespaceOwner(ns), portGroupName, portGroupHash)
+	if err != nil {
+		return fmt.Errorf("Failed to create port_group for %s (%v)",
+			portGroupName, err)
+	}
+	return addMulticastAllowACLs(ns, portGroupUUID, portGroupHash, newPolicy)
+}
+
 // Delete the policy to allow multicast traffic within 'ns'.
-func deleteMulticastAllowPolicy(ns string) error {
+func deleteMulticastAllowPolicy(ns string, policy multicastAllowPolicy) error {
 	_, portGroupHash := getMulticastPortGroup(ns)
 
-	err := deleteACLPortGroup(portGroupHash, fromLport,
-		defaultMcastAllowPriority, "ip4.mcast", "allow",
+	if err := deleteMulticastAllowACLs(ns, portGroupHash, policy); err != nil {
+		return err
+	}
+
+	deletePortGroup(portGroupHash)
+	return nil
+}
+
+// Adds the ACLs allowing multicast for the multicast port group of 'ns':
+// - one "from-lport" ACL allowing egress multicast traffic from the pods
+//   in 'ns' to the policy's groups
+// - one "to-lport" ACL allowing ingress multicast traffic to pods in 'ns'.
+//   This matches only traffic originated by pods in 'ns' or in one of the
+//   policy's source namespaces (based on the namespace address sets).
+func addMulticastAllowACLs(ns, portGroupUUID, portGroupHash string, policy multicastAllowPolicy) error {
+	err := addACLPortGroup(namespaceOwner(ns), portGroupUUID, portGroupHash, fromLport,
+		defaultMcastAllowPriority, getMulticastEgressMatch(policy), "allow",
 		knet.PolicyTypeEgress)
 	if err != nil {
-		return fmt.Errorf("Failed to delete allow egress multicast ACL for %s (%v)",
+		return fmt.Errorf("Failed to create allow egress multicast ACL for %s (%v)",
 			ns, err)
 	}
 
-	err = deleteACLPortGroup(portGroupHash, toLport,
-		defaultMcastAllowPriority, getMulticastACLMatch(ns), "allow",
-		knet.PolicyTypeIngress)
+	if match := getMulticastACLMatch(ns, policy); match != "" {
+		err = addACLPortGroup(namespaceOwner(ns), portGroupUUID, portGroupHash, toLport,
+			defaultMcastAllowPriority, match, "allow",
+			knet.PolicyTypeIngress)
+		if err != nil {
+			return fmt.Errorf("Failed to create allow ingress multicast ACL for %s (%v)",
+				ns, err)
+		}
+	}
+	return nil
+}
+
+func deleteMulticastAllowACLs(ns, portGroupHash string, policy multicastAllowPolicy) error {
+	err := deleteACLPortGroup(portGroupHash, fromLport,
+		defaultMcastAllowPriority, getMulticastEgressMatch(policy), "allow",
+		knet.PolicyTypeEgress)
 	if err != nil {
-		return fmt.Errorf("Failed to delete allow ingress multicast ACL for %s (%v)",
+		return fmt.Errorf("Failed to delete allow egress multicast ACL for %s (%v)",
 			ns, err)
 	}
 
-	deletePortGroup(portGroupHash)
+	if match := getMulticastACLMatch(ns, policy); match != "" {
+		err = deleteACLPortGroup(portGroupHash, toLport,
+			defaultMcastAllowPriority, match, "allow",
+			knet.PolicyTypeIngress)
+		if err != nil {
+			return fmt.Errorf("Failed to delete allow ingress multicast ACL for %s (%v)",
+				ns, err)
+		}
+	}
 	return nil
 }
 
@@ -318,7 +499,7 @@ func deleteMulticastAllowPolicy(ns string) error {
 // - one ACL dropping ingress multicast traffic to all pods.
 func createDefaultDenyMulticastPolicy() error {
 	portGroupName := "mcastPortGroupDeny"
-	portGroupUUID, err := createPortGroup(portGroupName, portGroupName)
+	portGroupUUID, err := createPortGroup(nil, portGroupName, portGroupName)
 	if err != nil {
 		return fmt.Errorf("Failed to create port_group for %s (%v)",
 			portGroupName, err)
@@ -327,16 +508,16 @@ func createDefaultDenyMulticastPolicy() error {
 	// By default deny any egress multicast traffic from any pod. This drops
 	// IP multicast membership reports therefore denying any multicast traffic
 	// to be forwarded to pods.
-	err = addACLPortGroup(portGroupUUID, portGroupName, fromLport,
-		defaultMcastDenyPriority, "ip4.mcast", "drop", knet.PolicyTypeEgress)
+	err = addACLPortGroup(nil, portGroupUUID, portGroupName, fromLport,
+		defaultMcastDenyPriority, getMulticastMatch(), "drop", knet.PolicyTypeEgress)
 	if err != nil {
 		return fmt.Errorf("Failed to create default deny multicast egress ACL (%v)",
 			err)
 	}
 
 	// By default deny any ingress multicast traffic to any pod.
-	err = addACLPortGroup(portGroupUUID, portGroupName, toLport,
-		defaultMcastDenyPriority, "ip4.mcast", "drop", knet.PolicyTypeIngress)
+	err = addACLPortGroup(nil, portGroupUUID, portGroupName, toLport,
+		defaultMcastDenyPriority, getMulticastMatch(), "drop", knet.PolicyTypeIngress)
 	if err != nil {
 		return fmt.Errorf("Failed to create default deny multicast ingress ACL (%v)",
 			err)
@@ -369,19 +550,19 @@ func podDeleteAllowMulticastPolicy(ns string, portInfo *lpInfo) error {
 	return deleteFromPortGroup(portGroupHash, portInfo)
 }
 
-func (oc *Controller) localPodAddDefaultDeny(
+func (oc *Controller) localPodAddDefaultDeny(logCtx *logging.Context,
 	policy *knet.NetworkPolicy, portInfo *lpInfo) {
 	oc.lspMutex.Lock()
 	defer oc.lspMutex.Unlock()
 
 	err := oc.createDefaultDenyPortGroup(knet.PolicyTypeIngress)
 	if err != nil {
-		klog.Errorf(err.Error())
+		klog.Errorf("%s %v", logCtx, err)
 		return
 	}
 	err = oc.createDefaultDenyPortGroup(knet.PolicyTypeEgress)
 	if err != nil {
-		klog.Errorf(err.Error())
+		klog.Errorf("%s %v", logCtx, err)
 		return
 	}
 
@@ -400,7 +581,7 @@ func (oc *Controller) localPodAddDefaultDeny(
 	if !(len(policy.Spec.PolicyTypes) == 1 && policy.Spec.PolicyTypes[0] == knet.PolicyTypeEgress) {
 		if oc.lspIngressDenyCache[portInfo.name] == 0 {
 			if err := addToPortGroup(oc.portGroupIngressDeny, portInfo); err != nil {
-				klog.Warningf("failed to add port %s to ingress deny ACL: %v", portInfo.name, err)
+				klog.Warningf("%s failed to add port %s to ingress deny ACL: %v", logCtx, portInfo.name, err)
 			}
 		}
 		oc.lspIngressDenyCache[portInfo.name]++
@@ -411,14 +592,14 @@ func (oc *Controller) localPodAddDefaultDeny(
 		len(policy.Spec.Egress) > 0 || len(policy.Spec.PolicyTypes) == 2 {
 		if oc.lspEgressDenyCache[portInfo.name] == 0 {
 			if err := addToPortGroup(oc.portGroupEgressDeny, portInfo); err != nil {
-				klog.Warningf("failed to add port %s to egress deny ACL: %v", portInfo.name, err)
+				klog.Warningf("%s failed to add port %s to egress deny ACL: %v", logCtx, portInfo.name, err)
 			}
 		}
 		oc.lspEgressDenyCache[portInfo.name]++
 	}
 }
 
-func (oc *Controller) localPodDelDefaultDeny(
+func (oc *Controller) localPodDelDefaultDeny(logCtx *logging.Context,
 	policy *knet.NetworkPolicy, portInfo *lpInfo) {
 	oc.lspMutex.Lock()
 	defer oc.lspMutex.Unlock()
@@ -428,7 +609,7 @@ func (oc *Controller) localPodDelDefaultDeny(
 			oc.lspIngressDenyCache[portInfo.name]--
 			if oc.lspIngressDenyCache[portInfo.name] == 0 {
 				if err := deleteFromPortGroup(oc.portGroupIngressDeny, portInfo); err != nil {
-					klog.Warningf("failed to remove port %s from ingress deny ACL: %v", portInfo.name, err)
+					klog.Warningf("%s failed to remove port %s from ingress deny ACL: %v", logCtx, portInfo.name, err)
 				}
 			}
 		}
@@ -440,7 +621,7 @@ func (oc *Controller) localPodDelDefaultDeny(
 			oc.lspEgressDenyCache[portInfo.name]--
 			if oc.lspEgressDenyCache[portInfo.name] == 0 {
 				if err := deleteFromPortGroup(oc.portGroupEgressDeny, portInfo); err != nil {
-					klog.Warningf("failed to remove port %s from egress deny ACL: %v", portInfo.name, err)
+					klog.Warningf("%s failed to remove port %s from egress deny ACL: %v", logCtx, portInfo.name, err)
 				}
 			}
 		}
@@ -457,10 +638,11 @@ func (oc *Controller) handleLocalPodSelectorAddFunc(
 	}
 
 	// Get the logical port info
+	logCtx := logging.Policy(policy.Namespace, policy.Name).Operation("update")
 	logicalPort := podLogicalPortName(pod)
 	portInfo, err := oc.logicalPortCache.get(logicalPort)
 	if err != nil {
-		klog.Errorf(err.Error())
+		klog.Errorf("%s %v", logCtx, err)
 		return
 	}
 
@@ -475,7 +657,7 @@ func (oc *Controller) handleLocalPodSelectorAddFunc(
 		return
 	}
 
-	oc.localPodAddDefaultDeny(policy, portInfo)
+	oc.localPodAddDefaultDeny(logCtx, policy, portInfo)
 
 	if np.portGroupUUID == "" {
 		return
@@ -485,8 +667,8 @@ func (oc *Controller) handleLocalPodSelectorAddFunc(
 		"port_group", np.portGroupUUID, "ports", portInfo.uuid, "--",
 		"add", "port_group", np.portGroupUUID, "ports", portInfo.uuid)
 	if err != nil {
-		klog.Errorf("Failed to add logicalPort %s to portGroup %s "+
-			"stderr: %q (%v)", logicalPort, np.portGroupUUID, stderr, err)
+		klog.Errorf("%s Failed to add logicalPort %s to portGroup %s "+
+			"stderr: %q (%v)", logCtx, logicalPort, np.portGroupUUID, stderr, err)
 	}
 
 	np.localPods[logicalPort] = portInfo
@@ -502,10 +684,11 @@ func (oc *Controller) handleLocalPodSelectorDelFunc(
 	}
 
 	// Get the logical port info
+	logCtx := logging.Policy(policy.Namespace, policy.Name).Operation("update")
 	logicalPort := podLogicalPortName(pod)
 	portInfo, err := oc.logicalPortCache.get(logicalPort)
 	if err != nil {
-		klog.Errorf(err.Error())
+		klog.Errorf("%s %v", logCtx, err)
 		return
 	}
 
@@ -520,7 +703,7 @@ func (oc *Controller) handleLocalPodSelectorDelFunc(
 		return
 	}
 	delete(np.localPods, logicalPort)
-	oc.localPodDelDefaultDeny(policy, portInfo)
+	oc.localPodDelDefaultDeny(logCtx, policy, portInfo)
 
 	oc.lspMutex.Lock()
 	delete(oc.lspIngressDenyCache, logicalPort)
@@ -534,8 +717,8 @@ func (oc *Controller) handleLocalPodSelectorDelFunc(
 	_, stderr, err := util.RunOVNNbctl("--if-exists", "remove",
 		"port_group", np.portGroupUUID, "ports", portInfo.uuid)
 	if err != nil {
-		klog.Errorf("Failed to delete logicalPort %s from portGroup %s "+
-			"stderr: %q (%v)", portInfo.uuid, np.portGroupUUID, stderr, err)
+		klog.Errorf("%s Failed to delete logicalPort %s from portGroup %s "+
+			"stderr: %q (%v)", logCtx, portInfo.uuid, np.portGroupUUID, stderr, err)
 	}
 }
 
@@ -556,8 +739,8 @@ func (oc *Controller) handleLocalPodSelector(
 			},
 		}, nil)
 	if err != nil {
-		klog.Errorf("error watching local pods for policy %s in namespace %s: %v",
-			policy.Name, policy.Namespace, err)
+		klog.Errorf("%s error watching local pods for policy %s in namespace %s: %v",
+			logging.Policy(policy.Namespace, policy.Name).Operation("add"), policy.Name, policy.Namespace, err)
 		return
 	}
 
@@ -576,20 +759,19 @@ func hasAnyLabelSelector(peers []knet.NetworkPolicyPeer) bool {
 
 // addNetworkPolicy creates and applies OVN ACLs to pod logical switch
 // ports from Kubernetes NetworkPolicy objects using OVN Port Groups
-func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
-	klog.Infof("Adding network policy %s in namespace %s", policy.Name,
-		policy.Namespace)
+func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) error {
+	logCtx := logging.Policy(policy.Namespace, policy.Name).Operation("add")
+	klog.Infof("%s Adding network policy %s in namespace %s", logCtx, policy.Name, policy.Namespace)
 
-	nsInfo, err := oc.waitForNamespaceLocked(policy.Namespace)
+	nsInfo, err := oc.requireNamespaceLocked(policy.Namespace)
 	if err != nil {
-		klog.Errorf("failed to wait for namespace %s event (%v)",
-			policy.Namespace, err)
-		return
+		return fmt.Errorf("failed to add network policy %s/%s: %v",
+			policy.Namespace, policy.Name, err)
 	}
 	_, alreadyExists := nsInfo.networkPolicies[policy.Name]
 	if alreadyExists {
 		nsInfo.Unlock()
-		return
+		return nil
 	}
 
 	np := NewNamespacePolicy(policy)
@@ -602,11 +784,15 @@ func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
 	readableGroupName := fmt.Sprintf("%s_%s", policy.Namespace, policy.Name)
 	np.portGroupName = hashedPortGroup(readableGroupName)
 
-	np.portGroupUUID, err = createPortGroup(readableGroupName, np.portGroupName)
+	np.portGroupUUID, err = createPortGroup(networkPolicyOwner(policy.Namespace, policy.Name), readableGroupName, np.portGroupName)
 	if err != nil {
-		klog.Errorf("Failed to create port_group for network policy %s in "+
-			"namespace %s", policy.Name, policy.Namespace)
-		return
+		// Forget the policy so that adding it is retried from scratch
+		np.Unlock()
+		if np := oc.deleteNetworkPolicyLocked(policy); np != nil {
+			np.Unlock()
+		}
+		return fmt.Errorf("failed to create port_group for network policy %s in "+
+			"namespace %s: %v", policy.Name, policy.Namespace, err)
 	}
 
 	type policyHandler struct {
@@ -620,7 +806,7 @@ func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
 	// Go through each ingress rule.  For each ingress rule, create an
 	// addressSet for the peer pods.
 	for i, ingressJSON := range policy.Spec.Ingress {
-		klog.V(5).Infof("Network policy ingress is %+v", ingressJSON)
+		klog.V(5).Infof("%s Network policy ingress is %+v", logCtx, ingressJSON)
 
 		ingress := newGressPolicy(knet.PolicyTypeIngress, i, policy.Namespace, policy.Name)
 
@@ -640,7 +826,7 @@ func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
 				policy.Name, "ingress", i)
 
 			hashedLocalAddressSet = hashedAddressSet(localPeerPods)
-			createAddressSet(localPeerPods, hashedLocalAddressSet, nil)
+			createAddressSet(networkPolicyOwner(policy.Namespace, policy.Name), localPeerPods, hashedLocalAddressSet, nil)
 			ingress.addAddressSet(hashedLocalAddressSet)
 		}
 
@@ -669,7 +855,7 @@ func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
 	// Go through each egress rule.  For each egress rule, create an
 	// addressSet for the peer pods.
 	for i, egressJSON := range policy.Spec.Egress {
-		klog.V(5).Infof("Network policy egress is %+v", egressJSON)
+		klog.V(5).Infof("%s Network policy egress is %+v", logCtx, egressJSON)
 
 		egress := newGressPolicy(knet.PolicyTypeEgress, i, policy.Namespace, policy.Name)
 
@@ -689,7 +875,7 @@ func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
 				policy.Name, "egress", i)
 
 			hashedLocalAddressSet = hashedAddressSet(localPeerPods)
-			createAddressSet(localPeerPods, hashedLocalAddressSet, nil)
+			createAddressSet(networkPolicyOwner(policy.Namespace, policy.Name), localPeerPods, hashedLocalAddressSet, nil)
 			egress.addAddressSet(hashedLocalAddressSet)
 		}
 
@@ -739,6 +925,7 @@ func (oc *Controller) addNetworkPolicy(policy *knet.NetworkPolicy) {
 				handler.addrSet, handler.peerMap, np)
 		}
 	}
+	return nil
 }
 
 // deletes the namespacePolicy for policy and returns it, locked
@@ -761,8 +948,8 @@ func (oc *Controller) deleteNetworkPolicyLocked(policy *knet.NetworkPolicy) *nam
 }
 
 func (oc *Controller) deleteNetworkPolicy(policy *knet.NetworkPolicy) {
-	klog.Infof("Deleting network policy %s in namespace %s",
-		policy.Name, policy.Namespace)
+	logCtx := logging.Policy(policy.Namespace, policy.Name).Operation("delete")
+	klog.Infof("%s Deleting network policy %s in namespace %s", logCtx, policy.Name, policy.Namespace)
 
 	np := oc.deleteNetworkPolicyLocked(policy)
 	if np == nil {
@@ -774,7 +961,7 @@ func (oc *Controller) deleteNetworkPolicy(policy *knet.NetworkPolicy) {
 	oc.shutdownHandlers(np)
 
 	for _, portInfo := range np.localPods {
-		oc.localPodDelDefaultDeny(policy, portInfo)
+		oc.localPodDelDefaultDeny(logCtx, policy, portInfo)
 	}
 
 	// Delete the port group
@@ -881,8 +1068,8 @@ func (oc *Controller) handlePeerPodSelector(
 			},
 		}, nil)
 	if err != nil {
-		klog.Errorf("error watching peer pods for policy %s in namespace %s: %v",
-			policy.Name, policy.Namespace, err)
+		klog.Errorf("%s error watching peer pods for policy %s in namespace %s: %v",
+			logging.Policy(policy.Namespace, policy.Name).Operation("add"), policy.Name, policy.Namespace, err)
 		return
 	}
 
@@ -919,7 +1106,8 @@ func (oc *Controller) handlePeerNamespaceAndPodSelector(policy *knet.NetworkPoli
 						},
 					}, nil)
 				if err != nil {
-					klog.Errorf("error watching pods in namespace %s for policy %s: %v", namespace.Name, policy.Name, err)
+					klog.Errorf("%s error watching pods in namespace %s for policy %s: %v",
+						logging.Policy(policy.Namespace, policy.Name).Operation("update"), namespace.Name, policy.Name, err)
 					return
 				}
 				np.Lock()
@@ -936,8 +1124,8 @@ func (oc *Controller) handlePeerNamespaceAndPodSelector(policy *knet.NetworkPoli
 			},
 		}, nil)
 	if err != nil {
-		klog.Errorf("error watching namespaces for policy %s: %v",
-			policy.Name, err)
+		klog.Errorf("%s error watching namespaces for policy %s: %v",
+			logging.Policy(policy.Namespace, policy.Name).Operation("add"), policy.Name, err)
 		return
 	}
 	np.nsHandlerList = append(np.nsHandlerList, namespaceHandler)
@@ -981,8 +1169,8 @@ func (oc *Controller) handlePeerNamespaceSelector(
 			},
 		}, nil)
 	if err != nil {
-		klog.Errorf("error watching namespaces for policy %s: %v",
-			policy.Name, err)
+		klog.Errorf("%s error watching namespaces for policy %s: %v",
+			logging.Policy(policy.Namespace, policy.Name).Operation("add"), policy.Name, err)
 		return
 	}
 
@@ -1,7 +1,9 @@
 package ovn
 
 import (
+	"context"
 	"fmt"
+	"net"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -48,7 +50,8 @@ func (n networkPolicy) baseCmds(fexec *ovntest.FakeExec, networkPolicy *knet.Net
 		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=%s", hashedGroupName),
 	})
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    fmt.Sprintf("ovn-nbctl --timeout=15 create port_group name=%s external-ids:name=%s", hashedGroupName, readableGroupName),
+		Cmd: fmt.Sprintf("ovn-nbctl --timeout=15 create port_group name=%s external-ids:name=%s external-ids:k8s-owner-kind=NetworkPolicy external-ids:k8s-owner=%s/%s",
+			hashedGroupName, readableGroupName, networkPolicy.Namespace, networkPolicy.Name),
 		Output: readableGroupName,
 	})
 	return readableGroupName
@@ -57,7 +60,8 @@ func (n networkPolicy) addNamespaceSelectorCmdsForGress(fexec *ovntest.FakeExec,
 	hashedOVNName := hashedAddressSet(fmt.Sprintf("%s.%s.%s.%d", networkPolicy.Namespace, networkPolicy.Name, gress, i))
 	fexec.AddFakeCmdsNoOutputNoError([]string{
 		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find address_set name=%s", hashedOVNName),
-		fmt.Sprintf("ovn-nbctl --timeout=15 create address_set name=%s external-ids:name=%s", hashedOVNName, fmt.Sprintf("%s.%s.%s.%v", networkPolicy.Namespace, networkPolicy.Name, gress, i)),
+		fmt.Sprintf("ovn-nbctl --timeout=15 create address_set name=%s external-ids:name=%s external-ids:k8s-owner-kind=NetworkPolicy external-ids:k8s-owner=%s/%s",
+			hashedOVNName, fmt.Sprintf("%s.%s.%s.%v", networkPolicy.Namespace, networkPolicy.Name, gress, i), networkPolicy.Namespace, networkPolicy.Name),
 	})
 }
 
@@ -127,7 +131,7 @@ func (n networkPolicy) addNamespaceSelectorCmds(fexec *ovntest.FakeExec, network
 		n.addNamespaceSelectorCmdsForGress(fexec, networkPolicy, "ingress", i)
 		fexec.AddFakeCmdsNoOutputNoError([]string{
 			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Ingress_num=%v external-ids:policy_type=Ingress", networkPolicy.Namespace, networkPolicy.Name, i),
-			"ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4.src == {$a10148211500778908391} && outport == @a14195333570786048679\" action=allow-related external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=namespace1 external-ids:policy=networkpolicy1 external-ids:Ingress_num=0 external-ids:policy_type=Ingress -- add port_group " + readableGroupName + " acls @acl",
+			"ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4.src == {$a10148211500778908391} && outport == @a14195333570786048679\" action=allow-related external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=namespace1 external-ids:policy=networkpolicy1 external-ids:Ingress_num=0 external-ids:policy_type=Ingress external-ids:k8s-owner-kind=NetworkPolicy external-ids:k8s-owner=namespace1/networkpolicy1 -- add port_group " + readableGroupName + " acls @acl",
 		})
 		if findAgain {
 			fexec.AddFakeCmdsNoOutputNoError([]string{
@@ -139,7 +143,7 @@ func (n networkPolicy) addNamespaceSelectorCmds(fexec *ovntest.FakeExec, network
 		n.addNamespaceSelectorCmdsForGress(fexec, networkPolicy, "egress", i)
 		fexec.AddFakeCmdsNoOutputNoError([]string{
 			fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=%v external-ids:policy_type=Egress", networkPolicy.Namespace, networkPolicy.Name, i),
-			"ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4.dst == {$a9824637386382239951} && inport == @a14195333570786048679\" action=allow external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=namespace1 external-ids:policy=networkpolicy1 external-ids:Egress_num=0 external-ids:policy_type=Egress -- add port_group " + readableGroupName + " acls @acl",
+			"ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4.dst == {$a9824637386382239951} && inport == @a14195333570786048679\" action=allow external-ids:l4Match=\"None\" external-ids:ipblock_cidr=false external-ids:namespace=namespace1 external-ids:policy=networkpolicy1 external-ids:Egress_num=0 external-ids:policy_type=Egress external-ids:k8s-owner-kind=NetworkPolicy external-ids:k8s-owner=namespace1/networkpolicy1 -- add port_group " + readableGroupName + " acls @acl",
 		})
 		if findAgain {
 			fexec.AddFakeCmdsNoOutputNoError([]string{
@@ -209,7 +213,9 @@ func (n networkPolicy) delPodCmds(fexec *ovntest.FakeExec, networkPolicy *knet.N
 	}
 }
 
-type multicastPolicy struct{}
+type multicastPolicy struct {
+	allow multicastAllowPolicy
+}
 
 func (p multicastPolicy) enableCmds(fExec *ovntest.FakeExec, ns string) {
 	pg_name, pg_hash := getMulticastPortGroup(ns)
@@ -218,11 +224,43 @@ func (p multicastPolicy) enableCmds(fExec *ovntest.FakeExec, ns string) {
 		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=" + pg_hash,
 	})
 	fExec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 create port_group name=" + pg_hash + " external-ids:name=" + pg_name,
+		Cmd: "ovn-nbctl --timeout=15 create port_group name=" + pg_hash + " external-ids:name=" + pg_name +
+			" external-ids:k8s-owner-kind=Namespace external-ids:k8s-owner=" + ns,
 		Output: "fake_uuid",
 	})
 
-	match := getACLMatch(pg_hash, "ip4.mcast", knet.PolicyTypeEgress)
+	p.addACLCmds(fExec, ns)
+}
+
+func (p multicastPolicy) updateCmds(fExec *ovntest.FakeExec, ns string, old multicastPolicy) {
+	_, pg_hash := getMulticastPortGroup(ns)
+
+	old.deleteACLCmds(fExec, ns)
+	fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=" + pg_hash,
+		Output: "fake_uuid",
+	})
+	p.addACLCmds(fExec, ns)
+}
+
+func (p multicastPolicy) disableCmds(fExec *ovntest.FakeExec, ns string) {
+	_, pg_hash := getMulticastPortGroup(ns)
+
+	p.deleteACLCmds(fExec, ns)
+
+	fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=" + pg_hash,
+		Output: "fake_uuid",
+	})
+	fExec.AddFakeCmdsNoOutputNoError([]string{
+		"ovn-nbctl --timeout=15 --if-exists destroy port_group fake_uuid",
+	})
+}
+
+func (p multicastPolicy) addACLCmds(fExec *ovntest.FakeExec, ns string) {
+	_, pg_hash := getMulticastPortGroup(ns)
+
+	match := getACLMatch(pg_hash, getMulticastEgressMatch(p.allow), knet.PolicyTypeEgress)
 	fExec.AddFakeCmdsNoOutputNoError([]string{
 		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL " +
 			match + " action=allow external-ids:default-deny-policy-type=Egress",
@@ -230,10 +268,11 @@ func (p multicastPolicy) enableCmds(fExec *ovntest.FakeExec, ns string) {
 	fExec.AddFakeCmdsNoOutputNoError([]string{
 		"ovn-nbctl --timeout=15 --id=@acl create acl priority=1012 direction=from-lport " +
 			match + " action=allow external-ids:default-deny-policy-type=Egress " +
+			"external-ids:k8s-owner-kind=Namespace external-ids:k8s-owner=" + ns + " " +
 			"-- add port_group fake_uuid acls @acl",
 	})
 
-	match = getMulticastACLMatch(ns)
+	match = getMulticastACLMatch(ns, p.allow)
 	match = getACLMatch(pg_hash, match, knet.PolicyTypeIngress)
 	fExec.AddFakeCmdsNoOutputNoError([]string{
 		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL " +
@@ -242,14 +281,15 @@ func (p multicastPolicy) enableCmds(fExec *ovntest.FakeExec, ns string) {
 	fExec.AddFakeCmdsNoOutputNoError([]string{
 		"ovn-nbctl --timeout=15 --id=@acl create acl priority=1012 direction=to-lport " +
 			match + " action=allow external-ids:default-deny-policy-type=Ingress " +
+			"external-ids:k8s-owner-kind=Namespace external-ids:k8s-owner=" + ns + " " +
 			"-- add port_group fake_uuid acls @acl",
 	})
 }
 
-func (p multicastPolicy) disableCmds(fExec *ovntest.FakeExec, ns string) {
+func (p multicastPolicy) deleteACLCmds(fExec *ovntest.FakeExec, ns string) {
 	_, pg_hash := getMulticastPortGroup(ns)
 
-	match := getACLMatch(pg_hash, "ip4.mcast", knet.PolicyTypeEgress)
+	match := getACLMatch(pg_hash, getMulticastEgressMatch(p.allow), knet.PolicyTypeEgress)
 	fExec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL " +
 			match + " " + "action=allow external-ids:default-deny-policy-type=Egress",
@@ -259,7 +299,7 @@ func (p multicastPolicy) disableCmds(fExec *ovntest.FakeExec, ns string) {
 		"ovn-nbctl --timeout=15 remove port_group " + pg_hash + " acls fake_uuid",
 	})
 
-	match = getMulticastACLMatch(ns)
+	match = getMulticastACLMatch(ns, p.allow)
 	match = getACLMatch(pg_hash, match, knet.PolicyTypeIngress)
 	fExec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd: "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL " +
@@ -269,14 +309,6 @@ func (p multicastPolicy) disableCmds(fExec *ovntest.FakeExec, ns string) {
 	fExec.AddFakeCmdsNoOutputNoError([]string{
 		"ovn-nbctl --timeout=15 remove port_group " + pg_hash + " acls fake_uuid",
 	})
-
-	fExec.AddFakeCmd(&ovntest.ExpectedCmd{
-		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find port_group name=" + pg_hash,
-		Output: "fake_uuid",
-	})
-	fExec.AddFakeCmdsNoOutputNoError([]string{
-		"ovn-nbctl --timeout=15 --if-exists destroy port_group fake_uuid",
-	})
 }
 
 func (p multicastPolicy) addPodCmds(fExec *ovntest.FakeExec, ns string) {
@@ -379,7 +411,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				fakeOvn.controller.WatchNamespaces()
 				fakeOvn.controller.WatchNetworkPolicy()
 
-				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(networkPolicy.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(context.TODO(), networkPolicy.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -468,7 +500,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				fakeOvn.controller.WatchNamespaces()
 				fakeOvn.controller.WatchNetworkPolicy()
 
-				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(networkPolicy.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(context.TODO(), networkPolicy.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -569,7 +601,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				fakeOvn.controller.WatchNamespaces()
 				fakeOvn.controller.WatchNetworkPolicy()
 
-				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(networkPolicy.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(context.TODO(), networkPolicy.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -640,9 +672,9 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				readableGroupName := fmt.Sprintf("%s_%s", networkPolicy.Namespace, networkPolicy.Name)
 				fExec.AddFakeCmdsNoOutputNoError([]string{
 					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"tcp && tcp.dst==%d\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Ingress_num=0 external-ids:policy_type=Ingress", portNum, networkPolicy.Namespace, networkPolicy.Name),
-					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4 && tcp && tcp.dst==%d && outport == @a14195333570786048679\" action=allow-related external-ids:l4Match=\"tcp && tcp.dst==%d\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Ingress_num=0 external-ids:policy_type=Ingress -- add port_group %s acls @acl", portNum, portNum, networkPolicy.Namespace, networkPolicy.Name, readableGroupName),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4 && tcp && tcp.dst==%d && outport == @a14195333570786048679\" action=allow-related external-ids:l4Match=\"tcp && tcp.dst==%d\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Ingress_num=0 external-ids:policy_type=Ingress external-ids:k8s-owner-kind=NetworkPolicy external-ids:k8s-owner=namespace1/networkpolicy1 -- add port_group %s acls @acl", portNum, portNum, networkPolicy.Namespace, networkPolicy.Name, readableGroupName),
 					fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL external-ids:l4Match=\"tcp && tcp.dst==%d\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=0 external-ids:policy_type=Egress", portNum, networkPolicy.Namespace, networkPolicy.Name),
-					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4 && tcp && tcp.dst==%d && inport == @a14195333570786048679\" action=allow external-ids:l4Match=\"tcp && tcp.dst==%d\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=0 external-ids:policy_type=Egress -- add port_group %s acls @acl", portNum, portNum, networkPolicy.Namespace, networkPolicy.Name, readableGroupName),
+					fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl priority=1001 direction=to-lport match=\"ip4 && tcp && tcp.dst==%d && inport == @a14195333570786048679\" action=allow external-ids:l4Match=\"tcp && tcp.dst==%d\" external-ids:ipblock_cidr=false external-ids:namespace=%s external-ids:policy=%s external-ids:Egress_num=0 external-ids:policy_type=Egress external-ids:k8s-owner-kind=NetworkPolicy external-ids:k8s-owner=namespace1/networkpolicy1 -- add port_group %s acls @acl", portNum, portNum, networkPolicy.Namespace, networkPolicy.Name, readableGroupName),
 				})
 
 				fakeOvn.start(ctx,
@@ -662,7 +694,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				fakeOvn.controller.WatchNamespaces()
 				fakeOvn.controller.WatchNetworkPolicy()
 
-				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(networkPolicy.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(context.TODO(), networkPolicy.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -755,7 +787,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				fakeOvn.controller.WatchNamespaces()
 				fakeOvn.controller.WatchNetworkPolicy()
 
-				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(networkPolicy.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(context.TODO(), networkPolicy.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -772,7 +804,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL match=\"ip4.dst == {$a6953373268003663638, $a9824637386382239951} && inport == @a14195333570786048679\" external-ids:namespace=namespace1 external-ids:policy=networkpolicy1 external-ids:Egress_num=0 external-ids:policy_type=Egress",
 				})
 
-				err = fakeOvn.fakeClient.CoreV1().Namespaces().Delete(namespace2.Name, metav1.NewDeleteOptions(0))
+				err = fakeOvn.fakeClient.CoreV1().Namespaces().Delete(context.TODO(), namespace2.Name, *metav1.NewDeleteOptions(0))
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -841,7 +873,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				fakeOvn.controller.WatchNamespaces()
 				fakeOvn.controller.WatchNetworkPolicy()
 
-				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(networkPolicy.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(context.TODO(), networkPolicy.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -857,7 +889,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 					"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find ACL match=\"ip4.dst == {$a6953373268003663638, $a9824637386382239951} && inport == @a14195333570786048679\" external-ids:namespace=namespace1 external-ids:policy=networkpolicy1 external-ids:Egress_num=0 external-ids:policy_type=Egress",
 				})
 
-				err = fakeOvn.fakeClient.CoreV1().Namespaces().Delete(namespace2.Name, metav1.NewDeleteOptions(0))
+				err = fakeOvn.fakeClient.CoreV1().Namespaces().Delete(context.TODO(), namespace2.Name, *metav1.NewDeleteOptions(0))
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -946,7 +978,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				fakeOvn.controller.WatchNamespaces()
 				fakeOvn.controller.WatchNetworkPolicy()
 
-				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(networkPolicy.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(context.TODO(), networkPolicy.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -954,7 +986,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				nPodTest.delFromNamespaceCmds(fExec, nPodTest, true)
 				npTest.delPodCmds(fExec, networkPolicy, true, nPodTest.podIP)
 
-				err = fakeOvn.fakeClient.CoreV1().Pods(nPodTest.namespace).Delete(nPodTest.podName, metav1.NewDeleteOptions(0))
+				err = fakeOvn.fakeClient.CoreV1().Pods(nPodTest.namespace).Delete(context.TODO(), nPodTest.podName, *metav1.NewDeleteOptions(0))
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -1055,7 +1087,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				fakeOvn.controller.WatchNamespaces()
 				fakeOvn.controller.WatchNetworkPolicy()
 
-				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(networkPolicy.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(context.TODO(), networkPolicy.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -1063,7 +1095,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				nPodTest.delFromNamespaceCmds(fExec, nPodTest, true)
 				npTest.delPodCmds(fExec, networkPolicy, false, nPodTest.podIP)
 
-				err = fakeOvn.fakeClient.CoreV1().Pods(nPodTest.namespace).Delete(nPodTest.podName, metav1.NewDeleteOptions(0))
+				err = fakeOvn.fakeClient.CoreV1().Pods(nPodTest.namespace).Delete(context.TODO(), nPodTest.podName, *metav1.NewDeleteOptions(0))
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -1152,13 +1184,13 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				fakeOvn.controller.WatchNamespaces()
 				fakeOvn.controller.WatchNetworkPolicy()
 
-				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(networkPolicy.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Get(context.TODO(), networkPolicy.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
 				npTest.delCmds(fExec, nPodTest, networkPolicy, true)
 
-				err = fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Delete(networkPolicy.Name, metav1.NewDeleteOptions(0))
+				err = fakeOvn.fakeClient.NetworkingV1().NetworkPolicies(networkPolicy.Namespace).Delete(context.TODO(), networkPolicy.Name, *metav1.NewDeleteOptions(0))
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -1185,8 +1217,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				nTest.baseCmds(fExec, namespace1)
 				nTest.addCmds(fExec, namespace1)
 				fakeOvn.controller.WatchNamespaces()
-				ns, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(
-					namespace1.Name, metav1.GetOptions{})
+				ns, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(context.TODO(), namespace1.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(ns).NotTo(BeNil())
 
@@ -1198,14 +1229,160 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				mcastPolicy := multicastPolicy{}
 				mcastPolicy.enableCmds(fExec, namespace1.Name)
 				ns.Annotations[nsMulticastAnnotation] = "true"
-				_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(ns)
+				_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
 				// Disable multicast in the namespace.
 				mcastPolicy.disableCmds(fExec, namespace1.Name)
 				ns.Annotations[nsMulticastAnnotation] = "false"
-				_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(ns)
+				_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("tests enabling/disabling multicast in a dual-stack namespace", func() {
+			app.Action = func(ctx *cli.Context) error {
+				nTest := namespace{}
+				namespace1 := *newNamespace("namespace1")
+
+				fakeOvn.start(ctx,
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+						},
+					},
+				)
+				Expect(config.IPv4Mode).To(BeTrue())
+				Expect(config.IPv6Mode).To(BeTrue())
+
+				// Both IGMP and MLD must be matched by the multicast ACLs.
+				Expect(getMulticastMatch()).To(Equal("(ip4.mcast || " +
+					"(mldv1 || mldv2 || (ip6.dst[120..127] == 0xff && ip6.dst[116] == 1)))"))
+				nsAddressSet := hashedAddressSet(namespace1.Name)
+				Expect(getMulticastACLMatch(namespace1.Name, multicastAllowPolicy{})).To(Equal(
+					"((ip4.src == $" + nsAddressSet + " && ip4.mcast) || " +
+						"(ip6.src == $" + nsAddressSet + " && " +
+						"(mldv1 || mldv2 || (ip6.dst[120..127] == 0xff && ip6.dst[116] == 1))))"))
+
+				nTest.baseCmds(fExec, namespace1)
+				nTest.addCmds(fExec, namespace1)
+				fakeOvn.controller.WatchNamespaces()
+				ns, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(context.TODO(), namespace1.Name, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ns).NotTo(BeNil())
+
+				// Enable multicast in the namespace.
+				mcastPolicy := multicastPolicy{}
+				mcastPolicy.enableCmds(fExec, namespace1.Name)
+				ns.Annotations[nsMulticastAnnotation] = "true"
+				_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// Disable multicast in the namespace.
+				mcastPolicy.disableCmds(fExec, namespace1.Name)
+				ns.Annotations[nsMulticastAnnotation] = "false"
+				_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				return nil
+			}
+
+			err := app.Run([]string{
+				app.Name,
+				"-cluster-subnets=10.128.0.0/14,fd00:10:128::/48",
+				"-k8s-service-cidrs=172.16.1.0/24,fd00:10:96::/112",
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("tests restricting multicast in a namespace to groups and source namespaces", func() {
+			app.Action = func(ctx *cli.Context) error {
+				nTest := namespace{}
+				namespace1 := *newNamespace("namespace1")
+				namespace1.Annotations[nsMulticastAnnotation] = "true"
+				namespace1.Annotations[nsMulticastGroupsAnnotation] = "239.1.1.1, 239.1.1.2"
+				namespace1.Annotations[nsMulticastSourceNamespacesAnnotation] = "namespace2"
+				namespace2 := *newNamespace("namespace2")
+
+				fakeOvn.start(ctx,
+					&v1.NamespaceList{
+						Items: []v1.Namespace{
+							namespace1,
+						},
+					},
+				)
+
+				groups := []net.IP{
+					ovntest.MustParseIP("239.1.1.1"),
+					ovntest.MustParseIP("239.1.1.2"),
+				}
+				mcastPolicy := multicastPolicy{
+					allow: multicastAllowPolicy{
+						groups:           groups,
+						sourceNamespaces: []string{"namespace2"},
+					},
+				}
+				Expect(getMulticastEgressMatch(mcastPolicy.allow)).To(Equal(
+					"(igmp || ip4.dst == {239.1.1.1, 239.1.1.2})"))
+				Expect(getMulticastACLMatch(namespace1.Name, mcastPolicy.allow)).To(Equal(
+					"ip4.src == {$" + hashedAddressSet(namespace1.Name) +
+						", $" + hashedAddressSet("namespace2") + "} && ip4.dst == {239.1.1.1, 239.1.1.2}"))
+
+				// The source namespace does not exist yet, the ACLs must not
+				// refer to its address set
+				noSourcePolicy := multicastPolicy{allow: multicastAllowPolicy{groups: groups}}
+				nTest.baseCmds(fExec, namespace1)
+				nTest.addCmds(fExec, namespace1)
+				noSourcePolicy.enableCmds(fExec, namespace1.Name)
+				fakeOvn.controller.WatchNamespaces()
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// Creating the source namespace allows its multicast traffic
+				nTest.addCmds(fExec, namespace2)
+				mcastPolicy.updateCmds(fExec, namespace1.Name, noSourcePolicy)
+				_, err := fakeOvn.fakeClient.CoreV1().Namespaces().Create(context.TODO(), &namespace2, metav1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// Changing the groups updates the ACLs in place.
+				ns, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(context.TODO(), namespace1.Name, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				newPolicy := multicastPolicy{
+					allow: multicastAllowPolicy{
+						groups:           []net.IP{ovntest.MustParseIP("239.1.1.1")},
+						sourceNamespaces: []string{"namespace2"},
+					},
+				}
+				newPolicy.updateCmds(fExec, namespace1.Name, mcastPolicy)
+				ns.Annotations[nsMulticastGroupsAnnotation] = "239.1.1.1"
+				_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// Deleting the source namespace stops referring to its
+				// address set before the address set is deleted
+				newNoSourcePolicy := multicastPolicy{
+					allow: multicastAllowPolicy{
+						groups: []net.IP{ovntest.MustParseIP("239.1.1.1")},
+					},
+				}
+				newNoSourcePolicy.updateCmds(fExec, namespace1.Name, newPolicy)
+				nTest.delCmds(fExec, namespace2)
+				err = fakeOvn.fakeClient.CoreV1().Namespaces().Delete(context.TODO(), namespace2.Name, *metav1.NewDeleteOptions(0))
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+				// Disabling multicast removes the updated ACLs.
+				newNoSourcePolicy.disableCmds(fExec, namespace1.Name)
+				ns.Annotations[nsMulticastAnnotation] = "false"
+				_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 				return nil
@@ -1252,8 +1429,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 
 				fakeOvn.controller.WatchPods()
 				fakeOvn.controller.WatchNamespaces()
-				ns, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(
-					namespace1.Name, metav1.GetOptions{})
+				ns, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(context.TODO(), namespace1.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(ns).NotTo(BeNil())
 
@@ -1263,7 +1439,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				// The pod should be added to the multicast allow port group.
 				mcastPolicy.addPodCmds(fExec, namespace1.Name)
 				ns.Annotations[nsMulticastAnnotation] = "true"
-				_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(ns)
+				_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 				return nil
@@ -1302,8 +1478,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				nTest.addCmds(fExec, namespace1)
 				fakeOvn.controller.WatchNamespaces()
 				fakeOvn.controller.WatchPods()
-				ns, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(
-					namespace1.Name, metav1.GetOptions{})
+				ns, err := fakeOvn.fakeClient.CoreV1().Namespaces().Get(context.TODO(), namespace1.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(ns).NotTo(BeNil())
 
@@ -1311,7 +1486,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				mcastPolicy := multicastPolicy{}
 				mcastPolicy.enableCmds(fExec, namespace1.Name)
 				ns.Annotations[nsMulticastAnnotation] = "true"
-				_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(ns)
+				_, err = fakeOvn.fakeClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -1323,8 +1498,8 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				// The pod should be added to the multicast allow group.
 				mcastPolicy.addPodCmds(fExec, namespace1.Name)
 
-				_, err = fakeOvn.fakeClient.CoreV1().Pods(nPodTest.namespace).Create(newPod(
-					nPodTest.namespace, nPodTest.podName, nPodTest.nodeName, nPodTest.podIP))
+				_, err = fakeOvn.fakeClient.CoreV1().Pods(nPodTest.namespace).Create(context.TODO(), newPod(
+					nPodTest.namespace, nPodTest.podName, nPodTest.nodeName, nPodTest.podIP), metav1.CreateOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
@@ -1336,8 +1511,7 @@ var _ = Describe("OVN NetworkPolicy Operations", func() {
 				nTest.delPodCmds(fExec, nPodTest, namespace1, false)
 				nPodTest.delCmds(fExec)
 
-				err = fakeOvn.fakeClient.CoreV1().Pods(nPodTest.namespace).Delete(
-					nPodTest.podName, metav1.NewDeleteOptions(0))
+				err = fakeOvn.fakeClient.CoreV1().Pods(nPodTest.namespace).Delete(context.TODO(), nPodTest.podName, *metav1.NewDeleteOptions(0))
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 				return nil
@@ -21,6 +21,9 @@ type lpInfo struct {
 	logicalSwitch string
 	ip            net.IP
 	mac           net.HardwareAddr
+	// node is the node of the pod, whose logicalSwitch is either the node's
+	// switch or the switch of the pod's provider network
+	node string
 	// expires, if non-nil, indicates that this object is scheduled to be
 	// removed at the given time
 	expires time.Time
@@ -42,10 +45,11 @@ func (c *portCache) get(logicalPort string) (*lpInfo, error) {
 	return nil, fmt.Errorf("logical port %s not found in cache", logicalPort)
 }
 
-func (c *portCache) add(logicalSwitch, logicalPort, uuid string, mac net.HardwareAddr, ip net.IP) *lpInfo {
+func (c *portCache) add(node, logicalSwitch, logicalPort, uuid string, mac net.HardwareAddr, ip net.IP) *lpInfo {
 	c.Lock()
 	defer c.Unlock()
 	portInfo := &lpInfo{
+		node:          node,
 		logicalSwitch: logicalSwitch,
 		name:          logicalPort,
 		uuid:          uuid,
@@ -0,0 +1,97 @@
+package ovn
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	kapi "k8s.io/api/core/v1"
+	utilnet "k8s.io/utils/net"
+)
+
+// providerNetworkGatewayIP returns the router address of a provider network,
+// the first address of its subnet.
+func providerNetworkGatewayIP(network *config.ProviderNetwork) net.IP {
+	return util.NextIP(network.Subnet.IP)
+}
+
+func getProviderNetwork(name string) *config.ProviderNetwork {
+	for i := range config.ProviderNetworks.Networks {
+		if config.ProviderNetworks.Networks[i].Name == name {
+			return &config.ProviderNetworks.Networks[i]
+		}
+	}
+	return nil
+}
+
+// setupProviderNetworks creates a logical switch with a localnet port for
+// each provider network. The switch spans every node that maps the physical
+// network to a bridge, and OVN assigns the addresses of the pods attached to
+// it from the network's subnet.
+func setupProviderNetworks() error {
+	for i := range config.ProviderNetworks.Networks {
+		network := &config.ProviderNetworks.Networks[i]
+		logicalSwitch := util.ProviderNetworkSwitch(network.Name)
+		localnetPort := logicalSwitch + "_localnet"
+
+		cmdArgs := []string{"--", "--may-exist", "ls-add", logicalSwitch}
+		if utilnet.IsIPv6CIDR(network.Subnet) {
+			cmdArgs = append(cmdArgs,
+				"--", "set", "logical_switch", logicalSwitch,
+				"other-config:ipv6_prefix="+network.Subnet.IP.String())
+		} else {
+			cmdArgs = append(cmdArgs,
+				"--", "set", "logical_switch", logicalSwitch,
+				"other-config:subnet="+network.Subnet.String(),
+				"other-config:exclude_ips="+providerNetworkGatewayIP(network).String())
+		}
+		cmdArgs = append(cmdArgs,
+			"--", "--may-exist", "lsp-add", logicalSwitch, localnetPort,
+			"--", "lsp-set-addresses", localnetPort, "unknown",
+			"--", "lsp-set-type", localnetPort, "localnet",
+			"--", "lsp-set-options", localnetPort, "network_name="+network.Name)
+		if network.VLANID != 0 {
+			cmdArgs = append(cmdArgs,
+				"--", "set", "logical_switch_port", localnetPort,
+				fmt.Sprintf("tag_request=%d", network.VLANID))
+		}
+
+		stdout, stderr, err := util.RunOVNNbctl(cmdArgs...)
+		if err != nil {
+			return fmt.Errorf("failed to create provider network %s logical switch, stdout: %q, "+
+				"stderr: %q, error: %v", network.Name, stdout, stderr, err)
+		}
+	}
+	return nil
+}
+
+// getPodProviderNetwork returns the provider network a pod is attached to by
+// its own or its namespace's annotation, or nil if the pod is on the cluster
+// network.
+func (oc *Controller) getPodProviderNetwork(pod *kapi.Pod) (*config.ProviderNetwork, error) {
+	if len(config.ProviderNetworks.Networks) == 0 {
+		return nil, nil
+	}
+
+	name, ok := pod.Annotations[util.ProviderNetworkAnnotation]
+	if !ok {
+		nsInfo, err := oc.requireNamespaceLocked(pod.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		name = nsInfo.providerNetwork
+		nsInfo.Unlock()
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	network := getProviderNetwork(name)
+	if network == nil {
+		return nil, fmt.Errorf("pod %s/%s is attached to unknown provider network %q",
+			pod.Namespace, pod.Name, name)
+	}
+	return network, nil
+}
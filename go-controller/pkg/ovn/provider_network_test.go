@@ -0,0 +1,200 @@
+package ovn
+
+import (
+	"context"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OVN Provider Network Operations", func() {
+	var (
+		app     *cli.App
+		fakeOvn *FakeOVN
+		fExec   *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fExec = ovntest.NewFakeExec()
+		fakeOvn = NewFakeOVN(fExec)
+	})
+
+	AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	It("attaches an annotated pod to the provider network switch", func() {
+		app.Action = func(ctx *cli.Context) error {
+			// The pod's port is added to the provider network switch
+			// rather than to the switch of the pod's node
+			t := newTPod(
+				util.ProviderNetworkSwitch("physnet1"),
+				"10.20.0.0/24",
+				"",
+				"10.20.0.1",
+				"myPod",
+				"10.20.0.5",
+				"11:22:33:44:55:66",
+				"namespace",
+			)
+
+			t.baseCmds(fExec)
+
+			fakeOvn.start(ctx, &v1.PodList{
+				Items: []v1.Pod{},
+			})
+			fakeOvn.controller.WatchPods()
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			t.addCmdsForNonExistingPod(fExec)
+			t.addPodDenyMcast(fExec)
+
+			pod := newPod(t.namespace, t.podName, "node1", t.podIP)
+			pod.Annotations = map[string]string{util.ProviderNetworkAnnotation: "physnet1"}
+			_, err := fakeOvn.fakeClient.CoreV1().Pods(t.namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			Eventually(func() string {
+				pod, err := fakeOvn.fakeClient.CoreV1().Pods(t.namespace).Get(context.TODO(), t.podName, metav1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				return pod.Annotations[util.OvnPodAnnotationName]
+			}).Should(MatchJSON(`{"default": {"ip_addresses":["` + t.podIP + `/24"], "mac_address":"` + t.podMAC + `", "gateway_ips": ["` + t.nodeGWIP + `"], "ip_address":"` + t.podIP + `/24", "gateway_ip": "` + t.nodeGWIP + `"}}`))
+
+			return nil
+		}
+
+		err := app.Run([]string{
+			app.Name,
+			"-provider-networks=physnet1:100:10.20.0.0/24",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("routes a provider network pod through its namespace's external gateways on its node's gateway router", func() {
+		app.Action = func(ctx *cli.Context) error {
+			test := namespace{}
+			namespaceT := *newNamespace("namespace")
+			namespaceT.Annotations[routingExternalGWsAnnotation] = "9.0.0.1"
+			t := newTPod(
+				util.ProviderNetworkSwitch("physnet1"),
+				"10.20.0.0/24",
+				"",
+				"10.20.0.1",
+				"myPod",
+				"10.20.0.5",
+				"11:22:33:44:55:66",
+				namespaceT.Name,
+			)
+
+			test.baseCmds(fExec, namespaceT)
+			test.addCmds(fExec, namespaceT)
+			t.baseCmds(fExec)
+
+			fakeOvn.start(ctx,
+				&v1.NamespaceList{
+					Items: []v1.Namespace{
+						namespaceT,
+					},
+				},
+				&v1.PodList{
+					Items: []v1.Pod{},
+				},
+			)
+			fakeOvn.controller.WatchNamespaces()
+			fakeOvn.controller.WatchPods()
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			// The route is on the gateway router of the pod's node, not
+			// of its provider network switch
+			t.addCmdsForNonExistingPod(fExec)
+			t.addPodDenyMcast(fExec)
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 --may-exist --policy=src-ip --ecmp-symmetric-reply lr-route-add GR_node1 10.20.0.5/32 9.0.0.1 rtoe-GR_node1",
+			})
+			test.addPodCmds(fExec, t, namespaceT, false)
+
+			pod := newPod(t.namespace, t.podName, "node1", t.podIP)
+			pod.Annotations = map[string]string{util.ProviderNetworkAnnotation: "physnet1"}
+			_, err := fakeOvn.fakeClient.CoreV1().Pods(t.namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			return nil
+		}
+
+		err := app.Run([]string{
+			app.Name,
+			"-provider-networks=physnet1:100:10.20.0.0/24",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("OVN Provider Network Setup", func() {
+	var (
+		app   *cli.App
+		fExec *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fExec = ovntest.NewFakeExec()
+		err := util.SetExec(fExec)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("creates the provider network switches", func() {
+		app.Action = func(ctx *cli.Context) error {
+			_, err := config.InitConfig(ctx, fExec, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			fExec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 -- --may-exist ls-add provnet_physnet1 " +
+					"-- set logical_switch provnet_physnet1 other-config:subnet=10.20.0.0/24 other-config:exclude_ips=10.20.0.1 " +
+					"-- --may-exist lsp-add provnet_physnet1 provnet_physnet1_localnet " +
+					"-- lsp-set-addresses provnet_physnet1_localnet unknown " +
+					"-- lsp-set-type provnet_physnet1_localnet localnet " +
+					"-- lsp-set-options provnet_physnet1_localnet network_name=physnet1 " +
+					"-- set logical_switch_port provnet_physnet1_localnet tag_request=100",
+				"ovn-nbctl --timeout=15 -- --may-exist ls-add provnet_physnet2 " +
+					"-- set logical_switch provnet_physnet2 other-config:ipv6_prefix=fd00:20:: " +
+					"-- --may-exist lsp-add provnet_physnet2 provnet_physnet2_localnet " +
+					"-- lsp-set-addresses provnet_physnet2_localnet unknown " +
+					"-- lsp-set-type provnet_physnet2_localnet localnet " +
+					"-- lsp-set-options provnet_physnet2_localnet network_name=physnet2",
+			})
+			err = setupProviderNetworks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+			return nil
+		}
+
+		err := app.Run([]string{
+			app.Name,
+			"-provider-networks=physnet1:100:10.20.0.0/24,physnet2:0:fd00:20::/64",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -0,0 +1,321 @@
+package ovn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+	kapi "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog"
+)
+
+const (
+	// DSCP value (0-63) to mark the pod's egress traffic with
+	qosDSCPAnnotation = "k8s.ovn.org/qos-dscp"
+	// Rate (bits per second, e.g. "10M") and burst (bits, e.g. "1M") limits
+	// of the pod's egress and ingress traffic
+	qosEgressRateAnnotation   = "k8s.ovn.org/qos-egress-rate"
+	qosEgressBurstAnnotation  = "k8s.ovn.org/qos-egress-burst"
+	qosIngressRateAnnotation  = "k8s.ovn.org/qos-ingress-rate"
+	qosIngressBurstAnnotation = "k8s.ovn.org/qos-ingress-burst"
+
+	// Priority of the pod QoS rules on the node logical switch
+	podQoSPriority = "1000"
+)
+
+var qosAnnotations = []string{
+	qosDSCPAnnotation,
+	qosEgressRateAnnotation,
+	qosEgressBurstAnnotation,
+	qosIngressRateAnnotation,
+	qosIngressBurstAnnotation,
+}
+
+// podQoS holds the QoS of a pod's logical port. Rates are in kbps and bursts
+// in kbits, as expected by OVN; zero values and a negative dscp are unset.
+type podQoS struct {
+	dscp         int
+	egressRate   int64
+	egressBurst  int64
+	ingressRate  int64
+	ingressBurst int64
+}
+
+func (q *podQoS) empty() bool {
+	return q.dscp < 0 && q.egressRate == 0 && q.ingressRate == 0
+}
+
+// getQoSAnnotations returns the QoS annotations of a pod or namespace.
+func getQoSAnnotations(annotations map[string]string) map[string]string {
+	qos := make(map[string]string)
+	for _, key := range qosAnnotations {
+		if value, ok := annotations[key]; ok {
+			qos[key] = value
+		}
+	}
+	return qos
+}
+
+// qosAnnotationsEqual returns true if both objects request the same QoS
+func qosAnnotationsEqual(a, b map[string]string) bool {
+	for _, key := range qosAnnotations {
+		if a[key] != b[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseQoSBandwidth parses a rate or burst quantity in bits and returns it
+// in kbits.
+func parseQoSBandwidth(annotations map[string]string, key string) (int64, error) {
+	value, ok := annotations[key]
+	if !ok {
+		return 0, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s annotation %q: %v", key, value, err)
+	}
+	kbits := quantity.Value() / 1000
+	if kbits < 1 {
+		return 0, fmt.Errorf("%s annotation %q is unreasonably small (< 1kbit)", key, value)
+	}
+	return kbits, nil
+}
+
+// getPodQoS returns the QoS of a pod's logical port. Each of the pod's QoS
+// annotations overrides the corresponding namespace default.
+func getPodQoS(pod *kapi.Pod, nsDefaults map[string]string) (*podQoS, error) {
+	annotations := make(map[string]string)
+	for key, value := range nsDefaults {
+		annotations[key] = value
+	}
+	for key, value := range getQoSAnnotations(pod.Annotations) {
+		annotations[key] = value
+	}
+
+	qos := &podQoS{dscp: -1}
+	if value, ok := annotations[qosDSCPAnnotation]; ok {
+		dscp, err := strconv.Atoi(value)
+		if err != nil || dscp < 0 || dscp > 63 {
+			return nil, fmt.Errorf("invalid %s annotation %q, must be between 0 and 63",
+				qosDSCPAnnotation, value)
+		}
+		qos.dscp = dscp
+	}
+
+	var err error
+	if qos.egressRate, err = parseQoSBandwidth(annotations, qosEgressRateAnnotation); err != nil {
+		return nil, err
+	}
+	if qos.egressBurst, err = parseQoSBandwidth(annotations, qosEgressBurstAnnotation); err != nil {
+		return nil, err
+	}
+	if qos.ingressRate, err = parseQoSBandwidth(annotations, qosIngressRateAnnotation); err != nil {
+		return nil, err
+	}
+	if qos.ingressBurst, err = parseQoSBandwidth(annotations, qosIngressBurstAnnotation); err != nil {
+		return nil, err
+	}
+	if qos.egressBurst != 0 && qos.egressRate == 0 {
+		return nil, fmt.Errorf("%s annotation requires %s", qosEgressBurstAnnotation, qosEgressRateAnnotation)
+	}
+	if qos.ingressBurst != 0 && qos.ingressRate == 0 {
+		return nil, fmt.Errorf("%s annotation requires %s", qosIngressBurstAnnotation, qosIngressRateAnnotation)
+	}
+	return qos, nil
+}
+
+// bandwidthArgs returns the bandwidth column of a QoS row
+func bandwidthArgs(rate, burst int64) []string {
+	if rate == 0 {
+		return nil
+	}
+	args := []string{fmt.Sprintf("bandwidth:rate=%d", rate)}
+	if burst != 0 {
+		args = append(args, fmt.Sprintf("bandwidth:burst=%d", burst))
+	}
+	return args
+}
+
+// addPodQoS creates the QoS rows of a logical port on its node logical
+// switch: one from-lport row for DSCP marking and egress limits, and one
+// to-lport row for ingress limits. Rows are tagged with the logical port
+// name so they can be found again.
+func addPodQoS(portInfo *lpInfo, qos *podQoS) error {
+	egressArgs := bandwidthArgs(qos.egressRate, qos.egressBurst)
+	if qos.dscp >= 0 {
+		egressArgs = append(egressArgs, fmt.Sprintf("action:dscp=%d", qos.dscp))
+	}
+	if len(egressArgs) > 0 {
+		if err := addPodQoSRow(portInfo, "from-lport", "inport", egressArgs); err != nil {
+			return err
+		}
+	}
+
+	if ingressArgs := bandwidthArgs(qos.ingressRate, qos.ingressBurst); len(ingressArgs) > 0 {
+		if err := addPodQoSRow(portInfo, "to-lport", "outport", ingressArgs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addPodQoSRow(portInfo *lpInfo, direction, portField string, qosArgs []string) error {
+	args := []string{"--id=@qos", "create", "qos",
+		"priority=" + podQoSPriority,
+		"direction=" + direction,
+		fmt.Sprintf(`match="%s == \"%s\""`, portField, portInfo.name),
+	}
+	args = append(args, qosArgs...)
+	args = append(args, "external-ids:logical_port="+portInfo.name)
+	args = append(args, podOwnerForPort(portInfo.name).externalIDs()...)
+	args = append(args, "--", "add", "logical_switch", portInfo.logicalSwitch, "qos_rules", "@qos")
+	stdout, stderr, err := util.RunOVNNbctl(args...)
+	if err != nil {
+		return fmt.Errorf("failed to create %s QoS for logical port %s, stdout: %q, stderr: %q (%v)",
+			direction, portInfo.name, stdout, stderr, err)
+	}
+	return nil
+}
+
+// deletePodQoS removes the QoS rows of a logical port from its node logical
+// switch.
+func deletePodQoS(portInfo *lpInfo) error {
+	uuids, stderr, err := util.RunOVNNbctl("--data=bare", "--no-heading",
+		"--columns=_uuid", "find", "qos", "external-ids:logical_port="+portInfo.name)
+	if err != nil {
+		return fmt.Errorf("failed to find QoS for logical port %s, stderr: %q (%v)",
+			portInfo.name, stderr, err)
+	}
+	for _, uuid := range strings.Fields(uuids) {
+		_, stderr, err = util.RunOVNNbctl("--if-exists", "remove", "logical_switch",
+			portInfo.logicalSwitch, "qos_rules", uuid)
+		if err != nil {
+			return fmt.Errorf("failed to delete QoS %s of logical port %s, stderr: %q (%v)",
+				uuid, portInfo.name, stderr, err)
+		}
+	}
+	return nil
+}
+
+// getNamespaceQoSDefaults returns the QoS defaults of the namespace
+func (oc *Controller) getNamespaceQoSDefaults(namespace string) map[string]string {
+	nsInfo := oc.getNamespaceLocked(namespace)
+	if nsInfo == nil {
+		return nil
+	}
+	defer nsInfo.Unlock()
+	return nsInfo.qosDefaults
+}
+
+// updatePodQoS replaces the QoS rows of a logical port when the QoS requested
+// for the pod changes from oldQoS to newQoS. Either may be nil if the pod had
+// or has no valid QoS.
+func updatePodQoS(portInfo *lpInfo, oldQoS, newQoS *podQoS) error {
+	if oldQoS != nil && newQoS != nil && *oldQoS == *newQoS {
+		return nil
+	}
+	// Rows of an unknown (invalid) old QoS are removed as well
+	if oldQoS == nil || !oldQoS.empty() {
+		if err := deletePodQoS(portInfo); err != nil {
+			return err
+		}
+	}
+	if newQoS != nil && !newQoS.empty() {
+		return addPodQoS(portInfo, newQoS)
+	}
+	return nil
+}
+
+// ensurePodQoS (re)creates the QoS rows of a newly created or reconciled
+// logical port, so restarts do not leave duplicate rows behind. The rows of a
+// reconciled port whose pod no longer requests QoS are removed.
+// An invalid QoS request is logged but does not fail the pod's creation.
+func (oc *Controller) ensurePodQoS(pod *kapi.Pod, portInfo *lpInfo, reconciled bool) error {
+	qos, err := getPodQoS(pod, oc.getNamespaceQoSDefaults(pod.Namespace))
+	if err != nil {
+		klog.Errorf("Failed to get QoS of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return nil
+	}
+	// a new port has no rows to replace
+	if qos.empty() && !reconciled {
+		return nil
+	}
+	if err := deletePodQoS(portInfo); err != nil {
+		return err
+	}
+	if qos.empty() {
+		return nil
+	}
+	return addPodQoS(portInfo, qos)
+}
+
+// updatePodQoSAnnotations updates the QoS of a pod's logical port when its QoS
+// annotations change.
+func (oc *Controller) updatePodQoSAnnotations(oldPod, newPod *kapi.Pod) {
+	if qosAnnotationsEqual(oldPod.Annotations, newPod.Annotations) {
+		return
+	}
+	portInfo, err := oc.logicalPortCache.get(podLogicalPortName(newPod))
+	if err != nil {
+		// QoS is applied when the logical port is created
+		return
+	}
+
+	nsDefaults := oc.getNamespaceQoSDefaults(newPod.Namespace)
+	oldQoS, _ := getPodQoS(oldPod, nsDefaults)
+	newQoS, err := getPodQoS(newPod, nsDefaults)
+	if err != nil {
+		klog.Errorf("Failed to get QoS of pod %s/%s: %v", newPod.Namespace, newPod.Name, err)
+	}
+	if err := updatePodQoS(portInfo, oldQoS, newQoS); err != nil {
+		klog.Errorf("Failed to update QoS of pod %s/%s: %v", newPod.Namespace, newPod.Name, err)
+	}
+}
+
+// deletePodQoSForPod removes the QoS of a deleted pod's logical port
+func (oc *Controller) deletePodQoSForPod(pod *kapi.Pod, portInfo *lpInfo) {
+	qos, err := getPodQoS(pod, oc.getNamespaceQoSDefaults(pod.Namespace))
+	if err == nil && qos.empty() {
+		return
+	}
+	if err := deletePodQoS(portInfo); err != nil {
+		klog.Errorf("Failed to delete QoS of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+}
+
+// updateNamespaceQoS updates the QoS defaults of a namespace and the QoS of
+// all the namespace's pods that do not override the changed defaults.
+// nsInfo must be locked.
+func (oc *Controller) updateNamespaceQoS(ns *kapi.Namespace, nsInfo *namespaceInfo) {
+	oldDefaults := nsInfo.qosDefaults
+	newDefaults := getQoSAnnotations(ns.Annotations)
+	nsInfo.qosDefaults = newDefaults
+	if qosAnnotationsEqual(oldDefaults, newDefaults) {
+		return
+	}
+
+	pods, err := oc.watchFactory.GetPods(ns.Name)
+	if err != nil {
+		klog.Errorf("Failed to get pods of namespace %s to update their QoS: %v", ns.Name, err)
+		return
+	}
+	for _, pod := range pods {
+		portInfo, err := oc.logicalPortCache.get(podLogicalPortName(pod))
+		if err != nil {
+			continue
+		}
+		oldQoS, _ := getPodQoS(pod, oldDefaults)
+		newQoS, err := getPodQoS(pod, newDefaults)
+		if err != nil {
+			klog.Errorf("Failed to get QoS of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+		if err := updatePodQoS(portInfo, oldQoS, newQoS); err != nil {
+			klog.Errorf("Failed to update QoS of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package ovn
+
+import (
+	"context"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	ovntest "github.com/ovn-org/ovn-kubernetes/go-controller/pkg/testing"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func (p pod) addQoSCmds(fexec *ovntest.FakeExec, existing string, egress, ingress string) {
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find qos external-ids:logical_port=" + p.portName,
+		Output: existing,
+	})
+	if existing != "" {
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovn-nbctl --timeout=15 --if-exists remove logical_switch " + p.nodeName + " qos_rules " + existing,
+		})
+	}
+	if egress != "" {
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovn-nbctl --timeout=15 --id=@qos create qos priority=1000 direction=from-lport " +
+				`match="inport == \"` + p.portName + `\"" ` + egress +
+				" external-ids:logical_port=" + p.portName +
+				" external-ids:k8s-owner-kind=Pod external-ids:k8s-owner=" + p.namespace + "/" + p.podName +
+				" -- add logical_switch " + p.nodeName + " qos_rules @qos",
+		})
+	}
+	if ingress != "" {
+		fexec.AddFakeCmdsNoOutputNoError([]string{
+			"ovn-nbctl --timeout=15 --id=@qos create qos priority=1000 direction=to-lport " +
+				`match="outport == \"` + p.portName + `\"" ` + ingress +
+				" external-ids:logical_port=" + p.portName +
+				" external-ids:k8s-owner-kind=Pod external-ids:k8s-owner=" + p.namespace + "/" + p.podName +
+				" -- add logical_switch " + p.nodeName + " qos_rules @qos",
+		})
+	}
+}
+
+var _ = Describe("OVN Pod QoS Operations", func() {
+	var (
+		app     *cli.App
+		fakeOvn *FakeOVN
+		fExec   *ovntest.FakeExec
+	)
+
+	BeforeEach(func() {
+		// Restore global default values before each testcase
+		config.PrepareTestConfig()
+
+		app = cli.NewApp()
+		app.Name = "test"
+		app.Flags = config.Flags
+
+		fExec = ovntest.NewFakeExec()
+		fakeOvn = NewFakeOVN(fExec)
+	})
+
+	AfterEach(func() {
+		fakeOvn.shutdown()
+	})
+
+	It("creates, updates and deletes the QoS of a pod", func() {
+		app.Action = func(ctx *cli.Context) error {
+			t := newTPod(
+				"node1",
+				"10.128.1.0/24",
+				"10.128.1.2",
+				"10.128.1.1",
+				"myPod",
+				"10.128.1.4",
+				"11:22:33:44:55:66",
+				"namespace",
+			)
+
+			t.baseCmds(fExec)
+
+			fakeOvn.start(ctx, &v1.PodList{
+				Items: []v1.Pod{},
+			})
+			t.populateLogicalSwitchCache(fakeOvn)
+			fakeOvn.controller.WatchPods()
+			Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
+
+			t.addCmdsForNonExistingPod(fExec)
+			t.addPodDenyMcast(fExec)
+			t.addQoSCmds(fExec, "",
+				"bandwidth:rate=10000 action:dscp=10",
+				"bandwidth:rate=20000 bandwidth:burst=2000")
+
+			pod := newPod(t.namespace, t.podName, t.nodeName, t.podIP)
+			pod.Annotations = map[string]string{
+				qosDSCPAnnotation:         "10",
+				qosEgressRateAnnotation:   "10M",
+				qosIngressRateAnnotation:  "20M",
+				qosIngressBurstAnnotation: "2M",
+			}
+			_, err := fakeOvn.fakeClient.CoreV1().Pods(t.namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			// Changing the QoS annotations replaces the pod's QoS rows
+			t.addQoSCmds(fExec, fakeUUID, "action:dscp=20", "")
+			pod, err = fakeOvn.fakeClient.CoreV1().Pods(t.namespace).Get(context.TODO(), t.podName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			pod.Annotations[qosDSCPAnnotation] = "20"
+			delete(pod.Annotations, qosEgressRateAnnotation)
+			delete(pod.Annotations, qosIngressRateAnnotation)
+			delete(pod.Annotations, qosIngressBurstAnnotation)
+			_, err = fakeOvn.fakeClient.CoreV1().Pods(t.namespace).Update(context.TODO(), pod, metav1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			// Deleting the pod removes its QoS rows
+			t.delPodDenyMcast(fExec)
+			t.addQoSCmds(fExec, fakeUUID, "", "")
+			t.delCmds(fExec)
+			err = fakeOvn.fakeClient.CoreV1().Pods(t.namespace).Delete(context.TODO(), t.podName, *metav1.NewDeleteOptions(0))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("removes the QoS of a reconciled pod that no longer requests QoS", func() {
+		app.Action = func(ctx *cli.Context) error {
+			t := newTPod(
+				"node1",
+				"10.128.1.0/24",
+				"10.128.1.2",
+				"10.128.1.1",
+				"myPod",
+				"10.128.1.4",
+				"11:22:33:44:55:66",
+				"namespace",
+			)
+
+			// The pod's QoS annotations were removed while the master was
+			// down, the rows of its logical port are removed on startup
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_switch_port external_ids:pod=true",
+				Output: t.portName + "\n",
+			})
+			fExec.AddFakeCmd(&ovntest.ExpectedCmd{
+				Cmd:    "ovn-nbctl --timeout=15 --if-exists get logical_switch_port " + t.portName + " _uuid",
+				Output: fakeUUID + "\n",
+			})
+			t.addCmdsForExistingPod(fExec, false)
+			t.addPodDenyMcast(fExec)
+			t.addQoSCmds(fExec, fakeUUID, "", "")
+
+			pod := newPod(t.namespace, t.podName, t.nodeName, t.podIP)
+			pod.Annotations = map[string]string{
+				util.OvnPodAnnotationName: `{"default": {"ip_addresses":["` + t.podIP + `/24"], "mac_address":"` + t.podMAC + `", "gateway_ips": ["` + t.nodeGWIP + `"]}}`,
+			}
+			fakeOvn.start(ctx, &v1.PodList{
+				Items: []v1.Pod{*pod},
+			})
+			t.populateLogicalSwitchCache(fakeOvn)
+			fakeOvn.controller.WatchPods()
+			Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+
+			return nil
+		}
+
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("OVN Pod QoS annotation parsing", func() {
+	It("overrides namespace defaults with pod annotations", func() {
+		pod := newPod("namespace1", "myPod", "node1", "10.128.1.4")
+		pod.Annotations = map[string]string{
+			qosEgressRateAnnotation: "5M",
+		}
+		nsDefaults := map[string]string{
+			qosDSCPAnnotation:        "46",
+			qosEgressRateAnnotation:  "1M",
+			qosEgressBurstAnnotation: "100k",
+		}
+		qos, err := getPodQoS(pod, nsDefaults)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*qos).To(Equal(podQoS{dscp: 46, egressRate: 5000, egressBurst: 100}))
+
+		qos, err = getPodQoS(pod, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*qos).To(Equal(podQoS{dscp: -1, egressRate: 5000}))
+
+		qos, err = getPodQoS(newPod("namespace1", "other", "node1", "10.128.1.5"), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(qos.empty()).To(BeTrue())
+	})
+
+	It("rejects invalid QoS annotations", func() {
+		for _, annotations := range []map[string]string{
+			{qosDSCPAnnotation: "64"},
+			{qosDSCPAnnotation: "af11"},
+			{qosEgressRateAnnotation: "10"},
+			{qosIngressRateAnnotation: "fast"},
+			{qosEgressBurstAnnotation: "1M"},
+		} {
+			pod := newPod("namespace1", "myPod", "node1", "10.128.1.4")
+			pod.Annotations = annotations
+			_, err := getPodQoS(pod, nil)
+			Expect(err).To(HaveOccurred(), "annotations %v", annotations)
+		}
+	})
+})
@@ -0,0 +1,231 @@
+package retry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+)
+
+const (
+	// defaultInitialDelay is how long a failed operation waits for its
+	// first retry
+	defaultInitialDelay = time.Second
+	// defaultMaxDelay caps the delay between the retries of an operation
+	defaultMaxDelay = 5 * time.Minute
+	// retryInterval is how often a Queue looks for operations due for a retry
+	retryInterval = time.Second
+)
+
+// Metrics are the metrics a Queue reports its retries with. They are
+// labeled with the type of resource the Queue retries the operations of.
+type Metrics struct {
+	// Retries counts the retried operations
+	Retries *prometheus.CounterVec
+	// Pending is the number of failed operations waiting for a retry
+	Pending *prometheus.GaugeVec
+	// OldestFailureAge is the time since the first failure of the
+	// longest failing operation
+	OldestFailureAge *prometheus.GaugeVec
+}
+
+// entry is the last failed operation for an object
+type entry struct {
+	op           func() error
+	attempts     int
+	firstFailure time.Time
+	next         time.Time
+}
+
+// keyLock serializes the operations for an object. It is dropped when no
+// operation for the object holds or waits for it.
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// Queue retries the failed operations on the objects of a resource type
+// with exponential backoff. Only the last operation for an object is
+// retried: an operation started for a newer event of the object replaces
+// the failed one. The operations for an object are run one at a time so
+// that a retry never races with the operation for a newer event; the
+// operations for different objects run concurrently.
+type Queue struct {
+	// the Mutex guards the entries and the keyLocks, it is not held while
+	// the operations run
+	sync.Mutex
+	resource string
+	metrics  *Metrics
+
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	entries      map[string]*entry
+	keyLocks     map[string]*keyLock
+}
+
+// NewQueue returns a Queue retrying the failed operations on the objects of
+// resource until stopChan is closed. metrics may be nil.
+func NewQueue(resource string, metrics *Metrics, stopChan <-chan struct{}) *Queue {
+	q := &Queue{
+		resource:     resource,
+		metrics:      metrics,
+		initialDelay: defaultInitialDelay,
+		maxDelay:     defaultMaxDelay,
+		entries:      make(map[string]*entry),
+		keyLocks:     make(map[string]*keyLock),
+	}
+	go wait.Until(func() {
+		q.retryDue(time.Now())
+	}, retryInterval, stopChan)
+	return q
+}
+
+// Do runs op for the object with key, replacing any failed operation
+// waiting for a retry. If op fails it is retried until it succeeds or
+// another operation for the object is run. The error of op is returned.
+func (q *Queue) Do(key string, op func() error) error {
+	q.lockKey(key)
+	defer q.unlockKey(key)
+
+	now := time.Now()
+	err := op()
+
+	q.Lock()
+	defer q.Unlock()
+	if err != nil {
+		e, ok := q.entries[key]
+		if !ok {
+			e = &entry{firstFailure: now}
+			q.entries[key] = e
+		}
+		// the newer operation starts its backoff over
+		e.op = op
+		e.attempts = 1
+		e.next = now.Add(q.delay(e.attempts))
+	} else {
+		delete(q.entries, key)
+	}
+	q.updateMetrics(now)
+	return err
+}
+
+// lockKey waits for the operation running for the object with key, if any,
+// and keeps the other operations for the object from running until
+// unlockKey is called
+func (q *Queue) lockKey(key string) {
+	q.Lock()
+	kl, ok := q.keyLocks[key]
+	if !ok {
+		kl = &keyLock{}
+		q.keyLocks[key] = kl
+	}
+	kl.refs++
+	q.Unlock()
+
+	kl.Lock()
+}
+
+// unlockKey lets the next operation for the object with key run
+func (q *Queue) unlockKey(key string) {
+	q.Lock()
+	defer q.Unlock()
+	kl := q.keyLocks[key]
+	kl.Unlock()
+	kl.refs--
+	if kl.refs == 0 {
+		delete(q.keyLocks, key)
+	}
+}
+
+// Pending returns true if a failed operation for the object with key is
+// waiting for a retry
+func (q *Queue) Pending(key string) bool {
+	q.Lock()
+	defer q.Unlock()
+	_, ok := q.entries[key]
+	return ok
+}
+
+// delay returns how long to wait before the retry following the given
+// number of attempts
+func (q *Queue) delay(attempts int) time.Duration {
+	delay := q.initialDelay
+	for i := 1; i < attempts && delay < q.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > q.maxDelay {
+		delay = q.maxDelay
+	}
+	return delay
+}
+
+// retryDue retries the failed operations due for a retry at now
+func (q *Queue) retryDue(now time.Time) {
+	q.Lock()
+	due := make(map[string]*entry)
+	for key, e := range q.entries {
+		if !now.Before(e.next) {
+			due[key] = e
+		}
+	}
+	q.Unlock()
+
+	for key, e := range due {
+		q.retry(key, e, now)
+	}
+
+	q.Lock()
+	q.updateMetrics(now)
+	q.Unlock()
+}
+
+// retry runs the failed operation e for the object with key, unless an
+// operation for a newer event of the object replaced it since e was found
+// due
+func (q *Queue) retry(key string, e *entry, now time.Time) {
+	q.lockKey(key)
+	defer q.unlockKey(key)
+
+	q.Lock()
+	op := e.op
+	current := q.entries[key] == e && !now.Before(e.next)
+	q.Unlock()
+	if !current {
+		return
+	}
+
+	if q.metrics != nil {
+		q.metrics.Retries.WithLabelValues(q.resource).Inc()
+	}
+	err := op()
+
+	q.Lock()
+	defer q.Unlock()
+	if err != nil {
+		e.attempts++
+		e.next = now.Add(q.delay(e.attempts))
+		klog.Warningf("Retry %d of %s %s failed, retrying in %v: %v",
+			e.attempts-1, q.resource, key, e.next.Sub(now), err)
+		return
+	}
+	klog.Infof("Retry %d of %s %s succeeded after failing for %v",
+		e.attempts, q.resource, key, now.Sub(e.firstFailure))
+	delete(q.entries, key)
+}
+
+// updateMetrics reports the pending operations at now
+func (q *Queue) updateMetrics(now time.Time) {
+	if q.metrics == nil {
+		return
+	}
+	var oldest time.Duration
+	for _, e := range q.entries {
+		if age := now.Sub(e.firstFailure); age > oldest {
+			oldest = age
+		}
+	}
+	q.metrics.Pending.WithLabelValues(q.resource).Set(float64(len(q.entries)))
+	q.metrics.OldestFailureAge.WithLabelValues(q.resource).Set(oldest.Seconds())
+}
@@ -0,0 +1,212 @@
+package retry
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func newTestQueue() (*Queue, *Metrics) {
+	metrics := &Metrics{
+		Retries:          prometheus.NewCounterVec(prometheus.CounterOpts{Name: "retries_total"}, []string{"resource"}),
+		Pending:          prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "pending"}, []string{"resource"}),
+		OldestFailureAge: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "oldest_failure_age"}, []string{"resource"}),
+	}
+	// the test drives the retries itself
+	stop := make(chan struct{})
+	close(stop)
+	return NewQueue("pod", metrics, stop), metrics
+}
+
+func metricValue(t *testing.T, collector prometheus.Collector) float64 {
+	ch := make(chan prometheus.Metric, 1)
+	collector.Collect(ch)
+	m := &dto.Metric{}
+	if err := (<-ch).Write(m); err != nil {
+		t.Fatalf("failed to read metric: %v", err)
+	}
+	if m.Counter != nil {
+		return m.Counter.GetValue()
+	}
+	return m.Gauge.GetValue()
+}
+
+func TestRetryBackoff(t *testing.T) {
+	q, metrics := newTestQueue()
+
+	calls := 0
+	failures := 3
+	op := func() error {
+		calls++
+		if calls <= failures {
+			return fmt.Errorf("failure %d", calls)
+		}
+		return nil
+	}
+	if err := q.Do("ns/pod1", op); err == nil {
+		t.Fatalf("expected the first attempt to fail")
+	}
+	if !q.Pending("ns/pod1") {
+		t.Fatalf("expected a pending retry")
+	}
+	start := q.entries["ns/pod1"].firstFailure
+
+	// The retries wait 1s, 2s and 4s
+	now := start
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		before := calls
+		q.retryDue(now.Add(delay - time.Millisecond))
+		if calls != before {
+			t.Fatalf("retried %v before the %v backoff", delay-time.Millisecond, delay)
+		}
+		now = now.Add(delay)
+		q.retryDue(now)
+		if calls != before+1 {
+			t.Fatalf("did not retry after the %v backoff", delay)
+		}
+	}
+	if q.Pending("ns/pod1") {
+		t.Fatalf("expected no pending retry after the operation succeeded")
+	}
+	if v := metricValue(t, metrics.Retries.WithLabelValues("pod")); v != 3 {
+		t.Fatalf("expected 3 retries, got %v", v)
+	}
+	if v := metricValue(t, metrics.Pending.WithLabelValues("pod")); v != 0 {
+		t.Fatalf("expected no pending retries, got %v", v)
+	}
+}
+
+func TestRetryMaxDelay(t *testing.T) {
+	q, _ := newTestQueue()
+	for attempts, expected := range map[int]time.Duration{
+		1:  time.Second,
+		5:  16 * time.Second,
+		9:  256 * time.Second,
+		10: 5 * time.Minute,
+		50: 5 * time.Minute,
+	} {
+		if delay := q.delay(attempts); delay != expected {
+			t.Fatalf("expected a delay of %v after %d attempts, got %v", expected, attempts, delay)
+		}
+	}
+}
+
+func TestRetryNewerOperationReplacesFailed(t *testing.T) {
+	q, metrics := newTestQueue()
+
+	var ran []string
+	failing := func(name string) func() error {
+		return func() error {
+			ran = append(ran, name)
+			return fmt.Errorf("%s failed", name)
+		}
+	}
+	_ = q.Do("ns/pod1", failing("add"))
+	firstFailure := q.entries["ns/pod1"].firstFailure
+	_ = q.Do("ns/pod1", failing("update"))
+
+	q.retryDue(time.Now().Add(time.Minute))
+	if expected := []string{"add", "update", "update"}; fmt.Sprint(ran) != fmt.Sprint(expected) {
+		t.Fatalf("expected operations %v, got %v", expected, ran)
+	}
+	// the object has been failing since the first operation
+	if q.entries["ns/pod1"].firstFailure != firstFailure {
+		t.Fatalf("expected the first failure to be kept")
+	}
+	if v := metricValue(t, metrics.OldestFailureAge.WithLabelValues("pod")); v < 59 {
+		t.Fatalf("expected the oldest failure to be about a minute old, got %vs", v)
+	}
+
+	// A successful operation drops the failed one
+	if err := q.Do("ns/pod1", func() error { return nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Pending("ns/pod1") {
+		t.Fatalf("expected no pending retry after a successful operation")
+	}
+	if v := metricValue(t, metrics.OldestFailureAge.WithLabelValues("pod")); v != 0 {
+		t.Fatalf("expected no failing operations, got an oldest failure of %vs", v)
+	}
+}
+
+func TestRetryRunsOperationsForOtherObjectsConcurrently(t *testing.T) {
+	q, _ := newTestQueue()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		_ = q.Do("ns/pod1", func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	defer close(release)
+
+	done := make(chan error)
+	go func() {
+		done <- q.Do("ns/pod2", func() error {
+			// the queue is not locked while the operations run
+			if q.Pending("ns/pod2") {
+				return fmt.Errorf("unexpected pending retry")
+			}
+			return nil
+		})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the operation for ns/pod2 waited for the operation for ns/pod1")
+	}
+}
+
+func TestRetrySerializesOperationsForAnObject(t *testing.T) {
+	q, _ := newTestQueue()
+
+	if err := q.Do("ns/pod1", func() error { return fmt.Errorf("add failed") }); err == nil {
+		t.Fatalf("expected the first attempt to fail")
+	}
+
+	// A newer operation for the object runs while its retry is due
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = q.Do("ns/pod1", func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	retried := make(chan struct{})
+	go func() {
+		defer close(retried)
+		q.retryDue(time.Now().Add(time.Minute))
+	}()
+	select {
+	case <-retried:
+		t.Fatalf("the retry did not wait for the running operation for the object")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	<-done
+	<-retried
+
+	// The newer operation succeeded, so the failed one is not retried
+	if q.Pending("ns/pod1") {
+		t.Fatalf("expected no pending retry after the newer operation succeeded")
+	}
+	if len(q.keyLocks) != 0 {
+		t.Fatalf("expected the locks of the objects to be dropped, got %d", len(q.keyLocks))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/config"
+	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/logging"
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/util"
 
 	kapi "k8s.io/api/core/v1"
@@ -44,8 +45,10 @@ func (ovn *Controller) syncServices(services []interface{}) {
 			continue
 		}
 
-		if !util.IsClusterIPSet(service) {
-			klog.V(5).Infof("Skipping service %s due to clusterIP = %q",
+		clusterIPs := util.GetClusterIPs(service)
+		if len(clusterIPs) == 0 {
+			klog.V(5).Infof("%s Skipping service %s due to clusterIP = %q",
+				logging.Service(service.Namespace, service.Name).Operation("sync"),
 				service.Name, service.Spec.ClusterIP)
 			continue
 		}
@@ -53,7 +56,8 @@ func (ovn *Controller) syncServices(services []interface{}) {
 		for _, svcPort := range service.Spec.Ports {
 			protocol, err := util.ValidateProtocol(svcPort.Protocol)
 			if err != nil {
-				klog.Errorf("Error validating protocol for port %s: %v", svcPort.Name, err)
+				klog.Errorf("%s Error validating protocol for port %s: %v",
+					logging.Service(service.Namespace, service.Name).Operation("sync"), svcPort.Name, err)
 				continue
 			}
 
@@ -66,8 +70,10 @@ func (ovn *Controller) syncServices(services []interface{}) {
 				continue
 			}
 
-			key := util.JoinHostPortInt32(service.Spec.ClusterIP, svcPort.Port)
-			clusterServices[protocol] = append(clusterServices[protocol], key)
+			for _, clusterIP := range clusterIPs {
+				key := util.JoinHostPortInt32(clusterIP, svcPort.Port)
+				clusterServices[protocol] = append(clusterServices[protocol], key)
+			}
 
 			if len(service.Spec.ExternalIPs) == 0 {
 				continue
@@ -160,12 +166,14 @@ func (ovn *Controller) syncServices(services []interface{}) {
 }
 
 func (ovn *Controller) createService(service *kapi.Service) error {
-	klog.V(5).Infof("Creating service %s", service.Name)
-	if !util.IsClusterIPSet(service) {
-		klog.V(5).Infof("Skipping service create: No cluster IP for service %s found", service.Name)
+	logCtx := logging.Service(service.Namespace, service.Name).Operation("add")
+	klog.V(5).Infof("%s Creating service %s", logCtx, service.Name)
+	clusterIPs := util.GetClusterIPs(service)
+	if len(clusterIPs) == 0 {
+		klog.V(5).Infof("%s Skipping service create: No cluster IP for service %s found", logCtx, service.Name)
 		return nil
 	} else if len(service.Spec.Ports) == 0 {
-		klog.V(5).Info("Skipping service create: No Ports specified for service")
+		klog.V(5).Infof("%s Skipping service create: No Ports specified for service", logCtx)
 		return nil
 	}
 
@@ -177,9 +185,9 @@ func (ovn *Controller) createService(service *kapi.Service) error {
 	ep, err := ovn.watchFactory.GetEndpoint(service.Namespace, service.Name)
 	if err == nil {
 		if len(ep.Subsets) > 0 {
-			klog.V(5).Infof("service: %s has endpoint, will create loadbalancer VIPs", service.Name)
+			klog.V(5).Infof("%s service: %s has endpoint, will create loadbalancer VIPs", logCtx, service.Name)
 		} else {
-			klog.V(5).Infof("service: %s has empty endpoint", service.Name)
+			klog.V(5).Infof("%s service: %s has empty endpoint", logCtx, service.Name)
 			ep = nil
 		}
 	}
@@ -203,7 +211,7 @@ func (ovn *Controller) createService(service *kapi.Service) error {
 		if !ovn.SCTPSupport && protocol == kapi.ProtocolSCTP {
 			ref, err := reference.GetReference(scheme.Scheme, service)
 			if err != nil {
-				klog.Errorf("Could not get reference for pod %v: %v\n", service.Name, err)
+				klog.Errorf("%s Could not get reference for pod %v: %v\n", logCtx, service.Name, err)
 			} else {
 				ovn.recorder.Event(ref, kapi.EventTypeWarning, "Unsupported protocol error",
 					"SCTP protocol is unsupported by this version of OVN")
@@ -221,88 +229,60 @@ func (ovn *Controller) createService(service *kapi.Service) error {
 			}
 
 			for _, physicalGateway := range physicalGateways {
-				loadBalancer, err := ovn.getGatewayLoadBalancer(physicalGateway, protocol)
-				if err != nil {
-					klog.Errorf("physical gateway %s does not have load_balancer "+
-						"(%v)", physicalGateway, err)
-					continue
-				}
-				if loadBalancer == "" {
-					continue
-				}
-				physicalIPs, err := ovn.getGatewayPhysicalIPs(physicalGateway)
-				if err != nil {
-					klog.Errorf("physical gateway %s does not have physical ip (%v)",
-						physicalGateway, err)
-					continue
-				}
-				for _, physicalIP := range physicalIPs {
-					// With the physical_ip:port as the VIP, add an entry in
-					// 'load_balancer'.
-					vip := util.JoinHostPortInt32(physicalIP, port)
-					// Skip creating LB if endpoints watcher already did it
-					if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
-						klog.V(5).Infof("Load Balancer already configured for %s, %s", loadBalancer, vip)
-					} else if ep != nil {
-						if err := ovn.AddEndpoints(ep); err != nil {
-							return err
-						}
-					} else if ovn.svcQualifiesForReject(service) {
-						aclUUID, err := ovn.createLoadBalancerRejectACL(loadBalancer, physicalIP, port, protocol)
-						if err != nil {
-							return fmt.Errorf("failed to create service ACL: %v", err)
-						}
-						klog.V(5).Infof("Service Reject ACL created for physical gateway: %s", aclUUID)
-					}
+				if err := ovn.createGatewayNodePortVIPs(logCtx, service, ep, physicalGateway, protocol, port); err != nil {
+					return err
 				}
 			}
 		}
 		if util.ServiceTypeHasClusterIP(service) {
 			loadBalancer, err := ovn.getLoadBalancer(protocol)
 			if err != nil {
-				klog.Errorf("Failed to get load-balancer for %s (%v)",
-					protocol, err)
+				klog.Errorf("%s Failed to get load-balancer for %s (%v)",
+					logCtx, protocol, err)
 				break
 			}
 			if ovn.svcQualifiesForReject(service) {
-				vip := util.JoinHostPortInt32(service.Spec.ClusterIP, svcPort.Port)
-				// Skip creating LB if endpoints watcher already did it
-				if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
-					klog.V(5).Infof("Load Balancer already configured for %s, %s", loadBalancer, vip)
-				} else if ep != nil {
-					if err := ovn.AddEndpoints(ep); err != nil {
-						return err
-					}
-				} else {
-					aclUUID, err := ovn.createLoadBalancerRejectACL(loadBalancer, service.Spec.ClusterIP,
-						svcPort.Port, protocol)
-					if err != nil {
-						return fmt.Errorf("failed to create service ACL: %v", err)
+				// A dual-stack service has a VIP in each family on the same
+				// cluster load balancer, each with its own reject ACL.
+				for _, clusterIP := range clusterIPs {
+					vip := util.JoinHostPortInt32(clusterIP, svcPort.Port)
+					// Skip creating LB if endpoints watcher already did it
+					if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
+						klog.V(5).Infof("%s Load Balancer already configured for %s, %s", logCtx, loadBalancer, vip)
+					} else if ep != nil {
+						if err := ovn.AddEndpoints(ep); err != nil {
+							return err
+						}
 					} else {
-						klog.V(5).Infof("Service Reject ACL created for cluster IP: %s", aclUUID)
+						aclUUID, err := ovn.createLoadBalancerRejectACL(service, loadBalancer, clusterIP,
+							svcPort.Port, protocol)
+						if err != nil {
+							return fmt.Errorf("failed to create service ACL: %v", err)
+						}
+						klog.V(5).Infof("%s Service Reject ACL created for cluster IP: %s", logCtx, aclUUID)
 					}
 				}
 				for _, extIP := range service.Spec.ExternalIPs {
 					exLoadBalancer := ovn.getDefaultGatewayLoadBalancer(svcPort.Protocol)
 					if exLoadBalancer == "" {
-						klog.Warningf("No default gateway found for protocol %s\n\tNote: 'nodeport'"+
-							"flag needs to be enabled for default gateway", svcPort.Protocol)
+						klog.Warningf("%s No default gateway found for protocol %s\n\tNote: 'nodeport'"+
+							"flag needs to be enabled for default gateway", logCtx, svcPort.Protocol)
 						continue
 					}
 					vip := util.JoinHostPortInt32(extIP, svcPort.Port)
 					// Skip creating LB if endpoints watcher already did it
-					if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
-						klog.V(5).Infof("Load Balancer already configured for %s, %s", loadBalancer, vip)
+					if _, hasEps := ovn.getServiceLBInfo(exLoadBalancer, vip); hasEps {
+						klog.V(5).Infof("%s Load Balancer already configured for %s, %s", logCtx, exLoadBalancer, vip)
 					} else if ep != nil {
 						if err := ovn.AddEndpoints(ep); err != nil {
 							return err
 						}
 					} else {
-						aclUUID, err := ovn.createLoadBalancerRejectACL(exLoadBalancer, extIP, svcPort.Port, protocol)
+						aclUUID, err := ovn.createLoadBalancerRejectACL(service, exLoadBalancer, extIP, svcPort.Port, protocol)
 						if err != nil {
 							return fmt.Errorf("failed to create service ACL for external IP")
 						} else {
-							klog.V(5).Infof("Service Reject ACL created for external IP: %s", aclUUID)
+							klog.V(5).Infof("%s Service Reject ACL created for external IP: %s", logCtx, aclUUID)
 						}
 					}
 				}
@@ -313,22 +293,26 @@ func (ovn *Controller) createService(service *kapi.Service) error {
 }
 
 func (ovn *Controller) updateService(oldSvc, newSvc *kapi.Service) error {
+	logCtx := logging.Service(newSvc.Namespace, newSvc.Name).Operation("update")
 	if reflect.DeepEqual(newSvc.Spec.Ports, oldSvc.Spec.Ports) &&
 		reflect.DeepEqual(newSvc.Spec.ExternalIPs, oldSvc.Spec.ExternalIPs) &&
-		reflect.DeepEqual(newSvc.Spec.ClusterIP, oldSvc.Spec.ClusterIP) &&
+		reflect.DeepEqual(util.GetClusterIPs(newSvc), util.GetClusterIPs(oldSvc)) &&
 		reflect.DeepEqual(newSvc.Spec.Type, oldSvc.Spec.Type) {
-		klog.V(5).Infof("skipping service update for: %s as change does not apply to any of .Spec.Ports, .Spec.ExternalIP, .Spec.ClusterIP, .Spec.Type", newSvc.Name)
+		klog.V(5).Infof("%s skipping service update for: %s as change does not apply to any of .Spec.Ports, .Spec.ExternalIP, .Spec.ClusterIP, .Spec.Type",
+			logCtx, newSvc.Name)
 		return nil
 	}
 
-	klog.V(5).Infof("updating service from: %v to: %v", oldSvc, newSvc)
+	klog.V(5).Infof("%s updating service from: %v to: %v", logCtx, oldSvc, newSvc)
 
 	ovn.deleteService(oldSvc)
 	return ovn.createService(newSvc)
 }
 
 func (ovn *Controller) deleteService(service *kapi.Service) {
-	if !util.IsClusterIPSet(service) || len(service.Spec.Ports) == 0 {
+	logCtx := logging.Service(service.Namespace, service.Name).Operation("delete")
+	clusterIPs := util.GetClusterIPs(service)
+	if len(clusterIPs) == 0 || len(service.Spec.Ports) == 0 {
 		return
 	}
 
@@ -347,7 +331,7 @@ func (ovn *Controller) deleteService(service *kapi.Service) {
 
 		protocol, err := util.ValidateProtocol(svcPort.Protocol)
 		if err != nil {
-			klog.Errorf("Skipping delete for service port %s: %v", svcPort.Name, err)
+			klog.Errorf("%s Skipping delete for service port %s: %v", logCtx, svcPort.Name, err)
 			continue
 		}
 
@@ -360,17 +344,61 @@ func (ovn *Controller) deleteService(service *kapi.Service) {
 		if util.ServiceTypeHasClusterIP(service) {
 			loadBalancer, err := ovn.getLoadBalancer(protocol)
 			if err != nil {
-				klog.Errorf("Failed to get load-balancer for %s (%v)",
-					protocol, err)
+				klog.Errorf("%s Failed to get load-balancer for %s (%v)",
+					logCtx, protocol, err)
 				break
 			}
-			vip := util.JoinHostPortInt32(service.Spec.ClusterIP, svcPort.Port)
-			ovn.deleteLoadBalancerVIP(loadBalancer, vip)
+			for _, clusterIP := range clusterIPs {
+				vip := util.JoinHostPortInt32(clusterIP, svcPort.Port)
+				ovn.deleteLoadBalancerVIP(loadBalancer, vip)
+			}
 			ovn.handleExternalIPs(service, svcPort, ips, targetPort, true)
 		}
 	}
 }
 
+// createGatewayNodePortVIPs creates the VIPs of a NodePort on each physical
+// IP of a gateway router, or reject ACLs for them if the service has no
+// endpoints.
+func (ovn *Controller) createGatewayNodePortVIPs(logCtx *logging.Context, service *kapi.Service, ep *kapi.Endpoints,
+	physicalGateway string, protocol kapi.Protocol, port int32) error {
+	loadBalancer, err := ovn.getGatewayLoadBalancer(physicalGateway, protocol)
+	if err != nil {
+		klog.Errorf("%s physical gateway %s does not have load_balancer "+
+			"(%v)", logCtx, physicalGateway, err)
+		return nil
+	}
+	if loadBalancer == "" {
+		return nil
+	}
+	physicalIPs, err := ovn.getGatewayPhysicalIPs(physicalGateway)
+	if err != nil {
+		klog.Errorf("%s physical gateway %s does not have physical ip (%v)",
+			logCtx, physicalGateway, err)
+		return nil
+	}
+	for _, physicalIP := range physicalIPs {
+		// With the physical_ip:port as the VIP, add an entry in
+		// 'load_balancer'.
+		vip := util.JoinHostPortInt32(physicalIP, port)
+		// Skip creating LB if endpoints watcher already did it
+		if _, hasEps := ovn.getServiceLBInfo(loadBalancer, vip); hasEps {
+			klog.V(5).Infof("%s Load Balancer already configured for %s, %s", logCtx, loadBalancer, vip)
+		} else if ep != nil {
+			if err := ovn.AddEndpoints(ep); err != nil {
+				return err
+			}
+		} else if ovn.svcQualifiesForReject(service) {
+			aclUUID, err := ovn.createLoadBalancerRejectACL(service, loadBalancer, physicalIP, port, protocol)
+			if err != nil {
+				return fmt.Errorf("failed to create service ACL: %v", err)
+			}
+			klog.V(5).Infof("%s Service Reject ACL created for physical gateway: %s", logCtx, aclUUID)
+		}
+	}
+	return nil
+}
+
 // svcQualifiesForReject determines if a service should have a reject ACL on it when it has no endpoints
 // The reject ACL is only applied to terminate incoming connections immediately when idling is not used
 // or OVNEmptyLbEvents are not enabled. When idilng or empty LB events are enabled, we want to ensure we
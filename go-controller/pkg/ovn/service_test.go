@@ -1,7 +1,9 @@
 package ovn
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -39,6 +41,14 @@ func newService(name, namespace, ip string, ports []v1.ServicePort, serviceType
 }
 
 func (s service) baseCmds(fexec *ovntest.FakeExec, service v1.Service) {
+	s.syncCmds(fexec, service)
+	fexec.AddFakeCmdsNoOutputNoError([]string{
+		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}k8s_tcp_load_balancer"),
+		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}k8s_tcp_load_balancer"),
+	})
+}
+
+func (s service) syncCmds(fexec *ovntest.FakeExec, service v1.Service) {
 	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
 		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find load_balancer external_ids:k8s-cluster-lb-tcp=yes",
 		Output: k8sTCPLoadBalancerIP,
@@ -109,9 +119,25 @@ func (s service) baseCmds(fexec *ovntest.FakeExec, service v1.Service) {
 	fexec.AddFakeCmdsNoOutputNoError([]string{
 		"ovn-nbctl --timeout=15 --if-exists remove load_balancer sctp_load_balancer_id_1 vips \"172.30.0.10:53\"",
 	})
+}
+
+// rejectACLCmds adds the commands creating the reject ACL of the VIP of a
+// service without endpoints on the cluster TCP load balancer
+func (s service) rejectACLCmds(fexec *ovntest.FakeExec, service v1.Service, l3Prefix, clusterIP string, port int32) {
+	aclName := fmt.Sprintf("%s-%s\\:%d", k8sTCPLoadBalancerIP, strings.ReplaceAll(clusterIP, ":", "\\:"), port)
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd:    "ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}k8s_tcp_load_balancer",
+		Output: "node1",
+	})
 	fexec.AddFakeCmdsNoOutputNoError([]string{
-		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find logical_switch load_balancer{>=}k8s_tcp_load_balancer"),
-		fmt.Sprintf("ovn-nbctl --timeout=15 --data=bare --no-heading --columns=name find logical_router load_balancer{>=}k8s_tcp_load_balancer"),
+		"ovn-nbctl --timeout=15 --data=bare --no-heading --columns=_uuid find acl name=" + aclName,
+	})
+	fexec.AddFakeCmd(&ovntest.ExpectedCmd{
+		Cmd: fmt.Sprintf("ovn-nbctl --timeout=15 --id=@acl create acl direction=from-lport priority=1000 "+
+			"match=\"%s.dst==%s && tcp && tcp.dst==%d\" action=reject name=%s "+
+			"external-ids:k8s-owner-kind=Service external-ids:k8s-owner=%s/%s -- add logical_switch node1 acls @acl",
+			l3Prefix, clusterIP, port, aclName, service.Namespace, service.Name),
+		Output: "acl-" + l3Prefix,
 	})
 }
 
@@ -178,7 +204,7 @@ var _ = Describe("OVN Namespace Operations", func() {
 				)
 				fakeOvn.controller.WatchServices()
 
-				_, err := fakeOvn.fakeClient.CoreV1().Services(service.Namespace).Get(service.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.CoreV1().Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
 
@@ -215,16 +241,16 @@ var _ = Describe("OVN Namespace Operations", func() {
 				)
 				fakeOvn.controller.WatchServices()
 
-				_, err := fakeOvn.fakeClient.CoreV1().Services(service.Namespace).Get(service.Name, metav1.GetOptions{})
+				_, err := fakeOvn.fakeClient.CoreV1().Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
 				Expect(err).NotTo(HaveOccurred())
 				Expect(fExec.CalledMatchesExpected()).To(BeTrue(), fExec.ErrorDesc)
 
 				test.delCmds(fExec, service)
-				err = fakeOvn.fakeClient.CoreV1().Services(service.Namespace).Delete(service.Name, metav1.NewDeleteOptions(0))
+				err = fakeOvn.fakeClient.CoreV1().Services(service.Namespace).Delete(context.TODO(), service.Name, *metav1.NewDeleteOptions(0))
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
 
-				s, err := fakeOvn.fakeClient.CoreV1().Services(service.Namespace).Get(service.Name, metav1.GetOptions{})
+				s, err := fakeOvn.fakeClient.CoreV1().Services(service.Namespace).Get(context.TODO(), service.Name, metav1.GetOptions{})
 				Expect(err).To(HaveOccurred())
 				Expect(s).To(BeNil())
 
@@ -235,4 +261,50 @@ var _ = Describe("OVN Namespace Operations", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
+
+	Context("on dual-stack services", func() {
+
+		It("creates a reject ACL for the VIP of each IP family of a service without endpoints", func() {
+			app.Action = func(ctx *cli.Context) error {
+
+				test := service{}
+
+				service := *newService("service1", "namespace1", "10.129.0.2",
+					[]v1.ServicePort{
+						{
+							Port:     8032,
+							Protocol: v1.ProtocolTCP,
+						},
+					},
+					v1.ServiceTypeClusterIP,
+				)
+				service.Spec.ClusterIPs = []string{"10.129.0.2", "fd00:10:96::2"}
+				service.Spec.IPFamilies = []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol}
+
+				test.syncCmds(fExec, service)
+				test.rejectACLCmds(fExec, service, "ip4", "10.129.0.2", 8032)
+				test.rejectACLCmds(fExec, service, "ip6", "fd00:10:96::2", 8032)
+
+				fakeOvn.start(ctx,
+					&v1.ServiceList{
+						Items: []v1.Service{
+							service,
+						},
+					},
+				)
+				fakeOvn.controller.WatchServices()
+
+				Eventually(fExec.CalledMatchesExpected).Should(BeTrue(), fExec.ErrorDesc)
+				for _, vip := range []string{"10.129.0.2:8032", "[fd00:10:96::2]:8032"} {
+					acl, _ := fakeOvn.controller.getServiceLBInfo(k8sTCPLoadBalancerIP, vip)
+					Expect(acl).NotTo(BeEmpty(), "no reject ACL for VIP %s", vip)
+				}
+
+				return nil
+			}
+
+			err := app.Run([]string{app.Name})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
 })
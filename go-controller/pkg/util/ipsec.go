@@ -0,0 +1,56 @@
+package util
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+)
+
+const (
+	// IPsecCSRNodeLabel labels the certificate signing requests of the
+	// nodes' IPsec certificates with the name of the requesting node
+	IPsecCSRNodeLabel = "k8s.ovn.org/ipsec-node"
+)
+
+// IPsecCSRUsages are the key usages requested for the nodes' IPsec
+// certificates
+var IPsecCSRUsages = []certificatesv1beta1.KeyUsage{
+	certificatesv1beta1.UsageDigitalSignature,
+	certificatesv1beta1.UsageKeyEncipherment,
+	certificatesv1beta1.UsageIPsecTunnel,
+}
+
+// ParseIPsecCSR parses and checks the request of a certificate signing
+// request for a node's IPsec certificate
+func ParseIPsecCSR(csr *certificatesv1beta1.CertificateSigningRequest) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("certificate signing request %s has no PEM encoded request", csr.Name)
+	}
+	req, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate signing request %s: %v", csr.Name, err)
+	}
+	if err := req.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("certificate signing request %s has an invalid signature: %v", csr.Name, err)
+	}
+
+	if len(csr.Spec.Usages) != len(IPsecCSRUsages) {
+		return nil, fmt.Errorf("certificate signing request %s has unexpected usages %v", csr.Name, csr.Spec.Usages)
+	}
+	usages := make(map[certificatesv1beta1.KeyUsage]bool)
+	for _, usage := range csr.Spec.Usages {
+		usages[usage] = true
+	}
+	for _, usage := range IPsecCSRUsages {
+		if !usages[usage] {
+			return nil, fmt.Errorf("certificate signing request %s has unexpected usages %v", csr.Name, csr.Spec.Usages)
+		}
+	}
+	if len(req.DNSNames) > 0 || len(req.IPAddresses) > 0 || len(req.EmailAddresses) > 0 || len(req.URIs) > 0 {
+		return nil, fmt.Errorf("certificate signing request %s has unexpected subject alternative names", csr.Name)
+	}
+	return req, nil
+}
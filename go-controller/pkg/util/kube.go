@@ -22,6 +22,20 @@ import (
 // NewClientset creates a Kubernetes clientset from either a kubeconfig,
 // TLS properties, or an apiserver URL
 func NewClientset(conf *config.KubernetesConfig) (*kubernetes.Clientset, error) {
+	kconfig, err := NewRESTConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	kconfig.AcceptContentTypes = "application/vnd.kubernetes.protobuf,application/json"
+	kconfig.ContentType = "application/vnd.kubernetes.protobuf"
+
+	return kubernetes.NewForConfig(kconfig)
+}
+
+// NewRESTConfig creates a Kubernetes client configuration from either a
+// kubeconfig, TLS properties, or an apiserver URL
+func NewRESTConfig(conf *config.KubernetesConfig) (*rest.Config, error) {
 	var kconfig *rest.Config
 	var err error
 
@@ -53,11 +67,7 @@ func NewClientset(conf *config.KubernetesConfig) (*kubernetes.Clientset, error)
 	if err != nil {
 		return nil, err
 	}
-
-	kconfig.AcceptContentTypes = "application/vnd.kubernetes.protobuf,application/json"
-	kconfig.ContentType = "application/vnd.kubernetes.protobuf"
-
-	return kubernetes.NewForConfig(kconfig)
+	return kconfig, nil
 }
 
 // IsClusterIPSet checks if the service is an headless service or not
@@ -65,6 +75,24 @@ func IsClusterIPSet(service *kapi.Service) bool {
 	return service.Spec.ClusterIP != kapi.ClusterIPNone && service.Spec.ClusterIP != ""
 }
 
+// GetClusterIPs returns the cluster IPs of a service, one per IP family, or
+// nil if the service is headless or has no cluster IP allocated. Callers
+// should treat each returned IP independently, as a dual-stack service has
+// VIPs in both families.
+func GetClusterIPs(service *kapi.Service) []string {
+	if !IsClusterIPSet(service) {
+		return nil
+	}
+	// Spec.ClusterIPs is not set by apiservers older than the dual-stack
+	// API, in which case the service only has the Spec.ClusterIP
+	if len(service.Spec.ClusterIPs) == 0 {
+		return []string{service.Spec.ClusterIP}
+	}
+	clusterIPs := make([]string, len(service.Spec.ClusterIPs))
+	copy(clusterIPs, service.Spec.ClusterIPs)
+	return clusterIPs
+}
+
 // ValidateProtocol checks if the protocol is a valid kapi.Protocol type (TCP, UDP, or SCTP) or returns an error
 func ValidateProtocol(proto kapi.Protocol) (kapi.Protocol, error) {
 	if proto == kapi.ProtocolTCP || proto == kapi.ProtocolUDP || proto == kapi.ProtocolSCTP {
@@ -126,6 +154,10 @@ const (
 
 	// NetworkAttachmentAnnotation is the pod annotation for network-attachment-definition
 	NetworkAttachmentAnnotation = "k8s.v1.cni.cncf.io/networks"
+
+	// NetworkStatusAnnotation is the pod annotation written by multus with the
+	// status of each of the pod's network attachments
+	NetworkStatusAnnotation = "k8s.v1.cni.cncf.io/networks-status"
 )
 
 // GetPodNetSelAnnotation returns the pod's Network Attachment Selection Annotation either for
@@ -161,6 +193,22 @@ func GetPodNetSelAnnotation(pod *kapi.Pod, netAttachAnnot string) ([]*types.Netw
 	return networks, nil
 }
 
+// GetPodNetworkStatus returns the status of the pod's network attachments as
+// reported in its Network Attachment Status Annotation, or nil if the pod has
+// no such annotation.
+func GetPodNetworkStatus(pod *kapi.Pod) ([]*types.NetworkStatus, error) {
+	statusAnnotation := pod.Annotations[NetworkStatusAnnotation]
+	if statusAnnotation == "" {
+		return nil, nil
+	}
+
+	var statuses []*types.NetworkStatus
+	if err := json.Unmarshal([]byte(statusAnnotation), &statuses); err != nil {
+		return nil, fmt.Errorf("failed to parse pod's network status JSON %q: %v", statusAnnotation, err)
+	}
+	return statuses, nil
+}
+
 // eventRecorder returns an EventRecorder type that can be
 // used to post Events to different object's lifecycles.
 func EventRecorder(kubeClient kubernetes.Interface) record.EventRecorder {
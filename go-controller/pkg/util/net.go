@@ -127,6 +127,16 @@ func IPAddrToHWAddr(ip net.IP) net.HardwareAddr {
 	return net.HardwareAddr{0x0A, 0x58, ip[0], ip[1], ip[14], ip[15]}
 }
 
+// HWAddrToIPv6LLA generates the IPv6 link local address from the given hwaddr,
+// with prefix 'fe80:/64' and an EUI-64 interface identifier.
+func HWAddrToIPv6LLA(hwaddr net.HardwareAddr) net.IP {
+	return net.IP{
+		0xfe, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		hwaddr[0] ^ 0x02, hwaddr[1], hwaddr[2], 0xff,
+		0xfe, hwaddr[3], hwaddr[4], hwaddr[5],
+	}
+}
+
 // JoinIPs joins the string forms of an array of net.IP, as with strings.Join
 func JoinIPs(ips []net.IP, sep string) string {
 	b := &strings.Builder{}
@@ -150,3 +160,41 @@ func JoinIPNets(ipnets []*net.IPNet, sep string) string {
 	}
 	return b.String()
 }
+
+// Lengths of the headers added to the packets of the overlay networks by
+// their encapsulation
+const (
+	ipv4HeaderLength     = 20
+	ipv6HeaderLength     = 40
+	udpHeaderLength      = 8
+	tcpHeaderLength      = 20
+	ethernetHeaderLength = 14
+	// geneveHeaderLength includes the 8 bytes of the option carrying
+	// OVN's logical port metadata
+	geneveHeaderLength = 16
+	vxlanHeaderLength  = 8
+	sttHeaderLength    = 18
+)
+
+// EncapOverhead returns the number of bytes the encapsulation type adds to
+// the packets of the overlay networks over an IPv4 or IPv6 underlay, that is
+// the difference between the MTU of the underlay interface and the MTU of
+// the overlay networks.
+func EncapOverhead(encapType string, ipv6Underlay bool) (int, error) {
+	overhead := ipv4HeaderLength
+	if ipv6Underlay {
+		overhead = ipv6HeaderLength
+	}
+	switch encapType {
+	case "geneve":
+		overhead += udpHeaderLength + geneveHeaderLength
+	case "vxlan":
+		overhead += udpHeaderLength + vxlanHeaderLength
+	case "stt":
+		overhead += tcpHeaderLength + sttHeaderLength
+	default:
+		return 0, fmt.Errorf("unknown encapsulation type %q", encapType)
+	}
+	// the encapsulated packets are Ethernet frames
+	return overhead + ethernetHeaderLength, nil
+}
@@ -52,6 +52,33 @@ var _ = Describe("Util tests", func() {
 		}
 	})
 
+	It("test HWAddrToIPv6LLA()", func() {
+		type testcase struct {
+			name       string
+			MAC        string
+			expectedIP string
+		}
+
+		testcases := []testcase{
+			{
+				name:       "MAC to IPv6 link local address",
+				MAC:        "0a:58:0a:01:02:03",
+				expectedIP: "fe80::858:aff:fe01:203",
+			},
+			{
+				name:       "universal MAC to IPv6 link local address",
+				MAC:        "00:11:22:33:44:55",
+				expectedIP: "fe80::211:22ff:fe33:4455",
+			},
+		}
+
+		for _, tc := range testcases {
+			mac := ovntest.MustParseMAC(tc.MAC)
+			ip := HWAddrToIPv6LLA(mac)
+			Expect(ip.String()).To(Equal(tc.expectedIP), " test case \"%s\" returned %s instead of %s from MAC %s", tc.name, ip.String(), tc.expectedIP, mac.String())
+		}
+	})
+
 	It("test JoinIPs", func() {
 		type testcase struct {
 			name string
@@ -137,4 +164,30 @@ var _ = Describe("Util tests", func() {
 			Expect(result).To(Equal(tc.out), " test case \"%s\" returned wrong results for %#v", tc.name, tc.cidrs)
 		}
 	})
+
+	It("test EncapOverhead", func() {
+		type testcase struct {
+			encapType string
+			ipv6      bool
+			overhead  int
+		}
+
+		testcases := []testcase{
+			{encapType: "geneve", ipv6: false, overhead: 58},
+			{encapType: "geneve", ipv6: true, overhead: 78},
+			{encapType: "vxlan", ipv6: false, overhead: 50},
+			{encapType: "vxlan", ipv6: true, overhead: 70},
+			{encapType: "stt", ipv6: false, overhead: 72},
+			{encapType: "stt", ipv6: true, overhead: 92},
+		}
+
+		for _, tc := range testcases {
+			overhead, err := EncapOverhead(tc.encapType, tc.ipv6)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(overhead).To(Equal(tc.overhead), "wrong overhead for %s over IPv6 %v", tc.encapType, tc.ipv6)
+		}
+
+		_, err := EncapOverhead("gre", false)
+		Expect(err).To(HaveOccurred())
+	})
 })
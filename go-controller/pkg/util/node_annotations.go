@@ -35,6 +35,7 @@ import (
 //       }
 //     k8s.ovn.org/node-chassis-id: b1f96182-2bdd-42b6-88f9-9a1fc1c85ece
 //     k8s.ovn.org/node-mgmt-port-mac-address: fa:f1:27:f5:54:69
+//     k8s.ovn.org/node-mtu: "1400"
 //
 // The "ip_address" and "next_hop" fields are deprecated and will eventually go away.
 // (And they are not output when "ip_addresses" or "next_hops" contains multiple
@@ -52,6 +53,9 @@ const (
 
 	// ovnNodeChassisID is the systemID of the node needed for creating L3 gateway
 	ovnNodeChassisID = "k8s.ovn.org/node-chassis-id"
+
+	// ovnNodeMTU is the MTU of the overlay networks on the node
+	ovnNodeMTU = "k8s.ovn.org/node-mtu"
 )
 
 type L3GatewayConfig struct {
@@ -230,3 +234,36 @@ func ParseNodeManagementPortMACAddress(node *kapi.Node) (net.HardwareAddr, error
 
 	return net.ParseMAC(macAddress)
 }
+
+// SetNodeMTU publishes the MTU the node uses for the overlay networks
+func SetNodeMTU(nodeAnnotator kube.Annotator, mtu int) error {
+	return nodeAnnotator.Set(ovnNodeMTU, strconv.Itoa(mtu))
+}
+
+// ParseNodeMTU returns the MTU the node uses for the overlay networks, or 0
+// if the node does not publish it
+func ParseNodeMTU(node *kapi.Node) (int, error) {
+	mtuAnnotation, ok := node.Annotations[ovnNodeMTU]
+	if !ok {
+		return 0, nil
+	}
+	mtu, err := strconv.Atoi(mtuAnnotation)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse node %s MTU annotation %q: %v", node.Name, mtuAnnotation, err)
+	}
+	return mtu, nil
+}
+
+// SetNodeChassisID publishes the node's OVN chassis ID
+func SetNodeChassisID(nodeAnnotator kube.Annotator, chassisID string) error {
+	return nodeAnnotator.Set(ovnNodeChassisID, chassisID)
+}
+
+// ParseNodeChassisID returns the node's OVN chassis ID
+func ParseNodeChassisID(node *kapi.Node) (string, error) {
+	chassisID, ok := node.Annotations[ovnNodeChassisID]
+	if !ok {
+		return "", fmt.Errorf("%s annotation not found for node %q", ovnNodeChassisID, node.Name)
+	}
+	return chassisID, nil
+}
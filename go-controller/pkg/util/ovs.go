@@ -8,7 +8,9 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unicode"
@@ -416,9 +418,69 @@ func RunOVNNbctlUnix(args ...string) (string, string, error) {
 		stderr.String(), err
 }
 
+// nbTransactionCounters holds the number of northbound database
+// transactions of the operations CountNBTransactions runs, keyed by the ID
+// of the goroutine running the operation. Each ovn-nbctl command is one
+// transaction.
+var nbTransactionCounters sync.Map
+
+// nbTransactionCounting is the number of operations CountNBTransactions
+// runs, the ovn-nbctl commands only look for a counter when it is not 0
+var nbTransactionCounting int32
+
+// goroutineID returns the ID of the calling goroutine
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	// the stack trace starts with "goroutine <id> ["
+	fields := bytes.Fields(buf)
+	if len(fields) < 2 {
+		return 0
+	}
+	id, _ := strconv.ParseUint(string(fields[1]), 10, 64)
+	return id
+}
+
+// CountNBTransactions runs op and returns the number of northbound database
+// transactions it ran through ovn-nbctl. Only the transactions run by the
+// calling goroutine are counted: those of the operations running
+// concurrently are not, nor are those of the goroutines op starts. The
+// transactions of nested calls are counted by the outer calls too.
+func CountNBTransactions(op func()) uint64 {
+	id := goroutineID()
+	var count uint64
+	outer, nested := nbTransactionCounters.Load(id)
+	nbTransactionCounters.Store(id, &count)
+	atomic.AddInt32(&nbTransactionCounting, 1)
+	defer func() {
+		atomic.AddInt32(&nbTransactionCounting, -1)
+		if nested {
+			atomic.AddUint64(outer.(*uint64), count)
+			nbTransactionCounters.Store(id, outer)
+		} else {
+			nbTransactionCounters.Delete(id)
+		}
+	}()
+
+	op()
+	return atomic.LoadUint64(&count)
+}
+
+// countNBTransaction counts an ovn-nbctl command in the operation the
+// calling goroutine runs, if CountNBTransactions runs it
+func countNBTransaction() {
+	if atomic.LoadInt32(&nbTransactionCounting) == 0 {
+		return
+	}
+	if count, ok := nbTransactionCounters.Load(goroutineID()); ok {
+		atomic.AddUint64(count.(*uint64), 1)
+	}
+}
+
 // RunOVNNbctlWithTimeout runs command via ovn-nbctl with a specific timeout
 func RunOVNNbctlWithTimeout(timeout int, args ...string) (string, string, error) {
 	cmdArgs, envVars := getNbctlArgsAndEnv(timeout, args...)
+	countNBTransaction()
 	start := time.Now()
 	stdout, stderr, err := runOVNretry(runner.nbctlPath, envVars, cmdArgs...)
 	if MetricOvnCliLatency != nil {
@@ -176,4 +176,43 @@ var _ = Describe("Util OVS/OVN command tests", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
+
+	It("counts the northbound database transactions of an operation", func() {
+		app.Action = func(ctx *cli.Context) error {
+			err := SetExec(fexec)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = config.InitConfig(ctx, fexec, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			fexec.AddFakeCmdsNoOutputNoError([]string{
+				"ovn-nbctl --timeout=15 outer",
+				"ovn-nbctl --timeout=15 inner",
+				"ovn-nbctl --timeout=15 concurrent",
+			})
+			var inner uint64
+			outer := CountNBTransactions(func() {
+				_, _, err := RunOVNNbctl("outer")
+				Expect(err).NotTo(HaveOccurred())
+				inner = CountNBTransactions(func() {
+					_, _, err := RunOVNNbctl("inner")
+					Expect(err).NotTo(HaveOccurred())
+				})
+				// The transactions of other goroutines are not counted
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(done)
+					_, _, err := RunOVNNbctl("concurrent")
+					Expect(err).NotTo(HaveOccurred())
+				}()
+				<-done
+			})
+			Expect(inner).To(Equal(uint64(1)))
+			Expect(outer).To(Equal(uint64(2)))
+			Expect(fexec.CalledMatchesExpected()).To(BeTrue(), fexec.ErrorDesc)
+			return nil
+		}
+		err := app.Run([]string{app.Name})
+		Expect(err).NotTo(HaveOccurred())
+	})
 })
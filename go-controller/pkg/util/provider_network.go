@@ -0,0 +1,17 @@
+package util
+
+const (
+	// ProviderNetworkAnnotation attaches a pod, or all pods of a namespace
+	// created after it is set, to the named provider network instead of the
+	// cluster network
+	ProviderNetworkAnnotation = "k8s.ovn.org/provider-network"
+	// providerNetworkSwitchPrefix prefixes the names of the logical switches
+	// of provider networks
+	providerNetworkSwitchPrefix = "provnet_"
+)
+
+// ProviderNetworkSwitch returns the name of the logical switch of the named
+// provider network
+func ProviderNetworkSwitch(name string) string {
+	return providerNetworkSwitchPrefix + name
+}
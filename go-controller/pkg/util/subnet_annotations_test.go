@@ -1,6 +1,7 @@
 package util
 
 import (
+	"context"
 	"net"
 
 	"github.com/ovn-org/ovn-kubernetes/go-controller/pkg/kube"
@@ -71,7 +72,7 @@ var _ = Describe("subnet annotation tests", func() {
 			err = nodeAnnotator.Run()
 			Expect(err).NotTo(HaveOccurred())
 
-			updatedNode, err := fakeClient.CoreV1().Nodes().Get(testNode.Name, metav1.GetOptions{})
+			updatedNode, err := fakeClient.CoreV1().Nodes().Get(context.TODO(), testNode.Name, metav1.GetOptions{})
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(updatedNode.Annotations[ovnNodeSubnets]).To(MatchJSON(tc.hsOut))